@@ -7,10 +7,14 @@ import (
 )
 
 type AddOrderRequest struct {
+	AccountID uint64
 	OrderType lob.OrderType
 	OrderSide lob.OrderSide
 	Price     lob.Price
 	Size      lob.Size
+
+	// ClientOrderID is optional; resending an order with the same one is rejected as a duplicate, so it's safe to retry.
+	ClientOrderID string
 }
 
 type AddOrderResponse struct {
@@ -18,13 +22,46 @@ type AddOrderResponse struct {
 }
 
 type CancelOrderResponse struct{}
-type CancelOrderRequest struct{}
+type CancelOrderRequest struct {
+	OrderID uint64
+
+	// AccountID & ClientOrderID identify the order instead, if OrderID is zero.
+	AccountID     uint64
+	ClientOrderID string
+}
 
-type EditOrderRequest struct{}
 type EditOrderResponse struct{}
+type EditOrderRequest struct {
+	OrderID uint64
+	Price   lob.Price
+	Size    lob.Size
+
+	// AccountID & ClientOrderID identify the order instead, if OrderID is zero.
+	AccountID     uint64
+	ClientOrderID string
+}
+
+type GetOrderRequest struct {
+	OrderID uint64
+
+	// AccountID & ClientOrderID identify the order instead, if OrderID is zero.
+	AccountID     uint64
+	ClientOrderID string
+}
+
+type GetOrderResponse struct {
+	Order lob.OrderInfo
+}
+
+type ListOpenOrdersRequest struct{}
+type ListOpenOrdersResponse struct {
+	Orders []lob.OrderInfo
+}
 
 type Client interface {
 	AddOrder(ctx context.Context, req AddOrderRequest) (AddOrderResponse, error)
 	CancelOrder(ctx context.Context, req CancelOrderRequest) (CancelOrderResponse, error)
 	EditOrder(ctx context.Context, req EditOrderRequest) (EditOrderResponse, error)
+	GetOrder(ctx context.Context, req GetOrderRequest) (GetOrderResponse, error)
+	ListOpenOrders(ctx context.Context, req ListOpenOrdersRequest) (ListOpenOrdersResponse, error)
 }
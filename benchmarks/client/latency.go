@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Operations timed by a LatencyClient.
+const (
+	OpAddOrder       = "add_order"
+	OpCancelOrder    = "cancel_order"
+	OpEditOrder      = "edit_order"
+	OpGetOrder       = "get_order"
+	OpListOpenOrders = "list_open_orders"
+)
+
+func NewLatencyClient(client Client) *LatencyClient {
+	return &LatencyClient{
+		client:  client,
+		samples: make(map[string][]time.Duration),
+	}
+}
+
+var _ Client = &LatencyClient{}
+
+// LatencyClient times every operation of the client it wraps, failed ones included; wrapping a networked client measures
+// the round trip through serialization & the network stack.
+type LatencyClient struct {
+	client  Client
+	samples map[string][]time.Duration
+	mu      sync.Mutex
+}
+
+// Latency summarises an operation's latencies.
+type Latency struct {
+	Count         int
+	P50, P90, P99 time.Duration
+	Max           time.Duration
+}
+
+func (l *LatencyClient) record(op string, start time.Time) {
+	elapsed := time.Since(start)
+
+	l.mu.Lock()
+	l.samples[op] = append(l.samples[op], elapsed)
+	l.mu.Unlock()
+}
+
+// Latencies summarises the latencies recorded so far, by operation.
+func (l *LatencyClient) Latencies() map[string]Latency {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	latencies := make(map[string]Latency, len(l.samples))
+	for op, samples := range l.samples {
+		sorted := append([]time.Duration(nil), samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		latencies[op] = Latency{
+			Count: len(sorted),
+			P50:   percentile(sorted, 0.50),
+			P90:   percentile(sorted, 0.90),
+			P99:   percentile(sorted, 0.99),
+			Max:   sorted[len(sorted)-1],
+		}
+	}
+
+	return latencies
+}
+
+// percentile returns the nearest-rank percentile of the sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return sorted[rank]
+}
+
+func (l *LatencyClient) AddOrder(ctx context.Context, req AddOrderRequest) (AddOrderResponse, error) {
+	defer l.record(OpAddOrder, time.Now())
+	return l.client.AddOrder(ctx, req)
+}
+
+func (l *LatencyClient) CancelOrder(ctx context.Context, req CancelOrderRequest) (CancelOrderResponse, error) {
+	defer l.record(OpCancelOrder, time.Now())
+	return l.client.CancelOrder(ctx, req)
+}
+
+func (l *LatencyClient) EditOrder(ctx context.Context, req EditOrderRequest) (EditOrderResponse, error) {
+	defer l.record(OpEditOrder, time.Now())
+	return l.client.EditOrder(ctx, req)
+}
+
+func (l *LatencyClient) GetOrder(ctx context.Context, req GetOrderRequest) (GetOrderResponse, error) {
+	defer l.record(OpGetOrder, time.Now())
+	return l.client.GetOrder(ctx, req)
+}
+
+func (l *LatencyClient) ListOpenOrders(ctx context.Context, req ListOpenOrdersRequest) (ListOpenOrdersResponse, error) {
+	defer l.record(OpListOpenOrders, time.Now())
+	return l.client.ListOpenOrders(ctx, req)
+}
@@ -3,9 +3,9 @@ package client
 import (
 	"context"
 	"fmt"
-	"log/slog"
 
 	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/session"
 )
 
 func NewLOBClient(lob *lob.Orderbook) *LOBClient {
@@ -17,18 +17,30 @@ func NewLOBClient(lob *lob.Orderbook) *LOBClient {
 var _ Client = &LOBClient{}
 
 type LOBClient struct {
-	lob *lob.Orderbook
+	lob     *lob.Orderbook
+	session *session.Session
+}
+
+// WithSession returns a client entering orders on the session's behalf: they're tagged with the session, and the client
+// order IDs of orders without an account are looked up in its scope.
+func (l *LOBClient) WithSession(s *session.Session) *LOBClient {
+	return &LOBClient{
+		lob:     l.lob,
+		session: s,
+	}
 }
 
 func (l *LOBClient) AddOrder(ctx context.Context, req AddOrderRequest) (AddOrderResponse, error) {
 	order := lob.NewOrder(req.OrderType, req.OrderSide, req.Price, req.Size)
-
-	// TODO: remove
-	slog.Info("Placing order", "order", order.String())
+	order.AccountID = req.AccountID
+	order.ClientOrderID = req.ClientOrderID
+	if l.session != nil {
+		l.session.Tag(order)
+	}
 
 	id, err := l.lob.PlaceOrder(order)
 	if err != nil {
-		return AddOrderResponse{}, fmt.Errorf("add order: %w", err)
+		return AddOrderResponse{OrderID: id}, fmt.Errorf("add order: %w", err)
 	}
 
 	return AddOrderResponse{
@@ -37,9 +49,79 @@ func (l *LOBClient) AddOrder(ctx context.Context, req AddOrderRequest) (AddOrder
 }
 
 func (l *LOBClient) CancelOrder(ctx context.Context, req CancelOrderRequest) (CancelOrderResponse, error) {
-	return CancelOrderResponse{}, fmt.Errorf(`unimplemented`)
+	orderID, err := l.resolve(req.OrderID, req.AccountID, req.ClientOrderID)
+	if err != nil {
+		return CancelOrderResponse{}, fmt.Errorf("cancel order: %w", err)
+	}
+
+	if err := l.lob.CancelOrder(orderID); err != nil {
+		return CancelOrderResponse{}, fmt.Errorf("cancel order: %w", err)
+	}
+
+	return CancelOrderResponse{}, nil
 }
 
 func (l *LOBClient) EditOrder(ctx context.Context, req EditOrderRequest) (EditOrderResponse, error) {
-	return EditOrderResponse{}, fmt.Errorf(`unimplemented`)
+	orderID, err := l.resolve(req.OrderID, req.AccountID, req.ClientOrderID)
+	if err != nil {
+		return EditOrderResponse{}, fmt.Errorf("edit order: %w", err)
+	}
+
+	if err := l.lob.EditOrder(&lob.Order{
+		ID:    orderID,
+		Price: req.Price,
+		Size:  req.Size,
+	}); err != nil {
+		return EditOrderResponse{}, fmt.Errorf("edit order: %w", err)
+	}
+
+	return EditOrderResponse{}, nil
+}
+
+func (l *LOBClient) GetOrder(ctx context.Context, req GetOrderRequest) (GetOrderResponse, error) {
+	if req.OrderID == 0 {
+		order, err := l.lob.ClientOrder(req.AccountID, l.sessionID(), req.ClientOrderID)
+		if err != nil {
+			return GetOrderResponse{}, fmt.Errorf("get order: %w", err)
+		}
+
+		return GetOrderResponse{Order: order}, nil
+	}
+
+	order, err := l.lob.GetOrder(req.OrderID)
+	if err != nil {
+		return GetOrderResponse{}, fmt.Errorf("get order: %w", err)
+	}
+
+	return GetOrderResponse{
+		Order: order,
+	}, nil
+}
+
+func (l *LOBClient) ListOpenOrders(ctx context.Context, req ListOpenOrdersRequest) (ListOpenOrdersResponse, error) {
+	return ListOpenOrdersResponse{
+		Orders: l.lob.OpenOrders(),
+	}, nil
+}
+
+// resolve returns the order ID, or if it's zero the ID of the account's order with the client order ID.
+func (l *LOBClient) resolve(orderID, accountID uint64, clientOrderID string) (uint64, error) {
+	if orderID != 0 {
+		return orderID, nil
+	}
+
+	order, err := l.lob.ClientOrder(accountID, l.sessionID(), clientOrderID)
+	if err != nil {
+		return 0, err
+	}
+
+	return order.ID, nil
+}
+
+func (l *LOBClient) sessionID() uint64 {
+	if l.session == nil {
+		return 0
+	}
+
+	return l.session.ID
 }
@@ -6,12 +6,13 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
-	"time"
 
 	"github.com/sashajdn/orderbook/benchmarks/client"
 	"github.com/sashajdn/orderbook/benchmarks/executor"
 	"github.com/sashajdn/orderbook/benchmarks/load"
+	"github.com/sashajdn/orderbook/benchmarks/scenario"
 	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/pnl"
 )
 
 func main() {
@@ -19,55 +20,20 @@ func main() {
 	// LOB setup.
 	lob := lob.NewOrderbook(2 << 16)
 
+	// Positions setup.
+	positions := pnl.NewTracker()
+	lob.Subscribe(positions.HandleEvent)
+
 	// Client setup.
 	client := client.NewLOBClient(lob)
 
 	// Executor setup.
-	taker := executor.NewTaker(executor.TakerConfig{
-		Users:  10,
-		Client: client,
-	})
-
-	maker := executor.NewMaker(executor.MakerConfig{
-		Client:      client,
-		Users:       10,
-		Spread:      5,
-		Midprice:    1000,
-		LaplaceBeta: 1.0,
-	})
+	trading := scenario.NewTrading(client)
 
 	slog.Info("Direct benchmark setup complete")
 	slog.Info(`Direct benchmark executing stages...`)
 
-	stages := []*load.Stage{
-		{
-			Name:                "book_warmup",
-			RelativeStartTime:   0,
-			Duration:            1 * time.Minute,
-			ThroughputPerMinute: 1000,
-			NumberOfExecutors:   10,
-			Executor:            maker,
-			LoadCurve:           load.LoadCurveLinear,
-		},
-		{
-			Name:                "maker",
-			RelativeStartTime:   1 * time.Minute,
-			Duration:            1 * time.Minute,
-			ThroughputPerMinute: 100,
-			NumberOfExecutors:   10,
-			Executor:            maker,
-			LoadCurve:           load.LoadCurveLinear,
-		},
-		{
-			Name:                "taker",
-			RelativeStartTime:   1 * time.Minute,
-			Duration:            1 * time.Minute,
-			ThroughputPerMinute: 100,
-			NumberOfExecutors:   10,
-			Executor:            taker,
-			LoadCurve:           load.LoadCurveLinear,
-		},
-	}
+	stages := trading.Stages(trading.InventoryReporters(positions, lob)...)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Kill, os.Interrupt)
 	defer cancel()
@@ -2,9 +2,36 @@ package executor
 
 import (
 	"context"
+	"sort"
 )
 
 type Executor interface {
 	RunIteration(ctx context.Context) error
 	Name() string
 }
+
+func newUsers(firstUserID uint64, users uint) map[uint64]struct{} {
+	if firstUserID == 0 {
+		firstUserID = 1
+	}
+
+	usersMap := make(map[uint64]struct{}, users)
+	for userID := firstUserID; userID < firstUserID+uint64(users); userID++ {
+		usersMap[userID] = struct{}{}
+	}
+
+	return usersMap
+}
+
+func sortedUsers(users map[uint64]struct{}) []uint64 {
+	sorted := make([]uint64, 0, len(users))
+	for user := range users {
+		sorted = append(sorted, user)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted
+}
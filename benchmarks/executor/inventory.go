@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/sashajdn/orderbook/pnl"
+)
+
+type InventoryReporterConfig struct {
+	Name      string
+	Users     []uint64
+	Positions *pnl.Tracker
+	Marker    pnl.Marker
+}
+
+func NewInventoryReporter(config InventoryReporterConfig) *InventoryReporter {
+	users := make(map[uint64]struct{}, len(config.Users))
+	for _, user := range config.Users {
+		users[user] = struct{}{}
+	}
+
+	return &InventoryReporter{
+		name:      config.Name,
+		users:     users,
+		positions: config.Positions,
+		marker:    config.Marker,
+	}
+}
+
+var _ Executor = &InventoryReporter{}
+
+// InventoryReporter logs the inventory drift & PnL of a set of users, marked to the book's mid.
+type InventoryReporter struct {
+	name      string
+	users     map[uint64]struct{}
+	positions *pnl.Tracker
+	marker    pnl.Marker
+}
+
+func (i *InventoryReporter) RunIteration(_ context.Context) error {
+	mid, err := i.marker.Mid()
+	if err != nil {
+		return fmt.Errorf("inventory reporter mark to mid: %w", err)
+	}
+
+	var (
+		net        float64
+		realized   float64
+		unrealized float64
+	)
+	for _, user := range sortedUsers(i.users) {
+		position := i.positions.Position(user, mid)
+
+		net += float64(position.Size)
+		realized += position.RealizedPnL
+		unrealized += position.UnrealizedPnL
+
+		slog.Debug("INVENTORY", "reporter", i.name, "position", position.String())
+	}
+
+	slog.Info(
+		"INVENTORY",
+		"reporter", i.name,
+		"mid", fmt.Sprintf("%.4f", mid),
+		"net_size", fmt.Sprintf("%.4f", net),
+		"realized_pnl", fmt.Sprintf("%.4f", realized),
+		"unrealized_pnl", fmt.Sprintf("%.4f", unrealized),
+	)
+
+	return nil
+}
+
+func (i *InventoryReporter) Name() string { return "inventory_reporter_" + i.name }
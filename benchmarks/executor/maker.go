@@ -11,7 +11,9 @@ import (
 )
 
 type MakerConfig struct {
-	Users       uint
+	Users uint
+	// FirstUserID is the account ID of the first user; users are numbered consecutively from it, starting at 1 if unset.
+	FirstUserID uint64
 	LaplaceBeta float64
 	Midprice    lob.Price
 	Spread      float64
@@ -19,14 +21,9 @@ type MakerConfig struct {
 }
 
 func NewMaker(config MakerConfig) *Maker {
-	usersMap := make(map[uint64]struct{}, config.Users)
-	for userID := 1; userID <= int(config.Users); userID++ {
-		usersMap[uint64(userID)] = struct{}{}
-	}
-
 	return &Maker{
 		client:      config.Client,
-		users:       usersMap,
+		users:       newUsers(config.FirstUserID, config.Users),
 		laplaceBeta: config.LaplaceBeta,
 		midprice:    config.Midprice,
 		spread:      config.Spread,
@@ -45,7 +42,7 @@ type Maker struct {
 
 func (m *Maker) RunIteration(ctx context.Context) error {
 	for user := range m.users {
-		if err := m.runIteration(ctx); err != nil {
+		if err := m.runIteration(ctx, user); err != nil {
 			return fmt.Errorf("maker run iteration for user %d: %w", user, err)
 		}
 
@@ -57,7 +54,10 @@ func (m *Maker) RunIteration(ctx context.Context) error {
 
 func (m *Maker) Name() string { return "maker" }
 
-func (m *Maker) runIteration(ctx context.Context) error {
+// Users returns the account IDs the maker places orders for.
+func (m *Maker) Users() []uint64 { return sortedUsers(m.users) }
+
+func (m *Maker) runIteration(ctx context.Context, user uint64) error {
 	side := lob.BuySide // TODO:
 	if rand.Float64() < 0.5 {
 		side = lob.SellSide
@@ -69,6 +69,7 @@ func (m *Maker) runIteration(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("generate order: %w", err)
 	}
+	order.AccountID = user
 
 	if _, err = m.client.AddOrder(ctx, order); err != nil {
 		return fmt.Errorf("add order: %w", err)
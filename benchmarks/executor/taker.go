@@ -10,19 +10,16 @@ import (
 )
 
 type TakerConfig struct {
-	Users  uint
-	Client client.Client
+	Users uint
+	// FirstUserID is the account ID of the first user; users are numbered consecutively from it, starting at 1 if unset.
+	FirstUserID uint64
+	Client      client.Client
 }
 
 func NewTaker(config TakerConfig) *Taker {
-	usersMap := make(map[uint64]struct{}, config.Users)
-	for userID := 1; userID <= int(config.Users); userID++ {
-		usersMap[uint64(userID)] = struct{}{}
-	}
-
 	return &Taker{
 		client: config.Client,
-		users:  usersMap,
+		users:  newUsers(config.FirstUserID, config.Users),
 	}
 }
 
@@ -35,7 +32,7 @@ type Taker struct {
 
 func (t *Taker) RunIteration(ctx context.Context) error {
 	for user := range t.users {
-		if err := t.runIteration(ctx); err != nil {
+		if err := t.runIteration(ctx, user); err != nil {
 			return fmt.Errorf("taker run iteration for user %d: %w", user, err)
 		}
 
@@ -47,13 +44,17 @@ func (t *Taker) RunIteration(ctx context.Context) error {
 
 func (t *Taker) Name() string { return "taker" }
 
-func (t *Taker) runIteration(ctx context.Context) error {
+// Users returns the account IDs the taker places orders for.
+func (t *Taker) Users() []uint64 { return sortedUsers(t.users) }
+
+func (t *Taker) runIteration(ctx context.Context, user uint64) error {
 	side := lob.BuySide // TODO:
 	if rand.Float64() < 0.5 {
 		side = lob.SellSide
 	}
 
 	order := client.AddOrderRequest{
+		AccountID: user,
 		OrderType: lob.MarketOrder,
 		OrderSide: side,
 		Size:      1,
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/benchmarks/load"
+	"github.com/sashajdn/orderbook/benchmarks/scenario"
+	"github.com/sashajdn/orderbook/rest"
+	"github.com/sashajdn/orderbook/rpc"
+	"github.com/sashajdn/orderbook/sbe"
+	"github.com/sashajdn/orderbook/ws"
+)
+
+// network runs the direct benchmark's stages against a server process, e.g. cmd/server, over one of its protocols; the
+// latencies reported include serialization & the network stack. The book's out of process, so inventories aren't
+// reported.
+func main() {
+	var (
+		protocol = flag.String("protocol", "http", "protocol to benchmark: http, ws, tcp, grpc or grpc-stream")
+		addr     = flag.String("addr", "", "server address; by default cmd/server's for the protocol")
+	)
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Kill, os.Interrupt)
+	defer cancel()
+
+	slog.Info("Running network benchmarking...", "protocol", *protocol)
+
+	// Client setup.
+	networkClient, closeClient, err := dial(ctx, *protocol, *addr)
+	if err != nil {
+		slog.Error("Failed to connect", "error", err)
+		os.Exit(1)
+	}
+	defer closeClient()
+
+	latencyClient := client.NewLatencyClient(networkClient)
+
+	// Executor setup.
+	trading := scenario.NewTrading(latencyClient)
+
+	slog.Info("Network benchmark setup complete")
+	slog.Info(`Network benchmark executing stages...`)
+
+	generator := load.NewGenerator(trading.Stages())
+	if err := generator.Run(ctx); err != nil {
+		slog.Error("Failed to run generator", "error", err)
+	}
+
+	latencies := latencyClient.Latencies()
+
+	ops := make([]string, 0, len(latencies))
+	for op := range latencies {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	for _, op := range ops {
+		latency := latencies[op]
+		slog.Info(
+			"LATENCY",
+			"op", op,
+			"count", latency.Count,
+			"p50", latency.P50.String(),
+			"p90", latency.P90.String(),
+			"p99", latency.P99.String(),
+			"max", latency.Max.String(),
+		)
+	}
+}
+
+func dial(ctx context.Context, protocol, addr string) (client.Client, func() error, error) {
+	switch protocol {
+	case "http":
+		if addr == "" {
+			addr = "localhost:8080"
+		}
+
+		// The stages' executors run concurrently, so keep a connection idle for each.
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = 64
+
+		return rest.NewClient("http://"+addr, &http.Client{Transport: transport}), func() error { return nil }, nil
+	case "ws":
+		if addr == "" {
+			addr = "localhost:8080"
+		}
+
+		c, err := ws.Dial(ctx, "ws://"+addr+"/ws")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return c, c.Close, nil
+	case "tcp":
+		if addr == "" {
+			addr = "localhost:9000"
+		}
+
+		c, err := sbe.Dial(ctx, addr, sbe.ClientConfig{})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return c, c.Close, nil
+	case "grpc", "grpc-stream":
+		if addr == "" {
+			addr = "localhost:9090"
+		}
+
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		c := rpc.NewClient(conn)
+		if protocol == "grpc" {
+			return c, conn.Close, nil
+		}
+
+		stream, err := c.OrderEntry(ctx)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+
+		return stream, func() error {
+			stream.Close()
+			return conn.Close()
+		}, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+}
@@ -0,0 +1,102 @@
+// Package scenario is the trading the benchmarks put the engine under, shared so the direct & network benchmarks run the
+// same stages and their results compare.
+package scenario
+
+import (
+	"time"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/benchmarks/executor"
+	"github.com/sashajdn/orderbook/benchmarks/load"
+	"github.com/sashajdn/orderbook/pnl"
+)
+
+// Trading is the makers quoting around the mid & the takers trading against them.
+type Trading struct {
+	Maker *executor.Maker
+	Taker *executor.Taker
+}
+
+func NewTrading(client client.Client) *Trading {
+	return &Trading{
+		Maker: executor.NewMaker(executor.MakerConfig{
+			Client:      client,
+			Users:       10,
+			Spread:      5,
+			Midprice:    1000,
+			LaplaceBeta: 1.0,
+		}),
+		Taker: executor.NewTaker(executor.TakerConfig{
+			Users:       10,
+			FirstUserID: 11,
+			Client:      client,
+		}),
+	}
+}
+
+// Stages warms the book up with the makers' quotes, then has the makers & takers trade for a minute; reporters run
+// alongside them while they trade.
+func (t *Trading) Stages(reporters ...executor.Executor) []*load.Stage {
+	stages := []*load.Stage{
+		{
+			Name:                "book_warmup",
+			RelativeStartTime:   0,
+			Duration:            1 * time.Minute,
+			ThroughputPerMinute: 1000,
+			NumberOfExecutors:   10,
+			Executor:            t.Maker,
+			LoadCurve:           load.LoadCurveLinear,
+		},
+		{
+			Name:                "maker",
+			RelativeStartTime:   1 * time.Minute,
+			Duration:            1 * time.Minute,
+			ThroughputPerMinute: 100,
+			NumberOfExecutors:   10,
+			Executor:            t.Maker,
+			LoadCurve:           load.LoadCurveLinear,
+		},
+		{
+			Name:                "taker",
+			RelativeStartTime:   1 * time.Minute,
+			Duration:            1 * time.Minute,
+			ThroughputPerMinute: 100,
+			NumberOfExecutors:   10,
+			Executor:            t.Taker,
+			LoadCurve:           load.LoadCurveLinear,
+		},
+	}
+
+	for _, reporter := range reporters {
+		stages = append(stages, &load.Stage{
+			Name:                reporter.Name(),
+			RelativeStartTime:   1 * time.Minute,
+			Duration:            1 * time.Minute,
+			ThroughputPerMinute: 6,
+			NumberOfExecutors:   1,
+			Executor:            reporter,
+			LoadCurve:           load.LoadCurveLinear,
+		})
+	}
+
+	return stages
+}
+
+// InventoryReporters report the makers' & takers' inventories, marked by marker; the positions are tracked from the
+// book's events, so they need the book in process.
+func (t *Trading) InventoryReporters(positions *pnl.Tracker, marker pnl.Marker) []executor.Executor {
+	return []executor.Executor{
+		executor.NewInventoryReporter(executor.InventoryReporterConfig{
+			Name:      t.Maker.Name(),
+			Users:     t.Maker.Users(),
+			Positions: positions,
+			Marker:    marker,
+		}),
+		executor.NewInventoryReporter(executor.InventoryReporterConfig{
+			Name:      t.Taker.Name(),
+			Users:     t.Taker.Users(),
+			Positions: positions,
+			Marker:    marker,
+		}),
+	}
+}
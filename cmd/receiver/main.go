@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/sashajdn/orderbook/multicast"
+	pkgslog "github.com/sashajdn/orderbook/pkg/slog"
+)
+
+// receiver is the reference multicast receiver: it joins the server's multicast groups, rebuilds the book's depth from
+// them, recovering missed packets from the retransmission server, and logs the top of the book & its stats periodically.
+func main() {
+	var (
+		mcastAddr   = flag.String("multicast", "239.255.0.1:30001", "multicast group depth updates & trades are published to")
+		snapAddr    = flag.String("multicast-snapshot", "239.255.0.2:30002", "multicast group depth snapshots are published to")
+		mcastIfName = flag.String("multicast-interface", "lo", "interface the groups are joined on; the system's default if empty")
+		rtxAddr     = flag.String("retransmit", "localhost:9100", "multicast retransmission server address; missed packets wait for a snapshot if empty")
+		interval    = flag.Duration("interval", time.Second, "how often the book is logged")
+		verbose     = flag.Bool("v", false, "debug logging; logs every trade")
+	)
+	flag.Parse()
+
+	level := pkgslog.Info
+	if *verbose {
+		level = pkgslog.Debug
+	}
+	pkgslog.Init(level)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Kill, os.Interrupt)
+	defer cancel()
+
+	config := multicast.ReceiverConfig{
+		RetransmitAddr: *rtxAddr,
+		OnTrade: func(trade multicast.Trade) {
+			slog.Debug("Trade", "price", trade.Price, "size", trade.Size, "aggressor_side", trade.AggressorSide.String())
+		},
+	}
+
+	var err error
+	if config.Incremental, err = net.ResolveUDPAddr("udp4", *mcastAddr); err != nil {
+		slog.Error("Invalid multicast group", "error", err)
+		os.Exit(2)
+	}
+
+	if config.Snapshot, err = net.ResolveUDPAddr("udp4", *snapAddr); err != nil {
+		slog.Error("Invalid multicast snapshot group", "error", err)
+		os.Exit(2)
+	}
+
+	if *mcastIfName != "" {
+		if config.Interface, err = net.InterfaceByName(*mcastIfName); err != nil {
+			slog.Error("Invalid multicast interface", "error", err)
+			os.Exit(2)
+		}
+	}
+
+	receiver, err := multicast.NewReceiver(config)
+	if err != nil {
+		slog.Error("Failed to join multicast groups", "error", err)
+		os.Exit(1)
+	}
+
+	go func() {
+		t := time.NewTicker(*interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+
+			depth, synced := receiver.Depth()
+			if !synced {
+				slog.Info("Waiting for snapshot", "stats", receiver.Stats())
+				continue
+			}
+
+			attrs := []any{"seq", depth.Seq, "bid_levels", len(depth.Bids), "ask_levels", len(depth.Asks), "stats", receiver.Stats()}
+			if len(depth.Bids) > 0 {
+				attrs = append(attrs, "best_bid", depth.Bids[0].Price, "best_bid_size", depth.Bids[0].Size)
+			}
+			if len(depth.Asks) > 0 {
+				attrs = append(attrs, "best_ask", depth.Asks[0].Price, "best_ask_size", depth.Asks[0].Size)
+			}
+			slog.Info("Book", attrs...)
+		}
+	}()
+
+	if err := receiver.Run(ctx); err != nil {
+		slog.Error("Receiver failed", "error", err)
+		os.Exit(1)
+	}
+}
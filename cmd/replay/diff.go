@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// diffSnapshots describes how the book changed between two snapshots: the trading state, every resting order added (+),
+// removed (-) or changed (~), bids before asks in queue order, then every order that finished (x).
+func diffSnapshots(before, after lob.Snapshot) []string {
+	var diff []string
+
+	if before.State != after.State {
+		diff = append(diff, fmt.Sprintf("state: %s -> %s", before.State, after.State))
+	}
+
+	if before.LastTradePrice != after.LastTradePrice {
+		diff = append(diff, fmt.Sprintf("last trade price: %.6f -> %.6f", before.LastTradePrice, after.LastTradePrice))
+	}
+
+	diff = append(diff, diffLevels(lob.BuySide, before.Bids, after.Bids)...)
+	diff = append(diff, diffLevels(lob.SellSide, before.Asks, after.Asks)...)
+
+	finished := make(map[uint64]bool, len(before.Finished))
+	for _, order := range before.Finished {
+		finished[order.ID] = true
+	}
+
+	for _, order := range after.Finished {
+		if !finished[order.ID] {
+			diff = append(diff, fmt.Sprintf("x %s %s", order.Side, describeOrder(order)))
+		}
+	}
+
+	if len(diff) == 0 {
+		diff = append(diff, "no change to the book")
+	}
+
+	return diff
+}
+
+type queuedOrder struct {
+	order    lob.OrderSnapshot
+	position int
+}
+
+func diffLevels(side lob.OrderSide, before, after []lob.LevelSnapshot) []string {
+	var (
+		diff     []string
+		previous = queuedOrders(before)
+		current  = queuedOrders(after)
+	)
+
+	for _, level := range before {
+		for _, order := range level.Orders {
+			if _, ok := current[order.ID]; !ok {
+				diff = append(diff, fmt.Sprintf("- %s %s", side, describeOrder(order)))
+			}
+		}
+	}
+
+	for _, level := range after {
+		for position, order := range level.Orders {
+			was, ok := previous[order.ID]
+			switch {
+			case !ok:
+				diff = append(diff, fmt.Sprintf("+ %s %s queue=%d", side, describeOrder(order), position))
+			case was.order != order || was.position != position:
+				diff = append(diff, fmt.Sprintf("~ %s %s queue=%d -> %s queue=%d", side, describeOrder(was.order), was.position, describeOrder(order), position))
+			}
+		}
+	}
+
+	return diff
+}
+
+func queuedOrders(levels []lob.LevelSnapshot) map[uint64]queuedOrder {
+	orders := make(map[uint64]queuedOrder)
+	for _, level := range levels {
+		for position, order := range level.Orders {
+			orders[order.ID] = queuedOrder{order: order, position: position}
+		}
+	}
+
+	return orders
+}
+
+func describeOrder(order lob.OrderSnapshot) string {
+	description := fmt.Sprintf("order=%d account=%d %.6f@%.6f remaining=%.6f filled=%.6f status=%s",
+		order.ID, order.AccountID, order.Size, order.Price, order.RemainingSize, order.FilledSize, order.Status)
+
+	if order.RejectReason != 0 {
+		description += " reason=" + order.RejectReason.String()
+	}
+
+	return description
+}
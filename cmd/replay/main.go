@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/sashajdn/orderbook/journal"
+	"github.com/sashajdn/orderbook/lob"
+	pkgslog "github.com/sashajdn/orderbook/pkg/slog"
+	"github.com/sashajdn/orderbook/risk"
+)
+
+// replay runs a recorded command journal through a fresh Orderbook, checking the book's rolling state hash after every
+// command against the hashes recorded at the time, and stops at the first divergence with a diff of what the command did.
+//
+// The Orderbook must be configured as the recording one was; price bands, retention, the instrument, and whether it had a
+// ledger, fee schedule or risk engine are set with flags. Deposits, fee tiers & risk limits are journaled, so they're replayed.
+func main() {
+	var (
+		journalPath   = flag.String("journal", "", "path to the command journal to replay")
+		size          = flag.Uint64("size", 2<<16, "orderbook size")
+		retention     = flag.Duration("retention", lob.DefaultOrderRetention, "finished order retention")
+		bandWidth     = flag.Float64("band-width", 0, "price band width as a fraction of the reference price; 0 disables bands")
+		bandWindow    = flag.Duration("band-window", 5*time.Minute, "price band reference window")
+		bandPause     = flag.Duration("band-pause", 5*time.Minute, "trading pause after a price band breach")
+		bandReference = flag.Float64("band-reference", 0, "price band reference price before the first trade")
+		symbol        = flag.String("symbol", "BASE/QUOTE", "instrument symbol")
+		base          = flag.String("base", "BASE", "instrument base asset")
+		quote         = flag.String("quote", "QUOTE", "instrument quote asset")
+		withLedger    = flag.Bool("ledger", false, "replay with a ledger")
+		withFees      = flag.Bool("fees", false, "replay with a fee schedule")
+		makerRate     = flag.Float64("maker-rate", 0, "default fee tier maker rate")
+		takerRate     = flag.Float64("taker-rate", 0, "default fee tier taker rate")
+		minFee        = flag.Float64("min-fee", 0, "default fee tier minimum fee")
+		feeAccount    = flag.Uint64("fee-account", 0, "account fees are paid into")
+		withRisk      = flag.Bool("risk", false, "replay with a risk engine")
+		verbose       = flag.Bool("v", false, "debug logging")
+	)
+	flag.Parse()
+
+	level := pkgslog.Info
+	if *verbose {
+		level = pkgslog.Debug
+	}
+	pkgslog.Init(level)
+
+	if *journalPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := []lob.Option{
+		lob.WithOrderRetention(*retention),
+		lob.WithInstrument(lob.Instrument{Symbol: *symbol, Base: lob.Asset(*base), Quote: lob.Asset(*quote)}),
+	}
+	if *bandWidth > 0 {
+		opts = append(opts, lob.WithPriceBands(lob.PriceBandConfig{
+			Width:          *bandWidth,
+			Window:         *bandWindow,
+			PauseDuration:  *bandPause,
+			ReferencePrice: lob.Price(*bandReference),
+		}))
+	}
+
+	// Each book has its own ledger, fee schedule & risk engine, as they're replayed into.
+	newBook := func() *lob.Orderbook {
+		opts := append([]lob.Option(nil), opts...)
+
+		if *withLedger {
+			opts = append(opts, lob.WithLedger(lob.NewLedger()))
+		}
+
+		if *withFees {
+			fees := lob.NewFeeSchedule(lob.FeeTier{MakerRate: *makerRate, TakerRate: *takerRate, MinFee: *minFee})
+			fees.SetFeeAccount(*feeAccount)
+			opts = append(opts, lob.WithFeeSchedule(fees))
+		}
+
+		var engine *risk.Engine
+		if *withRisk {
+			engine = risk.NewEngine(risk.Limits{})
+			opts = append(opts, lob.WithPreTradeCheck(engine))
+		}
+
+		book := lob.NewOrderbook(*size, opts...)
+		if engine != nil {
+			book.Subscribe(engine.HandleEvent)
+		}
+
+		return book
+	}
+
+	book := newBook()
+	divergence, checked, err := journal.Verify(*journalPath, book, newBook)
+	if err != nil {
+		slog.Error("Replay failed", "error", err)
+		os.Exit(1)
+	}
+
+	if divergence == nil {
+		if checked == 0 {
+			slog.Warn("Replay complete; the journal has no recorded hashes to check against", "seq", book.LastSeq())
+			return
+		}
+
+		slog.Info("Replay matched every recorded hash", "hashes_checked", checked, "seq", book.LastSeq())
+		return
+	}
+
+	fmt.Printf("Diverged after %s\n", divergence.Command.String())
+	fmt.Printf("  recorded hash: %016x\n", divergence.Recorded)
+	fmt.Printf("  replayed hash: %016x\n", divergence.Replayed)
+	fmt.Printf("  hashes matched before divergence: %d\n\n", checked-1)
+	fmt.Println("Replayed changes made by the command:")
+	for _, line := range diffSnapshots(divergence.Before, divergence.After) {
+		fmt.Println("  " + line)
+	}
+
+	os.Exit(1)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"google.golang.org/grpc"
+
+	"github.com/sashajdn/orderbook/fix"
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/multicast"
+	pkgslog "github.com/sashajdn/orderbook/pkg/slog"
+	"github.com/sashajdn/orderbook/rest"
+	"github.com/sashajdn/orderbook/rpc"
+	"github.com/sashajdn/orderbook/rpc/pb"
+	"github.com/sashajdn/orderbook/sbe"
+	"github.com/sashajdn/orderbook/session"
+	"github.com/sashajdn/orderbook/ws"
+)
+
+// server serves a single Orderbook over every protocol the engine speaks: the REST API at / & WebSocket at /ws on the
+// HTTP address, binary order entry on the TCP address, gRPC, and, if their addresses are given, FIX & multicast market
+// data.
+func main() {
+	var (
+		httpAddr    = flag.String("http", "localhost:8080", "REST & WebSocket listen address")
+		tcpAddr     = flag.String("tcp", "localhost:9000", "binary order entry listen address")
+		grpcAddr    = flag.String("grpc", "localhost:9090", "gRPC listen address")
+		fixAddr     = flag.String("fix", "", "FIX listen address; FIX is disabled if unset")
+		fixCompID   = flag.String("fix-comp-id", "ORDERBOOK", "FIX comp ID")
+		mcastAddr   = flag.String("multicast", "", "multicast group depth updates & trades are published to, e.g. 239.255.0.1:30001; multicast is disabled if unset")
+		snapAddr    = flag.String("multicast-snapshot", "239.255.0.2:30002", "multicast group depth snapshots are published to")
+		mcastIfName = flag.String("multicast-interface", "", "interface multicast is sent from; the system's default if unset")
+		rtxAddr     = flag.String("retransmit", "localhost:9100", "multicast retransmission listen address")
+		size        = flag.Uint64("size", 2<<16, "orderbook size")
+		depthLevels = flag.Int("depth-levels", 10, "levels per side published to market data subscribers")
+		verbose     = flag.Bool("v", false, "debug logging")
+	)
+	flag.Parse()
+
+	level := pkgslog.Info
+	if *verbose {
+		level = pkgslog.Debug
+	}
+	pkgslog.Init(level)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Kill, os.Interrupt)
+	defer cancel()
+
+	book := lob.NewOrderbook(*size, lob.WithDepthEvents(*depthLevels))
+
+	// Order entry sessions share a manager, so their IDs are unique across protocols.
+	sessions := session.NewManager(session.Config{Canceller: book, Clock: book.Clock()})
+	go sessions.Run(ctx)
+
+	restServer := rest.NewServer(rest.Config{Book: book})
+	defer restServer.Close()
+
+	wsServer := ws.NewServer(ws.Config{Book: book, Sessions: sessions, DepthLevels: *depthLevels})
+	defer wsServer.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/ws", wsServer)
+	mux.Handle("/", restServer)
+
+	httpServer := &http.Server{Addr: *httpAddr, Handler: mux}
+	go func() {
+		slog.Info("Serving REST & WebSocket", "addr", *httpAddr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server failed", "error", err)
+			cancel()
+		}
+	}()
+
+	sbeServer := sbe.NewServer(sbe.ServerConfig{Book: book, Sessions: sessions})
+	defer sbeServer.Close()
+
+	if err := serve(*tcpAddr, "binary order entry", sbeServer.Serve, cancel); err != nil {
+		slog.Error("Failed to listen", "error", err)
+		os.Exit(1)
+	}
+
+	rpcServer := rpc.NewServer(rpc.Config{Book: book, Sessions: sessions, DepthLevels: *depthLevels})
+	defer rpcServer.Close()
+
+	grpcServer := grpc.NewServer()
+	pb.RegisterOrderbookServer(grpcServer, rpcServer)
+	defer grpcServer.Stop()
+
+	if err := serve(*grpcAddr, "gRPC", grpcServer.Serve, cancel); err != nil {
+		slog.Error("Failed to listen", "error", err)
+		os.Exit(1)
+	}
+
+	if *fixAddr != "" {
+		acceptor := fix.NewAcceptor(fix.Config{
+			CompID:      *fixCompID,
+			Book:        book,
+			Sessions:    sessions,
+			MarketDepth: *depthLevels,
+		})
+		defer acceptor.Close()
+
+		if err := serve(*fixAddr, "FIX", acceptor.Serve, cancel); err != nil {
+			slog.Error("Failed to listen", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if *mcastAddr != "" {
+		publisher, err := newPublisher(book, *mcastAddr, *snapAddr, *mcastIfName, min(*depthLevels, multicast.MaxDepthLevels))
+		if err != nil {
+			slog.Error("Failed to publish multicast", "error", err)
+			os.Exit(1)
+		}
+		defer publisher.Close()
+
+		slog.Info("Publishing multicast", "incremental", *mcastAddr, "snapshot", *snapAddr)
+		if err := serve(*rtxAddr, "multicast retransmission", publisher.ServeRetransmit, cancel); err != nil {
+			slog.Error("Failed to listen", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	<-ctx.Done()
+	slog.Info("Shutting down")
+
+	if err := httpServer.Shutdown(context.Background()); err != nil {
+		slog.Warn("Failed to shut down HTTP server", "error", err)
+	}
+}
+
+// serve listens on addr, serving the listener until it fails; a failure cancels the server.
+func serve(addr, name string, serve func(net.Listener) error, cancel func()) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		slog.Info("Serving "+name, "addr", l.Addr().String())
+		if err := serve(l); err != nil {
+			slog.Error("Failed to serve "+name, "error", err)
+			cancel()
+		}
+	}()
+
+	return nil
+}
+
+func newPublisher(book *lob.Orderbook, incrementalAddr, snapshotAddr, ifName string, depthLevels int) (*multicast.Publisher, error) {
+	incremental, err := net.ResolveUDPAddr("udp4", incrementalAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := net.ResolveUDPAddr("udp4", snapshotAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	var ifi *net.Interface
+	if ifName != "" {
+		if ifi, err = net.InterfaceByName(ifName); err != nil {
+			return nil, err
+		}
+	}
+
+	return multicast.NewPublisher(multicast.PublisherConfig{
+		Book:        book,
+		DepthLevels: depthLevels,
+		Incremental: incremental,
+		Snapshot:    snapshot,
+		Interface:   ifi,
+	})
+}
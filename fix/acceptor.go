@@ -0,0 +1,798 @@
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/session"
+)
+
+const (
+	DefaultLogonTimeout = 10 * time.Second
+	DefaultWriteTimeout = 5 * time.Second
+
+	// outboundQueueSize bounds the messages waiting to be written to a session; a session that falls this far behind is disconnected.
+	outboundQueueSize = 4096
+)
+
+var errSlowConsumer = errors.New("outbound queue full")
+
+type Config struct {
+	// CompID is the acceptor's own comp ID; counterparties log on with it as their TargetCompID.
+	CompID string
+	Book   *lob.Orderbook
+
+	// Sessions tracks each connection as an order entry session; by default a manager cancelling through Book.
+	Sessions *session.Manager
+	// CancelOnDisconnect cancels every order a connection entered should it disconnect.
+	CancelOnDisconnect bool
+
+	// Accounts maps a counterparty's comp ID to the account its orders are for, when they don't carry an Account.
+	Accounts map[string]uint64
+	// Symbol is reported on execution reports & market data, and is the only symbol market data can be requested for.
+	Symbol string
+
+	// MarketDepth is the levels per side market data subscriptions are served at most; it must be no more than the book
+	// publishes DepthEvents with. Market data requests are rejected while it's 0.
+	MarketDepth int
+
+	// StoreDir, if set, is where each counterparty's sequence numbers & sent messages are persisted; otherwise they're kept in
+	// memory for as long as the acceptor lives.
+	StoreDir string
+
+	LogonTimeout time.Duration
+	WriteTimeout time.Duration
+}
+
+// NewAcceptor returns an acceptor routing orders to the book; execution reports & market data are built from the book's
+// events, so it subscribes to them until closed.
+func NewAcceptor(config Config) *Acceptor {
+	if config.Sessions == nil {
+		config.Sessions = session.NewManager(session.Config{Canceller: config.Book, Clock: config.Book.Clock()})
+	}
+
+	if config.LogonTimeout == 0 {
+		config.LogonTimeout = DefaultLogonTimeout
+	}
+
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = DefaultWriteTimeout
+	}
+
+	a := &Acceptor{
+		config:         config,
+		counterparties: make(map[string]*counterparty),
+		owners:         make(map[uint64]*counterparty),
+		subscriptions:  make(map[*conn]map[string]*subscription),
+		now:            time.Now,
+	}
+	a.execIDPrefix = fmt.Sprintf("%x", a.now().UnixNano())
+	a.unsubscribe = config.Book.Subscribe(a.onEvent)
+
+	return a
+}
+
+// Acceptor is a FIX 4.4 order entry acceptor: counterparties log on, then place, cancel & replace orders on the book,
+// receiving execution reports as their orders change. They may also subscribe to the book's market data.
+type Acceptor struct {
+	config Config
+
+	// counterparties by comp ID, and by the engine session IDs of each of their connections.
+	counterparties map[string]*counterparty
+	owners         map[uint64]*counterparty
+
+	// subscriptions are each connection's market data subscriptions by MDReqID, and lastTrade the last trade published.
+	subscriptions map[*conn]map[string]*subscription
+	lastTrade     *lob.TradeEvent
+	mdMu          sync.Mutex
+
+	execIDPrefix string
+	execIDs      atomic.Uint64
+	unsubscribe  func()
+	now          func() time.Time
+	mu           sync.Mutex
+}
+
+// counterparty is the state of a comp ID that outlives each of its connections: sequence numbers & its orders.
+type counterparty struct {
+	compID string
+	store  Store
+	conn   *conn
+
+	// clOrdIDs are the client order IDs of the counterparty's open orders, and orderIDs their inverse.
+	clOrdIDs map[uint64]string
+	orderIDs map[string]uint64
+
+	// pending is the request the counterparty's connection is waiting on the book for; its events are reported against it.
+	pending *pending
+
+	mu sync.Mutex
+}
+
+// Serve accepts connections until the listener is closed.
+func (a *Acceptor) Serve(l net.Listener) error {
+	for {
+		netConn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return fmt.Errorf("accept fix connection: %w", err)
+		}
+
+		go a.handle(netConn)
+	}
+}
+
+// Close disconnects every counterparty, and stops reporting the book's events.
+func (a *Acceptor) Close() error {
+	a.unsubscribe()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var errs []error
+	for _, cp := range a.counterparties {
+		cp.mu.Lock()
+		if cp.conn != nil {
+			cp.conn.close()
+		}
+		cp.mu.Unlock()
+
+		if err := cp.store.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close store for %s: %w", cp.compID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// counterparty returns the counterparty's state, opening its store should this be its first logon.
+func (a *Acceptor) counterparty(compID string) (*counterparty, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if cp, ok := a.counterparties[compID]; ok {
+		return cp, nil
+	}
+
+	var store Store = NewMemoryStore()
+	if a.config.StoreDir != "" {
+		fileStore, err := OpenFileStore(a.config.StoreDir, a.config.CompID, compID)
+		if err != nil {
+			return nil, err
+		}
+
+		store = fileStore
+	}
+
+	cp := &counterparty{
+		compID:   compID,
+		store:    store,
+		clOrdIDs: make(map[uint64]string),
+		orderIDs: make(map[string]uint64),
+	}
+	a.counterparties[compID] = cp
+
+	return cp, nil
+}
+
+func (a *Acceptor) handle(netConn net.Conn) {
+	defer netConn.Close()
+
+	r := NewReader(netConn)
+
+	if err := netConn.SetReadDeadline(a.now().Add(a.config.LogonTimeout)); err != nil {
+		return
+	}
+
+	logon, err := readMessage(r)
+	if err != nil {
+		slog.Warn("FIX: failed to read logon", "remote", netConn.RemoteAddr().String(), "error", err)
+		return
+	}
+
+	c, err := a.logon(netConn, logon)
+	if err != nil {
+		slog.Warn("FIX: rejecting logon", "remote", netConn.RemoteAddr().String(), "logon", logon.String(), "error", err)
+		return
+	}
+	defer a.logout(c)
+
+	if err := netConn.SetReadDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	go c.write()
+	go c.monitor()
+
+	if err := c.afterLogon(logon); err != nil {
+		slog.Warn("FIX: logon sequence check failed", "counterparty", c.cp.compID, "error", err)
+		c.flush()
+		return
+	}
+
+	for {
+		raw, err := r.Next()
+		if err != nil {
+			select {
+			case <-c.done:
+			default:
+				slog.Info("FIX: connection closed", "counterparty", c.cp.compID, "error", err)
+			}
+			return
+		}
+
+		c.lastReceived.Store(a.now().UnixNano())
+		if err := a.config.Sessions.Heartbeat(c.engine.ID); err != nil {
+			slog.Warn("FIX: failed to heartbeat session", "counterparty", c.cp.compID, "error", err)
+		}
+
+		msg, err := Parse(raw)
+		if err != nil {
+			// Garbled messages are ignored, without consuming a sequence number.
+			slog.Warn("FIX: ignoring garbled message", "counterparty", c.cp.compID, "error", err)
+			continue
+		}
+
+		if !c.receive(msg) {
+			c.flush()
+			return
+		}
+	}
+}
+
+func readMessage(r *Reader) (*Message, error) {
+	raw, err := r.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(raw)
+}
+
+// logon validates the logon & registers the connection as the counterparty's.
+func (a *Acceptor) logon(netConn net.Conn, logon *Message) (*conn, error) {
+	if logon.MsgType() != MsgTypeLogon {
+		return nil, fmt.Errorf("expected logon, got message type %q", logon.MsgType())
+	}
+
+	if target, _ := logon.Get(TagTargetCompID); target != a.config.CompID {
+		return nil, fmt.Errorf("unknown target comp id %q", target)
+	}
+
+	compID, err := logon.Require(TagSenderCompID)
+	if err != nil {
+		return nil, err
+	}
+
+	heartBtInt, err := logon.Int(TagHeartBtInt)
+	if err != nil {
+		return nil, err
+	}
+
+	if heartBtInt == 0 {
+		return nil, errors.New("heartbeat interval must be positive")
+	}
+
+	cp, err := a.counterparty(compID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{
+		acceptor:  a,
+		cp:        cp,
+		netConn:   netConn,
+		heartbeat: time.Duration(heartBtInt) * time.Second,
+		out:       make(chan outbound, outboundQueueSize),
+		done:      make(chan struct{}),
+	}
+	c.lastReceived.Store(a.now().UnixNano())
+	c.lastSent.Store(a.now().UnixNano())
+
+	cp.mu.Lock()
+	if cp.conn != nil && !cp.conn.closed() {
+		cp.mu.Unlock()
+		return nil, fmt.Errorf("%s is already logged on", compID)
+	}
+
+	if logon.Bool(TagResetSeqNumFlag) {
+		if err := cp.store.Reset(); err != nil {
+			cp.mu.Unlock()
+			return nil, err
+		}
+	}
+
+	c.engine = a.config.Sessions.Connect(a.config.Accounts[compID], a.config.CancelOnDisconnect)
+	cp.conn = c
+	cp.mu.Unlock()
+
+	a.mu.Lock()
+	a.owners[c.engine.ID] = cp
+	a.mu.Unlock()
+
+	slog.Info("FIX: logged on", "counterparty", compID, "session", c.engine.ID, "heartbeat", c.heartbeat)
+
+	return c, nil
+}
+
+// logout unregisters the connection, disconnecting its engine session.
+func (a *Acceptor) logout(c *conn) {
+	c.close()
+	a.removeSubscriptions(c)
+
+	c.cp.mu.Lock()
+	if c.cp.conn == c {
+		c.cp.conn = nil
+	}
+	c.cp.mu.Unlock()
+
+	if _, err := a.config.Sessions.Disconnect(c.engine.ID); err != nil {
+		slog.Warn("FIX: failed to disconnect session", "counterparty", c.cp.compID, "error", err)
+	}
+
+	slog.Info("FIX: logged out", "counterparty", c.cp.compID, "session", c.engine.ID)
+}
+
+// onEvent reports order events to the counterparty whose order it is, and publishes depth & trades to subscribers.
+func (a *Acceptor) onEvent(event lob.Event) {
+	orderEvent, ok := event.(lob.OrderEvent)
+	if !ok {
+		a.onMarketData(event)
+		return
+	}
+
+	a.mu.Lock()
+	cp, ok := a.owners[orderEvent.Order.SessionID]
+	a.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	cp.report(a, orderEvent)
+}
+
+func (a *Acceptor) nextExecID() string {
+	return fmt.Sprintf("%s-%d", a.execIDPrefix, a.execIDs.Add(1))
+}
+
+type outbound struct {
+	msg *Message
+
+	// seq is set when resending a message with its original sequence number.
+	seq uint64
+	// origSendingTime is set on resent messages.
+	origSendingTime string
+	// closeAfter closes the connection once the message is written.
+	closeAfter bool
+}
+
+// conn is a counterparty's logged on connection.
+type conn struct {
+	acceptor  *Acceptor
+	cp        *counterparty
+	netConn   net.Conn
+	engine    *session.Session
+	heartbeat time.Duration
+
+	out       chan outbound
+	done      chan struct{}
+	closeOnce sync.Once
+
+	lastReceived atomic.Int64
+	lastSent     atomic.Int64
+
+	// resending is set once a gap's been detected, until messages arrive in sequence again.
+	resending bool
+}
+
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.netConn.Close()
+	})
+}
+
+// closed reports whether the connection's closed, though it may not have been logged out yet.
+func (c *conn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// flush waits for the logout queued last to be written, and the connection closed after it.
+func (c *conn) flush() {
+	t := time.NewTimer(c.acceptor.config.WriteTimeout)
+	defer t.Stop()
+
+	select {
+	case <-c.done:
+	case <-t.C:
+	}
+}
+
+// send queues the message to be written; it never blocks, as it's called while the book is locked.
+func (c *conn) send(item outbound) {
+	select {
+	case c.out <- item:
+	default:
+		slog.Warn("FIX: disconnecting slow counterparty", "counterparty", c.cp.compID, "error", errSlowConsumer)
+		c.close()
+	}
+}
+
+// sendWait queues the message, waiting for room rather than disconnecting; it's only for the reader goroutine, which never
+// holds the book locked. It returns false once the connection's closed.
+func (c *conn) sendWait(item outbound) bool {
+	select {
+	case c.out <- item:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
+func (c *conn) sendLogout(text string) {
+	c.send(outbound{msg: NewMessage(MsgTypeLogout).Set(TagText, text), closeAfter: true})
+}
+
+// write writes queued messages in order, assigning each its sequence number & keeping application messages for resends.
+func (c *conn) write() {
+	var buf []byte
+	for {
+		select {
+		case item := <-c.out:
+			var err error
+			if buf, err = c.writeMessage(buf[:0], item); err != nil {
+				slog.Warn("FIX: failed to write message", "counterparty", c.cp.compID, "error", err)
+				c.close()
+				return
+			}
+
+			if item.closeAfter {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *conn) writeMessage(buf []byte, item outbound) ([]byte, error) {
+	var (
+		now   = c.acceptor.now()
+		store = c.cp.store
+		seq   = item.seq
+	)
+	if seq == 0 {
+		seq = store.NextSenderSeq()
+	}
+
+	msg := NewMessage(item.msg.MsgType())
+	msg.Set(TagSenderCompID, c.acceptor.config.CompID)
+	msg.Set(TagTargetCompID, c.cp.compID)
+	msg.SetInt(TagMsgSeqNum, seq)
+	if item.seq != 0 {
+		msg.SetBool(TagPossDupFlag, true)
+		msg.Set(TagOrigSendingTime, item.origSendingTime)
+	}
+	msg.SetTime(TagSendingTime, now)
+
+	for _, field := range item.msg.Fields {
+		switch field.Tag {
+		case TagMsgType, TagSenderCompID, TagTargetCompID, TagMsgSeqNum, TagPossDupFlag, TagOrigSendingTime, TagSendingTime:
+			continue
+		}
+
+		msg.Fields = append(msg.Fields, field)
+	}
+
+	buf = msg.Encode(buf)
+
+	if item.seq == 0 {
+		if !isAdmin(msg.MsgType()) && !isMarketData(msg.MsgType()) {
+			if err := store.SaveMessage(seq, buf); err != nil {
+				return buf, err
+			}
+		}
+
+		if err := store.SetNextSenderSeq(seq + 1); err != nil {
+			return buf, err
+		}
+	}
+
+	if err := c.netConn.SetWriteDeadline(now.Add(c.acceptor.config.WriteTimeout)); err != nil {
+		return buf, err
+	}
+
+	if _, err := c.netConn.Write(buf); err != nil {
+		return buf, err
+	}
+
+	c.lastSent.Store(now.UnixNano())
+
+	return buf, nil
+}
+
+// monitor heartbeats an idle connection, and test requests a quiet counterparty, disconnecting it should it stay quiet.
+func (c *conn) monitor() {
+	t := time.NewTicker(c.heartbeat / 4)
+	defer t.Stop()
+
+	var (
+		grace         = c.heartbeat / 5
+		testRequested bool
+	)
+	for {
+		select {
+		case <-t.C:
+			now := c.acceptor.now()
+
+			if now.Sub(time.Unix(0, c.lastSent.Load())) >= c.heartbeat {
+				c.send(outbound{msg: NewMessage(MsgTypeHeartbeat)})
+			}
+
+			quiet := now.Sub(time.Unix(0, c.lastReceived.Load()))
+			switch {
+			case quiet >= 2*c.heartbeat+grace:
+				slog.Warn("FIX: counterparty stopped responding", "counterparty", c.cp.compID, "quiet", quiet)
+				c.close()
+				return
+			case quiet >= c.heartbeat+grace && !testRequested:
+				testRequested = true
+				c.send(outbound{msg: NewMessage(MsgTypeTestRequest).Set(TagTestReqID, now.UTC().Format(timeFormat))})
+			case quiet < c.heartbeat:
+				testRequested = false
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// afterLogon answers the logon, then checks its sequence number; the logon itself is validated by the acceptor.
+func (c *conn) afterLogon(logon *Message) error {
+	seq, err := logon.Int(TagMsgSeqNum)
+	if err != nil {
+		c.sendLogout(err.Error())
+		return err
+	}
+
+	expected := c.cp.store.NextTargetSeq()
+	if seq < expected {
+		err := fmt.Errorf("MsgSeqNum too low, expecting %d but received %d", expected, seq)
+		c.sendLogout(err.Error())
+		return err
+	}
+
+	reply := NewMessage(MsgTypeLogon).Set(TagEncryptMethod, "0")
+	reply.SetInt(TagHeartBtInt, uint64(c.heartbeat/time.Second))
+	if logon.Bool(TagResetSeqNumFlag) {
+		reply.SetBool(TagResetSeqNumFlag, true)
+	}
+	c.send(outbound{msg: reply})
+
+	if seq > expected {
+		c.requestResend(expected)
+		return nil
+	}
+
+	return c.cp.store.SetNextTargetSeq(seq + 1)
+}
+
+func (c *conn) requestResend(from uint64) {
+	c.resending = true
+
+	msg := NewMessage(MsgTypeResendRequest)
+	msg.SetInt(TagBeginSeqNo, from)
+	msg.SetInt(TagEndSeqNo, 0)
+	c.send(outbound{msg: msg})
+}
+
+// receive checks the message's sequence number, then handles it; it returns false once the connection should close.
+func (c *conn) receive(msg *Message) bool {
+	seq, err := msg.Int(TagMsgSeqNum)
+	if err != nil {
+		c.sendLogout(err.Error())
+		return false
+	}
+
+	var (
+		msgType  = msg.MsgType()
+		store    = c.cp.store
+		expected = store.NextTargetSeq()
+	)
+
+	// A sequence reset outside of gap fill mode moves the expected sequence number regardless.
+	if msgType == MsgTypeSequenceReset && !msg.Bool(TagGapFillFlag) {
+		return c.sequenceReset(msg, expected)
+	}
+
+	switch {
+	case seq > expected:
+		if !c.resending {
+			c.requestResend(expected)
+		}
+
+		// Resend requests & logouts are answered out of sequence, lest both sides wait on each other.
+		switch msgType {
+		case MsgTypeResendRequest:
+			c.resend(msg)
+		case MsgTypeLogout:
+			c.sendLogout("logout")
+			return false
+		}
+
+		return true
+	case seq < expected:
+		if msg.Bool(TagPossDupFlag) {
+			return true
+		}
+
+		c.sendLogout(fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", expected, seq))
+		return false
+	}
+
+	if !msg.Bool(TagPossDupFlag) {
+		c.resending = false
+	}
+
+	if msgType == MsgTypeSequenceReset {
+		return c.sequenceReset(msg, expected)
+	}
+
+	if err := store.SetNextTargetSeq(seq + 1); err != nil {
+		slog.Error("FIX: failed to persist sequence number", "counterparty", c.cp.compID, "error", err)
+		c.sendLogout("internal error")
+		return false
+	}
+
+	switch msgType {
+	case MsgTypeHeartbeat, MsgTypeReject:
+	case MsgTypeTestRequest:
+		reply := NewMessage(MsgTypeHeartbeat)
+		if id, ok := msg.Get(TagTestReqID); ok {
+			reply.Set(TagTestReqID, id)
+		}
+		c.send(outbound{msg: reply})
+	case MsgTypeResendRequest:
+		c.resend(msg)
+	case MsgTypeLogout:
+		c.sendLogout("logout")
+		return false
+	case MsgTypeLogon:
+		c.reject(seq, msgType, 0, "already logged on")
+	case MsgTypeNewOrderSingle:
+		c.newOrder(seq, msg)
+	case MsgTypeOrderCancelRequest:
+		c.cancelOrder(seq, msg)
+	case MsgTypeOrderCancelReplaceRequest:
+		c.replaceOrder(seq, msg)
+	case MsgTypeMarketDataRequest:
+		c.marketDataRequest(seq, msg)
+	default:
+		reply := NewMessage(MsgTypeBusinessMessageReject)
+		reply.SetInt(TagRefSeqNum, seq)
+		reply.Set(TagRefMsgType, msgType)
+		reply.Set(TagBusinessRejectReason, "3")
+		reply.Set(TagText, "unsupported message type")
+		c.send(outbound{msg: reply})
+	}
+
+	return true
+}
+
+func (c *conn) sequenceReset(msg *Message, expected uint64) bool {
+	newSeq, err := msg.Int(TagNewSeqNo)
+	if err != nil {
+		c.sendLogout(err.Error())
+		return false
+	}
+
+	if newSeq < expected {
+		seq, _ := msg.Int(TagMsgSeqNum)
+		c.reject(seq, MsgTypeSequenceReset, TagNewSeqNo, fmt.Sprintf("attempt to lower sequence number from %d to %d", expected, newSeq))
+		return true
+	}
+
+	if err := c.cp.store.SetNextTargetSeq(newSeq); err != nil {
+		slog.Error("FIX: failed to persist sequence number", "counterparty", c.cp.compID, "error", err)
+		c.sendLogout("internal error")
+		return false
+	}
+
+	return true
+}
+
+// resend resends the application messages in the requested range, gap filling over admin & market data messages. A range
+// can be longer than the outbound queue, so resends wait for room in it.
+func (c *conn) resend(msg *Message) {
+	begin, err := msg.Int(TagBeginSeqNo)
+	if err != nil {
+		seq, _ := msg.Int(TagMsgSeqNum)
+		c.reject(seq, MsgTypeResendRequest, TagBeginSeqNo, err.Error())
+		return
+	}
+
+	// Messages still queued haven't been sent, so aren't resent; they'll follow with their own sequence numbers.
+	last := c.cp.store.NextSenderSeq() - 1
+	end, _ := msg.Int(TagEndSeqNo)
+	if end == 0 || end > last {
+		end = last
+	}
+
+	messages, err := c.cp.store.Messages(begin, end)
+	if err != nil {
+		slog.Error("FIX: failed to load messages to resend", "counterparty", c.cp.compID, "error", err)
+		messages = nil
+	}
+
+	gapFrom := uint64(0)
+	gapFill := func(to uint64) bool {
+		if gapFrom == 0 {
+			return true
+		}
+
+		fill := NewMessage(MsgTypeSequenceReset).SetBool(TagGapFillFlag, true)
+		fill.SetInt(TagNewSeqNo, to)
+		from := gapFrom
+		gapFrom = 0
+
+		return c.sendWait(outbound{msg: fill, seq: from, origSendingTime: c.acceptor.now().UTC().Format(timeFormat)})
+	}
+
+	for seq := begin; seq <= end; seq++ {
+		raw, ok := messages[seq]
+		if !ok {
+			if gapFrom == 0 {
+				gapFrom = seq
+			}
+			continue
+		}
+
+		original, err := Parse(raw)
+		if err != nil {
+			slog.Error("FIX: failed to parse message to resend", "counterparty", c.cp.compID, "seq", seq, "error", err)
+			if gapFrom == 0 {
+				gapFrom = seq
+			}
+			continue
+		}
+
+		if !gapFill(seq) {
+			return
+		}
+
+		sendingTime, _ := original.Get(TagSendingTime)
+		if !c.sendWait(outbound{msg: original, seq: seq, origSendingTime: sendingTime}) {
+			return
+		}
+	}
+
+	gapFill(end + 1)
+}
+
+// reject rejects a message at the session level.
+func (c *conn) reject(refSeq uint64, refMsgType string, refTag Tag, text string) {
+	reply := NewMessage(MsgTypeReject)
+	reply.SetInt(TagRefSeqNum, refSeq)
+	reply.Set(TagRefMsgType, refMsgType)
+	if refTag != 0 {
+		reply.SetInt(TagRefTagID, uint64(refTag))
+	}
+	reply.Set(TagText, text)
+	c.send(outbound{msg: reply})
+}
@@ -0,0 +1,470 @@
+package fix
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_EncodeParse(t *testing.T) {
+	t.Parallel()
+
+	msg := NewMessage(MsgTypeNewOrderSingle).Set(TagClOrdID, "abc").SetFloat(TagPrice, 100.5).SetInt(TagOrderQty, 3)
+	raw := msg.Encode(nil)
+
+	parsed, err := Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, msg.Fields, parsed.Fields)
+
+	read, err := NewReader(bytes.NewReader(append(append([]byte(nil), raw...), raw...))).Next()
+	require.NoError(t, err)
+	assert.Equal(t, raw, read)
+
+	corrupt := append([]byte(nil), raw...)
+	corrupt[len("8=FIX.4.4\x019=")+4] ^= 1
+	_, err = Parse(corrupt)
+	assert.ErrorIs(t, err, ErrGarbled)
+}
+
+func TestFileStore_PersistsAcrossReopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	store, err := OpenFileStore(dir, "LOB", "OMS")
+	require.NoError(t, err)
+	require.NoError(t, store.SetNextSenderSeq(5))
+	require.NoError(t, store.SetNextTargetSeq(7))
+	require.NoError(t, store.SaveMessage(4, []byte("four")))
+	require.NoError(t, store.Close())
+
+	store, err = OpenFileStore(dir, "LOB", "OMS")
+	require.NoError(t, err)
+	defer store.Close()
+
+	assert.Equal(t, uint64(5), store.NextSenderSeq())
+	assert.Equal(t, uint64(7), store.NextTargetSeq())
+
+	messages, err := store.Messages(1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64][]byte{4: []byte("four")}, messages)
+
+	require.NoError(t, store.Reset())
+	assert.Equal(t, uint64(1), store.NextSenderSeq())
+	messages, err = store.Messages(1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestFileStore_DropsTornTail(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	store, err := OpenFileStore(dir, "LOB", "OMS")
+	require.NoError(t, err)
+	require.NoError(t, store.SaveMessage(1, []byte("one")))
+	require.NoError(t, store.Close())
+
+	// Simulate a crash part way through saving the second message.
+	body, err := os.OpenFile(filepath.Join(dir, "LOB-OMS.body"), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = body.Write([]byte{2, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 't'})
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+
+	store, err = OpenFileStore(dir, "LOB", "OMS")
+	require.NoError(t, err)
+	require.NoError(t, store.SaveMessage(3, []byte("three")))
+	require.NoError(t, store.Close())
+
+	store, err = OpenFileStore(dir, "LOB", "OMS")
+	require.NoError(t, err)
+	defer store.Close()
+
+	messages, err := store.Messages(1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64][]byte{1: []byte("one"), 3: []byte("three")}, messages)
+}
+
+// testCounterparty is the initiator side of a session, talking to the acceptor over loopback.
+type testCounterparty struct {
+	t    *testing.T
+	conn net.Conn
+	r    *Reader
+	seq  uint64
+}
+
+func dial(t *testing.T, addr string, seq uint64) *testCounterparty {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &testCounterparty{t: t, conn: conn, r: NewReader(conn), seq: seq}
+}
+
+func (c *testCounterparty) send(msg *Message) {
+	c.t.Helper()
+
+	framed := NewMessage(msg.MsgType())
+	framed.Set(TagSenderCompID, "OMS").Set(TagTargetCompID, "LOB").SetInt(TagMsgSeqNum, c.seq).SetTime(TagSendingTime, time.Now())
+	framed.Fields = append(framed.Fields, msg.Fields[1:]...)
+	c.seq++
+
+	_, err := c.conn.Write(framed.Encode(nil))
+	require.NoError(c.t, err)
+}
+
+func (c *testCounterparty) expect(msgType string) *Message {
+	c.t.Helper()
+
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	msg, err := readMessage(c.r)
+	require.NoError(c.t, err)
+	require.Equal(c.t, msgType, msg.MsgType(), msg.String())
+
+	return msg
+}
+
+func (c *testCounterparty) logon() *Message {
+	c.t.Helper()
+
+	c.send(NewMessage(MsgTypeLogon).Set(TagEncryptMethod, "0").SetInt(TagHeartBtInt, 30))
+	return c.expect(MsgTypeLogon)
+}
+
+// logout logs out, waiting for the acceptor to close the connection.
+func (c *testCounterparty) logout() {
+	c.t.Helper()
+
+	c.send(NewMessage(MsgTypeLogout))
+	c.expect(MsgTypeLogout)
+
+	_, err := c.r.Next()
+	require.Error(c.t, err)
+}
+
+func get(t *testing.T, msg *Message, tag Tag) string {
+	t.Helper()
+
+	value, ok := msg.Get(tag)
+	require.True(t, ok, "tag %d missing from %s", tag, msg)
+
+	return value
+}
+
+func newOrderSingle(clOrdID, side string, price, size float64) *Message {
+	return NewMessage(MsgTypeNewOrderSingle).Set(TagClOrdID, clOrdID).Set(TagSide, side).Set(TagOrdType, "2").
+		SetFloat(TagPrice, price).SetFloat(TagOrderQty, size).SetTime(TagTransactTime, time.Now())
+}
+
+func serve(t *testing.T, config Config) (*Acceptor, string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	acceptor := NewAcceptor(config)
+	go acceptor.Serve(l)
+
+	t.Cleanup(func() {
+		l.Close()
+		acceptor.Close()
+	})
+
+	return acceptor, l.Addr().String()
+}
+
+func TestAcceptor_OrderEntry(t *testing.T) {
+	t.Parallel()
+
+	var (
+		storeDir = t.TempDir()
+		book     = lob.NewOrderbook(128)
+		config   = Config{CompID: "LOB", Book: book, Symbol: "BTC/USD", Accounts: map[string]uint64{"OMS": 7}, StoreDir: storeDir}
+	)
+
+	acceptor, addr := serve(t, config)
+	oms := dial(t, addr, 1)
+
+	logon := oms.logon()
+	assert.Equal(t, "30", get(t, logon, TagHeartBtInt))
+	assert.Equal(t, "1", get(t, logon, TagMsgSeqNum))
+
+	oms.send(NewMessage(MsgTypeTestRequest).Set(TagTestReqID, "ping"))
+	assert.Equal(t, "ping", get(t, oms.expect(MsgTypeHeartbeat), TagTestReqID))
+
+	// A resting bid, then an ask crossing part of it.
+	oms.send(newOrderSingle("b1", "1", 100, 10))
+	report := oms.expect(MsgTypeExecutionReport)
+	assert.Equal(t, "b1", get(t, report, TagClOrdID))
+	assert.Equal(t, execTypeNew, get(t, report, TagExecType))
+	assert.Equal(t, "7", get(t, report, TagAccount))
+	assert.Equal(t, "BTC/USD", get(t, report, TagSymbol))
+	bidID := get(t, report, TagOrderID)
+
+	oms.send(newOrderSingle("s1", "2", 100, 4))
+	report = oms.expect(MsgTypeExecutionReport)
+	assert.Equal(t, "s1", get(t, report, TagClOrdID))
+	assert.Equal(t, execTypeNew, get(t, report, TagExecType))
+
+	report = oms.expect(MsgTypeExecutionReport)
+	assert.Equal(t, "b1", get(t, report, TagClOrdID))
+	assert.Equal(t, execTypeTrade, get(t, report, TagExecType))
+	assert.Equal(t, "1", get(t, report, TagOrdStatus))
+	assert.Equal(t, "4", get(t, report, TagLastQty))
+	assert.Equal(t, "6", get(t, report, TagLeavesQty))
+
+	report = oms.expect(MsgTypeExecutionReport)
+	assert.Equal(t, "s1", get(t, report, TagClOrdID))
+	assert.Equal(t, "2", get(t, report, TagOrdStatus))
+
+	// Replaced by client order ID, then cancelled by the new one.
+	oms.send(NewMessage(MsgTypeOrderCancelReplaceRequest).Set(TagClOrdID, "b2").Set(TagOrigClOrdID, "b1").
+		Set(TagSide, "1").Set(TagOrdType, "2").SetFloat(TagPrice, 99).SetFloat(TagOrderQty, 8))
+	report = oms.expect(MsgTypeExecutionReport)
+	assert.Equal(t, execTypeReplaced, get(t, report, TagExecType))
+	assert.Equal(t, "b2", get(t, report, TagClOrdID))
+	assert.Equal(t, "b1", get(t, report, TagOrigClOrdID))
+	assert.Equal(t, bidID, get(t, report, TagOrderID))
+	assert.Equal(t, "99", get(t, report, TagPrice))
+
+	oms.send(NewMessage(MsgTypeOrderCancelRequest).Set(TagClOrdID, "c1").Set(TagOrigClOrdID, "b2").Set(TagSide, "1"))
+	report = oms.expect(MsgTypeExecutionReport)
+	assert.Equal(t, execTypeCancelled, get(t, report, TagExecType))
+	assert.Equal(t, "c1", get(t, report, TagClOrdID))
+	assert.Equal(t, "b2", get(t, report, TagOrigClOrdID))
+	assert.Empty(t, book.OpenOrders())
+
+	oms.send(NewMessage(MsgTypeOrderCancelRequest).Set(TagClOrdID, "c2").Set(TagOrigClOrdID, "b2").Set(TagSide, "1"))
+	cancelReject := oms.expect(MsgTypeOrderCancelReject)
+	assert.Equal(t, cxlRejReasonUnknownOrder, get(t, cancelReject, TagCxlRejReason))
+	assert.Equal(t, cxlRejResponseToCancel, get(t, cancelReject, TagCxlRejResponseTo))
+
+	// Orders the book rejects are reported as rejected.
+	oms.send(newOrderSingle("bad", "1", -1, 1))
+	report = oms.expect(MsgTypeExecutionReport)
+	assert.Equal(t, execTypeRejected, get(t, report, TagExecType))
+	assert.Equal(t, "bad", get(t, report, TagClOrdID))
+
+	oms.send(NewMessage("AE"))
+	assert.Equal(t, "3", get(t, oms.expect(MsgTypeBusinessMessageReject), TagBusinessRejectReason))
+
+	// Resending from 2 gap fills over admin messages, and resends execution reports as possible duplicates.
+	oms.send(NewMessage(MsgTypeResendRequest).SetInt(TagBeginSeqNo, 2).SetInt(TagEndSeqNo, 0))
+	gapFill := oms.expect(MsgTypeSequenceReset)
+	assert.Equal(t, "2", get(t, gapFill, TagMsgSeqNum))
+	assert.Equal(t, "3", get(t, gapFill, TagNewSeqNo))
+	assert.Equal(t, "Y", get(t, gapFill, TagGapFillFlag))
+
+	resent := oms.expect(MsgTypeExecutionReport)
+	assert.Equal(t, "3", get(t, resent, TagMsgSeqNum))
+	assert.Equal(t, "Y", get(t, resent, TagPossDupFlag))
+	assert.Equal(t, "b1", get(t, resent, TagClOrdID))
+	get(t, resent, TagOrigSendingTime)
+
+	for seq := 4; seq <= 8; seq++ {
+		oms.expect(MsgTypeExecutionReport)
+	}
+
+	// The cancel reject & business reject are application messages too.
+	oms.expect(MsgTypeOrderCancelReject)
+	oms.expect(MsgTypeExecutionReport)
+	oms.expect(MsgTypeBusinessMessageReject)
+
+	oms.logout()
+	nextSeq := oms.seq
+
+	// Sequence numbers carry on across a restart.
+	require.NoError(t, acceptor.Close())
+	_, addr = serve(t, config)
+
+	oms = dial(t, addr, nextSeq)
+	assert.Equal(t, "13", get(t, oms.logon(), TagMsgSeqNum))
+	oms.logout()
+
+	// A logon ahead of the expected sequence number is answered with a resend request for the gap.
+	oms = dial(t, addr, oms.seq+3)
+	oms.logon()
+	resendRequest := oms.expect(MsgTypeResendRequest)
+	assert.Equal(t, "0", get(t, resendRequest, TagEndSeqNo))
+}
+
+func TestAcceptor_RejectsLowSequenceNumber(t *testing.T) {
+	t.Parallel()
+
+	_, addr := serve(t, Config{CompID: "LOB", Book: lob.NewOrderbook(128)})
+
+	oms := dial(t, addr, 1)
+	oms.logon()
+	oms.logout()
+
+	oms = dial(t, addr, 1)
+	oms.send(NewMessage(MsgTypeLogon).Set(TagEncryptMethod, "0").SetInt(TagHeartBtInt, 30))
+	assert.Contains(t, get(t, oms.expect(MsgTypeLogout), TagText), "MsgSeqNum too low")
+}
+
+func TestAcceptor_ResendsMoreThanTheQueueHolds(t *testing.T) {
+	t.Parallel()
+
+	// More sent messages than fit in the outbound queue, as though from an earlier connection.
+	storeDir := t.TempDir()
+	store, err := OpenFileStore(storeDir, "LOB", "OMS")
+	require.NoError(t, err)
+
+	const sent = outboundQueueSize + 100
+	for seq := uint64(1); seq <= sent; seq++ {
+		report := NewMessage(MsgTypeExecutionReport).Set(TagSenderCompID, "LOB").Set(TagTargetCompID, "OMS").SetInt(TagMsgSeqNum, seq)
+		report.SetTime(TagSendingTime, time.Now())
+		require.NoError(t, store.SaveMessage(seq, report.Encode(nil)))
+	}
+	require.NoError(t, store.SetNextSenderSeq(sent+1))
+	require.NoError(t, store.Close())
+
+	_, addr := serve(t, Config{CompID: "LOB", Book: lob.NewOrderbook(128), StoreDir: storeDir})
+
+	oms := dial(t, addr, 1)
+	oms.logon()
+
+	// The whole range is resent without the session being disconnected as a slow consumer.
+	oms.send(NewMessage(MsgTypeResendRequest).SetInt(TagBeginSeqNo, 1).SetInt(TagEndSeqNo, 0))
+	for seq := uint64(1); seq <= sent; seq++ {
+		resent := oms.expect(MsgTypeExecutionReport)
+		assert.Equal(t, strconv.FormatUint(seq, 10), get(t, resent, TagMsgSeqNum))
+	}
+
+	// Then the gap fill over this connection's logon.
+	oms.expect(MsgTypeSequenceReset)
+	oms.logout()
+}
+
+// expectMarketData reads n market data messages by MDReqID; each subscription's are in order, but not across them.
+func (c *testCounterparty) expectMarketData(n int) map[string][]*Message {
+	c.t.Helper()
+
+	messages := make(map[string][]*Message)
+	for i := 0; i < n; i++ {
+		require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		msg, err := readMessage(c.r)
+		require.NoError(c.t, err)
+		require.Contains(c.t, []string{MsgTypeMarketDataSnapshot, MsgTypeMarketDataIncremental}, msg.MsgType(), msg.String())
+
+		reqID := get(c.t, msg, TagMDReqID)
+		messages[reqID] = append(messages[reqID], msg)
+	}
+
+	return messages
+}
+
+func marketDataRequest(reqID, subscriptionType, depth, updateType, symbol string, entryTypes ...string) *Message {
+	msg := NewMessage(MsgTypeMarketDataRequest).Set(TagMDReqID, reqID).Set(TagSubscriptionReqType, subscriptionType).
+		Set(TagMarketDepth, depth).Set(TagMDUpdateType, updateType)
+
+	msg.SetInt(TagNoMDEntryTypes, uint64(len(entryTypes)))
+	for _, entryType := range entryTypes {
+		msg.Add(TagMDEntryType, entryType)
+	}
+
+	msg.SetInt(TagNoRelatedSym, 1).Set(TagSymbol, symbol)
+
+	return msg
+}
+
+func TestAcceptor_MarketData(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128, lob.WithDepthEvents(5))
+	for _, level := range []lob.DepthLevel{{Price: 100, Size: 2}, {Price: 99, Size: 3}, {Price: 98, Size: 1}} {
+		_, err := book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, level.Price, level.Size))
+		require.NoError(t, err)
+	}
+
+	_, addr := serve(t, Config{CompID: "LOB", Book: book, Symbol: "BTC/USD", MarketDepth: 5})
+	oms := dial(t, addr, 1)
+	oms.logon()
+
+	oms.send(marketDataRequest("inc", subscriptionSubscribe, "2", mdUpdateTypeIncremental, "BTC/USD",
+		mdEntryTypeBid, mdEntryTypeOffer, mdEntryTypeTrade))
+	snapshot := oms.expect(MsgTypeMarketDataSnapshot)
+	assert.Equal(t, "2", get(t, snapshot, TagNoMDEntries))
+	assert.Equal(t, []string{"100", "99"}, snapshot.GetAll(TagMDEntryPx))
+	assert.Equal(t, []string{"2", "3"}, snapshot.GetAll(TagMDEntrySize))
+
+	oms.send(marketDataRequest("full", subscriptionSubscribe, "0", mdUpdateTypeFullRefresh, "BTC/USD", mdEntryTypeBid))
+	snapshot = oms.expect(MsgTypeMarketDataSnapshot)
+	assert.Equal(t, []string{"100", "99", "98"}, snapshot.GetAll(TagMDEntryPx))
+
+	oms.send(marketDataRequest("inc", subscriptionSubscribe, "2", mdUpdateTypeIncremental, "BTC/USD", mdEntryTypeBid))
+	assert.Equal(t, mdReqRejReasonDuplicateMDReqID, get(t, oms.expect(MsgTypeMarketDataRequestReject), TagMDReqRejReason))
+
+	oms.send(marketDataRequest("eth", subscriptionSubscribe, "2", mdUpdateTypeIncremental, "ETH/USD", mdEntryTypeBid))
+	assert.Equal(t, mdReqRejReasonUnknownSymbol, get(t, oms.expect(MsgTypeMarketDataRequestReject), TagMDReqRejReason))
+
+	oms.send(marketDataRequest("bad", subscriptionSubscribe, "2", mdUpdateTypeIncremental, "BTC/USD", "B"))
+	assert.Equal(t, mdReqRejReasonUnsupportedEntry, get(t, oms.expect(MsgTypeMarketDataRequestReject), TagMDReqRejReason))
+
+	// A trade is published incrementally to trade subscribers, then the level it took from to everyone.
+	_, err := book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.SellSide, 100, 1))
+	require.NoError(t, err)
+
+	messages := oms.expectMarketData(3)
+	require.Len(t, messages["inc"], 2)
+
+	trade := messages["inc"][0]
+	assert.Equal(t, MsgTypeMarketDataIncremental, trade.MsgType())
+	assert.Equal(t, []string{mdUpdateActionNew}, trade.GetAll(TagMDUpdateAction))
+	assert.Equal(t, []string{mdEntryTypeTrade}, trade.GetAll(TagMDEntryType))
+	assert.Equal(t, "100", get(t, trade, TagMDEntryPx))
+	assert.Equal(t, "1", get(t, trade, TagMDEntrySize))
+	assert.Equal(t, "BTC/USD", get(t, trade, TagSymbol))
+
+	change := messages["inc"][1]
+	assert.Equal(t, []string{mdUpdateActionChange}, change.GetAll(TagMDUpdateAction))
+	assert.Equal(t, []string{"100"}, change.GetAll(TagMDEntryPx))
+	assert.Equal(t, []string{"1"}, change.GetAll(TagMDEntrySize))
+	assert.Equal(t, []string{"1"}, change.GetAll(TagMDEntryPositionNo))
+
+	require.Len(t, messages["full"], 1)
+	assert.Equal(t, MsgTypeMarketDataSnapshot, messages["full"][0].MsgType())
+	assert.Equal(t, []string{"1", "3", "1"}, messages["full"][0].GetAll(TagMDEntrySize))
+
+	// A new best bid pushes the worst of the incremental subscriber's two levels out.
+	_, err = book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 101, 4))
+	require.NoError(t, err)
+
+	messages = oms.expectMarketData(2)
+	require.Len(t, messages["inc"], 1)
+
+	update := messages["inc"][0]
+	assert.Equal(t, []string{mdUpdateActionDelete, mdUpdateActionNew}, update.GetAll(TagMDUpdateAction))
+	assert.Equal(t, []string{"99", "101"}, update.GetAll(TagMDEntryPx))
+	assert.Equal(t, []string{"2", "1"}, update.GetAll(TagMDEntryPositionNo))
+	assert.Equal(t, []string{"101", "100", "99", "98"}, messages["full"][0].GetAll(TagMDEntryPx))
+
+	// Once unsubscribed, only the full refresh subscriber is published to.
+	oms.send(marketDataRequest("inc", subscriptionUnsubscribe, "2", mdUpdateTypeIncremental, "BTC/USD", mdEntryTypeBid))
+	oms.send(marketDataRequest("once", subscriptionSnapshot, "1", "", "BTC/USD", mdEntryTypeBid, mdEntryTypeOffer))
+	assert.Equal(t, []string{"101"}, oms.expect(MsgTypeMarketDataSnapshot).GetAll(TagMDEntryPx))
+
+	_, err = book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 97, 1))
+	require.NoError(t, err)
+
+	messages = oms.expectMarketData(1)
+	assert.Equal(t, []string{"101", "100", "99", "98", "97"}, messages["full"][0].GetAll(TagMDEntryPx))
+
+	// Market data isn't resent; it's gap filled over.
+	oms.send(NewMessage(MsgTypeResendRequest).SetInt(TagBeginSeqNo, 2).SetInt(TagEndSeqNo, 0))
+	gapFill := oms.expect(MsgTypeSequenceReset)
+	assert.Equal(t, "2", get(t, gapFill, TagMsgSeqNum))
+	assert.Equal(t, "Y", get(t, gapFill, TagGapFillFlag))
+}
@@ -0,0 +1,360 @@
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// Subscription request types, update types, entry types, update actions & request reject reasons as FIX encodes them.
+const (
+	subscriptionSnapshot    = "0"
+	subscriptionSubscribe   = "1"
+	subscriptionUnsubscribe = "2"
+
+	mdUpdateTypeFullRefresh = "0"
+	mdUpdateTypeIncremental = "1"
+
+	mdEntryTypeBid   = "0"
+	mdEntryTypeOffer = "1"
+	mdEntryTypeTrade = "2"
+
+	mdUpdateActionNew    = "0"
+	mdUpdateActionChange = "1"
+	mdUpdateActionDelete = "2"
+
+	mdReqRejReasonUnknownSymbol      = "0"
+	mdReqRejReasonDuplicateMDReqID   = "1"
+	mdReqRejReasonUnsupportedRequest = "4"
+	mdReqRejReasonUnsupportedDepth   = "5"
+	mdReqRejReasonUnsupportedUpdate  = "6"
+	mdReqRejReasonUnsupportedEntry   = "8"
+)
+
+// subscription is a connection's market data request, and the depth it was last sent.
+type subscription struct {
+	conn  *conn
+	reqID string
+
+	// depth is the levels per side the subscription's served.
+	depth       int
+	incremental bool
+	bids        bool
+	offers      bool
+	trades      bool
+
+	// ready is set once the subscription's snapshot's been sent; until then, buffered holds the latest depth published.
+	ready    bool
+	buffered *lob.DepthSnapshot
+	last     lob.DepthSnapshot
+}
+
+// marketDataRequest snapshots the book for the request, subscribing the connection to updates unless it only asked for the
+// snapshot. Updates are published from the book's depth & trade events, so the book must be built WithDepthEvents.
+func (c *conn) marketDataRequest(seq uint64, msg *Message) {
+	a := c.acceptor
+
+	reqID, err := msg.Require(TagMDReqID)
+	if err != nil {
+		c.reject(seq, MsgTypeMarketDataRequest, TagMDReqID, err.Error())
+		return
+	}
+
+	subscriptionType, err := msg.Require(TagSubscriptionReqType)
+	if err != nil {
+		c.reject(seq, MsgTypeMarketDataRequest, TagSubscriptionReqType, err.Error())
+		return
+	}
+
+	switch subscriptionType {
+	case subscriptionSnapshot, subscriptionSubscribe:
+	case subscriptionUnsubscribe:
+		a.removeSubscription(c, reqID)
+		return
+	default:
+		c.marketDataReject(reqID, mdReqRejReasonUnsupportedRequest, fmt.Sprintf("unsupported SubscriptionRequestType %q", subscriptionType))
+		return
+	}
+
+	sub, reason, err := a.parseSubscription(c, reqID, msg)
+	if err != nil {
+		c.marketDataReject(reqID, reason, err.Error())
+		return
+	}
+
+	if subscriptionType == subscriptionSnapshot {
+		sub.last = a.config.Book.DepthSnapshot(sub.depth)
+		c.send(outbound{msg: a.fullRefresh(sub, nil)})
+		return
+	}
+
+	// Registered before the snapshot's taken, so depth published in between is buffered rather than missed; the book's
+	// events are handled with the book locked, so the snapshot mustn't be taken holding mdMu.
+	a.mdMu.Lock()
+	if _, ok := a.subscriptions[c][reqID]; ok {
+		a.mdMu.Unlock()
+		c.marketDataReject(reqID, mdReqRejReasonDuplicateMDReqID, fmt.Sprintf("MDReqID %q is already subscribed", reqID))
+		return
+	}
+
+	if a.subscriptions[c] == nil {
+		a.subscriptions[c] = make(map[string]*subscription)
+	}
+	a.subscriptions[c][reqID] = sub
+	a.mdMu.Unlock()
+
+	snapshot := a.config.Book.DepthSnapshot(sub.depth)
+
+	a.mdMu.Lock()
+	defer a.mdMu.Unlock()
+
+	sub.last = snapshot
+	sub.ready = true
+	c.send(outbound{msg: a.fullRefresh(sub, a.lastTrade)})
+
+	if sub.buffered != nil && sub.buffered.Seq > snapshot.Seq {
+		a.publishDepth(sub, *sub.buffered)
+	}
+	sub.buffered = nil
+}
+
+// parseSubscription validates the request's symbols, depth, update & entry types; errors are returned with the reason
+// they're rejected for.
+func (a *Acceptor) parseSubscription(c *conn, reqID string, msg *Message) (*subscription, string, error) {
+	if a.config.MarketDepth <= 0 {
+		return nil, mdReqRejReasonUnsupportedDepth, errors.New("market data isn't enabled")
+	}
+
+	for _, symbol := range msg.GetAll(TagSymbol) {
+		if a.config.Symbol != "" && symbol != a.config.Symbol {
+			return nil, mdReqRejReasonUnknownSymbol, fmt.Errorf("unknown symbol %q", symbol)
+		}
+	}
+
+	depth, err := msg.Int(TagMarketDepth)
+	if err != nil {
+		return nil, mdReqRejReasonUnsupportedDepth, err
+	}
+
+	sub := &subscription{conn: c, reqID: reqID, depth: a.config.MarketDepth}
+	// A depth of 0 is the full book, as far as it's published.
+	if depth > 0 && depth < uint64(a.config.MarketDepth) {
+		sub.depth = int(depth)
+	}
+
+	switch updateType, _ := msg.Get(TagMDUpdateType); updateType {
+	case mdUpdateTypeFullRefresh, "":
+	case mdUpdateTypeIncremental:
+		sub.incremental = true
+	default:
+		return nil, mdReqRejReasonUnsupportedUpdate, fmt.Errorf("unsupported MDUpdateType %q", updateType)
+	}
+
+	entryTypes := msg.GetAll(TagMDEntryType)
+	if len(entryTypes) == 0 {
+		return nil, mdReqRejReasonUnsupportedEntry, errors.New("no MDEntryType requested")
+	}
+
+	for _, entryType := range entryTypes {
+		switch entryType {
+		case mdEntryTypeBid:
+			sub.bids = true
+		case mdEntryTypeOffer:
+			sub.offers = true
+		case mdEntryTypeTrade:
+			sub.trades = true
+		default:
+			return nil, mdReqRejReasonUnsupportedEntry, fmt.Errorf("unsupported MDEntryType %q", entryType)
+		}
+	}
+
+	return sub, "", nil
+}
+
+func (c *conn) marketDataReject(reqID, reason, text string) {
+	reply := NewMessage(MsgTypeMarketDataRequestReject)
+	reply.Set(TagMDReqID, reqID)
+	reply.Set(TagMDReqRejReason, reason)
+	reply.Set(TagText, text)
+	c.send(outbound{msg: reply})
+}
+
+func (a *Acceptor) removeSubscription(c *conn, reqID string) {
+	a.mdMu.Lock()
+	defer a.mdMu.Unlock()
+
+	delete(a.subscriptions[c], reqID)
+}
+
+// removeSubscriptions unsubscribes the connection from everything it subscribed to.
+func (a *Acceptor) removeSubscriptions(c *conn) {
+	a.mdMu.Lock()
+	defer a.mdMu.Unlock()
+
+	delete(a.subscriptions, c)
+}
+
+// onMarketData publishes depth & trades to subscribers; it's called with the book locked.
+func (a *Acceptor) onMarketData(event lob.Event) {
+	a.mdMu.Lock()
+	defer a.mdMu.Unlock()
+
+	switch event := event.(type) {
+	case lob.DepthEvent:
+		for _, subs := range a.subscriptions {
+			for _, sub := range subs {
+				if !sub.ready {
+					snapshot := event.DepthSnapshot
+					sub.buffered = &snapshot
+					continue
+				}
+
+				a.publishDepth(sub, event.DepthSnapshot)
+			}
+		}
+	case lob.TradeEvent:
+		a.lastTrade = &event
+
+		for _, subs := range a.subscriptions {
+			for _, sub := range subs {
+				if !sub.ready || !sub.trades {
+					continue
+				}
+
+				if sub.incremental {
+					msg := NewMessage(MsgTypeMarketDataIncremental).Set(TagMDReqID, sub.reqID)
+					msg.SetInt(TagNoMDEntries, 1)
+					a.addTrade(msg, event, true)
+					sub.conn.send(outbound{msg: msg})
+					continue
+				}
+
+				sub.conn.send(outbound{msg: a.fullRefresh(sub, &event)})
+			}
+		}
+	}
+}
+
+// publishDepth sends the subscriber whatever's changed in its levels since it was last sent them.
+func (a *Acceptor) publishDepth(sub *subscription, depth lob.DepthSnapshot) {
+	depth = depth.Truncate(sub.depth)
+
+	var changes []lob.DepthChange
+	for _, change := range lob.DiffDepth(sub.last, depth) {
+		if (change.Side == lob.BuySide && sub.bids) || (change.Side == lob.SellSide && sub.offers) {
+			changes = append(changes, change)
+		}
+	}
+	sub.last = depth
+
+	if len(changes) == 0 {
+		return
+	}
+
+	if !sub.incremental {
+		sub.conn.send(outbound{msg: a.fullRefresh(sub, nil)})
+		return
+	}
+
+	msg := NewMessage(MsgTypeMarketDataIncremental).Set(TagMDReqID, sub.reqID)
+	msg.SetInt(TagNoMDEntries, uint64(len(changes)))
+	for _, change := range changes {
+		msg.Add(TagMDUpdateAction, mdUpdateAction(change.Action))
+		msg.Add(TagMDEntryType, mdEntryType(change.Side))
+		a.addSymbol(msg)
+		msg.Add(TagMDEntryPx, formatFloat(float64(change.Level.Price)))
+		msg.Add(TagMDEntrySize, formatFloat(float64(change.Level.Size)))
+		msg.Add(TagMDEntryPositionNo, strconv.Itoa(change.Position+1))
+	}
+
+	sub.conn.send(outbound{msg: msg})
+}
+
+// fullRefresh builds a snapshot of the subscriber's last depth, and the trade if there's one & it asked for trades.
+func (a *Acceptor) fullRefresh(sub *subscription, trade *lob.TradeEvent) *Message {
+	var (
+		bids    []lob.DepthLevel
+		offers  []lob.DepthLevel
+		entries int
+	)
+	if sub.bids {
+		bids = sub.last.Bids
+	}
+
+	if sub.offers {
+		offers = sub.last.Asks
+	}
+
+	entries = len(bids) + len(offers)
+	if trade != nil && sub.trades {
+		entries++
+	}
+
+	msg := NewMessage(MsgTypeMarketDataSnapshot).Set(TagMDReqID, sub.reqID)
+	if a.config.Symbol != "" {
+		msg.Set(TagSymbol, a.config.Symbol)
+	}
+	msg.SetInt(TagNoMDEntries, uint64(entries))
+
+	for _, side := range []struct {
+		entryType string
+		levels    []lob.DepthLevel
+	}{
+		{entryType: mdEntryTypeBid, levels: bids},
+		{entryType: mdEntryTypeOffer, levels: offers},
+	} {
+		for i, level := range side.levels {
+			msg.Add(TagMDEntryType, side.entryType)
+			msg.Add(TagMDEntryPx, formatFloat(float64(level.Price)))
+			msg.Add(TagMDEntrySize, formatFloat(float64(level.Size)))
+			msg.Add(TagMDEntryPositionNo, strconv.Itoa(i+1))
+		}
+	}
+
+	if trade != nil && sub.trades {
+		a.addTrade(msg, *trade, false)
+	}
+
+	return msg
+}
+
+// addTrade adds the trade as an entry; incremental entries lead with their update action, and carry their symbol.
+func (a *Acceptor) addTrade(msg *Message, trade lob.TradeEvent, incremental bool) {
+	if incremental {
+		msg.Add(TagMDUpdateAction, mdUpdateActionNew)
+	}
+	msg.Add(TagMDEntryType, mdEntryTypeTrade)
+	if incremental {
+		a.addSymbol(msg)
+	}
+	msg.Add(TagMDEntryPx, formatFloat(float64(trade.Price)))
+	msg.Add(TagMDEntrySize, formatFloat(float64(trade.Size)))
+}
+
+// addSymbol adds the symbol to an incremental entry, as each entry may be for a different instrument.
+func (a *Acceptor) addSymbol(msg *Message) {
+	if a.config.Symbol != "" {
+		msg.Add(TagSymbol, a.config.Symbol)
+	}
+}
+
+func mdUpdateAction(action lob.DepthAction) string {
+	switch action {
+	case lob.DepthActionNew:
+		return mdUpdateActionNew
+	case lob.DepthActionDelete:
+		return mdUpdateActionDelete
+	default:
+		return mdUpdateActionChange
+	}
+}
+
+func mdEntryType(side lob.OrderSide) string {
+	if side == lob.SellSide {
+		return mdEntryTypeOffer
+	}
+
+	return mdEntryTypeBid
+}
@@ -0,0 +1,418 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	BeginString = "FIX.4.4"
+
+	soh = '\x01'
+
+	// maxBodyLength bounds a message's body, so a garbled BodyLength can't have us buffer without end.
+	maxBodyLength = 64 << 10
+)
+
+// timeFormat is the UTCTimestamp format, to milliseconds.
+const timeFormat = "20060102-15:04:05.000"
+
+type Tag int
+
+const (
+	TagAccount              Tag = 1
+	TagAvgPx                Tag = 6
+	TagBeginSeqNo           Tag = 7
+	TagBeginString          Tag = 8
+	TagBodyLength           Tag = 9
+	TagCheckSum             Tag = 10
+	TagClOrdID              Tag = 11
+	TagCumQty               Tag = 14
+	TagEndSeqNo             Tag = 16
+	TagExecID               Tag = 17
+	TagLastPx               Tag = 31
+	TagLastQty              Tag = 32
+	TagMsgSeqNum            Tag = 34
+	TagMsgType              Tag = 35
+	TagNewSeqNo             Tag = 36
+	TagOrderID              Tag = 37
+	TagOrderQty             Tag = 38
+	TagOrdStatus            Tag = 39
+	TagOrdType              Tag = 40
+	TagOrigClOrdID          Tag = 41
+	TagPossDupFlag          Tag = 43
+	TagPrice                Tag = 44
+	TagRefSeqNum            Tag = 45
+	TagSenderCompID         Tag = 49
+	TagSendingTime          Tag = 52
+	TagSide                 Tag = 54
+	TagSymbol               Tag = 55
+	TagTargetCompID         Tag = 56
+	TagText                 Tag = 58
+	TagTransactTime         Tag = 60
+	TagEncryptMethod        Tag = 98
+	TagCxlRejReason         Tag = 102
+	TagOrdRejReason         Tag = 103
+	TagHeartBtInt           Tag = 108
+	TagTestReqID            Tag = 112
+	TagOrigSendingTime      Tag = 122
+	TagGapFillFlag          Tag = 123
+	TagResetSeqNumFlag      Tag = 141
+	TagExecType             Tag = 150
+	TagLeavesQty            Tag = 151
+	TagNoRelatedSym         Tag = 146
+	TagMDReqID              Tag = 262
+	TagSubscriptionReqType  Tag = 263
+	TagMarketDepth          Tag = 264
+	TagMDUpdateType         Tag = 265
+	TagNoMDEntryTypes       Tag = 267
+	TagNoMDEntries          Tag = 268
+	TagMDEntryType          Tag = 269
+	TagMDEntryPx            Tag = 270
+	TagMDEntrySize          Tag = 271
+	TagMDUpdateAction       Tag = 279
+	TagMDReqRejReason       Tag = 281
+	TagMDEntryPositionNo    Tag = 290
+	TagRefTagID             Tag = 371
+	TagRefMsgType           Tag = 372
+	TagSessionRejectReason  Tag = 373
+	TagBusinessRejectReason Tag = 380
+	TagCxlRejResponseTo     Tag = 434
+)
+
+// Message types.
+const (
+	MsgTypeHeartbeat                 = "0"
+	MsgTypeTestRequest               = "1"
+	MsgTypeResendRequest             = "2"
+	MsgTypeReject                    = "3"
+	MsgTypeSequenceReset             = "4"
+	MsgTypeLogout                    = "5"
+	MsgTypeExecutionReport           = "8"
+	MsgTypeOrderCancelReject         = "9"
+	MsgTypeLogon                     = "A"
+	MsgTypeNewOrderSingle            = "D"
+	MsgTypeOrderCancelRequest        = "F"
+	MsgTypeOrderCancelReplaceRequest = "G"
+	MsgTypeMarketDataRequest         = "V"
+	MsgTypeMarketDataSnapshot        = "W"
+	MsgTypeMarketDataIncremental     = "X"
+	MsgTypeMarketDataRequestReject   = "Y"
+	MsgTypeBusinessMessageReject     = "j"
+)
+
+var (
+	ErrGarbled      = errors.New("garbled message")
+	ErrFieldMissing = errors.New("required field missing")
+)
+
+// isAdmin reports whether the message type is session level; admin messages aren't resent, but gap filled.
+func isAdmin(msgType string) bool {
+	switch msgType {
+	case MsgTypeHeartbeat, MsgTypeTestRequest, MsgTypeResendRequest, MsgTypeReject, MsgTypeSequenceReset, MsgTypeLogout, MsgTypeLogon:
+		return true
+	default:
+		return false
+	}
+}
+
+// isMarketData reports whether the message type is market data; it's stale by the time it'd be resent, so it's gap filled.
+func isMarketData(msgType string) bool {
+	switch msgType {
+	case MsgTypeMarketDataSnapshot, MsgTypeMarketDataIncremental:
+		return true
+	default:
+		return false
+	}
+}
+
+type Field struct {
+	Tag   Tag
+	Value string
+}
+
+// Message is a FIX message's fields in order, from MsgType on; BeginString, BodyLength & CheckSum are added when it's encoded.
+type Message struct {
+	Fields []Field
+}
+
+func NewMessage(msgType string) *Message {
+	return &Message{Fields: []Field{{Tag: TagMsgType, Value: msgType}}}
+}
+
+func (m *Message) MsgType() string {
+	value, _ := m.Get(TagMsgType)
+	return value
+}
+
+func (m *Message) Get(tag Tag) (string, bool) {
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			return field.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// GetAll returns every value of the field, in order; as in a repeating group.
+func (m *Message) GetAll(tag Tag) []string {
+	var values []string
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			values = append(values, field.Value)
+		}
+	}
+
+	return values
+}
+
+// Add appends the field, even if the message already has it; as in a repeating group.
+func (m *Message) Add(tag Tag, value string) *Message {
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+	return m
+}
+
+// Set replaces the field's value, adding the field if the message doesn't have it.
+func (m *Message) Set(tag Tag, value string) *Message {
+	for i := range m.Fields {
+		if m.Fields[i].Tag == tag {
+			m.Fields[i].Value = value
+			return m
+		}
+	}
+
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+	return m
+}
+
+func (m *Message) SetInt(tag Tag, v uint64) *Message {
+	return m.Set(tag, strconv.FormatUint(v, 10))
+}
+
+func (m *Message) SetFloat(tag Tag, f float64) *Message {
+	return m.Set(tag, formatFloat(f))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (m *Message) SetTime(tag Tag, t time.Time) *Message {
+	return m.Set(tag, t.UTC().Format(timeFormat))
+}
+
+func (m *Message) SetBool(tag Tag, v bool) *Message {
+	if v {
+		return m.Set(tag, "Y")
+	}
+
+	return m.Set(tag, "N")
+}
+
+// Require returns the message's field by tag, or an error if it's missing.
+func (m *Message) Require(tag Tag) (string, error) {
+	value, ok := m.Get(tag)
+	if !ok || value == "" {
+		return "", fmt.Errorf("tag %d: %w", tag, ErrFieldMissing)
+	}
+
+	return value, nil
+}
+
+func (m *Message) Int(tag Tag) (uint64, error) {
+	value, err := m.Require(tag)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("tag %d: parse %q: %w", tag, value, err)
+	}
+
+	return v, nil
+}
+
+func (m *Message) Float(tag Tag) (float64, error) {
+	value, err := m.Require(tag)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("tag %d: parse %q: %w", tag, value, err)
+	}
+
+	return f, nil
+}
+
+func (m *Message) Bool(tag Tag) bool {
+	value, _ := m.Get(tag)
+	return value == "Y"
+}
+
+// String renders the message's fields with | in place of SOH, for logging.
+func (m *Message) String() string {
+	var b strings.Builder
+	for i, field := range m.Fields {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+
+		fmt.Fprintf(&b, "%d=%s", field.Tag, field.Value)
+	}
+
+	return b.String()
+}
+
+// Encode appends the message to buf, framed with BeginString, BodyLength & CheckSum.
+func (m *Message) Encode(buf []byte) []byte {
+	var body []byte
+	for _, field := range m.Fields {
+		body = strconv.AppendInt(body, int64(field.Tag), 10)
+		body = append(body, '=')
+		body = append(body, field.Value...)
+		body = append(body, soh)
+	}
+
+	start := len(buf)
+	buf = append(buf, "8="+BeginString+"\x019="...)
+	buf = strconv.AppendInt(buf, int64(len(body)), 10)
+	buf = append(buf, soh)
+	buf = append(buf, body...)
+
+	sum := checksum(buf[start:])
+	buf = append(buf, "10="...)
+	buf = append(buf, byte('0'+sum/100), byte('0'+sum/10%10), byte('0'+sum%10), soh)
+
+	return buf
+}
+
+func checksum(b []byte) int {
+	var sum int
+	for _, c := range b {
+		sum += int(c)
+	}
+
+	return sum % 256
+}
+
+// Parse parses a framed message, checking its BodyLength & CheckSum.
+func Parse(raw []byte) (*Message, error) {
+	if !bytes.HasPrefix(raw, []byte("8="+BeginString+"\x01")) {
+		return nil, fmt.Errorf("%w: expected begin string %s", ErrGarbled, BeginString)
+	}
+
+	trailer := bytes.LastIndex(raw[:len(raw)-1], []byte("\x0110="))
+	if trailer < 0 || raw[len(raw)-1] != soh {
+		return nil, fmt.Errorf("%w: missing checksum", ErrGarbled)
+	}
+
+	trailer++ // past the SOH ending the body.
+	want, err := strconv.Atoi(string(raw[trailer+3 : len(raw)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: checksum: %v", ErrGarbled, err)
+	}
+
+	if got := checksum(raw[:trailer]); got != want {
+		return nil, fmt.Errorf("%w: checksum %03d, want %03d", ErrGarbled, got, want)
+	}
+
+	fields, err := parseFields(raw[:trailer])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) < 3 || fields[1].Tag != TagBodyLength || fields[2].Tag != TagMsgType {
+		return nil, fmt.Errorf("%w: expected BodyLength then MsgType", ErrGarbled)
+	}
+
+	return &Message{Fields: fields[2:]}, nil
+}
+
+func parseFields(raw []byte) ([]Field, error) {
+	var fields []Field
+	for len(raw) > 0 {
+		end := bytes.IndexByte(raw, soh)
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated field", ErrGarbled)
+		}
+
+		tag, value, ok := bytes.Cut(raw[:end], []byte("="))
+		if !ok {
+			return nil, fmt.Errorf("%w: field %q", ErrGarbled, raw[:end])
+		}
+
+		t, err := strconv.Atoi(string(tag))
+		if err != nil {
+			return nil, fmt.Errorf("%w: tag %q", ErrGarbled, tag)
+		}
+
+		fields = append(fields, Field{Tag: Tag(t), Value: string(value)})
+		raw = raw[end+1:]
+	}
+
+	return fields, nil
+}
+
+// Reader reads framed messages from a stream.
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next reads the next message's raw bytes; parse them with Parse.
+func (r *Reader) Next() ([]byte, error) {
+	begin, err := r.r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := r.r.ReadBytes(soh)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	if !bytes.HasPrefix(length, []byte("9=")) {
+		return nil, fmt.Errorf("%w: expected body length, got %q", ErrGarbled, length)
+	}
+
+	n, err := strconv.Atoi(string(length[2 : len(length)-1]))
+	if err != nil || n < 0 || n > maxBodyLength {
+		return nil, fmt.Errorf("%w: body length %q", ErrGarbled, length)
+	}
+
+	raw := make([]byte, 0, len(begin)+len(length)+n+7)
+	raw = append(raw, begin...)
+	raw = append(raw, length...)
+	raw = raw[:len(raw)+n]
+	if _, err := io.ReadFull(r.r, raw[len(begin)+len(length):]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	trailer, err := r.r.ReadBytes(soh)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return append(raw, trailer...), nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
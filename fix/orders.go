@@ -0,0 +1,453 @@
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+type requestKind uint8
+
+const (
+	requestNewOrder requestKind = iota + 1
+	requestCancel
+	requestReplace
+)
+
+// pending is a request waiting on the book; the book reports the orders it changes synchronously, so events for it are
+// reported against its client order IDs, and as the request's exec type.
+type pending struct {
+	kind        requestKind
+	clOrdID     string
+	origClOrdID string
+	orderID     uint64
+
+	// reported is set once an event's been reported for the request.
+	reported bool
+}
+
+// Exec types, order statuses, sides & order types as FIX encodes them.
+const (
+	execTypeNew         = "0"
+	execTypeCancelled   = "4"
+	execTypeReplaced    = "5"
+	execTypeRejected    = "8"
+	execTypeExpired     = "C"
+	execTypeTrade       = "F"
+	execTypeOrderStatus = "I"
+
+	cxlRejResponseToCancel  = "1"
+	cxlRejResponseToReplace = "2"
+
+	cxlRejReasonTooLate      = "0"
+	cxlRejReasonUnknownOrder = "1"
+	cxlRejReasonOther        = "99"
+
+	ordRejReasonExchangeClosed = "2"
+	ordRejReasonExceedsLimit   = "3"
+	ordRejReasonDuplicate      = "6"
+	ordRejReasonOther          = "99"
+)
+
+func ordStatus(status lob.OrderStatus) string {
+	switch status {
+	case lob.OrderStatusNew:
+		return "0"
+	case lob.OrderStatusPartiallyFilled:
+		return "1"
+	case lob.OrderStatusFilled:
+		return "2"
+	case lob.OrderStatusCancelled:
+		return "4"
+	case lob.OrderStatusRejected:
+		return "8"
+	case lob.OrderStatusExpired:
+		return "C"
+	default:
+		return "8"
+	}
+}
+
+func ordRejReason(reason lob.RejectReason) string {
+	switch reason {
+	case lob.RejectReasonTradingState:
+		return ordRejReasonExchangeClosed
+	case lob.RejectReasonMaxOrderSize, lob.RejectReasonMaxOrderNotional, lob.RejectReasonMaxOpenOrders,
+		lob.RejectReasonMaxGrossPosition, lob.RejectReasonMaxNetPosition, lob.RejectReasonCreditLimit,
+		lob.RejectReasonInsufficientBalance:
+		return ordRejReasonExceedsLimit
+	case lob.RejectReasonDuplicateOrder:
+		return ordRejReasonDuplicate
+	default:
+		return ordRejReasonOther
+	}
+}
+
+func encodeSide(side lob.OrderSide) string {
+	switch side {
+	case lob.BuySide:
+		return "1"
+	case lob.SellSide:
+		return "2"
+	default:
+		return ""
+	}
+}
+
+func parseSide(value string) (lob.OrderSide, error) {
+	switch value {
+	case "1":
+		return lob.BuySide, nil
+	case "2":
+		return lob.SellSide, nil
+	default:
+		return 0, fmt.Errorf("unsupported side %q", value)
+	}
+}
+
+func encodeOrdType(orderType lob.OrderType) string {
+	switch orderType {
+	case lob.MarketOrder:
+		return "1"
+	case lob.LimitOrder:
+		return "2"
+	default:
+		return ""
+	}
+}
+
+func parseOrdType(value string) (lob.OrderType, error) {
+	switch value {
+	case "1":
+		return lob.MarketOrder, nil
+	case "2":
+		return lob.LimitOrder, nil
+	default:
+		return 0, fmt.Errorf("unsupported order type %q", value)
+	}
+}
+
+// report sends an execution report for the order event, should the counterparty be connected.
+func (cp *counterparty) report(a *Acceptor, event lob.OrderEvent) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	var (
+		info           = event.Order
+		p              = cp.pending
+		clOrdID, known = cp.clOrdIDs[info.ID]
+		origClOrdID    string
+		forPending     = p != nil && p.orderID == info.ID
+		execType       string
+	)
+
+	switch {
+	case !known && p != nil && p.kind == requestNewOrder:
+		// The first event for a new order; the book's only now assigned its ID.
+		p.orderID, forPending = info.ID, true
+		clOrdID = p.clOrdID
+	case forPending && p.kind != requestNewOrder:
+		clOrdID, origClOrdID = p.clOrdID, p.origClOrdID
+	}
+
+	if forPending {
+		p.reported = true
+		delete(cp.orderIDs, cp.clOrdIDs[info.ID])
+		cp.clOrdIDs[info.ID], cp.orderIDs[clOrdID] = clOrdID, info.ID
+	}
+
+	switch {
+	case event.LastSize > 0:
+		execType = execTypeTrade
+	case info.Status == lob.OrderStatusRejected:
+		execType = execTypeRejected
+	case info.Status == lob.OrderStatusCancelled:
+		execType = execTypeCancelled
+	case info.Status == lob.OrderStatusExpired:
+		execType = execTypeExpired
+	case forPending && p.kind == requestReplace:
+		execType = execTypeReplaced
+	case forPending && p.kind == requestNewOrder:
+		execType = execTypeNew
+	default:
+		execType = execTypeOrderStatus
+	}
+
+	if info.Status.Finished() {
+		delete(cp.clOrdIDs, info.ID)
+		delete(cp.orderIDs, clOrdID)
+	}
+
+	if cp.conn == nil {
+		slog.Debug("FIX: dropping execution report for disconnected counterparty", "counterparty", cp.compID, "order", info.ID)
+		return
+	}
+
+	report := a.executionReport(info, execType, clOrdID, origClOrdID)
+	if event.LastSize > 0 {
+		report.SetFloat(TagLastQty, float64(event.LastSize))
+		report.SetFloat(TagLastPx, float64(event.LastPrice))
+	}
+
+	cp.conn.send(outbound{msg: report})
+}
+
+func (a *Acceptor) executionReport(info lob.OrderInfo, execType, clOrdID, origClOrdID string) *Message {
+	msg := NewMessage(MsgTypeExecutionReport)
+	msg.SetInt(TagOrderID, info.ID)
+	msg.Set(TagClOrdID, clOrdID)
+	if origClOrdID != "" {
+		msg.Set(TagOrigClOrdID, origClOrdID)
+	}
+	msg.Set(TagExecID, a.nextExecID())
+	msg.Set(TagExecType, execType)
+	msg.Set(TagOrdStatus, ordStatus(info.Status))
+	if info.AccountID != 0 {
+		msg.SetInt(TagAccount, info.AccountID)
+	}
+	if a.config.Symbol != "" {
+		msg.Set(TagSymbol, a.config.Symbol)
+	}
+	msg.Set(TagSide, encodeSide(info.Side))
+	msg.Set(TagOrdType, encodeOrdType(info.OrderType))
+	if info.OrderType == lob.LimitOrder {
+		msg.SetFloat(TagPrice, float64(info.Price))
+	}
+	msg.SetFloat(TagOrderQty, float64(info.Size))
+	msg.SetFloat(TagCumQty, float64(info.FilledSize))
+	msg.SetFloat(TagLeavesQty, float64(info.RemainingSize))
+	msg.SetFloat(TagAvgPx, float64(info.AvgPrice))
+	if !info.UpdatedAt.IsZero() {
+		msg.SetTime(TagTransactTime, info.UpdatedAt)
+	} else {
+		msg.SetTime(TagTransactTime, a.now())
+	}
+
+	if info.Status == lob.OrderStatusRejected {
+		msg.Set(TagOrdRejReason, ordRejReason(info.RejectReason))
+		msg.Set(TagText, info.RejectReason.String())
+	}
+
+	return msg
+}
+
+// begin marks the request as pending on the book, until the returned func's called.
+func (cp *counterparty) begin(p *pending) func() *pending {
+	cp.mu.Lock()
+	cp.pending = p
+	cp.mu.Unlock()
+
+	return func() *pending {
+		cp.mu.Lock()
+		defer cp.mu.Unlock()
+
+		cp.pending = nil
+		return p
+	}
+}
+
+// lookup resolves the order a cancel or replace refers to: by OrderID if it's given, otherwise by OrigClOrdID. Only the
+// counterparty's own open orders are found.
+func (cp *counterparty) lookup(msg *Message) (uint64, bool) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if orderID, err := msg.Int(TagOrderID); err == nil {
+		_, ok := cp.clOrdIDs[orderID]
+		return orderID, ok
+	}
+
+	origClOrdID, _ := msg.Get(TagOrigClOrdID)
+	orderID, ok := cp.orderIDs[origClOrdID]
+
+	return orderID, ok
+}
+
+func (c *conn) newOrder(seq uint64, msg *Message) {
+	clOrdID, err := msg.Require(TagClOrdID)
+	if err != nil {
+		c.reject(seq, MsgTypeNewOrderSingle, TagClOrdID, err.Error())
+		return
+	}
+
+	order, refTag, err := parseNewOrder(msg)
+	if err != nil {
+		c.reject(seq, MsgTypeNewOrderSingle, refTag, err.Error())
+		return
+	}
+
+	c.cp.mu.Lock()
+	_, duplicate := c.cp.orderIDs[clOrdID]
+	c.cp.mu.Unlock()
+
+	if duplicate {
+		report := c.acceptor.executionReport(lob.OrderInfo{
+			OrderType: order.OrderType,
+			Side:      order.Side,
+			Price:     order.Price,
+			Size:      order.Size,
+			AccountID: order.AccountID,
+			Status:    lob.OrderStatusRejected,
+		}, execTypeRejected, clOrdID, "")
+		report.Set(TagOrdRejReason, ordRejReasonDuplicate)
+		report.Set(TagText, "duplicate client order id")
+		c.send(outbound{msg: report})
+		return
+	}
+
+	order.ClientOrderID = clOrdID
+	c.engine.Tag(order)
+
+	end := c.cp.begin(&pending{kind: requestNewOrder, clOrdID: clOrdID})
+	_, err = c.acceptor.config.Book.PlaceOrder(order)
+	p := end()
+
+	// Orders the book rejected were reported as they were rejected; anything else never reached it.
+	if err != nil && !p.reported {
+		info := order.Info()
+		info.Status = lob.OrderStatusRejected
+
+		report := c.acceptor.executionReport(info, execTypeRejected, clOrdID, "")
+		var rejectErr *lob.RejectError
+		if errors.As(err, &rejectErr) {
+			report.Set(TagOrdRejReason, ordRejReason(rejectErr.Reason))
+		}
+		report.Set(TagText, err.Error())
+		c.send(outbound{msg: report})
+	}
+}
+
+func parseNewOrder(msg *Message) (*lob.Order, Tag, error) {
+	value, err := msg.Require(TagSide)
+	if err != nil {
+		return nil, TagSide, err
+	}
+
+	side, err := parseSide(value)
+	if err != nil {
+		return nil, TagSide, err
+	}
+
+	value, err = msg.Require(TagOrdType)
+	if err != nil {
+		return nil, TagOrdType, err
+	}
+
+	orderType, err := parseOrdType(value)
+	if err != nil {
+		return nil, TagOrdType, err
+	}
+
+	size, err := msg.Float(TagOrderQty)
+	if err != nil {
+		return nil, TagOrderQty, err
+	}
+
+	var price float64
+	if orderType == lob.LimitOrder {
+		if price, err = msg.Float(TagPrice); err != nil {
+			return nil, TagPrice, err
+		}
+	}
+
+	order := lob.NewOrder(orderType, side, lob.Price(price), lob.Size(size))
+
+	if _, ok := msg.Get(TagAccount); ok {
+		if order.AccountID, err = msg.Int(TagAccount); err != nil {
+			return nil, TagAccount, err
+		}
+	}
+
+	return order, 0, nil
+}
+
+func (c *conn) cancelOrder(seq uint64, msg *Message) {
+	clOrdID, err := msg.Require(TagClOrdID)
+	if err != nil {
+		c.reject(seq, MsgTypeOrderCancelRequest, TagClOrdID, err.Error())
+		return
+	}
+
+	origClOrdID, _ := msg.Get(TagOrigClOrdID)
+
+	orderID, ok := c.cp.lookup(msg)
+	if !ok {
+		c.cancelReject(orderID, clOrdID, origClOrdID, cxlRejResponseToCancel, lob.ErrOrderNotFound)
+		return
+	}
+
+	end := c.cp.begin(&pending{kind: requestCancel, clOrdID: clOrdID, origClOrdID: origClOrdID, orderID: orderID})
+	err = c.acceptor.config.Book.CancelOrder(orderID)
+	end()
+
+	if err != nil {
+		c.cancelReject(orderID, clOrdID, origClOrdID, cxlRejResponseToCancel, err)
+	}
+}
+
+func (c *conn) replaceOrder(seq uint64, msg *Message) {
+	clOrdID, err := msg.Require(TagClOrdID)
+	if err != nil {
+		c.reject(seq, MsgTypeOrderCancelReplaceRequest, TagClOrdID, err.Error())
+		return
+	}
+
+	price, err := msg.Float(TagPrice)
+	if err != nil {
+		c.reject(seq, MsgTypeOrderCancelReplaceRequest, TagPrice, err.Error())
+		return
+	}
+
+	size, err := msg.Float(TagOrderQty)
+	if err != nil {
+		c.reject(seq, MsgTypeOrderCancelReplaceRequest, TagOrderQty, err.Error())
+		return
+	}
+
+	origClOrdID, _ := msg.Get(TagOrigClOrdID)
+
+	orderID, ok := c.cp.lookup(msg)
+	if !ok {
+		c.cancelReject(orderID, clOrdID, origClOrdID, cxlRejResponseToReplace, lob.ErrOrderNotFound)
+		return
+	}
+
+	end := c.cp.begin(&pending{kind: requestReplace, clOrdID: clOrdID, origClOrdID: origClOrdID, orderID: orderID})
+	err = c.acceptor.config.Book.EditOrder(&lob.Order{ID: orderID, Price: lob.Price(price), Size: lob.Size(size)})
+	end()
+
+	if err != nil {
+		c.cancelReject(orderID, clOrdID, origClOrdID, cxlRejResponseToReplace, err)
+	}
+}
+
+// cancelReject rejects a cancel or replace request, reporting the order's current status.
+func (c *conn) cancelReject(orderID uint64, clOrdID, origClOrdID, responseTo string, err error) {
+	reply := NewMessage(MsgTypeOrderCancelReject)
+	reply.SetInt(TagOrderID, orderID)
+	reply.Set(TagClOrdID, clOrdID)
+	reply.Set(TagOrigClOrdID, origClOrdID)
+
+	info, getErr := c.acceptor.config.Book.GetOrder(orderID)
+
+	status := ordStatus(lob.OrderStatusRejected)
+	if getErr == nil {
+		status = ordStatus(info.Status)
+	}
+	reply.Set(TagOrdStatus, status)
+	reply.Set(TagCxlRejResponseTo, responseTo)
+
+	reason := cxlRejReasonOther
+	switch {
+	case errors.Is(err, lob.ErrOrderNotFound):
+		reason = cxlRejReasonUnknownOrder
+	case getErr == nil && info.Status.Finished():
+		reason = cxlRejReasonTooLate
+	}
+	reply.Set(TagCxlRejReason, reason)
+	reply.Set(TagText, err.Error())
+
+	c.send(outbound{msg: reply})
+}
@@ -0,0 +1,303 @@
+package fix
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store persists a session's sequence numbers, and the application messages it sent so they can be resent.
+type Store interface {
+	// NextSenderSeq is the sequence number of the next message we send, and NextTargetSeq that of the next we expect.
+	NextSenderSeq() uint64
+	NextTargetSeq() uint64
+	SetNextSenderSeq(seq uint64) error
+	SetNextTargetSeq(seq uint64) error
+
+	// SaveMessage keeps an encoded message sent with the given sequence number.
+	SaveMessage(seq uint64, raw []byte) error
+	// Messages returns the kept messages with sequence numbers in [begin, end], keyed by sequence number.
+	Messages(begin, end uint64) (map[uint64][]byte, error)
+
+	// Reset starts the session over from sequence number 1, forgetting every kept message.
+	Reset() error
+	Close() error
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		nextSender: 1,
+		nextTarget: 1,
+		messages:   make(map[uint64][]byte),
+	}
+}
+
+// MemoryStore keeps a session's state for as long as the process lives.
+type MemoryStore struct {
+	nextSender uint64
+	nextTarget uint64
+	messages   map[uint64][]byte
+	mu         sync.Mutex
+}
+
+func (s *MemoryStore) NextSenderSeq() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.nextSender
+}
+
+func (s *MemoryStore) NextTargetSeq() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.nextTarget
+}
+
+func (s *MemoryStore) SetNextSenderSeq(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextSender = seq
+	return nil
+}
+
+func (s *MemoryStore) SetNextTargetSeq(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextTarget = seq
+	return nil
+}
+
+func (s *MemoryStore) SaveMessage(seq uint64, raw []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages[seq] = append([]byte(nil), raw...)
+	return nil
+}
+
+func (s *MemoryStore) Messages(begin, end uint64) (map[uint64][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make(map[uint64][]byte)
+	for seq, raw := range s.messages {
+		if seq >= begin && seq <= end {
+			messages[seq] = raw
+		}
+	}
+
+	return messages, nil
+}
+
+func (s *MemoryStore) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextSender, s.nextTarget = 1, 1
+	s.messages = make(map[uint64][]byte)
+
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// OpenFileStore opens the store for a session in dir, named for the session's comp IDs, creating it if it doesn't exist.
+// Sequence numbers are kept in a .seqnums file, and messages appended to a .body file; both are synced on every write.
+func OpenFileStore(dir, senderCompID, targetCompID string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("open fix store: %w", err)
+	}
+
+	base := filepath.Join(dir, senderCompID+"-"+targetCompID)
+	s := &FileStore{
+		memory:      NewMemoryStore(),
+		seqnumsPath: base + ".seqnums",
+		bodyPath:    base + ".body",
+	}
+
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("open fix store %s: %w", base, err)
+	}
+
+	body, err := os.OpenFile(s.bodyPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open fix store %s: %w", base, err)
+	}
+
+	s.body = body
+
+	return s, nil
+}
+
+// FileStore keeps a session's state on disk, so sequence numbers carry on across restarts.
+type FileStore struct {
+	memory      *MemoryStore
+	seqnumsPath string
+	bodyPath    string
+	body        *os.File
+	mu          sync.Mutex
+}
+
+func (s *FileStore) load() error {
+	seqnums, err := os.ReadFile(s.seqnumsPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	case len(seqnums) != 16:
+		return fmt.Errorf("seqnums: expected 16 bytes, got %d", len(seqnums))
+	default:
+		s.memory.nextSender = binary.LittleEndian.Uint64(seqnums[:8])
+		s.memory.nextTarget = binary.LittleEndian.Uint64(seqnums[8:])
+	}
+
+	body, err := os.Open(s.bodyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var (
+		r      = bufio.NewReader(body)
+		header [12]byte
+		// end is just past the last complete record.
+		end int64
+	)
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			// A torn record at the tail was never acknowledged as saved; it's dropped so the next record's appended after
+			// the last complete one, rather than after the garbage.
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return os.Truncate(s.bodyPath, end)
+			}
+
+			return err
+		}
+
+		raw := make([]byte, binary.LittleEndian.Uint32(header[8:]))
+		if _, err := io.ReadFull(r, raw); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return os.Truncate(s.bodyPath, end)
+			}
+
+			return err
+		}
+
+		s.memory.messages[binary.LittleEndian.Uint64(header[:8])] = raw
+		end += int64(len(header) + len(raw))
+	}
+}
+
+func (s *FileStore) NextSenderSeq() uint64 { return s.memory.NextSenderSeq() }
+func (s *FileStore) NextTargetSeq() uint64 { return s.memory.NextTargetSeq() }
+
+func (s *FileStore) SetNextSenderSeq(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory.SetNextSenderSeq(seq)
+	return s.saveSeqnums()
+}
+
+func (s *FileStore) SetNextTargetSeq(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory.SetNextTargetSeq(seq)
+	return s.saveSeqnums()
+}
+
+// saveSeqnums rewrites the sequence numbers through a temporary file, so a crash leaves either the old or the new ones.
+func (s *FileStore) saveSeqnums() error {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], s.memory.NextSenderSeq())
+	binary.LittleEndian.PutUint64(buf[8:], s.memory.NextTargetSeq())
+
+	tmp := s.seqnumsPath + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("save seqnums: %w", err)
+	}
+
+	if _, err := file.Write(buf[:]); err != nil {
+		file.Close()
+		return fmt.Errorf("save seqnums: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("save seqnums: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("save seqnums: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.seqnumsPath); err != nil {
+		return fmt.Errorf("save seqnums: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileStore) SaveMessage(seq uint64, raw []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := make([]byte, 12, 12+len(raw))
+	binary.LittleEndian.PutUint64(record[:8], seq)
+	binary.LittleEndian.PutUint32(record[8:], uint32(len(raw)))
+	record = append(record, raw...)
+
+	if _, err := s.body.Write(record); err != nil {
+		return fmt.Errorf("save message %d: %w", seq, err)
+	}
+
+	if err := s.body.Sync(); err != nil {
+		return fmt.Errorf("save message %d: %w", seq, err)
+	}
+
+	return s.memory.SaveMessage(seq, raw)
+}
+
+func (s *FileStore) Messages(begin, end uint64) (map[uint64][]byte, error) {
+	return s.memory.Messages(begin, end)
+}
+
+func (s *FileStore) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory.Reset()
+
+	if err := s.body.Truncate(0); err != nil {
+		return fmt.Errorf("reset store: %w", err)
+	}
+
+	return s.saveSeqnums()
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.body.Close()
+}
@@ -2,9 +2,19 @@ module github.com/sashajdn/orderbook
 
 go 1.22.1
 
+require (
+	github.com/coder/websocket v1.8.13
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/net v0.29.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1
+	google.golang.org/grpc v1.68.2
+	google.golang.org/protobuf v1.35.2
+)
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.9.0 // indirect
+	golang.org/x/sys v0.25.0 // indirect
+	golang.org/x/text v0.18.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
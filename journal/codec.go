@@ -0,0 +1,282 @@
+package journal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// codecVersion prefixes every encoded command, so the layout can change without breaking old journals.
+const codecVersion = 1
+
+// hashRecordMarker starts a hash record's payload, in place of a command's codec version.
+const hashRecordMarker = 0xff
+
+var (
+	errShortPayload = errors.New("short payload")
+
+	// ErrFieldTooLong is returned encoding a command with a string longer than its length prefix can hold.
+	ErrFieldTooLong = errors.New("field too long")
+)
+
+// HashRecord is the Orderbook's rolling state hash, recorded once the command with the same Seq was applied.
+type HashRecord struct {
+	Seq  uint64
+	Hash uint64
+}
+
+// EncodeHash appends the binary encoding of the hash record to buf.
+func EncodeHash(buf []byte, record HashRecord) []byte {
+	buf = append(buf, hashRecordMarker)
+	buf = binary.LittleEndian.AppendUint64(buf, record.Seq)
+	buf = binary.LittleEndian.AppendUint64(buf, record.Hash)
+
+	return buf
+}
+
+// DecodeHash decodes a hash record encoded by EncodeHash.
+func DecodeHash(payload []byte) (HashRecord, error) {
+	d := decoder{buf: payload}
+	if marker := d.byte(); marker != hashRecordMarker && d.err == nil {
+		return HashRecord{}, fmt.Errorf("decode hash: unexpected marker %d", marker)
+	}
+
+	record := HashRecord{
+		Seq:  d.uint64(),
+		Hash: d.uint64(),
+	}
+
+	if d.err != nil {
+		return HashRecord{}, fmt.Errorf("decode hash: %w", d.err)
+	}
+
+	return record, nil
+}
+
+// EncodeCommand appends the binary encoding of the command to buf. Only the exported fields of the command's order are encoded.
+// Strings are prefixed with their length as a uint16, so a command with a longer one can't be encoded.
+func EncodeCommand(buf []byte, cmd lob.Command) ([]byte, error) {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"client order id", cmd.Order.ClientOrderID},
+		{"reason", cmd.Reason},
+		{"asset", string(cmd.Asset)},
+		{"fee tier", cmd.Tier},
+	} {
+		if len(field.value) > math.MaxUint16 {
+			return buf, fmt.Errorf("encode command %d: %s of %d bytes: %w", cmd.Seq, field.name, len(field.value), ErrFieldTooLong)
+		}
+	}
+
+	buf = append(buf, codecVersion)
+	buf = binary.LittleEndian.AppendUint64(buf, cmd.Seq)
+	buf = append(buf, byte(cmd.Type))
+	buf = appendTime(buf, cmd.Time)
+
+	order := cmd.Order
+	buf = append(buf, byte(order.OrderType), byte(order.Side))
+	buf = appendFloat(buf, float64(order.Price))
+	buf = appendFloat(buf, float64(order.Size))
+	buf = binary.LittleEndian.AppendUint64(buf, order.ID)
+	buf = binary.LittleEndian.AppendUint64(buf, order.AccountID)
+	buf = binary.LittleEndian.AppendUint64(buf, order.SessionID)
+	buf = appendBool(buf, order.CancelOnDisconnect)
+	buf = appendString(buf, order.ClientOrderID)
+	buf = appendTime(buf, order.ReceivedAt)
+
+	buf = binary.LittleEndian.AppendUint64(buf, cmd.OrderID)
+
+	req := cmd.MassCancel
+	buf = binary.LittleEndian.AppendUint64(buf, req.AccountID)
+	buf = binary.LittleEndian.AppendUint64(buf, req.SessionID)
+	buf = append(buf, byte(req.Side))
+	buf = appendBool(buf, req.CancelOnDisconnectOnly)
+	buf = appendFloat(buf, float64(req.Beyond))
+
+	buf = append(buf, byte(cmd.State))
+	buf = appendString(buf, cmd.Reason)
+	buf = appendTime(buf, cmd.At)
+
+	buf = binary.LittleEndian.AppendUint64(buf, cmd.AccountID)
+	buf = appendString(buf, string(cmd.Asset))
+	buf = appendFloat(buf, cmd.Amount)
+
+	buf = appendString(buf, cmd.Tier)
+	buf = appendFloat(buf, cmd.FeeTier.MakerRate)
+	buf = appendFloat(buf, cmd.FeeTier.TakerRate)
+	buf = appendFloat(buf, cmd.FeeTier.MinFee)
+
+	limits := cmd.Limits
+	buf = appendFloat(buf, float64(limits.MaxOrderSize))
+	buf = appendFloat(buf, limits.MaxOrderNotional)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(limits.MaxOpenOrders))
+	buf = appendFloat(buf, float64(limits.MaxGrossPosition))
+	buf = appendFloat(buf, float64(limits.MaxNetPosition))
+	buf = appendFloat(buf, limits.CreditLimit)
+
+	return buf, nil
+}
+
+// DecodeCommand decodes a command encoded by EncodeCommand.
+func DecodeCommand(payload []byte) (lob.Command, error) {
+	d := decoder{buf: payload}
+
+	version := d.byte()
+	if version != codecVersion {
+		if d.err != nil {
+			return lob.Command{}, fmt.Errorf("decode command: %w", d.err)
+		}
+
+		return lob.Command{}, fmt.Errorf("decode command: unknown version %d", version)
+	}
+
+	var cmd lob.Command
+	cmd.Seq = d.uint64()
+	cmd.Type = lob.CommandType(d.byte())
+	cmd.Time = d.time()
+
+	cmd.Order.OrderType = lob.OrderType(d.byte())
+	cmd.Order.Side = lob.OrderSide(d.byte())
+	cmd.Order.Price = lob.Price(d.float())
+	cmd.Order.Size = lob.Size(d.float())
+	cmd.Order.ID = d.uint64()
+	cmd.Order.AccountID = d.uint64()
+	cmd.Order.SessionID = d.uint64()
+	cmd.Order.CancelOnDisconnect = d.bool()
+	cmd.Order.ClientOrderID = d.string()
+	cmd.Order.ReceivedAt = d.time()
+
+	cmd.OrderID = d.uint64()
+
+	cmd.MassCancel.AccountID = d.uint64()
+	cmd.MassCancel.SessionID = d.uint64()
+	cmd.MassCancel.Side = lob.OrderSide(d.byte())
+	cmd.MassCancel.CancelOnDisconnectOnly = d.bool()
+	cmd.MassCancel.Beyond = lob.Price(d.float())
+
+	cmd.State = lob.TradingState(d.byte())
+	cmd.Reason = d.string()
+	cmd.At = d.time()
+
+	cmd.AccountID = d.uint64()
+	cmd.Asset = lob.Asset(d.string())
+	cmd.Amount = d.float()
+
+	cmd.Tier = d.string()
+	cmd.FeeTier.MakerRate = d.float()
+	cmd.FeeTier.TakerRate = d.float()
+	cmd.FeeTier.MinFee = d.float()
+
+	cmd.Limits.MaxOrderSize = lob.Size(d.float())
+	cmd.Limits.MaxOrderNotional = d.float()
+	cmd.Limits.MaxOpenOrders = int(d.uint64())
+	cmd.Limits.MaxGrossPosition = lob.Size(d.float())
+	cmd.Limits.MaxNetPosition = lob.Size(d.float())
+	cmd.Limits.CreditLimit = d.float()
+
+	if d.err != nil {
+		return lob.Command{}, fmt.Errorf("decode command: %w", d.err)
+	}
+
+	return cmd, nil
+}
+
+func appendFloat(buf []byte, f float64) []byte {
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(f))
+}
+
+// appendString prefixes the string with its length as a uint16; EncodeCommand checks it fits.
+func appendString(buf []byte, s string) []byte {
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+func appendBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, 1)
+	}
+
+	return append(buf, 0)
+}
+
+// appendTime encodes the zero time as 0, rather than its out of range unix nanoseconds.
+func appendTime(buf []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return binary.LittleEndian.AppendUint64(buf, 0)
+	}
+
+	return binary.LittleEndian.AppendUint64(buf, uint64(t.UnixNano()))
+}
+
+// decoder reads fields in order, recording the first error so callers only need to check once at the end.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if len(d.buf) < n {
+		d.err = errShortPayload
+		return nil
+	}
+
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+func (d *decoder) bool() bool {
+	return d.byte() == 1
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	return 0
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes(int(d.uint16())))
+}
+
+func (d *decoder) float() float64 {
+	return math.Float64frombits(d.uint64())
+}
+
+func (d *decoder) time() time.Time {
+	nanos := d.uint64()
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, int64(nanos))
+}
@@ -0,0 +1,335 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// SyncPolicy controls when appended commands are fsynced to disk.
+type SyncPolicy uint8
+
+const (
+	// SyncEveryCommand fsyncs before every Append returns, so no command the Orderbook applied is lost on a crash.
+	SyncEveryCommand SyncPolicy = iota + 1
+	// SyncBatch fsyncs every BatchSize commands, or every BatchInterval, whichever comes first.
+	SyncBatch
+	// SyncOS leaves flushing to the operating system; commands survive a process crash, but not necessarily a machine crash.
+	SyncOS
+)
+
+func (s SyncPolicy) String() string {
+	switch s {
+	case SyncEveryCommand:
+		return "every_command"
+	case SyncBatch:
+		return "batch"
+	case SyncOS:
+		return "os"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	DefaultBatchSize     = 64
+	DefaultBatchInterval = 10 * time.Millisecond
+)
+
+var ErrClosed = errors.New("journal closed")
+
+type Config struct {
+	Sync          SyncPolicy
+	BatchSize     int
+	BatchInterval time.Duration
+}
+
+// Open opens the journal at the given path for appending, creating it if it doesn't exist. A torn record at the tail, left by
+// a crash mid-write, is truncated away; corruption anywhere else is returned as an error.
+func Open(path string, config Config) (*Writer, error) {
+	if config.Sync == 0 {
+		config.Sync = SyncEveryCommand
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+
+	if config.BatchInterval <= 0 {
+		config.BatchInterval = DefaultBatchInterval
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal %s: %w", path, err)
+	}
+
+	size, lastSeq, err := repair(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("open journal %s: %w", path, err)
+	}
+
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("open journal %s: seek to end: %w", path, err)
+	}
+
+	w := &Writer{
+		file:    file,
+		config:  config,
+		size:    size,
+		lastSeq: lastSeq,
+		done:    make(chan struct{}),
+	}
+
+	if config.Sync == SyncBatch {
+		w.wg.Add(1)
+		go w.run()
+	}
+
+	return w, nil
+}
+
+// repair scans the journal, truncating a torn final record. Returns the size of the valid journal and the last sequence number in it.
+func repair(file *os.File) (int64, uint64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, 0, fmt.Errorf("stat: %w", err)
+	}
+
+	var (
+		r       = NewReader(file)
+		lastSeq uint64
+	)
+	for {
+		cmd, err := r.Next()
+		switch {
+		case err == nil:
+			lastSeq = cmd.Seq
+			continue
+		case errors.Is(err, io.EOF):
+			return r.Offset(), lastSeq, nil
+		case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, ErrCorrupt) && r.recordEnd() >= info.Size():
+			slog.Warn("Journal: truncating torn record", "offset", r.Offset(), "size", info.Size(), "error", err)
+
+			if err := file.Truncate(r.Offset()); err != nil {
+				return 0, 0, fmt.Errorf("truncate torn record: %w", err)
+			}
+
+			return r.Offset(), lastSeq, nil
+		default:
+			return 0, 0, fmt.Errorf("read record at offset %d: %w", r.Offset(), err)
+		}
+	}
+}
+
+// journalFile is the part of *os.File the Writer uses.
+type journalFile interface {
+	io.WriteSeeker
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// Writer appends framed commands, and optionally state hashes, to a journal file; it satisfies lob.Journal & lob.HashLog.
+type Writer struct {
+	file    journalFile
+	config  Config
+	buf     []byte
+	size    int64
+	pending int
+	lastSeq uint64
+	closed  bool
+	broken  error
+	mu      sync.Mutex
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Append writes the command to the journal, syncing it according to the sync policy.
+func (w *Writer) Append(cmd lob.Command) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+
+	if w.broken != nil {
+		return fmt.Errorf("append command %d: %w", cmd.Seq, w.broken)
+	}
+
+	frame, err := AppendFrame(w.buf[:0], cmd)
+	if err != nil {
+		return fmt.Errorf("append command %d: %w", cmd.Seq, err)
+	}
+
+	size, lastSeq := w.size, w.lastSeq
+	if err := w.write(frame); err != nil {
+		return fmt.Errorf("append command %d: %w", cmd.Seq, err)
+	}
+
+	w.lastSeq = cmd.Seq
+	w.pending++
+
+	if w.config.Sync == SyncEveryCommand || w.config.Sync == SyncBatch && w.pending >= w.config.BatchSize {
+		if err := w.sync(); err != nil {
+			// The Orderbook doesn't apply a command it failed to journal, & reuses its Seq for the next, so the frame's
+			// dropped too. Commands before it were already applied, so they stay even though they weren't synced either.
+			if err := w.truncate(size); err != nil {
+				slog.Error("Journal: failed to drop unsynced command", "seq", cmd.Seq, "error", err)
+			}
+
+			w.lastSeq = lastSeq
+			w.pending--
+
+			return fmt.Errorf("append command %d: %w", cmd.Seq, err)
+		}
+	}
+
+	return nil
+}
+
+// RecordHash writes the Orderbook's rolling state hash after applying the command with the given sequence number; it
+// satisfies lob.HashLog. Hash records are synced along with the commands that follow them.
+func (w *Writer) RecordHash(seq, hash uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+
+	if w.broken != nil {
+		return fmt.Errorf("record hash %d: %w", seq, w.broken)
+	}
+
+	if err := w.write(AppendHashFrame(w.buf[:0], HashRecord{Seq: seq, Hash: hash})); err != nil {
+		return fmt.Errorf("record hash %d: %w", seq, err)
+	}
+
+	return nil
+}
+
+// write appends a frame to the file, dropping any partial write so the next frame doesn't land after garbage.
+func (w *Writer) write(frame []byte) error {
+	w.buf = frame
+
+	n, err := w.file.Write(frame)
+	if err != nil {
+		if n > 0 {
+			if err := w.truncate(w.size); err != nil {
+				slog.Error("Journal: failed to drop partial write", "error", err)
+			}
+		}
+
+		return err
+	}
+
+	w.size += int64(n)
+
+	return nil
+}
+
+// truncate drops everything written after the given size, so the next frame is written from there. If it can't, the
+// journal refuses anything else, rather than leave frames after one that shouldn't be there.
+func (w *Writer) truncate(size int64) error {
+	if err := w.file.Truncate(size); err != nil {
+		w.broken = fmt.Errorf("truncate to %d: %w", size, err)
+		return w.broken
+	}
+
+	if _, err := w.file.Seek(size, io.SeekStart); err != nil {
+		w.broken = fmt.Errorf("seek to %d: %w", size, err)
+		return w.broken
+	}
+
+	w.size = size
+
+	return nil
+}
+
+// Sync fsyncs everything appended so far.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+
+	return w.sync()
+}
+
+func (w *Writer) sync() error {
+	if w.pending == 0 {
+		return nil
+	}
+
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("sync journal: %w", err)
+	}
+
+	w.pending = 0
+
+	return nil
+}
+
+// LastSeq returns the sequence number of the last command in the journal.
+func (w *Writer) LastSeq() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.lastSeq
+}
+
+// Close syncs anything outstanding and closes the journal file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrClosed
+	}
+
+	w.closed = true
+	close(w.done)
+	w.mu.Unlock()
+
+	w.wg.Wait()
+
+	syncErr := w.sync()
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("close journal: %w", err)
+	}
+
+	return syncErr
+}
+
+// run syncs batches that haven't filled up within the batch interval.
+func (w *Writer) run() {
+	defer w.wg.Done()
+
+	t := time.NewTicker(w.config.BatchInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			w.mu.Lock()
+			if err := w.sync(); err != nil {
+				slog.Error("Journal: failed to sync batch", "error", err)
+			}
+			w.mu.Unlock()
+		case <-w.done:
+			return
+		}
+	}
+}
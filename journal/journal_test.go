@@ -0,0 +1,380 @@
+package journal
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 123456789)
+
+	tests := []struct {
+		name string
+		cmd  lob.Command
+	}{
+		{
+			name: "place_order",
+			cmd: lob.Command{
+				Seq:  1,
+				Type: lob.CommandPlaceOrder,
+				Time: now,
+				Order: lob.Order{
+					OrderType:          lob.LimitOrder,
+					Side:               lob.SellSide,
+					Price:              101.25,
+					Size:               3.5,
+					AccountID:          7,
+					SessionID:          2,
+					CancelOnDisconnect: true,
+					ClientOrderID:      "strategy-1-42",
+					ReceivedAt:         now.Add(-time.Microsecond),
+				},
+			},
+		},
+		{
+			name: "cancel_order",
+			cmd:  lob.Command{Seq: 2, Type: lob.CommandCancelOrder, Time: now, OrderID: 1},
+		},
+		{
+			name: "mass_cancel",
+			cmd: lob.Command{
+				Seq:        3,
+				Type:       lob.CommandMassCancel,
+				Time:       now,
+				MassCancel: lob.MassCancelRequest{AccountID: 7, Side: lob.BuySide, Beyond: 99, CancelOnDisconnectOnly: true},
+			},
+		},
+		{
+			name: "schedule_transition",
+			cmd: lob.Command{
+				Seq:    4,
+				Type:   lob.CommandScheduleTransition,
+				Time:   now,
+				State:  lob.TradingStateClosed,
+				Reason: "end of day",
+				At:     now.Add(time.Hour),
+			},
+		},
+		{
+			name: "deposit",
+			cmd:  lob.Command{Seq: 5, Type: lob.CommandDeposit, Time: now, AccountID: 7, Asset: "USD", Amount: 1_000.5},
+		},
+		{
+			name: "set_fee_tier",
+			cmd: lob.Command{
+				Seq:     6,
+				Type:    lob.CommandSetFeeTier,
+				Time:    now,
+				Tier:    "vip",
+				FeeTier: lob.FeeTier{MakerRate: -0.0001, TakerRate: 0.001, MinFee: 0.01},
+			},
+		},
+		{
+			name: "set_risk_limits",
+			cmd: lob.Command{
+				Seq:       7,
+				Type:      lob.CommandSetRiskLimits,
+				Time:      now,
+				AccountID: 7,
+				Limits: lob.RiskLimits{
+					MaxOrderSize:     10,
+					MaxOrderNotional: 10_000,
+					MaxOpenOrders:    5,
+					MaxGrossPosition: 20,
+					MaxNetPosition:   15,
+					CreditLimit:      50_000,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := EncodeCommand(nil, tt.cmd)
+			require.NoError(t, err)
+
+			decoded, err := DecodeCommand(encoded)
+			require.NoError(t, err)
+
+			assert.True(t, tt.cmd.Time.Equal(decoded.Time))
+			assert.True(t, tt.cmd.At.Equal(decoded.At))
+			assert.True(t, tt.cmd.Order.ReceivedAt.Equal(decoded.Order.ReceivedAt))
+
+			decoded.Time, decoded.At, decoded.Order.ReceivedAt = tt.cmd.Time, tt.cmd.At, tt.cmd.Order.ReceivedAt
+			assert.Equal(t, tt.cmd, decoded)
+		})
+	}
+}
+
+func TestCodec_RejectsUnknownVersion(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := EncodeCommand(nil, lob.Command{Seq: 1, Type: lob.CommandTick})
+	require.NoError(t, err)
+
+	encoded[0] = codecVersion + 1
+
+	_, err = DecodeCommand(encoded)
+	assert.ErrorContains(t, err, "unknown version")
+}
+
+func TestWriter_AppendRefusesOverlongFields(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "journal")
+	w, err := Open(path, Config{})
+	require.NoError(t, err)
+	defer w.Close()
+
+	err = w.Append(lob.Command{Seq: 1, Type: lob.CommandPlaceOrder, Order: lob.Order{ClientOrderID: strings.Repeat("x", math.MaxUint16+1)}})
+	assert.ErrorIs(t, err, ErrFieldTooLong)
+
+	err = w.Append(lob.Command{Seq: 1, Type: lob.CommandTransition, Reason: strings.Repeat("x", math.MaxUint16+1)})
+	assert.ErrorIs(t, err, ErrFieldTooLong)
+
+	require.NoError(t, w.Append(lob.Command{Seq: 1, Type: lob.CommandTick}))
+	require.NoError(t, w.Close())
+
+	var commands []lob.Command
+	require.NoError(t, ReadFile(path, func(cmd lob.Command) error {
+		commands = append(commands, cmd)
+		return nil
+	}))
+	require.Len(t, commands, 1)
+	assert.Equal(t, lob.CommandTick, commands[0].Type)
+}
+
+func TestWriter_AppendAndRead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{
+			name:   "sync_every_command",
+			config: Config{Sync: SyncEveryCommand},
+		},
+		{
+			name:   "sync_batch",
+			config: Config{Sync: SyncBatch, BatchSize: 2, BatchInterval: time.Millisecond},
+		},
+		{
+			name:   "sync_os",
+			config: Config{Sync: SyncOS},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "journal")
+
+			w, err := Open(path, tt.config)
+			require.NoError(t, err)
+
+			for seq := uint64(1); seq <= 5; seq++ {
+				require.NoError(t, w.Append(lob.Command{Seq: seq, Type: lob.CommandCancelOrder, OrderID: seq * 10}))
+			}
+
+			assert.Equal(t, uint64(5), w.LastSeq())
+			require.NoError(t, w.Close())
+			assert.ErrorIs(t, w.Append(lob.Command{Seq: 6}), ErrClosed)
+
+			var seqs []uint64
+			require.NoError(t, ReadFile(path, func(cmd lob.Command) error {
+				seqs = append(seqs, cmd.Seq)
+				assert.Equal(t, cmd.Seq*10, cmd.OrderID)
+				return nil
+			}))
+
+			assert.Equal(t, []uint64{1, 2, 3, 4, 5}, seqs)
+		})
+	}
+}
+
+// failingSync fails every sync while fail is set.
+type failingSync struct {
+	*os.File
+	fail bool
+}
+
+func (f *failingSync) Sync() error {
+	if f.fail {
+		return errors.New("sync failed")
+	}
+
+	return f.File.Sync()
+}
+
+func TestWriter_AppendDropsCommandItFailedToSync(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected []uint64
+	}{
+		{
+			name:     "sync_every_command",
+			config:   Config{Sync: SyncEveryCommand},
+			expected: []uint64{1, 2, 3, 4},
+		},
+		{
+			// The batch's earlier commands were already applied, so they're kept even though they weren't synced.
+			name:     "sync_batch",
+			config:   Config{Sync: SyncBatch, BatchSize: 3, BatchInterval: time.Hour},
+			expected: []uint64{1, 2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "journal")
+
+			w, err := Open(path, tt.config)
+			require.NoError(t, err)
+
+			file := &failingSync{File: w.file.(*os.File)}
+			w.file = file
+
+			require.NoError(t, w.Append(lob.Command{Seq: 1, Type: lob.CommandCancelOrder, OrderID: 10}))
+
+			file.fail = true
+			if tt.config.Sync == SyncBatch {
+				require.NoError(t, w.Append(lob.Command{Seq: 2, Type: lob.CommandCancelOrder, OrderID: 20}))
+				assert.Error(t, w.Append(lob.Command{Seq: 3, Type: lob.CommandCancelOrder, OrderID: 99}))
+				assert.Equal(t, uint64(2), w.LastSeq())
+			} else {
+				assert.Error(t, w.Append(lob.Command{Seq: 2, Type: lob.CommandCancelOrder, OrderID: 99}))
+				assert.Equal(t, uint64(1), w.LastSeq())
+			}
+			file.fail = false
+
+			// The Orderbook reuses the Seq of the command it didn't apply.
+			for seq := w.LastSeq() + 1; seq <= 4; seq++ {
+				require.NoError(t, w.Append(lob.Command{Seq: seq, Type: lob.CommandCancelOrder, OrderID: seq * 10}))
+			}
+			require.NoError(t, w.Close())
+
+			var seqs []uint64
+			require.NoError(t, ReadFile(path, func(cmd lob.Command) error {
+				seqs = append(seqs, cmd.Seq)
+				assert.Equal(t, cmd.Seq*10, cmd.OrderID)
+				return nil
+			}))
+
+			assert.Equal(t, tt.expected, seqs)
+		})
+	}
+}
+
+func TestOpen_RepairsTornTail(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "journal")
+
+	w, err := Open(path, Config{})
+	require.NoError(t, err)
+	require.NoError(t, w.Append(lob.Command{Seq: 1, Type: lob.CommandTick}))
+	require.NoError(t, w.Close())
+
+	// Simulate a crash part way through writing the second record.
+	torn, err := AppendFrame(nil, lob.Command{Seq: 2, Type: lob.CommandTick})
+	require.NoError(t, err)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.Write(torn[:len(torn)-3])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	w, err = Open(path, Config{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), w.LastSeq())
+	require.NoError(t, w.Append(lob.Command{Seq: 2, Type: lob.CommandTick}))
+	require.NoError(t, w.Close())
+
+	var seqs []uint64
+	require.NoError(t, ReadFile(path, func(cmd lob.Command) error {
+		seqs = append(seqs, cmd.Seq)
+		return nil
+	}))
+	assert.Equal(t, []uint64{1, 2}, seqs)
+}
+
+func TestOpen_DetectsCorruption(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "journal")
+
+	w, err := Open(path, Config{})
+	require.NoError(t, err)
+	for seq := uint64(1); seq <= 3; seq++ {
+		require.NoError(t, w.Append(lob.Command{Seq: seq, Type: lob.CommandTick}))
+	}
+	require.NoError(t, w.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[frameHeaderSize+1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	_, err = Open(path, Config{})
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestJournal_ReplayRebuildsOrderbook(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "journal")
+
+	w, err := Open(path, Config{Sync: SyncOS})
+	require.NoError(t, err)
+
+	book := lob.NewOrderbook(128, lob.WithJournal(w))
+
+	place := func(side lob.OrderSide, price lob.Price, size lob.Size) uint64 {
+		id, err := book.PlaceOrder(lob.NewOrder(lob.LimitOrder, side, price, size))
+		require.NoError(t, err)
+		return id
+	}
+
+	place(lob.BuySide, 99, 2)
+	bid := place(lob.BuySide, 98, 1)
+	place(lob.SellSide, 101, 3)
+	place(lob.SellSide, 99, 1)
+	require.NoError(t, book.CancelOrder(bid))
+	require.NoError(t, book.EditOrder(&lob.Order{ID: 3, Price: 102, Size: 2}))
+	require.NoError(t, w.Close())
+
+	replayed := lob.NewOrderbook(128)
+	require.NoError(t, ReadFile(path, func(cmd lob.Command) error {
+		return replayed.Apply(cmd)
+	}))
+
+	assert.Equal(t, book.LastSeq(), replayed.LastSeq())
+	assert.Equal(t, book.OpenOrders(), replayed.OpenOrders())
+
+	// Commands already applied aren't applied again.
+	assert.Error(t, replayed.Apply(lob.Command{Seq: 1, Type: lob.CommandTick}))
+}
@@ -0,0 +1,201 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+const (
+	// frameHeaderSize is the payload length followed by the payload's CRC32-C, both little endian uint32s.
+	frameHeaderSize = 8
+
+	// MaxRecordSize bounds a single record's payload, so a corrupt length can't trigger a huge allocation.
+	MaxRecordSize = 1 << 20
+)
+
+var (
+	ErrCorrupt = errors.New("corrupt journal record")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// RecordType distinguishes the records in a journal.
+type RecordType uint8
+
+const (
+	RecordCommand RecordType = iota + 1
+	RecordHash
+)
+
+// Record is a single journal record; either a command, or the state hash recorded once it was applied.
+type Record struct {
+	Type    RecordType
+	Command lob.Command
+	Hash    HashRecord
+}
+
+// AppendFrame appends the framed encoding of the command to buf; if the command can't be encoded buf is returned as it was.
+func AppendFrame(buf []byte, cmd lob.Command) ([]byte, error) {
+	start := len(buf)
+	framed, err := EncodeCommand(append(buf, make([]byte, frameHeaderSize)...), cmd)
+	if err != nil {
+		return buf[:start], err
+	}
+
+	return sealFrame(framed, start), nil
+}
+
+// AppendHashFrame appends the framed encoding of the hash record to buf.
+func AppendHashFrame(buf []byte, record HashRecord) []byte {
+	start := len(buf)
+
+	return sealFrame(EncodeHash(append(buf, make([]byte, frameHeaderSize)...), record), start)
+}
+
+// sealFrame fills in the header of the frame starting at start, now its payload's been appended.
+func sealFrame(buf []byte, start int) []byte {
+	payload := buf[start+frameHeaderSize:]
+	binary.LittleEndian.PutUint32(buf[start:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[start+4:], crc32.Checksum(payload, crcTable))
+
+	return buf
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Reader reads framed commands back from a journal.
+type Reader struct {
+	r       *bufio.Reader
+	offset  int64
+	length  int64
+	header  [frameHeaderSize]byte
+	payload []byte
+}
+
+// Next returns the next command in the journal, skipping hash records. It returns io.EOF at the end of the journal,
+// io.ErrUnexpectedEOF if the journal ends part way through a record, and ErrCorrupt if a record fails its checksum.
+func (r *Reader) Next() (lob.Command, error) {
+	for {
+		record, err := r.NextRecord()
+		if err != nil {
+			return lob.Command{}, err
+		}
+
+		if record.Type == RecordCommand {
+			return record.Command, nil
+		}
+	}
+}
+
+// NextRecord returns the next record in the journal, whatever its type; errors are as for Next.
+func (r *Reader) NextRecord() (Record, error) {
+	r.length = 0
+
+	if _, err := io.ReadFull(r.r, r.header[:]); err != nil {
+		return Record{}, err
+	}
+
+	var (
+		length   = binary.LittleEndian.Uint32(r.header[:4])
+		checksum = binary.LittleEndian.Uint32(r.header[4:])
+	)
+
+	r.length = frameHeaderSize + int64(length)
+	if length > MaxRecordSize {
+		return Record{}, fmt.Errorf("%w: record of %d bytes exceeds max of %d", ErrCorrupt, length, MaxRecordSize)
+	}
+
+	if cap(r.payload) < int(length) {
+		r.payload = make([]byte, length)
+	}
+	r.payload = r.payload[:length]
+
+	if _, err := io.ReadFull(r.r, r.payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Record{}, io.ErrUnexpectedEOF
+		}
+
+		return Record{}, err
+	}
+
+	if crc32.Checksum(r.payload, crcTable) != checksum {
+		return Record{}, fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+
+	record, err := decodeRecord(r.payload)
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	r.offset += r.length
+	r.length = 0
+
+	return record, nil
+}
+
+func decodeRecord(payload []byte) (Record, error) {
+	if len(payload) > 0 && payload[0] == hashRecordMarker {
+		hash, err := DecodeHash(payload)
+		return Record{Type: RecordHash, Hash: hash}, err
+	}
+
+	cmd, err := DecodeCommand(payload)
+	return Record{Type: RecordCommand, Command: cmd}, err
+}
+
+// Offset returns the offset just past the last record read successfully.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// recordEnd returns where the record that failed to read claims to end.
+func (r *Reader) recordEnd() int64 {
+	return r.offset + r.length
+}
+
+// ReadFile calls fn with every command in the journal at the given path, in order, stopping at the first error.
+func ReadFile(path string, fn func(cmd lob.Command) error) error {
+	return ReadRecords(path, func(record Record) error {
+		if record.Type != RecordCommand {
+			return nil
+		}
+
+		return fn(record.Command)
+	})
+}
+
+// ReadRecords calls fn with every record in the journal at the given path, in order, stopping at the first error.
+func ReadRecords(path string, fn func(record Record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open journal %s: %w", path, err)
+	}
+	defer file.Close()
+
+	r := NewReader(file)
+	for {
+		record, err := r.NextRecord()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("read journal %s at offset %d: %w", path, r.Offset(), err)
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
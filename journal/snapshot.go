@@ -0,0 +1,304 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".snap"
+
+	DefaultSnapshotInterval = time.Minute
+	DefaultSnapshotRetain   = 3
+)
+
+// WriteSnapshot writes the snapshot into dir, named by its sequence number. It's written to a temporary file then renamed
+// into place, so a crash never leaves a partial snapshot behind.
+func WriteSnapshot(dir string, snapshot lob.Snapshot) (string, error) {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(snapshot); err != nil {
+		return "", fmt.Errorf("encode snapshot %d: %w", snapshot.Seq, err)
+	}
+
+	var header [frameHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[:4], uint32(payload.Len()))
+	binary.LittleEndian.PutUint32(header[4:], crc32.Checksum(payload.Bytes(), crcTable))
+
+	tmp, err := os.CreateTemp(dir, snapshotPrefix+"*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("create snapshot %d: %w", snapshot.Seq, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(header[:], payload.Bytes()...)); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("write snapshot %d: %w", snapshot.Seq, err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("sync snapshot %d: %w", snapshot.Seq, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("close snapshot %d: %w", snapshot.Seq, err)
+	}
+
+	path := filepath.Join(dir, snapshotName(snapshot.Seq))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("rename snapshot %d: %w", snapshot.Seq, err)
+	}
+
+	if err := syncDir(dir); err != nil {
+		return "", fmt.Errorf("sync snapshot dir: %w", err)
+	}
+
+	return path, nil
+}
+
+// ReadSnapshot reads the snapshot at the given path, verifying its checksum.
+func ReadSnapshot(path string) (lob.Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lob.Snapshot{}, fmt.Errorf("read snapshot %s: %w", path, err)
+	}
+
+	if len(data) < frameHeaderSize {
+		return lob.Snapshot{}, fmt.Errorf("read snapshot %s: %w: missing header", path, ErrCorrupt)
+	}
+
+	var (
+		length   = binary.LittleEndian.Uint32(data[:4])
+		checksum = binary.LittleEndian.Uint32(data[4:])
+		payload  = data[frameHeaderSize:]
+	)
+	if int(length) != len(payload) || crc32.Checksum(payload, crcTable) != checksum {
+		return lob.Snapshot{}, fmt.Errorf("read snapshot %s: %w: checksum mismatch", path, ErrCorrupt)
+	}
+
+	var snapshot lob.Snapshot
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&snapshot); err != nil {
+		return lob.Snapshot{}, fmt.Errorf("decode snapshot %s: %w", path, err)
+	}
+
+	return snapshot, nil
+}
+
+// LatestSnapshot returns the snapshot in dir with the highest sequence number, falling back to older snapshots should it be
+// corrupt. Returns false if there's no usable snapshot.
+func LatestSnapshot(dir string) (lob.Snapshot, bool, error) {
+	paths, err := snapshots(dir)
+	if err != nil {
+		return lob.Snapshot{}, false, err
+	}
+
+	for i := len(paths) - 1; i >= 0; i-- {
+		snapshot, err := ReadSnapshot(paths[i])
+		if err != nil {
+			slog.Warn("Journal: skipping unreadable snapshot", "path", paths[i], "error", err)
+			continue
+		}
+
+		return snapshot, true, nil
+	}
+
+	return lob.Snapshot{}, false, nil
+}
+
+// PruneSnapshots removes all but the newest keep snapshots in dir.
+func PruneSnapshots(dir string, keep int) error {
+	paths, err := snapshots(dir)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(paths)-keep; i++ {
+		if err := os.Remove(paths[i]); err != nil {
+			return fmt.Errorf("remove snapshot %s: %w", paths[i], err)
+		}
+	}
+
+	return nil
+}
+
+// snapshots returns the paths of every snapshot in dir, oldest first.
+func snapshots(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots in %s: %w", dir, err)
+	}
+
+	type snapshotFile struct {
+		seq  uint64
+		path string
+	}
+
+	var files []snapshotFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		files = append(files, snapshotFile{seq: seq, path: filepath.Join(dir, name)})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].seq < files[j].seq
+	})
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.path)
+	}
+
+	return paths, nil
+}
+
+func snapshotName(seq uint64) string {
+	return fmt.Sprintf("%s%020d%s", snapshotPrefix, seq, snapshotSuffix)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// Snapshottable is the state a Snapshotter persists; satisfied by *lob.Orderbook.
+type Snapshottable interface {
+	Snapshot() lob.Snapshot
+}
+
+type SnapshotterConfig struct {
+	Dir      string
+	Source   Snapshottable
+	Interval time.Duration
+
+	// Retain is how many snapshots to keep; older ones are removed once a new one is written.
+	Retain int
+}
+
+func NewSnapshotter(config SnapshotterConfig) *Snapshotter {
+	if config.Interval <= 0 {
+		config.Interval = DefaultSnapshotInterval
+	}
+
+	if config.Retain <= 0 {
+		config.Retain = DefaultSnapshotRetain
+	}
+
+	return &Snapshotter{
+		config: config,
+	}
+}
+
+// Snapshotter periodically writes snapshots of an Orderbook, skipping any interval in which nothing was sequenced.
+type Snapshotter struct {
+	config  SnapshotterConfig
+	lastSeq uint64
+}
+
+// Snapshot writes a snapshot now, unless there's been nothing sequenced since the last one.
+func (s *Snapshotter) Snapshot() error {
+	snapshot := s.config.Source.Snapshot()
+	if snapshot.Seq == s.lastSeq && s.lastSeq != 0 {
+		return nil
+	}
+
+	path, err := WriteSnapshot(s.config.Dir, snapshot)
+	if err != nil {
+		return err
+	}
+
+	s.lastSeq = snapshot.Seq
+	slog.Debug("Journal: wrote snapshot", "path", path, "seq", snapshot.Seq)
+
+	return PruneSnapshots(s.config.Dir, s.config.Retain)
+}
+
+// Run snapshots every interval until the context is cancelled, taking a final snapshot on the way out.
+func (s *Snapshotter) Run(ctx context.Context) {
+	t := time.NewTicker(s.config.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if err := s.Snapshot(); err != nil {
+				slog.Error("Journal: failed to snapshot", "error", err)
+			}
+		case <-ctx.Done():
+			if err := s.Snapshot(); err != nil {
+				slog.Error("Journal: failed to snapshot", "error", err)
+			}
+			return
+		}
+	}
+}
+
+// Recover restores the Orderbook from the latest snapshot in snapshotDir, if there is one, then applies every command in the
+// journal after it. Commands are applied as they were originally, rejections and all. Returns the sequence number recovered to.
+//
+// Open the journal for appending before recovering, so any torn record at its tail is repaired first.
+func Recover(book *lob.Orderbook, snapshotDir, journalPath string) (uint64, error) {
+	snapshot, ok, err := LatestSnapshot(snapshotDir)
+	if err != nil {
+		return 0, fmt.Errorf("recover: %w", err)
+	}
+
+	if ok {
+		if err := book.Restore(snapshot); err != nil {
+			return 0, fmt.Errorf("recover: %w", err)
+		}
+	}
+
+	var applied int
+	err = ReadFile(journalPath, func(cmd lob.Command) error {
+		if cmd.Seq <= book.LastSeq() {
+			return nil
+		}
+
+		if err := book.Apply(cmd); err != nil {
+			slog.Debug("Journal: recovered command failed", "cmd", cmd.String(), "error", err)
+		}
+
+		applied++
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("recover: %w", err)
+	}
+
+	slog.Info("Journal: recovered orderbook", "snapshot_seq", snapshot.Seq, "commands_applied", applied, "seq", book.LastSeq())
+
+	return book.LastSeq(), nil
+}
@@ -0,0 +1,139 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/risk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_LatestAndPrune(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for _, seq := range []uint64{3, 10, 7} {
+		_, err := WriteSnapshot(dir, lob.Snapshot{Seq: seq})
+		require.NoError(t, err)
+	}
+
+	snapshot, ok, err := LatestSnapshot(dir)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(10), snapshot.Seq)
+
+	// Falls back to an older snapshot should the latest be corrupt.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, snapshotName(10)), []byte("garbage"), 0o644))
+	snapshot, ok, err = LatestSnapshot(dir)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(7), snapshot.Seq)
+
+	require.NoError(t, PruneSnapshots(dir, 1))
+	paths, err := snapshots(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, snapshotName(10))}, paths)
+
+	_, ok, err = LatestSnapshot(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRecover_RebuildsIdenticalBook(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir         = t.TempDir()
+		journalPath = filepath.Join(dir, "journal")
+	)
+
+	newBook := func(journal lob.Journal) *lob.Orderbook {
+		engine := risk.NewEngine(risk.Limits{})
+
+		ledger := lob.NewLedger()
+		require.NoError(t, ledger.Deposit(1, "USD", 100_000))
+		require.NoError(t, ledger.Deposit(2, "BTC", 100))
+		require.NoError(t, ledger.Deposit(2, "USD", 1_000))
+
+		opts := []lob.Option{
+			lob.WithLedger(ledger),
+			lob.WithInstrument(lob.Instrument{Symbol: "BTC/USD", Base: "BTC", Quote: "USD"}),
+			lob.WithPriceBands(lob.PriceBandConfig{Width: 0.1, Window: time.Minute, PauseDuration: time.Minute}),
+			lob.WithFeeSchedule(lob.NewFeeSchedule(lob.FeeTier{MakerRate: -0.0001, TakerRate: 0.0005})),
+			lob.WithPreTradeCheck(engine),
+		}
+		if journal != nil {
+			opts = append(opts, lob.WithJournal(journal))
+		}
+
+		book := lob.NewOrderbook(128, opts...)
+		book.Subscribe(engine.HandleEvent)
+
+		return book
+	}
+
+	w, err := Open(journalPath, Config{Sync: SyncOS})
+	require.NoError(t, err)
+
+	book := newBook(w)
+
+	place := func(accountID uint64, orderType lob.OrderType, side lob.OrderSide, price lob.Price, size lob.Size) uint64 {
+		order := lob.NewOrder(orderType, side, price, size)
+		order.AccountID = accountID
+
+		id, _ := book.PlaceOrder(order)
+		return id
+	}
+
+	require.NoError(t, book.SetFeeTier("vip", lob.FeeTier{MakerRate: -0.0002, TakerRate: 0.0003}))
+	require.NoError(t, book.SetAccountFeeTier(1, "vip"))
+	require.NoError(t, book.SetRiskLimits(1, risk.Limits{MaxOrderSize: 4}))
+
+	place(1, lob.LimitOrder, lob.BuySide, 1000, 2)
+	place(1, lob.LimitOrder, lob.BuySide, 1000, 1.5)
+	place(2, lob.LimitOrder, lob.SellSide, 1010, 3)
+	place(2, lob.MarketOrder, lob.SellSide, 0, 2.5)
+
+	_, err = WriteSnapshot(dir, book.Snapshot())
+	require.NoError(t, err)
+
+	ask := place(2, lob.LimitOrder, lob.SellSide, 1005, 1)
+	place(1, lob.LimitOrder, lob.BuySide, 999, 4)
+	require.NoError(t, book.EditOrder(&lob.Order{ID: ask, Price: 1004, Size: 2}))
+	place(1, lob.LimitOrder, lob.BuySide, 1004, 0.5)
+	require.NoError(t, book.ScheduleTransition(time.Now().Add(time.Hour), lob.TradingStateClosed, "end of day"))
+	require.NoError(t, w.Close())
+
+	// Crash, then recover into a fresh book.
+	w, err = Open(journalPath, Config{Sync: SyncOS})
+	require.NoError(t, err)
+	defer w.Close()
+
+	recovered := newBook(w)
+	seq, err := Recover(recovered, dir, journalPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, book.LastSeq(), seq)
+	assert.Equal(t, book.Snapshot(), recovered.Snapshot())
+
+	// Order IDs carry on from where they left off.
+	order := lob.NewOrder(lob.LimitOrder, lob.BuySide, 990, 1)
+	order.AccountID = 1
+	id, err := recovered.PlaceOrder(order)
+	require.NoError(t, err)
+	assert.Equal(t, seq+1, id)
+
+	// Limits set before the snapshot still hold.
+	order = lob.NewOrder(lob.LimitOrder, lob.BuySide, 990, 5)
+	order.AccountID = 1
+	_, err = recovered.PlaceOrder(order)
+
+	var rejectErr *lob.RejectError
+	require.ErrorAs(t, err, &rejectErr)
+	assert.Equal(t, lob.RejectReasonMaxOrderSize, rejectErr.Reason)
+}
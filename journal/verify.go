@@ -0,0 +1,105 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+var (
+	errDiverged = errors.New("diverged")
+	errReplayed = errors.New("replayed")
+)
+
+// Divergence is the first command after which the replayed state hash didn't match the one recorded.
+type Divergence struct {
+	Command  lob.Command
+	Recorded uint64
+	Replayed uint64
+
+	// Before & After are the replayed book either side of the command.
+	Before lob.Snapshot
+	After  lob.Snapshot
+}
+
+// Verify replays the journal at the given path through the Orderbook, fresh & configured as the recording one was, checking
+// the rolling state hash after every command against the hashes recorded. Returns the first divergence, or nil if every
+// recorded hash matched, along with how many hashes were checked.
+//
+// The book isn't snapshotted as it's replayed; on a divergence newBook builds another, configured the same, that the journal's
+// replayed through again up to the command before, for the book as it was before the command diverged.
+func Verify(path string, book *lob.Orderbook, newBook func() *lob.Orderbook) (*Divergence, int, error) {
+	var (
+		rolling    uint64
+		checked    int
+		divergence *Divergence
+		last       lob.Command
+	)
+
+	err := ReadRecords(path, func(record Record) error {
+		switch record.Type {
+		case RecordCommand:
+			last = record.Command
+
+			// Rejections are part of what's being replayed.
+			_ = book.Apply(record.Command)
+
+			rolling = lob.RollHash(rolling, book.StateHash())
+		case RecordHash:
+			// Hashes are recorded straight after their command is applied, before the next is sequenced.
+			if record.Hash.Seq != last.Seq {
+				return fmt.Errorf("hash recorded for seq %d doesn't follow its command", record.Hash.Seq)
+			}
+
+			checked++
+
+			if rolling == record.Hash.Hash {
+				return nil
+			}
+
+			divergence = &Divergence{
+				Command:  last,
+				Recorded: record.Hash.Hash,
+				Replayed: rolling,
+				After:    book.Snapshot(),
+			}
+
+			return errDiverged
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDiverged) {
+		return nil, checked, fmt.Errorf("verify journal: %w", err)
+	}
+
+	if divergence != nil {
+		before, err := replayBefore(path, newBook(), divergence.Command.Seq)
+		if err != nil {
+			return nil, checked, fmt.Errorf("verify journal: %w", err)
+		}
+
+		divergence.Before = before
+	}
+
+	return divergence, checked, nil
+}
+
+// replayBefore replays the journal through the book up to, but not including, the command with the given Seq.
+func replayBefore(path string, book *lob.Orderbook, seq uint64) (lob.Snapshot, error) {
+	err := ReadFile(path, func(cmd lob.Command) error {
+		if cmd.Seq >= seq {
+			return errReplayed
+		}
+
+		_ = book.Apply(cmd)
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errReplayed) {
+		return lob.Snapshot{}, fmt.Errorf("replay before seq %d: %w", seq, err)
+	}
+
+	return book.Snapshot(), nil
+}
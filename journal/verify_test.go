@@ -0,0 +1,82 @@
+package journal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "journal")
+
+	w, err := Open(path, Config{Sync: SyncOS})
+	require.NoError(t, err)
+
+	book := lob.NewOrderbook(128, lob.WithJournal(w), lob.WithHashLog(w))
+
+	orders := []*lob.Order{
+		lob.NewOrder(lob.LimitOrder, lob.BuySide, 1000, 2),
+		lob.NewOrder(lob.LimitOrder, lob.SellSide, 1002, 1),
+		lob.NewOrder(lob.LimitOrder, lob.SellSide, 1100, 1),
+		lob.NewOrder(lob.MarketOrder, lob.BuySide, 0, 1.5),
+	}
+	for _, order := range orders {
+		_, err := book.PlaceOrder(order)
+		require.NoError(t, err)
+	}
+	require.NoError(t, book.CancelOrder(1))
+	require.NoError(t, w.Close())
+
+	tests := []struct {
+		name             string
+		opts             []lob.Option
+		expectedChecked  int
+		expectDivergence bool
+		expectedSeq      uint64
+	}{
+		{
+			name:            "same_configuration_matches",
+			expectedChecked: 5,
+		},
+		{
+			name: "different_configuration_diverges",
+			opts: []lob.Option{
+				lob.WithPriceBands(lob.PriceBandConfig{Width: 0.01, Window: time.Second, ReferencePrice: 1000}),
+			},
+			expectedChecked:  3,
+			expectDivergence: true,
+			expectedSeq:      3,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			newBook := func() *lob.Orderbook { return lob.NewOrderbook(128, tt.opts...) }
+
+			divergence, checked, err := Verify(path, newBook(), newBook)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedChecked, checked)
+
+			if !tt.expectDivergence {
+				assert.Nil(t, divergence)
+				return
+			}
+
+			require.NotNil(t, divergence)
+			assert.Equal(t, tt.expectedSeq, divergence.Command.Seq)
+			assert.NotEqual(t, divergence.Recorded, divergence.Replayed)
+			assert.Equal(t, tt.expectedSeq-1, divergence.Before.Seq)
+			assert.Equal(t, tt.expectedSeq, divergence.After.Seq)
+			assert.NotEqual(t, divergence.Before, divergence.After)
+		})
+	}
+}
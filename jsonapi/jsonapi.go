@@ -0,0 +1,326 @@
+// Package jsonapi is the JSON encoding of orders, depth, trades & errors shared by the engine's JSON APIs.
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// Error codes for failures that aren't the engine rejecting an order; those are coded as the lob.RejectReason.
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeOrderNotFound  = "order_not_found"
+	CodeInternal       = "internal"
+)
+
+// Error is a failed request; Code is stable for clients to match on, while Message is for people.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Err returns the error as the engine would have returned it: a *lob.RejectError for the engine's reject reasons, and
+// lob.ErrOrderNotFound for unknown orders; clients of the JSON APIs can then handle errors as they would the engine's.
+func (e *Error) Err() error {
+	if e.Code == CodeOrderNotFound {
+		return fmt.Errorf("%s: %w", e.Message, lob.ErrOrderNotFound)
+	}
+
+	if reason := ParseRejectReason(e.Code); reason != 0 {
+		return &lob.RejectError{Reason: reason, Err: errors.New(e.Message)}
+	}
+
+	return e
+}
+
+// ParseRejectReason returns the reject reason as coded by RejectReason.String, or 0 if it's not one.
+func ParseRejectReason(value string) lob.RejectReason {
+	for reason := lob.RejectReasonInvalidOrder; reason <= lob.RejectReasonDuplicateOrder; reason++ {
+		if value == reason.String() {
+			return reason
+		}
+	}
+
+	return 0
+}
+
+// InvalidRequest returns an error for a request that couldn't be decoded or validated.
+func InvalidRequest(format string, args ...any) *Error {
+	return &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorFor codes an error returned by the engine: rejections by their reason, and unknown orders as CodeOrderNotFound.
+func ErrorFor(err error) *Error {
+	var (
+		apiErr    *Error
+		rejectErr *lob.RejectError
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.As(err, &rejectErr):
+		return &Error{Code: rejectErr.Reason.String(), Message: err.Error()}
+	case errors.Is(err, lob.ErrOrderNotFound):
+		return &Error{Code: CodeOrderNotFound, Message: err.Error()}
+	default:
+		return &Error{Code: CodeInternal, Message: err.Error()}
+	}
+}
+
+// Order is an order's state.
+type Order struct {
+	ID            uint64    `json:"id"`
+	AccountID     uint64    `json:"account_id"`
+	ClientOrderID string    `json:"client_order_id,omitempty"`
+	Type          string    `json:"type"`
+	Side          string    `json:"side"`
+	Price         float64   `json:"price"`
+	Size          float64   `json:"size"`
+	Status        string    `json:"status"`
+	RejectReason  string    `json:"reject_reason,omitempty"`
+	FilledSize    float64   `json:"filled_size"`
+	RemainingSize float64   `json:"remaining_size"`
+	AvgPrice      float64   `json:"avg_price"`
+	Fees          float64   `json:"fees"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	ReceivedAt    time.Time `json:"received_at"`
+	SequencedAt   time.Time `json:"sequenced_at"`
+}
+
+func NewOrder(info lob.OrderInfo) Order {
+	order := Order{
+		ID:            info.ID,
+		AccountID:     info.AccountID,
+		ClientOrderID: info.ClientOrderID,
+		Type:          encodeOrderType(info.OrderType),
+		Side:          info.Side.String(),
+		Price:         float64(info.Price),
+		Size:          float64(info.Size),
+		Status:        info.Status.String(),
+		FilledSize:    float64(info.FilledSize),
+		RemainingSize: float64(info.RemainingSize),
+		AvgPrice:      float64(info.AvgPrice),
+		Fees:          info.Fees,
+		UpdatedAt:     info.UpdatedAt,
+		ReceivedAt:    info.ReceivedAt,
+		SequencedAt:   info.SequencedAt,
+	}
+	if info.RejectReason != 0 {
+		order.RejectReason = info.RejectReason.String()
+	}
+
+	return order
+}
+
+// OrderInfo decodes the order's state; anything unrecognised is left zero.
+func (o Order) OrderInfo() lob.OrderInfo {
+	orderType, _ := parseOrderType(o.Type)
+	side, _ := parseSide(o.Side)
+
+	info := lob.OrderInfo{
+		ID:            o.ID,
+		AccountID:     o.AccountID,
+		ClientOrderID: o.ClientOrderID,
+		OrderType:     orderType,
+		Side:          side,
+		Price:         lob.Price(o.Price),
+		Size:          lob.Size(o.Size),
+		RejectReason:  ParseRejectReason(o.RejectReason),
+		FilledSize:    lob.Size(o.FilledSize),
+		RemainingSize: lob.Size(o.RemainingSize),
+		AvgPrice:      lob.Price(o.AvgPrice),
+		Fees:          o.Fees,
+		UpdatedAt:     o.UpdatedAt,
+		ReceivedAt:    o.ReceivedAt,
+		SequencedAt:   o.SequencedAt,
+	}
+
+	for status := lob.OrderStatusNew; status <= lob.OrderStatusExpired; status++ {
+		if o.Status == status.String() {
+			info.Status = status
+		}
+	}
+
+	return info
+}
+
+func NewOrders(infos []lob.OrderInfo) []Order {
+	orders := make([]Order, 0, len(infos))
+	for _, info := range infos {
+		orders = append(orders, NewOrder(info))
+	}
+
+	return orders
+}
+
+// NewOrderRequest places an order; Type is "limit" or "market", and Side "buy" or "sell". ClientOrderID is optional, and
+// unique to the account.
+type NewOrderRequest struct {
+	AccountID     uint64  `json:"account_id"`
+	ClientOrderID string  `json:"client_order_id,omitempty"`
+	Type          string  `json:"type"`
+	Side          string  `json:"side"`
+	Price         float64 `json:"price"`
+	Size          float64 `json:"size"`
+}
+
+func NewOrderRequestFrom(req client.AddOrderRequest) NewOrderRequest {
+	return NewOrderRequest{
+		AccountID:     req.AccountID,
+		ClientOrderID: req.ClientOrderID,
+		Type:          encodeOrderType(req.OrderType),
+		Side:          req.OrderSide.String(),
+		Price:         float64(req.Price),
+		Size:          float64(req.Size),
+	}
+}
+
+func (r NewOrderRequest) AddOrderRequest() (client.AddOrderRequest, error) {
+	orderType, err := parseOrderType(r.Type)
+	if err != nil {
+		return client.AddOrderRequest{}, err
+	}
+
+	side, err := parseSide(r.Side)
+	if err != nil {
+		return client.AddOrderRequest{}, err
+	}
+
+	return client.AddOrderRequest{
+		AccountID:     r.AccountID,
+		OrderType:     orderType,
+		OrderSide:     side,
+		Price:         lob.Price(r.Price),
+		Size:          lob.Size(r.Size),
+		ClientOrderID: r.ClientOrderID,
+	}, nil
+}
+
+// NewOrderResponse is the ID of the order placed; the order's ID even when the engine rejects it.
+type NewOrderResponse struct {
+	OrderID uint64 `json:"order_id"`
+}
+
+// AmendOrderRequest changes an open order's price & size.
+type AmendOrderRequest struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+func encodeOrderType(orderType lob.OrderType) string {
+	switch orderType {
+	case lob.LimitOrder:
+		return "limit"
+	case lob.MarketOrder:
+		return "market"
+	default:
+		return "unknown"
+	}
+}
+
+func parseOrderType(value string) (lob.OrderType, error) {
+	switch value {
+	case "limit":
+		return lob.LimitOrder, nil
+	case "market":
+		return lob.MarketOrder, nil
+	default:
+		return 0, InvalidRequest("unknown order type %q", value)
+	}
+}
+
+func parseSide(value string) (lob.OrderSide, error) {
+	switch value {
+	case "buy":
+		return lob.BuySide, nil
+	case "sell":
+		return lob.SellSide, nil
+	default:
+		return 0, InvalidRequest("unknown side %q", value)
+	}
+}
+
+// Level is a price level's aggregate size.
+type Level struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+// Depth is the top levels of both sides of the book, best first; Checksum is as computed by lob.Checksum.
+type Depth struct {
+	Seq      uint64  `json:"seq"`
+	Bids     []Level `json:"bids"`
+	Asks     []Level `json:"asks"`
+	Checksum uint32  `json:"checksum"`
+}
+
+func NewDepth(snapshot lob.DepthSnapshot) Depth {
+	return Depth{
+		Seq:      snapshot.Seq,
+		Bids:     newLevels(snapshot.Bids),
+		Asks:     newLevels(snapshot.Asks),
+		Checksum: snapshot.Checksum,
+	}
+}
+
+func newLevels(levels []lob.DepthLevel) []Level {
+	encoded := make([]Level, 0, len(levels))
+	for _, level := range levels {
+		encoded = append(encoded, Level{Price: float64(level.Price), Size: float64(level.Size)})
+	}
+
+	return encoded
+}
+
+// DepthChange is a level that changed, as returned by lob.DiffDepth; Action is "new", "change" or "delete".
+type DepthChange struct {
+	Action   string  `json:"action"`
+	Side     string  `json:"side"`
+	Position int     `json:"position"`
+	Price    float64 `json:"price"`
+	Size     float64 `json:"size"`
+}
+
+func NewDepthChanges(changes []lob.DepthChange) []DepthChange {
+	encoded := make([]DepthChange, 0, len(changes))
+	for _, change := range changes {
+		encoded = append(encoded, DepthChange{
+			Action:   change.Action.String(),
+			Side:     change.Side.String(),
+			Position: change.Position,
+			Price:    float64(change.Level.Price),
+			Size:     float64(change.Level.Size),
+		})
+	}
+
+	return encoded
+}
+
+// Trade is a match between two orders; Time is when the command that matched them was sequenced.
+type Trade struct {
+	Price         float64   `json:"price"`
+	Size          float64   `json:"size"`
+	AggressorSide string    `json:"aggressor_side"`
+	MakerOrderID  uint64    `json:"maker_order_id"`
+	TakerOrderID  uint64    `json:"taker_order_id"`
+	Time          time.Time `json:"time"`
+}
+
+func NewTrade(event lob.TradeEvent) Trade {
+	return Trade{
+		Price:         float64(event.Price),
+		Size:          float64(event.Size),
+		AggressorSide: event.AggressorSide.String(),
+		MakerOrderID:  event.MakerOrderID,
+		TakerOrderID:  event.TakerOrderID,
+		Time:          event.SequencedAt,
+	}
+}
@@ -0,0 +1,244 @@
+package lob
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+// Equilibrium is the single price an auction uncrosses at, along with the volume executable at that price.
+type Equilibrium struct {
+	Price  Price
+	Volume Size
+
+	// Imbalance is the buy volume less the sell volume available at the price; negative when sellers are in surplus.
+	Imbalance Size
+}
+
+func (e Equilibrium) String() string {
+	return fmt.Sprintf(`%.6f @ %.6f imbalance=%.6f`, e.Volume, e.Price, e.Imbalance)
+}
+
+// StartAuction moves the Orderbook into an auction call phase; orders accumulate in the book without matching, even when they cross,
+// until Uncross is called. The same call phase is used for both opening and closing auctions.
+func (o *Orderbook) StartAuction() error {
+	if err := o.Transition(TradingStateAuction, "auction started"); err != nil {
+		return fmt.Errorf("start auction: %w", err)
+	}
+
+	return nil
+}
+
+func (o *Orderbook) InAuction() bool {
+	return o.State() == TradingStateAuction
+}
+
+// Indicative returns the price & volume the book would currently uncross at.
+func (o *Orderbook) Indicative() Equilibrium {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.equilibrium()
+}
+
+// Uncross ends the auction call phase, executing every crossing order at the equilibrium price and opening the book for continuous trading.
+func (o *Orderbook) Uncross() (Equilibrium, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cmd := Command{Type: CommandUncross}
+	if err := o.sequence(&cmd); err != nil {
+		return Equilibrium{}, fmt.Errorf("uncross: %w", err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	return o.uncrossAuction(cmd.Time)
+}
+
+// uncrossAuction ends the auction call phase, as of the given time.
+func (o *Orderbook) uncrossAuction(now time.Time) (Equilibrium, error) {
+	o.runSchedule(now)
+
+	if o.state != TradingStateAuction {
+		return Equilibrium{}, fmt.Errorf("uncross: no auction in progress")
+	}
+
+	eq := o.equilibrium()
+	if err := o.transition(TradingStateOpen, "auction uncrossed", false, now); err != nil {
+		return Equilibrium{}, fmt.Errorf("uncross: %w", err)
+	}
+
+	return eq, nil
+}
+
+func (o *Orderbook) uncross(now time.Time) Equilibrium {
+	eq := o.equilibrium()
+
+	slog.Debug("LOB: uncrossing auction", "equilibrium", eq.String())
+
+	if eq.Volume > 0 {
+		buys := o.fillOrders(o.bids.TakeUntil(eq.Volume, eq.Price))
+		sells := o.fillOrders(o.asks.TakeUntil(eq.Volume, eq.Price))
+
+		// Pair off the buy & sell fills, both in time priority, into trades at the equilibrium price.
+		var (
+			bi, si       int
+			bLeft, sLeft Size
+		)
+		for bi < len(buys) && si < len(sells) {
+			if bLeft == 0 {
+				bLeft = buys[bi].size
+			}
+
+			if sLeft == 0 {
+				sLeft = sells[si].size
+			}
+
+			size := min(bLeft, sLeft)
+			o.execute(buys[bi].order, sells[si].order, eq.Price, size, true, now)
+
+			if bLeft -= size; bLeft <= 0 {
+				bi++
+			}
+
+			if sLeft -= size; sLeft <= 0 {
+				si++
+			}
+		}
+
+		for _, fills := range [][]auctionFill{buys, sells} {
+			for _, fill := range fills {
+				if fill.order.status == OrderStatusFilled {
+					o.finish(fill.order)
+				}
+			}
+		}
+	}
+
+	o.publish(UncrossEvent{
+		Equilibrium: eq,
+		SequencedAt: now,
+	})
+
+	return eq
+}
+
+type auctionFill struct {
+	order *Order
+	size  Size
+}
+
+// fillOrders looks up the order for each fill taken from the book.
+func (o *Orderbook) fillOrders(fills []*FillEvent) []auctionFill {
+	auctionFills := make([]auctionFill, 0, len(fills))
+	for _, fill := range fills {
+		order, ok := o.orders.open[fill.OrderID]
+		if !ok {
+			continue
+		}
+
+		auctionFills = append(auctionFills, auctionFill{order: order, size: fill.Size})
+	}
+
+	return auctionFills
+}
+
+func (o *Orderbook) publishIndicative(now time.Time) {
+	if len(o.subscribers) == 0 {
+		return
+	}
+
+	o.publish(IndicativeEvent{
+		Equilibrium: o.equilibrium(),
+		SequencedAt: now,
+	})
+}
+
+// equilibrium finds the price which maximises executable volume, then minimises the imbalance, then is closest to the reference price.
+// The reference price is the last traded price, or the middle of the remaining candidates if the book hasn't traded.
+func (o *Orderbook) equilibrium() Equilibrium {
+	var (
+		asks = o.asks.levels // Ascending.
+		bids = o.bids.levels // Descending.
+	)
+
+	if len(asks) == 0 || len(bids) == 0 || bids[0].price < asks[0].price {
+		return Equilibrium{}
+	}
+
+	prices := make([]Price, 0, len(asks)+len(bids))
+	for _, pl := range asks {
+		prices = append(prices, pl.price)
+	}
+
+	for _, pl := range bids {
+		prices = append(prices, pl.price)
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i] < prices[j]
+	})
+
+	var (
+		sellVolume Size
+		buyVolume  = bids.TotalVolume()
+		ai         int
+		bi         = len(bids) - 1
+		candidates []Equilibrium
+	)
+	for i, price := range prices {
+		if i > 0 && price == prices[i-1] {
+			continue
+		}
+
+		for ai < len(asks) && asks[ai].price <= price {
+			sellVolume += asks[ai].totalSize
+			ai++
+		}
+
+		for bi >= 0 && bids[bi].price < price {
+			buyVolume -= bids[bi].totalSize
+			bi--
+		}
+
+		candidate := Equilibrium{
+			Price:     price,
+			Volume:    min(buyVolume, sellVolume),
+			Imbalance: buyVolume - sellVolume,
+		}
+
+		switch {
+		case candidate.Volume <= 0:
+			continue
+		case len(candidates) == 0 || candidate.Volume > candidates[0].Volume:
+			candidates = append(candidates[:0], candidate)
+		case candidate.Volume < candidates[0].Volume:
+			continue
+		case math.Abs(float64(candidate.Imbalance)) < math.Abs(float64(candidates[0].Imbalance)):
+			candidates = append(candidates[:0], candidate)
+		case math.Abs(float64(candidate.Imbalance)) == math.Abs(float64(candidates[0].Imbalance)):
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return Equilibrium{}
+	}
+
+	reference := o.lastTradePrice
+	if reference == 0 {
+		reference = (candidates[0].Price + candidates[len(candidates)-1].Price) / 2
+	}
+
+	// Candidates are ascending in price, so ties on distance to the reference resolve to the lower price.
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if math.Abs(float64(candidate.Price-reference)) < math.Abs(float64(best.Price-reference)) {
+			best = candidate
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,216 @@
+package lob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOrder struct {
+	side  OrderSide
+	price Price
+	size  Size
+}
+
+func TestAuction_Equilibrium(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                string
+		orders              []testOrder
+		referencePrice      Price
+		expectedEquilibrium Equilibrium
+	}{
+		{
+			name: "maximum_volume",
+			orders: []testOrder{
+				{BuySide, 101, 10},
+				{BuySide, 100, 5},
+				{BuySide, 99, 10},
+				{SellSide, 98, 5},
+				{SellSide, 100, 10},
+				{SellSide, 102, 10},
+			},
+			expectedEquilibrium: Equilibrium{Price: 100, Volume: 15, Imbalance: 0},
+		},
+		{
+			name: "minimum_imbalance",
+			orders: []testOrder{
+				{BuySide, 101, 10},
+				{BuySide, 100, 5},
+				{SellSide, 99, 5},
+				{SellSide, 100, 5},
+			},
+			expectedEquilibrium: Equilibrium{Price: 101, Volume: 10, Imbalance: 0},
+		},
+		{
+			name: "reference_price",
+			orders: []testOrder{
+				{BuySide, 101, 10},
+				{SellSide, 99, 10},
+			},
+			referencePrice:      100.75,
+			expectedEquilibrium: Equilibrium{Price: 101, Volume: 10, Imbalance: 0},
+		},
+		{
+			name: "no_reference_price_resolves_to_lower_price",
+			orders: []testOrder{
+				{BuySide, 101, 10},
+				{SellSide, 99, 10},
+			},
+			expectedEquilibrium: Equilibrium{Price: 99, Volume: 10, Imbalance: 0},
+		},
+		{
+			name: "uncrossed_book",
+			orders: []testOrder{
+				{BuySide, 99, 10},
+				{SellSide, 101, 10},
+			},
+			expectedEquilibrium: Equilibrium{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lob := NewOrderbook(128)
+			lob.lastTradePrice = tt.referencePrice
+			require.NoError(t, lob.StartAuction())
+
+			for _, order := range tt.orders {
+				_, err := lob.PlaceOrder(NewOrder(LimitOrder, order.side, order.price, order.size))
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expectedEquilibrium, lob.Indicative())
+		})
+	}
+}
+
+func TestAuction_Uncross(t *testing.T) {
+	t.Parallel()
+
+	lob := NewOrderbook(128)
+
+	var (
+		trades     []TradeEvent
+		indicative []Equilibrium
+		uncrossed  []Equilibrium
+	)
+	lob.Subscribe(func(event Event) {
+		switch e := event.(type) {
+		case TradeEvent:
+			trades = append(trades, e)
+		case IndicativeEvent:
+			indicative = append(indicative, e.Equilibrium)
+		case UncrossEvent:
+			uncrossed = append(uncrossed, e.Equilibrium)
+		}
+	})
+
+	require.NoError(t, lob.StartAuction())
+	require.Error(t, lob.StartAuction())
+
+	ids := make([]uint64, 0, 6)
+	for _, order := range []testOrder{
+		{BuySide, 101, 10},
+		{BuySide, 100, 5},
+		{BuySide, 99, 10},
+		{SellSide, 98, 5},
+		{SellSide, 100, 10},
+		{SellSide, 102, 10},
+	} {
+		id, err := lob.PlaceOrder(NewOrder(LimitOrder, order.side, order.price, order.size))
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	// The book is crossed, but nothing matches during the call phase.
+	assert.Empty(t, trades)
+	assert.Len(t, indicative, 7)
+	assert.Equal(t, Equilibrium{Price: 100, Volume: 15}, indicative[len(indicative)-1])
+
+	_, err := lob.PlaceOrder(NewOrder(MarketOrder, BuySide, 0, 1))
+	require.Error(t, err)
+
+	eq, err := lob.Uncross()
+	require.NoError(t, err)
+	assert.Equal(t, Equilibrium{Price: 100, Volume: 15}, eq)
+	assert.Equal(t, []Equilibrium{eq}, uncrossed)
+	assert.False(t, lob.InAuction())
+
+	var volume Size
+	for _, trade := range trades {
+		assert.Equal(t, Price(100), trade.Price)
+		assert.True(t, trade.AuctionUncross)
+		volume += trade.Size
+	}
+	assert.Equal(t, Size(15), volume)
+
+	for i, expectedStatus := range []OrderStatus{
+		OrderStatusFilled,
+		OrderStatusFilled,
+		OrderStatusNew,
+		OrderStatusFilled,
+		OrderStatusFilled,
+		OrderStatusNew,
+	} {
+		order, err := lob.GetOrder(ids[i])
+		require.NoError(t, err)
+		assert.Equal(t, expectedStatus, order.Status, "order %d", ids[i])
+
+		if expectedStatus == OrderStatusFilled {
+			assert.Equal(t, Price(100), order.AvgPrice)
+		}
+	}
+
+	bb, err := lob.BestBid()
+	require.NoError(t, err)
+	assert.Equal(t, Price(99), bb)
+
+	ba, err := lob.BestAsk()
+	require.NoError(t, err)
+	assert.Equal(t, Price(102), ba)
+
+	_, err = lob.Uncross()
+	require.Error(t, err)
+}
+
+func TestAuction_UncrossReplayed(t *testing.T) {
+	t.Parallel()
+
+	journal := &testJournal{}
+	lob := NewOrderbook(128, WithJournal(journal))
+	require.NoError(t, lob.StartAuction())
+
+	for _, order := range []testOrder{
+		{BuySide, 101, 10},
+		{SellSide, 98, 5},
+		{SellSide, 100, 10},
+	} {
+		_, err := lob.PlaceOrder(NewOrder(LimitOrder, order.side, order.price, order.size))
+		require.NoError(t, err)
+	}
+
+	_, err := lob.Uncross()
+	require.NoError(t, err)
+
+	_, err = lob.Uncross()
+	require.Error(t, err)
+
+	require.Len(t, journal.commands, 6)
+	assert.Equal(t, CommandUncross, journal.commands[4].Type)
+
+	replayed := NewOrderbook(128)
+	for _, cmd := range journal.commands {
+		_ = replayed.Apply(cmd)
+	}
+
+	assert.Equal(t, TradingStateOpen, replayed.State())
+	assert.Len(t, replayed.OpenOrders(), 1)
+	assert.Equal(t, lob.Snapshot(), replayed.Snapshot())
+	assert.Equal(t, lob.StateHash(), replayed.StateHash())
+}
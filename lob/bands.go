@@ -0,0 +1,176 @@
+package lob
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// PriceBandConfig configures dynamic price bands around a reference price, which is the rolling average of trade prices.
+type PriceBandConfig struct {
+	// Width is the half width of the band as a fraction of the reference price, i.e. 0.05 gives bands at ±5%.
+	Width float64
+
+	// Window is how far back trades are averaged over to give the reference price.
+	Window time.Duration
+
+	// PauseDuration is how long trading pauses in auction after a breach before reopening; zero leaves the book in auction until reopened manually.
+	PauseDuration time.Duration
+
+	// ReferencePrice seeds the reference price until the book has traded.
+	ReferencePrice Price
+}
+
+// WithPriceBands enables price bands; limit orders priced outside them are rejected, and any match that would trade outside them pauses trading into an auction.
+func WithPriceBands(config PriceBandConfig) Option {
+	return func(o *Orderbook) {
+		o.bands = newPriceBands(config)
+	}
+}
+
+// PriceBandBreachEvent is published when matching is stopped by a price band, immediately before trading pauses;
+// SequencedAt is when the command that breached it was sequenced.
+type PriceBandBreachEvent struct {
+	Price       Price
+	Reference   Price
+	Lower       Price
+	Upper       Price
+	SequencedAt time.Time
+}
+
+func (PriceBandBreachEvent) isEvent() {}
+
+func (p PriceBandBreachEvent) String() string {
+	return fmt.Sprintf(`price band breach @ %.6f : reference=%.6f bands=[%.6f, %.6f]`, p.Price, p.Reference, p.Lower, p.Upper)
+}
+
+type bandTrade struct {
+	at    time.Time
+	price Price
+}
+
+func newPriceBands(config PriceBandConfig) *priceBands {
+	return &priceBands{
+		config: config,
+		last:   config.ReferencePrice,
+	}
+}
+
+type priceBands struct {
+	config PriceBandConfig
+
+	// trades within the window, ordered by time, along with their running sum.
+	trades []bandTrade
+	sum    float64
+
+	// last is the reference price used once there have been no trades within the window.
+	last Price
+}
+
+func (p *priceBands) record(price Price, at time.Time) {
+	p.trades = append(p.trades, bandTrade{at: at, price: price})
+	p.sum += float64(price)
+	p.last = price
+}
+
+func (p *priceBands) reference(now time.Time) Price {
+	var i int
+	for ; i < len(p.trades) && now.Sub(p.trades[i].at) > p.config.Window; i++ {
+		p.sum -= float64(p.trades[i].price)
+	}
+
+	if i > 0 {
+		p.trades = p.trades[i:]
+	}
+
+	if len(p.trades) == 0 {
+		p.sum = 0
+		return p.last
+	}
+
+	return Price(p.sum / float64(len(p.trades)))
+}
+
+// limits returns the current bands, or false if there's no reference price to set them around.
+func (p *priceBands) limits(now time.Time) (lower, upper, reference Price, ok bool) {
+	reference = p.reference(now)
+	if reference <= 0 {
+		return 0, 0, 0, false
+	}
+
+	width := reference * Price(p.config.Width)
+
+	return reference - width, reference + width, reference, true
+}
+
+// PriceBands returns the current bands and the reference price they're set around; false if bands are disabled or there's no reference price yet.
+func (o *Orderbook) PriceBands() (lower, upper, reference Price, ok bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.bands == nil {
+		return 0, 0, 0, false
+	}
+
+	return o.bands.limits(o.now())
+}
+
+// inBand returns a predicate for whether a price is within the current bands.
+func (o *Orderbook) inBand(now time.Time) func(price Price) bool {
+	if o.bands == nil {
+		return func(Price) bool { return true }
+	}
+
+	lower, upper, _, ok := o.bands.limits(now)
+	if !ok {
+		return func(Price) bool { return true }
+	}
+
+	return func(price Price) bool {
+		return price >= lower && price <= upper
+	}
+}
+
+// checkBreach pauses trading if the taker still has size left and the best opposing level crosses it, but lies outside the bands.
+func (o *Orderbook) checkBreach(book *Book, taker *Order, inBand func(Price) bool, now time.Time) {
+	if o.bands == nil || taker.remainingSize <= 0 {
+		return
+	}
+
+	top, err := book.Top()
+	if err != nil || inBand(top) {
+		return
+	}
+
+	if taker.OrderType == LimitOrder && !book.Crosses(taker.Price, top) {
+		return
+	}
+
+	lower, upper, reference, _ := o.bands.limits(now)
+	slog.Warn("LOB: price band breached; pausing trading", "price", top, "lower", lower, "upper", upper)
+
+	o.publish(PriceBandBreachEvent{
+		Price:       top,
+		Reference:   reference,
+		Lower:       lower,
+		Upper:       upper,
+		SequencedAt: now,
+	})
+
+	if err := o.transition(TradingStateAuction, "price band breach", false, now); err != nil {
+		slog.Error("LOB: failed to pause trading on price band breach", "error", err)
+		return
+	}
+
+	if o.bands.config.PauseDuration > 0 {
+		o.schedule = append(o.schedule, scheduledTransition{
+			at:     now.Add(o.bands.config.PauseDuration),
+			to:     TradingStateOpen,
+			reason: "price band pause ended",
+		})
+		sort.SliceStable(o.schedule, func(i, j int) bool {
+			return o.schedule[i].at.Before(o.schedule[j].at)
+		})
+	}
+}
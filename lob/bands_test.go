@@ -0,0 +1,172 @@
+package lob
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceBands_RejectsOrdersOutsideBands(t *testing.T) {
+	t.Parallel()
+
+	lob := NewOrderbook(128, WithPriceBands(PriceBandConfig{
+		Width:          0.05,
+		Window:         5 * time.Minute,
+		ReferencePrice: 100,
+	}))
+
+	lower, upper, reference, ok := lob.PriceBands()
+	require.True(t, ok)
+	assert.Equal(t, Price(95), lower)
+	assert.Equal(t, Price(105), upper)
+	assert.Equal(t, Price(100), reference)
+
+	_, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 95, 1))
+	require.NoError(t, err)
+
+	_, err = lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 105, 1))
+	require.NoError(t, err)
+
+	id, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 94, 1))
+	assertRejected(t, err, RejectReasonPriceBand)
+
+	order, err := lob.GetOrder(id)
+	require.NoError(t, err)
+	assert.Equal(t, RejectReasonPriceBand, order.RejectReason)
+
+	_, err = lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 106, 1))
+	assertRejected(t, err, RejectReasonPriceBand)
+}
+
+func TestPriceBands_RollingReference(t *testing.T) {
+	t.Parallel()
+
+	clock := NewVirtualClock(time.Now())
+
+	lob := NewOrderbook(128, WithClock(clock), WithPriceBands(PriceBandConfig{
+		Width:          0.1,
+		Window:         5 * time.Minute,
+		ReferencePrice: 100,
+	}))
+
+	for _, price := range []Price{100, 104} {
+		_, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, price, 1))
+		require.NoError(t, err)
+
+		_, err = lob.PlaceOrder(NewOrder(MarketOrder, BuySide, 0, 1))
+		require.NoError(t, err)
+
+		clock.Advance(time.Minute)
+	}
+
+	_, _, reference, ok := lob.PriceBands()
+	require.True(t, ok)
+	assert.Equal(t, Price(102), reference)
+
+	// Once the first trade falls out of the window, only the second is averaged.
+	clock.Advance(4 * time.Minute)
+
+	_, _, reference, ok = lob.PriceBands()
+	require.True(t, ok)
+	assert.Equal(t, Price(104), reference)
+
+	// With no trades in the window at all, the last trade price is used.
+	clock.Advance(10 * time.Minute)
+
+	_, _, reference, ok = lob.PriceBands()
+	require.True(t, ok)
+	assert.Equal(t, Price(104), reference)
+}
+
+func TestPriceBands_BreachPausesTrading(t *testing.T) {
+	t.Parallel()
+
+	clock := NewVirtualClock(time.Now())
+
+	lob := NewOrderbook(128, WithClock(clock), WithPriceBands(PriceBandConfig{
+		Width:          0.05,
+		Window:         5 * time.Minute,
+		PauseDuration:  time.Minute,
+		ReferencePrice: 100,
+	}))
+
+	for _, price := range []Price{101, 104} {
+		_, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, price, 1))
+		require.NoError(t, err)
+	}
+
+	// Widen the bands to rest an ask outside of where they end up.
+	lob.bands.config.Width = 0.1
+
+	_, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 108, 1))
+	require.NoError(t, err)
+
+	lob.bands.config.Width = 0.05
+
+	var (
+		breaches []PriceBandBreachEvent
+		trades   []TradeEvent
+	)
+	lob.Subscribe(func(event Event) {
+		switch e := event.(type) {
+		case PriceBandBreachEvent:
+			breaches = append(breaches, e)
+		case TradeEvent:
+			trades = append(trades, e)
+		}
+	})
+
+	id, err := lob.PlaceOrder(NewOrder(MarketOrder, BuySide, 0, 3))
+	require.NoError(t, err)
+
+	// Both asks within the bands trade, but the ask at 108 doesn't.
+	require.Len(t, trades, 2)
+	require.Len(t, breaches, 1)
+	assert.Equal(t, Price(108), breaches[0].Price)
+	assert.Equal(t, TradingStateAuction, lob.State())
+
+	order, err := lob.GetOrder(id)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusExpired, order.Status)
+	assert.Equal(t, Size(2), order.FilledSize)
+
+	clock.Advance(time.Minute)
+	lob.Tick()
+	assert.Equal(t, TradingStateOpen, lob.State())
+}
+
+func TestPriceBands_CrossingOrderStoppedByBandsAcknowledgedOnce(t *testing.T) {
+	t.Parallel()
+
+	lob := NewOrderbook(128, WithPriceBands(PriceBandConfig{
+		Width:          0.05,
+		Window:         5 * time.Minute,
+		ReferencePrice: 100,
+	}))
+
+	// Widen the bands to rest an ask below where they end up.
+	lob.bands.config.Width = 0.1
+
+	_, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 94, 1))
+	require.NoError(t, err)
+
+	lob.bands.config.Width = 0.05
+
+	var events []OrderEvent
+	lob.Subscribe(func(event Event) {
+		if e, ok := event.(OrderEvent); ok {
+			events = append(events, e)
+		}
+	})
+
+	// Crosses the ask, but it's outside of the bands so nothing trades & the order rests.
+	id, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 100, 1))
+	require.NoError(t, err)
+	assert.Equal(t, TradingStateAuction, lob.State())
+
+	require.Len(t, events, 1)
+	assert.Equal(t, id, events[0].Order.ID)
+	assert.Equal(t, OrderStatusNew, events[0].Order.Status)
+}
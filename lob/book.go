@@ -78,20 +78,44 @@ func (b *Book) Take(size Size) ([]*FillEvent, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	priceLevels := b.levels
 	if b.Depth() == 0 {
 		// TODO: we should store this per the price level rather than being in a position whereby we need to calculate.
 		return nil, fmt.Errorf("not enough liquidity in book %.2f/%.2f", size, b.levels.TotalVolume())
 	}
 
+	return b.take(size, func(Price) bool { return true }), nil
+}
+
+// TakeUntil takes up to size from the book, only matching price levels at or better than the limit price from the taker's perspective.
+func (b *Book) TakeUntil(size Size, limit Price) []*FillEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.take(size, func(price Price) bool { return b.Crosses(limit, price) })
+}
+
+// TakeWhile takes up to size from the book, level by level, for as long as canMatch holds for the level's price.
+func (b *Book) TakeWhile(size Size, canMatch func(price Price) bool) []*FillEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.take(size, canMatch)
+}
+
+// Crosses returns true if a taker limit price can match a level in this book at the given price.
+func (b *Book) Crosses(limit, price Price) bool {
+	return !b.cmp(limit, price)
+}
+
+func (b *Book) take(size Size, canMatch func(price Price) bool) []*FillEvent {
 	var (
 		qtyLeft    = size
 		totalFills = []*FillEvent{}
 	)
 
 	toRemoveFrom := -1
-	for i, priceLevel := range priceLevels {
-		if qtyLeft == 0 {
+	for i, priceLevel := range b.levels {
+		if qtyLeft == 0 || !canMatch(priceLevel.price) {
 			break
 		}
 
@@ -117,7 +141,45 @@ func (b *Book) Take(size Size) ([]*FillEvent, error) {
 		}
 	}
 
-	return totalFills, nil
+	return totalFills
+}
+
+// Remove removes a resting order from the book, dropping its price level if it's left empty.
+func (b *Book) Remove(order *Order) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, pl := range b.levels {
+		if pl.price != order.Price {
+			continue
+		}
+
+		if _, ok := pl.Remove(order.ID); !ok {
+			return false
+		}
+
+		if pl.NumberOfOrders() == 0 {
+			b.levels = append(b.levels[:i], b.levels[i+1:]...)
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// Reduce sets a resting order's remaining size in place, keeping its queue priority.
+func (b *Book) Reduce(order *Order, remaining Size) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, pl := range b.levels {
+		if pl.price == order.Price {
+			return pl.Resize(order.ID, remaining)
+		}
+	}
+
+	return false
 }
 
 func (b *Book) Depth() int {
@@ -108,3 +108,20 @@ func TestBook(t *testing.T) {
 		})
 	}
 }
+
+func TestBook_Reduce(t *testing.T) {
+	t.Parallel()
+
+	book := NewBook(BuySide)
+	order := &Order{ID: 1, OrderType: LimitOrder, Price: 1000, Size: 4, remainingSize: 4}
+	book.Make(order)
+	book.Make(&Order{ID: 2, OrderType: LimitOrder, Price: 999, Size: 1, remainingSize: 1})
+
+	require.True(t, book.Reduce(order, 1))
+	assert.Equal(t, Size(1), order.remainingSize)
+	assert.Equal(t, Size(2), book.levels.TotalVolume())
+
+	assert.False(t, book.Reduce(&Order{ID: 3, Price: 998}, 1), "no level at the order's price")
+	assert.False(t, book.Reduce(&Order{ID: 3, Price: 1000}, 1), "no such order at the level")
+	assert.Equal(t, Size(2), book.levels.TotalVolume())
+}
@@ -0,0 +1,115 @@
+package lob
+
+import "fmt"
+
+// PreTradeCheck is run against every order before it's accepted into the book, and against every amended order before the
+// amend is. Returning a *RejectError rejects the order, or the amend, with its reason. Checks are run while the Orderbook
+// is locked, so they must not call back into it.
+type PreTradeCheck interface {
+	// CheckOrder is given the price the order is expected to trade at; its limit price, or for market orders the best opposing price.
+	CheckOrder(order *Order, price Price) error
+}
+
+// WithPreTradeCheck adds a check that every order must pass before it's accepted; checks run in the order they're added.
+func WithPreTradeCheck(check PreTradeCheck) Option {
+	return func(o *Orderbook) {
+		o.checks = append(o.checks, check)
+	}
+}
+
+// NewRejectError returns a reject error for use by pre-trade checks.
+func NewRejectError(reason RejectReason, err error) *RejectError {
+	return &RejectError{
+		Reason: reason,
+		Err:    err,
+	}
+}
+
+// RiskLimits are an account's pre-trade limits; a zero value disables that limit.
+type RiskLimits struct {
+	MaxOrderSize     Size
+	MaxOrderNotional float64
+	MaxOpenOrders    int
+
+	// MaxGrossPosition limits the absolute position plus every open order on both sides.
+	MaxGrossPosition Size
+
+	// MaxNetPosition limits the position the account would hold were every open order on one side to fill.
+	MaxNetPosition Size
+
+	// CreditLimit limits the notional of every open order plus the notional of the absolute position.
+	CreditLimit float64
+}
+
+// RiskLimiter is a PreTradeCheck with per account limits, which SetRiskLimits sets.
+type RiskLimiter interface {
+	SetLimits(accountID uint64, limits RiskLimits)
+}
+
+// CheckSnapshotter is a PreTradeCheck with state of its own, such as the open orders & positions it tracks from events,
+// that's snapshotted & restored along with the Orderbook. Both are called while the Orderbook is locked.
+type CheckSnapshotter interface {
+	SnapshotCheck() []byte
+	RestoreCheck(data []byte) error
+}
+
+// SetRiskLimits sets the account's limits on every pre-trade check that has them. It's journaled, so unlike setting them
+// on the checks directly, the limits are set again on replay.
+func (o *Orderbook) SetRiskLimits(accountID uint64, limits RiskLimits) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.hasRiskLimiter() {
+		return fmt.Errorf("set account %d risk limits: orderbook has no pre-trade check with limits", accountID)
+	}
+
+	cmd := Command{Type: CommandSetRiskLimits, AccountID: accountID, Limits: limits}
+	if err := o.sequence(&cmd); err != nil {
+		return fmt.Errorf("set account %d risk limits: %w", accountID, err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	return o.setRiskLimits(accountID, limits)
+}
+
+func (o *Orderbook) setRiskLimits(accountID uint64, limits RiskLimits) error {
+	if !o.hasRiskLimiter() {
+		return fmt.Errorf("set account %d risk limits: orderbook has no pre-trade check with limits", accountID)
+	}
+
+	for _, check := range o.checks {
+		if limiter, ok := check.(RiskLimiter); ok {
+			limiter.SetLimits(accountID, limits)
+		}
+	}
+
+	// Kept to snapshot, as the checks may not snapshot their limits themselves.
+	if o.riskLimits == nil {
+		o.riskLimits = make(map[uint64]RiskLimits)
+	}
+	o.riskLimits[accountID] = limits
+
+	return nil
+}
+
+func (o *Orderbook) hasRiskLimiter() bool {
+	for _, check := range o.checks {
+		if _, ok := check.(RiskLimiter); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkSnapshotters returns the pre-trade checks with state of their own, in the order they were added.
+func (o *Orderbook) checkSnapshotters() []CheckSnapshotter {
+	var snapshotters []CheckSnapshotter
+	for _, check := range o.checks {
+		if snapshotter, ok := check.(CheckSnapshotter); ok {
+			snapshotters = append(snapshotters, snapshotter)
+		}
+	}
+
+	return snapshotters
+}
@@ -0,0 +1,77 @@
+package lob
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time the Orderbook stamps orders & events with, to the nanosecond.
+type Clock interface {
+	Now() time.Time
+}
+
+// WithClock stamps orders & events with the clock's time, which also drives order retention, scheduled transitions &
+// price bands; by default it's a MonotonicClock.
+func WithClock(clock Clock) Option {
+	return func(o *Orderbook) {
+		o.clock = clock
+		o.sequencer.clock = clock
+	}
+}
+
+// NewMonotonicClock returns a clock of the wall time, as of now.
+func NewMonotonicClock() *MonotonicClock {
+	return &MonotonicClock{start: time.Now()}
+}
+
+// MonotonicClock is wall time that never goes backwards: it's the wall time it was created at, advanced by the system's
+// monotonic clock, so stepping the system's wall clock can't reorder timestamps.
+type MonotonicClock struct {
+	start time.Time
+}
+
+// Now returns the time without its monotonic reading, so it's the same once journaled & read back.
+func (c *MonotonicClock) Now() time.Time {
+	return c.start.Add(time.Since(c.start)).Round(0)
+}
+
+// NewVirtualClock returns a clock stopped at the given time.
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start.Round(0)}
+}
+
+// VirtualClock is time that only moves when it's moved, for tests & backtests.
+type VirtualClock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+func (c *VirtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward by d, returning the new time.
+func (c *VirtualClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+
+	return c.now
+}
+
+// Set moves the clock forward to t, e.g. to the time of a backtest's next event; it never moves backwards, so a t before
+// the clock's time is ignored.
+func (c *VirtualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t.After(c.now) {
+		c.now = t.Round(0)
+	}
+}
@@ -0,0 +1,41 @@
+package lob
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonotonicClock(t *testing.T) {
+	t.Parallel()
+
+	clock := NewMonotonicClock()
+
+	last := clock.Now()
+	for i := 0; i < 1000; i++ {
+		now := clock.Now()
+		assert.False(t, now.Before(last))
+		assert.Equal(t, now.Round(0), now)
+		last = now
+	}
+}
+
+func TestVirtualClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1700000000, 0)
+	clock := NewVirtualClock(start)
+	assert.Equal(t, start, clock.Now())
+
+	assert.Equal(t, start.Add(time.Nanosecond), clock.Advance(time.Nanosecond))
+	assert.Equal(t, start.Add(time.Nanosecond), clock.Now())
+
+	// It never goes backwards.
+	clock.Advance(-time.Second)
+	clock.Set(start)
+	assert.Equal(t, start.Add(time.Nanosecond), clock.Now())
+
+	clock.Set(start.Add(time.Minute))
+	assert.Equal(t, start.Add(time.Minute), clock.Now())
+}
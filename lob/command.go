@@ -0,0 +1,199 @@
+package lob
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type CommandType uint8
+
+const (
+	CommandPlaceOrder CommandType = iota + 1
+	CommandCancelOrder
+	CommandEditOrder
+	CommandMassCancel
+	CommandTransition
+	CommandScheduleTransition
+	CommandTick
+	CommandDeposit
+	CommandWithdraw
+	CommandSetFeeTier
+	CommandSetAccountFeeTier
+	CommandSetRiskLimits
+	CommandUncross
+)
+
+func (c CommandType) String() string {
+	switch c {
+	case CommandPlaceOrder:
+		return "place_order"
+	case CommandCancelOrder:
+		return "cancel_order"
+	case CommandEditOrder:
+		return "edit_order"
+	case CommandMassCancel:
+		return "mass_cancel"
+	case CommandTransition:
+		return "transition"
+	case CommandScheduleTransition:
+		return "schedule_transition"
+	case CommandTick:
+		return "tick"
+	case CommandDeposit:
+		return "deposit"
+	case CommandWithdraw:
+		return "withdraw"
+	case CommandSetFeeTier:
+		return "set_fee_tier"
+	case CommandSetAccountFeeTier:
+		return "set_account_fee_tier"
+	case CommandSetRiskLimits:
+		return "set_risk_limits"
+	case CommandUncross:
+		return "uncross"
+	default:
+		return "unknown"
+	}
+}
+
+// Command is a single sequenced request that mutates the Orderbook. Applying the same commands, in sequence order,
+// to a fresh Orderbook with the same options rebuilds the same book.
+type Command struct {
+	// Seq is assigned by the Sequencer; a placed order's ID is its command's Seq.
+	Seq  uint64
+	Type CommandType
+	Time time.Time
+
+	// Order is the order as submitted when placing, or the order's ID along with its new price & size when editing.
+	Order Order
+
+	// OrderID is the order to cancel.
+	OrderID uint64
+
+	MassCancel MassCancelRequest
+
+	// State & Reason are the target of a transition; At is when a scheduled transition fires.
+	State  TradingState
+	Reason string
+	At     time.Time
+
+	// AccountID is whose balance is deposited to or withdrawn from, or whose fee tier or risk limits are set.
+	AccountID uint64
+	Asset     Asset
+	Amount    float64
+
+	// Tier is the name of the fee tier set, or assigned to the account; FeeTier is its rates when it's set.
+	Tier    string
+	FeeTier FeeTier
+
+	Limits RiskLimits
+}
+
+func (c Command) String() string {
+	return fmt.Sprintf("CMD: seq=%d type=%s time=%s", c.Seq, c.Type, c.Time.Format(time.RFC3339Nano))
+}
+
+// Journal records every sequenced command before the Orderbook applies it. If Append fails the command isn't applied.
+// Append is called while the Orderbook is locked, so it must not call back into it.
+type Journal interface {
+	Append(cmd Command) error
+}
+
+// WithJournal records every command to the journal before it's applied.
+func WithJournal(journal Journal) Option {
+	return func(o *Orderbook) {
+		o.journal = journal
+	}
+}
+
+// LastSeq returns the sequence number of the last command sequenced or applied.
+func (o *Orderbook) LastSeq() uint64 {
+	return o.sequencer.Last()
+}
+
+// Apply applies a command that's already been sequenced, e.g. one read back from a journal, at its own sequence number & time.
+// It isn't journaled again, nor is its hash recorded, though the rolling hash carries on. Rejections are returned as they were
+// when the command was first applied.
+func (o *Orderbook) Apply(cmd Command) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if last := o.sequencer.Last(); cmd.Seq <= last {
+		return fmt.Errorf("apply command %d: already at sequence %d", cmd.Seq, last)
+	}
+
+	o.sequencer.advance(cmd.Seq)
+	defer o.applied(cmd.Seq, cmd.Time, true)
+
+	return o.apply(cmd)
+}
+
+// sequence assigns the command its sequence number & time, then journals it. The sequence number is only taken once the
+// command's journaled, so a refused command leaves no gap.
+func (o *Orderbook) sequence(cmd *Command) error {
+	cmd.Seq = o.sequencer.Last() + 1
+	cmd.Time = o.sequencer.Now()
+
+	if o.journal != nil {
+		if err := o.journal.Append(*cmd); err != nil {
+			return fmt.Errorf("journal command %d: %w", cmd.Seq, err)
+		}
+	}
+
+	o.sequencer.advance(cmd.Seq)
+
+	return nil
+}
+
+// applied runs once a command's been applied: publishing depth, then rolling the state hash on & recording it.
+// Replayed commands had their hashes recorded when they were first applied.
+func (o *Orderbook) applied(seq uint64, now time.Time, replayed bool) {
+	o.publishDepth(now)
+
+	if !o.roll() || replayed {
+		return
+	}
+
+	if err := o.hashLog.RecordHash(seq, o.rollingHash); err != nil {
+		slog.Warn("LOB: failed to record state hash", "seq", seq, "error", err)
+	}
+}
+
+func (o *Orderbook) apply(cmd Command) error {
+	switch cmd.Type {
+	case CommandPlaceOrder:
+		order := cmd.Order
+		order.ID = cmd.Seq
+		return o.placeOrder(&order, cmd.Time)
+	case CommandCancelOrder:
+		return o.cancelOrder(cmd.OrderID, cmd.Time)
+	case CommandEditOrder:
+		return o.editOrder(cmd.Order.ID, cmd.Order.Price, cmd.Order.Size, cmd.Order.ReceivedAt, cmd.Time)
+	case CommandMassCancel:
+		_, err := o.massCancel(cmd.MassCancel, cmd.Time)
+		return err
+	case CommandTransition:
+		o.runSchedule(cmd.Time)
+		return o.transition(cmd.State, cmd.Reason, false, cmd.Time)
+	case CommandScheduleTransition:
+		o.scheduleTransition(cmd.At, cmd.State, cmd.Reason)
+		return nil
+	case CommandTick:
+		o.runSchedule(cmd.Time)
+		return nil
+	case CommandDeposit, CommandWithdraw:
+		return o.applyFunding(cmd)
+	case CommandSetFeeTier:
+		return o.setFeeTier(cmd.Tier, cmd.FeeTier)
+	case CommandSetAccountFeeTier:
+		return o.setAccountFeeTier(cmd.AccountID, cmd.Tier)
+	case CommandSetRiskLimits:
+		return o.setRiskLimits(cmd.AccountID, cmd.Limits)
+	case CommandUncross:
+		_, err := o.uncrossAuction(cmd.Time)
+		return err
+	default:
+		return fmt.Errorf("apply command %d: unknown command type %d", cmd.Seq, cmd.Type)
+	}
+}
@@ -0,0 +1,129 @@
+package lob
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testJournal struct {
+	commands []Command
+	err      error
+}
+
+func (j *testJournal) Append(cmd Command) error {
+	if j.err != nil {
+		return j.err
+	}
+
+	j.commands = append(j.commands, cmd)
+	return nil
+}
+
+func TestCommand_JournaledBeforeApplied(t *testing.T) {
+	t.Parallel()
+
+	journal := &testJournal{}
+	lob := NewOrderbook(128, WithJournal(journal))
+
+	id, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 1000, 1))
+	require.NoError(t, err)
+	require.NoError(t, lob.CancelOrder(id))
+	require.NoError(t, lob.Transition(TradingStateHalted, "test"))
+
+	require.Len(t, journal.commands, 3)
+	assert.Equal(t, CommandPlaceOrder, journal.commands[0].Type)
+	assert.Equal(t, id, journal.commands[0].Seq)
+	assert.Equal(t, CommandCancelOrder, journal.commands[1].Type)
+	assert.Equal(t, id, journal.commands[1].OrderID)
+	assert.Equal(t, CommandTransition, journal.commands[2].Type)
+	assert.Equal(t, uint64(3), lob.LastSeq())
+
+	// Nothing is applied if the command can't be journaled.
+	journal.err = errors.New("disk full")
+	_, err = lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 1000, 1))
+	require.Error(t, err)
+	assert.Empty(t, lob.OpenOrders())
+	assert.Equal(t, uint64(3), lob.LastSeq())
+
+	// Replaying the journal rebuilds the same state.
+	replayed := NewOrderbook(128)
+	for _, cmd := range journal.commands {
+		_ = replayed.Apply(cmd)
+	}
+
+	assert.Equal(t, TradingStateHalted, replayed.State())
+	assert.Equal(t, uint64(3), replayed.LastSeq())
+}
+
+type testLimiter struct {
+	limits map[uint64]RiskLimits
+}
+
+func (l *testLimiter) CheckOrder(order *Order, price Price) error {
+	if limit := l.limits[order.AccountID].MaxOrderSize; limit > 0 && order.Size > limit {
+		return NewRejectError(RejectReasonMaxOrderSize, errors.New("too big"))
+	}
+
+	return nil
+}
+
+func (l *testLimiter) SetLimits(accountID uint64, limits RiskLimits) {
+	l.limits[accountID] = limits
+}
+
+func TestCommand_AdminCommandsReplayed(t *testing.T) {
+	t.Parallel()
+
+	newBook := func(journal Journal) (*Orderbook, *Ledger, *FeeSchedule, *testLimiter) {
+		var (
+			ledger  = NewLedger()
+			fees    = NewFeeSchedule(FeeTier{})
+			limiter = &testLimiter{limits: make(map[uint64]RiskLimits)}
+			opts    = []Option{WithLedger(ledger), WithFeeSchedule(fees), WithPreTradeCheck(limiter)}
+		)
+		if journal != nil {
+			opts = append(opts, WithJournal(journal))
+		}
+
+		return NewOrderbook(128, opts...), ledger, fees, limiter
+	}
+
+	journal := &testJournal{}
+	lob, _, _, _ := newBook(journal)
+
+	require.NoError(t, lob.Deposit(1, "QUOTE", 1_000))
+	require.NoError(t, lob.Withdraw(1, "QUOTE", 250))
+	require.Error(t, lob.Withdraw(1, "QUOTE", 1_000))
+	require.NoError(t, lob.SetFeeTier("vip", FeeTier{TakerRate: 0.001}))
+	require.NoError(t, lob.SetAccountFeeTier(1, "vip"))
+	require.Error(t, lob.SetAccountFeeTier(2, "unknown"))
+	require.NoError(t, lob.SetRiskLimits(1, RiskLimits{MaxOrderSize: 5}))
+
+	require.Len(t, journal.commands, 7)
+	for i, expected := range []CommandType{
+		CommandDeposit, CommandWithdraw, CommandWithdraw, CommandSetFeeTier, CommandSetAccountFeeTier, CommandSetAccountFeeTier, CommandSetRiskLimits,
+	} {
+		assert.Equal(t, expected, journal.commands[i].Type)
+	}
+
+	replayed, ledger, fees, limiter := newBook(nil)
+	for _, cmd := range journal.commands {
+		_ = replayed.Apply(cmd)
+	}
+
+	assert.Equal(t, Balance{Total: 750}, ledger.Balance(1, "QUOTE"))
+	assert.Equal(t, FeeTier{TakerRate: 0.001}, fees.Tier(1))
+	assert.Equal(t, FeeTier{}, fees.Tier(2))
+	assert.Equal(t, RiskLimits{MaxOrderSize: 5}, limiter.limits[1])
+
+	// Without a ledger, fee schedule or limits to set there's nothing to journal.
+	journal = &testJournal{}
+	bare := NewOrderbook(128, WithJournal(journal))
+	require.Error(t, bare.Deposit(1, "QUOTE", 1))
+	require.Error(t, bare.SetFeeTier("vip", FeeTier{}))
+	require.Error(t, bare.SetRiskLimits(1, RiskLimits{}))
+	assert.Empty(t, journal.commands)
+}
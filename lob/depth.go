@@ -0,0 +1,265 @@
+package lob
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"time"
+)
+
+// DepthLevel is a price level's aggregate size.
+type DepthLevel struct {
+	Price Price
+	Size  Size
+}
+
+// DepthSnapshot is the top levels of both sides of the book as of a sequence number, best price first.
+type DepthSnapshot struct {
+	Seq      uint64
+	Bids     []DepthLevel
+	Asks     []DepthLevel
+	Checksum uint32
+}
+
+// DepthEvent is published after a command changes the top levels of the book, when enabled with WithDepthEvents;
+// SequencedAt is when the command was sequenced.
+type DepthEvent struct {
+	DepthSnapshot
+	SequencedAt time.Time
+}
+
+func (DepthEvent) isEvent() {}
+
+func (d DepthEvent) String() string {
+	return fmt.Sprintf(`depth seq=%d bids=%d asks=%d checksum=%d`, d.Seq, len(d.Bids), len(d.Asks), d.Checksum)
+}
+
+// WithDepthEvents publishes a DepthEvent with the given number of levels per side whenever a command changes them.
+func WithDepthEvents(levels int) Option {
+	return func(o *Orderbook) {
+		o.depthLevels = levels
+	}
+}
+
+// DepthSnapshot returns up to the given number of levels from each side of the book, along with their checksum.
+func (o *Orderbook) DepthSnapshot(levels int) DepthSnapshot {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.depthSnapshot(levels)
+}
+
+func (o *Orderbook) depthSnapshot(levels int) DepthSnapshot {
+	snapshot := DepthSnapshot{
+		Seq:  o.sequencer.Last(),
+		Bids: depthLevels(o.bids, levels),
+		Asks: depthLevels(o.asks, levels),
+	}
+	snapshot.Checksum = Checksum(snapshot.Bids, snapshot.Asks)
+
+	return snapshot
+}
+
+func depthLevels(book *Book, levels int) []DepthLevel {
+	book.mu.RLock()
+	defer book.mu.RUnlock()
+
+	depth := make([]DepthLevel, 0, min(levels, len(book.levels)))
+	for _, pl := range book.levels {
+		if len(depth) == levels {
+			break
+		}
+
+		depth = append(depth, DepthLevel{Price: pl.price, Size: pl.totalSize})
+	}
+
+	return depth
+}
+
+// Checksum is the CRC32 (IEEE) of the levels interleaved best first, as "bid price:bid size:ask price:ask size:...";
+// once one side runs out only the other's levels follow. Prices & sizes are formatted by FormatDecimal.
+//
+// Clients mirroring the book compute it over the same number of levels to check their copy hasn't drifted.
+func Checksum(bids, asks []DepthLevel) uint32 {
+	buf := make([]byte, 0, 32*(len(bids)+len(asks)))
+
+	appendLevel := func(level DepthLevel) {
+		if len(buf) > 0 {
+			buf = append(buf, ':')
+		}
+
+		buf = appendDecimal(buf, float64(level.Price))
+		buf = append(buf, ':')
+		buf = appendDecimal(buf, float64(level.Size))
+	}
+
+	for i := 0; i < max(len(bids), len(asks)); i++ {
+		if i < len(bids) {
+			appendLevel(bids[i])
+		}
+
+		if i < len(asks) {
+			appendLevel(asks[i])
+		}
+	}
+
+	return crc32.ChecksumIEEE(buf)
+}
+
+// FormatDecimal formats a price or size as the checksum does; the shortest decimal that reads back as the same value.
+func FormatDecimal(f float64) string {
+	return string(appendDecimal(nil, f))
+}
+
+func appendDecimal(buf []byte, f float64) []byte {
+	return strconv.AppendFloat(buf, f, 'f', -1, 64)
+}
+
+// DepthAction is how a level changed between two depth snapshots.
+type DepthAction uint8
+
+const (
+	DepthActionNew DepthAction = iota + 1
+	DepthActionChange
+	DepthActionDelete
+)
+
+func (d DepthAction) String() string {
+	switch d {
+	case DepthActionNew:
+		return "new"
+	case DepthActionChange:
+		return "change"
+	case DepthActionDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// DepthChange is a level that changed between two depth snapshots. Position is the level's index in its side, best first;
+// in the earlier snapshot for deletes, and in the later one otherwise.
+type DepthChange struct {
+	Action   DepthAction
+	Side     OrderSide
+	Position int
+	Level    DepthLevel
+}
+
+// DiffDepth returns the changes that turn prev's levels into next's: deletes first, then new & changed levels, each side
+// best first, bids before asks. ApplyDepth applies them to prev's levels, leaving next's.
+func DiffDepth(prev, next DepthSnapshot) []DepthChange {
+	var deletes, updates []DepthChange
+	for _, side := range []struct {
+		side       OrderSide
+		prev, next []DepthLevel
+	}{
+		{side: BuySide, prev: prev.Bids, next: next.Bids},
+		{side: SellSide, prev: prev.Asks, next: next.Asks},
+	} {
+		sizes := make(map[Price]Size, len(side.next))
+		for _, level := range side.next {
+			sizes[level.Price] = level.Size
+		}
+
+		previous := make(map[Price]Size, len(side.prev))
+		for i, level := range side.prev {
+			previous[level.Price] = level.Size
+			if _, ok := sizes[level.Price]; !ok {
+				deletes = append(deletes, DepthChange{Action: DepthActionDelete, Side: side.side, Position: i, Level: level})
+			}
+		}
+
+		for i, level := range side.next {
+			size, ok := previous[level.Price]
+			switch {
+			case !ok:
+				updates = append(updates, DepthChange{Action: DepthActionNew, Side: side.side, Position: i, Level: level})
+			case size != level.Size:
+				updates = append(updates, DepthChange{Action: DepthActionChange, Side: side.side, Position: i, Level: level})
+			}
+		}
+	}
+
+	return append(deletes, updates...)
+}
+
+// ApplyDepth applies changes, as returned by DiffDepth, to prev's levels, returning them along with their checksum; the
+// sequence number is left as prev's. Deletes are matched by price, so several on one side apply in any order.
+func ApplyDepth(prev DepthSnapshot, changes []DepthChange) DepthSnapshot {
+	next := DepthSnapshot{
+		Seq:  prev.Seq,
+		Bids: append([]DepthLevel(nil), prev.Bids...),
+		Asks: append([]DepthLevel(nil), prev.Asks...),
+	}
+
+	for _, change := range changes {
+		levels := &next.Bids
+		if change.Side == SellSide {
+			levels = &next.Asks
+		}
+
+		switch change.Action {
+		case DepthActionDelete:
+			for i, level := range *levels {
+				if level.Price == change.Level.Price {
+					*levels = append((*levels)[:i], (*levels)[i+1:]...)
+					break
+				}
+			}
+		case DepthActionNew:
+			position := min(change.Position, len(*levels))
+			*levels = append((*levels)[:position], append([]DepthLevel{change.Level}, (*levels)[position:]...)...)
+		case DepthActionChange:
+			if change.Position < len(*levels) {
+				(*levels)[change.Position] = change.Level
+			}
+		}
+	}
+
+	next.Checksum = Checksum(next.Bids, next.Asks)
+
+	return next
+}
+
+// Truncate returns the snapshot cut down to the given number of levels per side, along with their checksum.
+func (d DepthSnapshot) Truncate(levels int) DepthSnapshot {
+	if len(d.Bids) <= levels && len(d.Asks) <= levels {
+		return d
+	}
+
+	d.Bids = d.Bids[:min(levels, len(d.Bids))]
+	d.Asks = d.Asks[:min(levels, len(d.Asks))]
+	d.Checksum = Checksum(d.Bids, d.Asks)
+
+	return d
+}
+
+// publishDepth publishes the top levels if they've changed since they were last published.
+func (o *Orderbook) publishDepth(now time.Time) {
+	if o.depthLevels <= 0 || len(o.subscribers) == 0 {
+		return
+	}
+
+	depth := o.depthSnapshot(o.depthLevels)
+	if o.lastDepth != nil && sameLevels(o.lastDepth.Bids, depth.Bids) && sameLevels(o.lastDepth.Asks, depth.Asks) {
+		return
+	}
+
+	o.lastDepth = &depth
+	o.publish(DepthEvent{DepthSnapshot: depth, SequencedAt: now})
+}
+
+func sameLevels(a, b []DepthLevel) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,116 @@
+package lob
+
+import (
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		bids     []DepthLevel
+		asks     []DepthLevel
+		expected string
+	}{
+		{
+			name:     "interleaves_both_sides",
+			bids:     []DepthLevel{{1000, 2}, {999.5, 1.25}},
+			asks:     []DepthLevel{{1001, 3}, {1002, 0.1}},
+			expected: "1000:2:1001:3:999.5:1.25:1002:0.1",
+		},
+		{
+			name:     "uneven_sides",
+			bids:     []DepthLevel{{1000, 2}},
+			asks:     []DepthLevel{{1001, 3}, {1002, 4}, {1003, 5}},
+			expected: "1000:2:1001:3:1002:4:1003:5",
+		},
+		{
+			name:     "empty_book",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, crc32.ChecksumIEEE([]byte(tt.expected)), Checksum(tt.bids, tt.asks))
+		})
+	}
+}
+
+func TestDepthEvents(t *testing.T) {
+	t.Parallel()
+
+	lob := NewOrderbook(128, WithDepthEvents(2))
+
+	var events []DepthEvent
+	lob.Subscribe(func(event Event) {
+		if depth, ok := event.(DepthEvent); ok {
+			events = append(events, depth)
+		}
+	})
+
+	for _, price := range []Price{1000, 999, 998} {
+		_, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, price, 1))
+		require.NoError(t, err)
+	}
+
+	// The third bid is below the top two levels, so doesn't change them.
+	require.Len(t, events, 2)
+
+	_, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1001, 2))
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	last := events[2]
+	assert.Equal(t, uint64(4), last.Seq)
+	assert.Equal(t, []DepthLevel{{1000, 1}, {999, 1}}, last.Bids)
+	assert.Equal(t, []DepthLevel{{1001, 2}}, last.Asks)
+
+	// A client mirroring the book checks its copy against the published checksum.
+	assert.Equal(t, last.Checksum, Checksum([]DepthLevel{{1000, 1}, {999, 1}}, []DepthLevel{{1001, 2}}))
+	assert.Equal(t, last.DepthSnapshot, lob.DepthSnapshot(2))
+}
+
+func TestDiffDepth(t *testing.T) {
+	t.Parallel()
+
+	prev := DepthSnapshot{
+		Bids: []DepthLevel{{1000, 1}, {999, 2}, {998, 3}},
+		Asks: []DepthLevel{{1001, 1}},
+	}
+	next := DepthSnapshot{
+		Bids: []DepthLevel{{1000, 1}, {998, 4}, {997, 1}},
+		Asks: []DepthLevel{{1001, 2}},
+	}
+
+	assert.Equal(t, []DepthChange{
+		{Action: DepthActionDelete, Side: BuySide, Position: 1, Level: DepthLevel{999, 2}},
+		{Action: DepthActionChange, Side: BuySide, Position: 1, Level: DepthLevel{998, 4}},
+		{Action: DepthActionNew, Side: BuySide, Position: 2, Level: DepthLevel{997, 1}},
+		{Action: DepthActionChange, Side: SellSide, Position: 0, Level: DepthLevel{1001, 2}},
+	}, DiffDepth(prev, next))
+	assert.Empty(t, DiffDepth(next, next))
+
+	applied := ApplyDepth(prev, DiffDepth(prev, next))
+	assert.Equal(t, next.Bids, applied.Bids)
+	assert.Equal(t, next.Asks, applied.Asks)
+	assert.Equal(t, Checksum(next.Bids, next.Asks), applied.Checksum)
+
+	// Several deletes on a side, positioned in the earlier snapshot.
+	emptied := DepthSnapshot{Bids: []DepthLevel{{997, 1}}}
+	applied = ApplyDepth(next, DiffDepth(next, emptied))
+	assert.Equal(t, emptied.Bids, applied.Bids)
+	assert.Empty(t, applied.Asks)
+
+	truncated := next.Truncate(1)
+	assert.Equal(t, []DepthLevel{{1000, 1}}, truncated.Bids)
+	assert.Equal(t, Checksum(truncated.Bids, truncated.Asks), truncated.Checksum)
+}
@@ -0,0 +1,136 @@
+package lob
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event is published by the Orderbook whenever its state changes.
+type Event interface {
+	fmt.Stringer
+	isEvent()
+}
+
+// EventHandler receives events synchronously, in order, while the Orderbook is locked; handlers must not call back into the Orderbook.
+type EventHandler func(event Event)
+
+// TradeEvent is published for every match between two orders.
+type TradeEvent struct {
+	Price          Price
+	Size           Size
+	BuyOrderID     uint64
+	SellOrderID    uint64
+	BuyAccountID   uint64
+	SellAccountID  uint64
+	BuyFee         float64
+	SellFee        float64
+	AggressorSide  OrderSide
+	MakerOrderID   uint64
+	TakerOrderID   uint64
+	AuctionUncross bool
+
+	// ReceivedAt is when the taker was received & SequencedAt when the command that matched it was sequenced, which is
+	// when the trade's time is taken to be, as it replays the same. An auction uncross has no taker, so isn't received.
+	ReceivedAt  time.Time
+	SequencedAt time.Time
+}
+
+func (TradeEvent) isEvent() {}
+
+func (t TradeEvent) String() string {
+	return fmt.Sprintf(`trade %.6f @ %.6f : buy=%d sell=%d aggressor=%s`, t.Size, t.Price, t.BuyOrderID, t.SellOrderID, t.AggressorSide)
+}
+
+// OrderEvent is published every time an order's state changes; LastPrice, LastSize & LastFee are set when the change is
+// a fill.
+type OrderEvent struct {
+	Order     OrderInfo
+	LastPrice Price
+	LastSize  Size
+	LastFee   float64
+}
+
+func (OrderEvent) isEvent() {}
+
+func (o OrderEvent) String() string {
+	return fmt.Sprintf(`order %s last=%.6f@%.6f`, o.Order, o.LastSize, o.LastPrice)
+}
+
+// IndicativeEvent is published whenever the book changes during an auction call phase; SequencedAt is when the command
+// that changed it was sequenced.
+type IndicativeEvent struct {
+	Equilibrium Equilibrium
+	SequencedAt time.Time
+}
+
+func (IndicativeEvent) isEvent() {}
+
+func (i IndicativeEvent) String() string {
+	return fmt.Sprintf(`indicative %s`, i.Equilibrium)
+}
+
+// UncrossEvent is published once an auction has been uncrossed, after all of its trades; SequencedAt is when the command
+// that uncrossed it was sequenced.
+type UncrossEvent struct {
+	Equilibrium Equilibrium
+	SequencedAt time.Time
+}
+
+func (UncrossEvent) isEvent() {}
+
+func (u UncrossEvent) String() string {
+	return fmt.Sprintf(`uncross %s`, u.Equilibrium)
+}
+
+type subscriber struct {
+	id      uint64
+	handler EventHandler
+}
+
+// Subscribe registers a handler for every event published from now on, returning a func to unsubscribe.
+func (o *Orderbook) Subscribe(handler EventHandler) func() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.nextSubscriberID++
+	id := o.nextSubscriberID
+	o.subscribers = append(o.subscribers, subscriber{id: id, handler: handler})
+
+	return func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+
+		for i, s := range o.subscribers {
+			if s.id == id {
+				o.subscribers = append(o.subscribers[:i:i], o.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (o *Orderbook) publish(event Event) {
+	for _, s := range o.subscribers {
+		s.handler(event)
+	}
+}
+
+// execution is a single fill of an order, as reported in its order event.
+type execution struct {
+	price Price
+	size  Size
+	fee   float64
+}
+
+func (o *Orderbook) publishOrder(order *Order, fill execution) {
+	if len(o.subscribers) == 0 {
+		return
+	}
+
+	o.publish(OrderEvent{
+		Order:     order.Info(),
+		LastPrice: fill.price,
+		LastSize:  fill.size,
+		LastFee:   fill.fee,
+	})
+}
@@ -0,0 +1,246 @@
+package lob
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// FeeTier is a set of fee rates, as fractions of a fill's notional. A negative maker rate pays a rebate.
+type FeeTier struct {
+	MakerRate float64
+	TakerRate float64
+
+	// MinFee is the least charged on any fill that isn't a rebate.
+	MinFee float64
+}
+
+func NewFeeSchedule(defaultTier FeeTier) *FeeSchedule {
+	return &FeeSchedule{
+		defaultTier: defaultTier,
+		tiers:       make(map[string]FeeTier),
+		accounts:    make(map[uint64]string),
+	}
+}
+
+// FeeSchedule holds the fees for a single instrument; accounts pay the default tier unless assigned to another.
+// Fees are charged in the quote asset, and paid to, or rebates paid from, the fee account.
+type FeeSchedule struct {
+	defaultTier  FeeTier
+	tiers        map[string]FeeTier
+	accounts     map[uint64]string
+	feeAccountID uint64
+	mu           sync.RWMutex
+}
+
+// WithFeeSchedule charges fees on every fill.
+func WithFeeSchedule(schedule *FeeSchedule) Option {
+	return func(o *Orderbook) {
+		o.fees = schedule
+	}
+}
+
+// SetTier adds, or replaces, a named tier. Like SetAccountTier it isn't journaled; set tiers through the Orderbook to have
+// them replayed.
+func (f *FeeSchedule) SetTier(name string, tier FeeTier) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tiers[name] = tier
+}
+
+func (f *FeeSchedule) SetAccountTier(accountID uint64, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.tiers[name]; !ok {
+		return fmt.Errorf("set account %d fee tier: unknown tier %q", accountID, name)
+	}
+
+	f.accounts[accountID] = name
+
+	return nil
+}
+
+// SetFeeAccount sets the account fees are paid into when a ledger is used.
+func (f *FeeSchedule) SetFeeAccount(accountID uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.feeAccountID = accountID
+}
+
+// Tier returns the tier the account pays.
+func (f *FeeSchedule) Tier(accountID uint64) FeeTier {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if name, ok := f.accounts[accountID]; ok {
+		return f.tiers[name]
+	}
+
+	return f.defaultTier
+}
+
+// Fee returns the fee on a fill of the given notional; negative for a rebate.
+func (f *FeeSchedule) Fee(accountID uint64, notional float64, maker bool) float64 {
+	tier := f.Tier(accountID)
+
+	rate := tier.TakerRate
+	if maker {
+		rate = tier.MakerRate
+	}
+
+	fee := notional * rate
+	if fee >= 0 {
+		fee = math.Max(fee, tier.MinFee)
+	}
+
+	return fee
+}
+
+// SetFeeTier adds, or replaces, a named tier in the Orderbook's fee schedule, journaling the change.
+func (o *Orderbook) SetFeeTier(name string, tier FeeTier) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.fees == nil {
+		return fmt.Errorf("set fee tier %q: orderbook has no fee schedule", name)
+	}
+
+	cmd := Command{Type: CommandSetFeeTier, Tier: name, FeeTier: tier}
+	if err := o.sequence(&cmd); err != nil {
+		return fmt.Errorf("set fee tier %q: %w", name, err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	return o.setFeeTier(name, tier)
+}
+
+// SetAccountFeeTier assigns the account to a tier in the Orderbook's fee schedule, journaling the change.
+func (o *Orderbook) SetAccountFeeTier(accountID uint64, name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.fees == nil {
+		return fmt.Errorf("set account %d fee tier: orderbook has no fee schedule", accountID)
+	}
+
+	cmd := Command{Type: CommandSetAccountFeeTier, AccountID: accountID, Tier: name}
+	if err := o.sequence(&cmd); err != nil {
+		return fmt.Errorf("set account %d fee tier: %w", accountID, err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	return o.setAccountFeeTier(accountID, name)
+}
+
+func (o *Orderbook) setFeeTier(name string, tier FeeTier) error {
+	if o.fees == nil {
+		return fmt.Errorf("set fee tier %q: orderbook has no fee schedule", name)
+	}
+
+	o.fees.SetTier(name, tier)
+
+	return nil
+}
+
+func (o *Orderbook) setAccountFeeTier(accountID uint64, name string) error {
+	if o.fees == nil {
+		return fmt.Errorf("set account %d fee tier: orderbook has no fee schedule", accountID)
+	}
+
+	return o.fees.SetAccountTier(accountID, name)
+}
+
+// snapshot returns the schedule's tiers, ordered by name, & account assignments, ordered by account.
+func (f *FeeSchedule) snapshot() *FeesSnapshot {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	snapshot := &FeesSnapshot{}
+	for name, tier := range f.tiers {
+		snapshot.Tiers = append(snapshot.Tiers, NamedFeeTier{Name: name, Tier: tier})
+	}
+
+	for accountID, name := range f.accounts {
+		snapshot.Accounts = append(snapshot.Accounts, AccountFeeTier{AccountID: accountID, Tier: name})
+	}
+
+	sort.Slice(snapshot.Tiers, func(i, j int) bool { return snapshot.Tiers[i].Name < snapshot.Tiers[j].Name })
+	sort.Slice(snapshot.Accounts, func(i, j int) bool { return snapshot.Accounts[i].AccountID < snapshot.Accounts[j].AccountID })
+
+	return snapshot
+}
+
+// restore replaces the schedule's tiers & account assignments; a nil snapshot leaves it with neither.
+func (f *FeeSchedule) restore(snapshot *FeesSnapshot) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tiers = make(map[string]FeeTier)
+	f.accounts = make(map[uint64]string)
+	if snapshot == nil {
+		return
+	}
+
+	for _, tier := range snapshot.Tiers {
+		f.tiers[tier.Name] = tier.Tier
+	}
+
+	for _, account := range snapshot.Accounts {
+		f.accounts[account.AccountID] = account.Tier
+	}
+}
+
+func (f *FeeSchedule) feeAccount() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.feeAccountID
+}
+
+func (o *Orderbook) fee(order *Order, notional float64, maker bool) float64 {
+	if o.fees == nil {
+		return 0
+	}
+
+	return o.fees.Fee(order.AccountID, notional, maker)
+}
+
+// feeReserve is the most the order's remaining size could be charged in fees: its tier's higher rate on the most it could
+// trade for, plus one minimum fee. Anything charged beyond it, over many small fills or once its tier's changed, comes out
+// of the account's available balance.
+func (o *Orderbook) feeReserve(order *Order) float64 {
+	if o.fees == nil {
+		return 0
+	}
+
+	tier := o.fees.Tier(order.AccountID)
+
+	rate := math.Max(tier.MakerRate, tier.TakerRate)
+	if rate < 0 {
+		return 0
+	}
+
+	return rate*o.maxNotional(order) + tier.MinFee
+}
+
+// chargeFee takes the fee from the order's account in the quote asset, out of what it reserved for fees first, or pays
+// out the rebate.
+func (o *Orderbook) chargeFee(order *Order, fee float64) {
+	if o.ledger == nil || fee == 0 {
+		return
+	}
+
+	feeAccountID := o.fees.feeAccount()
+	if fee < 0 {
+		o.ledger.transfer(feeAccountID, o.instrument.Quote, -fee, 0, order.AccountID)
+		return
+	}
+
+	released := min(fee, order.reservedFee)
+	order.reservedFee -= released
+	o.ledger.transfer(order.AccountID, o.instrument.Quote, fee, released, feeAccountID)
+}
@@ -0,0 +1,125 @@
+package lob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeSchedule_Fee(t *testing.T) {
+	t.Parallel()
+
+	schedule := NewFeeSchedule(FeeTier{MakerRate: 0.001, TakerRate: 0.002, MinFee: 0.5})
+	schedule.SetTier("vip", FeeTier{MakerRate: -0.0001, TakerRate: 0.001})
+	require.NoError(t, schedule.SetAccountTier(2, "vip"))
+	require.Error(t, schedule.SetAccountTier(3, "unknown"))
+
+	tests := []struct {
+		name        string
+		accountID   uint64
+		notional    float64
+		maker       bool
+		expectedFee float64
+	}{
+		{
+			name:        "default_taker",
+			accountID:   1,
+			notional:    1000,
+			expectedFee: 2,
+		},
+		{
+			name:        "default_maker",
+			accountID:   1,
+			notional:    1000,
+			maker:       true,
+			expectedFee: 1,
+		},
+		{
+			name:        "minimum_fee",
+			accountID:   1,
+			notional:    100,
+			expectedFee: 0.5,
+		},
+		{
+			name:        "tier_taker",
+			accountID:   2,
+			notional:    1000,
+			expectedFee: 1,
+		},
+		{
+			name:        "tier_maker_rebate",
+			accountID:   2,
+			notional:    1000,
+			maker:       true,
+			expectedFee: -0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.InDelta(t, tt.expectedFee, schedule.Fee(tt.accountID, tt.notional, tt.maker), 1e-9)
+		})
+	}
+}
+
+func TestFeeSchedule_ChargedOnFills(t *testing.T) {
+	t.Parallel()
+
+	const feeAccount uint64 = 99
+
+	schedule := NewFeeSchedule(FeeTier{MakerRate: -0.001, TakerRate: 0.002})
+	schedule.SetFeeAccount(feeAccount)
+
+	lob, ledger := newLedgerOrderbook(t, WithFeeSchedule(schedule))
+	require.NoError(t, ledger.Deposit(feeAccount, "USD", 100))
+
+	var (
+		trades []TradeEvent
+		orders []OrderEvent
+	)
+	lob.Subscribe(func(event Event) {
+		switch e := event.(type) {
+		case TradeEvent:
+			trades = append(trades, e)
+		case OrderEvent:
+			if e.LastSize > 0 {
+				orders = append(orders, e)
+			}
+		}
+	})
+
+	// Fees are paid in the quote asset, so sellers need it to cover the most they could be charged.
+	_, err := placeAccountOrder(t, lob, testSeller, LimitOrder, SellSide, 1000, 1)
+	assertRejected(t, err, RejectReasonInsufficientBalance)
+	require.NoError(t, ledger.Deposit(testSeller, "USD", 10))
+
+	makerID, err := placeAccountOrder(t, lob, testSeller, LimitOrder, SellSide, 1000, 1)
+	require.NoError(t, err)
+
+	// The maker's reserved its fee at the higher, taker, rate, as it'd pay that were it to match in an auction.
+	assert.Equal(t, Balance{Total: 10, Reserved: 2}, ledger.Balance(testSeller, "USD"))
+
+	takerID, err := placeAccountOrder(t, lob, testBuyer, MarketOrder, BuySide, 0, 1)
+	require.NoError(t, err)
+
+	require.Len(t, trades, 1)
+	assert.Equal(t, 2.0, trades[0].BuyFee)
+	assert.Equal(t, -1.0, trades[0].SellFee)
+
+	require.Len(t, orders, 2)
+	assert.Equal(t, makerID, orders[0].Order.ID)
+	assert.Equal(t, -1.0, orders[0].LastFee)
+	assert.Equal(t, takerID, orders[1].Order.ID)
+	assert.Equal(t, 2.0, orders[1].LastFee)
+
+	taker, err := lob.GetOrder(takerID)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, taker.Fees)
+
+	assert.Equal(t, Balance{Total: 10_000 - 1000 - 2}, ledger.Balance(testBuyer, "USD"))
+	assert.Equal(t, Balance{Total: 10 + 1000 + 1}, ledger.Balance(testSeller, "USD"))
+	assert.Equal(t, Balance{Total: 100 + 2 - 1}, ledger.Balance(feeAccount, "USD"))
+}
@@ -0,0 +1,106 @@
+package lob
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+)
+
+// HashLog records the Orderbook's rolling state hash after every command it applies, so a replay of the same commands can
+// check it reaches the same states. RecordHash is called while the Orderbook is locked, so it must not call back into it.
+type HashLog interface {
+	RecordHash(seq, hash uint64) error
+}
+
+// WithHashLog records the rolling state hash to the log after every command is applied. Hashing walks the whole book,
+// so it's best kept for recording runs that'll be replayed.
+func WithHashLog(log HashLog) Option {
+	return func(o *Orderbook) {
+		o.hashLog = log
+	}
+}
+
+// RollHash folds the state hash after a command into the rolling hash of every state before it.
+func RollHash(rolling, state uint64) uint64 {
+	h := fnv.New64a()
+
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], rolling)
+	binary.LittleEndian.PutUint64(buf[8:], state)
+	h.Write(buf[:])
+
+	return h.Sum64()
+}
+
+// StateHash returns a hash of the book's state: the sequence number, trading state, last trade price, every resting order
+// in queue order, and the ledger's balances.
+func (o *Orderbook) StateHash() uint64 {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.stateHash()
+}
+
+func (o *Orderbook) stateHash() uint64 {
+	w := hashWriter{h: fnv.New64a()}
+
+	w.uint64(o.sequencer.Last())
+	w.uint64(uint64(o.state))
+	w.float(float64(o.lastTradePrice))
+
+	for _, book := range []*Book{o.bids, o.asks} {
+		w.uint64(uint64(len(book.levels)))
+		for _, pl := range book.levels {
+			w.float(float64(pl.price))
+			w.float(float64(pl.totalSize))
+			w.uint64(uint64(len(pl.orderQueue)))
+
+			for _, order := range pl.orderQueue {
+				w.uint64(order.ID)
+				w.uint64(order.AccountID)
+				w.float(float64(order.Price))
+				w.float(float64(order.Size))
+				w.float(float64(order.remainingSize))
+				w.float(float64(order.filledSize))
+				w.uint64(uint64(order.status))
+			}
+		}
+	}
+
+	if o.ledger != nil {
+		for _, balance := range o.ledger.Snapshot() {
+			w.uint64(balance.AccountID)
+			w.h.Write([]byte(balance.Asset))
+			w.float(balance.Balance.Total)
+			w.float(balance.Balance.Reserved)
+		}
+	}
+
+	return w.h.Sum64()
+}
+
+// roll folds the current state into the rolling hash, if there's a hash log to keep it for.
+func (o *Orderbook) roll() bool {
+	if o.hashLog == nil {
+		return false
+	}
+
+	o.rollingHash = RollHash(o.rollingHash, o.stateHash())
+
+	return true
+}
+
+type hashWriter struct {
+	h   hash.Hash64
+	buf [8]byte
+}
+
+func (w *hashWriter) uint64(v uint64) {
+	binary.LittleEndian.PutUint64(w.buf[:], v)
+	w.h.Write(w.buf[:])
+}
+
+func (w *hashWriter) float(f float64) {
+	w.uint64(math.Float64bits(f))
+}
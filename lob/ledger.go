@@ -0,0 +1,371 @@
+package lob
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+type Asset string
+
+// Instrument is what an Orderbook trades; Size is denominated in the base asset and Price in the quote asset.
+type Instrument struct {
+	Symbol string
+	Base   Asset
+	Quote  Asset
+}
+
+var defaultInstrument = Instrument{
+	Symbol: "BASE/QUOTE",
+	Base:   "BASE",
+	Quote:  "QUOTE",
+}
+
+func WithInstrument(instrument Instrument) Option {
+	return func(o *Orderbook) {
+		o.instrument = instrument
+	}
+}
+
+// WithLedger constrains every order by its account's balances in the ledger. Limit orders reserve the quote asset for buys and the base asset
+// for sells, fills move balances between the buyer and seller, and whatever's still reserved is released once the order finishes.
+func WithLedger(ledger *Ledger) Option {
+	return func(o *Orderbook) {
+		o.ledger = ledger
+	}
+}
+
+func (o *Orderbook) Instrument() Instrument {
+	return o.instrument
+}
+
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+type Balance struct {
+	Total    float64
+	Reserved float64
+}
+
+func (b Balance) Available() float64 {
+	return b.Total - b.Reserved
+}
+
+func NewLedger() *Ledger {
+	return &Ledger{
+		balances: make(map[uint64]map[Asset]*Balance),
+	}
+}
+
+// Ledger holds every account's balances. It belongs to a single Orderbook, which snapshots, restores & hashes every
+// balance in it, so it can't be shared by the Orderbooks of several instruments.
+type Ledger struct {
+	balances map[uint64]map[Asset]*Balance
+	mu       sync.RWMutex
+}
+
+// Deposit adds funds to the account. Deposits made directly to the ledger aren't journaled; make them through the
+// Orderbook's Deposit to have them replayed.
+func (l *Ledger) Deposit(accountID uint64, asset Asset, amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("deposit %.6f %s: amount must be positive", amount, asset)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.balance(accountID, asset).Total += amount
+
+	return nil
+}
+
+// Withdraw removes funds from the account, so long as they're not reserved by open orders.
+func (l *Ledger) Withdraw(accountID uint64, asset Asset, amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("withdraw %.6f %s: amount must be positive", amount, asset)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	balance := l.balance(accountID, asset)
+	if balance.Available() < amount {
+		return fmt.Errorf("withdraw %.6f %s: %w; %.6f available", amount, asset, ErrInsufficientBalance, balance.Available())
+	}
+
+	balance.Total -= amount
+
+	return nil
+}
+
+func (l *Ledger) Balance(accountID uint64, asset Asset) Balance {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if balances, ok := l.balances[accountID]; ok {
+		if balance, ok := balances[asset]; ok {
+			return *balance
+		}
+	}
+
+	return Balance{}
+}
+
+// AccountBalance is one account's balance of a single asset.
+type AccountBalance struct {
+	AccountID uint64
+	Asset     Asset
+	Balance   Balance
+}
+
+// Snapshot returns every balance, ordered by account then asset.
+func (l *Ledger) Snapshot() []AccountBalance {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var balances []AccountBalance
+	for accountID, assets := range l.balances {
+		for asset, balance := range assets {
+			balances = append(balances, AccountBalance{AccountID: accountID, Asset: asset, Balance: *balance})
+		}
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].AccountID != balances[j].AccountID {
+			return balances[i].AccountID < balances[j].AccountID
+		}
+
+		return balances[i].Asset < balances[j].Asset
+	})
+
+	return balances
+}
+
+// Restore replaces the balances given, leaving every other account's, and asset's, as it is.
+func (l *Ledger) Restore(balances []AccountBalance) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, b := range balances {
+		*l.balance(b.AccountID, b.Asset) = b.Balance
+	}
+}
+
+func (l *Ledger) reserve(accountID uint64, asset Asset, amount float64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	balance := l.balance(accountID, asset)
+	if balance.Available() < amount {
+		return fmt.Errorf("reserve %.6f %s: %w; %.6f available", amount, asset, ErrInsufficientBalance, balance.Available())
+	}
+
+	balance.Reserved += amount
+
+	return nil
+}
+
+func (l *Ledger) release(accountID uint64, asset Asset, amount float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.balance(accountID, asset).Reserved -= amount
+}
+
+// transfer moves funds out of the account, consuming the given amount of its reservation.
+func (l *Ledger) transfer(accountID uint64, asset Asset, debit, released float64, toAccountID uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	from := l.balance(accountID, asset)
+	from.Total -= debit
+	from.Reserved -= released
+
+	l.balance(toAccountID, asset).Total += debit
+}
+
+func (l *Ledger) balance(accountID uint64, asset Asset) *Balance {
+	balances, ok := l.balances[accountID]
+	if !ok {
+		balances = make(map[Asset]*Balance)
+		l.balances[accountID] = balances
+	}
+
+	balance, ok := balances[asset]
+	if !ok {
+		balance = &Balance{}
+		balances[asset] = balance
+	}
+
+	return balance
+}
+
+// Deposit adds funds to the account in the Orderbook's ledger, journaling the deposit.
+func (o *Orderbook) Deposit(accountID uint64, asset Asset, amount float64) error {
+	return o.fund(CommandDeposit, accountID, asset, amount)
+}
+
+// Withdraw removes funds from the account in the Orderbook's ledger, journaling the withdrawal.
+func (o *Orderbook) Withdraw(accountID uint64, asset Asset, amount float64) error {
+	return o.fund(CommandWithdraw, accountID, asset, amount)
+}
+
+func (o *Orderbook) fund(cmdType CommandType, accountID uint64, asset Asset, amount float64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.ledger == nil {
+		return fmt.Errorf("%s %.6f %s: orderbook has no ledger", cmdType, amount, asset)
+	}
+
+	cmd := Command{Type: cmdType, AccountID: accountID, Asset: asset, Amount: amount}
+	if err := o.sequence(&cmd); err != nil {
+		return fmt.Errorf("%s %.6f %s: %w", cmdType, amount, asset, err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	return o.applyFunding(cmd)
+}
+
+func (o *Orderbook) applyFunding(cmd Command) error {
+	if o.ledger == nil {
+		return fmt.Errorf("%s %.6f %s: orderbook has no ledger", cmd.Type, cmd.Amount, cmd.Asset)
+	}
+
+	if cmd.Type == CommandWithdraw {
+		return o.ledger.Withdraw(cmd.AccountID, cmd.Asset, cmd.Amount)
+	}
+
+	return o.ledger.Deposit(cmd.AccountID, cmd.Asset, cmd.Amount)
+}
+
+// reserve reserves what the order needs to trade its remaining size, and the most it could be charged in fees for it.
+// Market buys reserve the cost of sweeping the asks for their size.
+func (o *Orderbook) reserve(order *Order) error {
+	if o.ledger == nil {
+		return nil
+	}
+
+	fee := o.feeReserve(order)
+
+	var (
+		asset  = o.instrument.Base
+		amount = float64(order.remainingSize)
+	)
+	if order.Side == BuySide {
+		asset = o.instrument.Quote
+
+		switch order.OrderType {
+		case LimitOrder:
+			amount = float64(order.Price) * float64(order.remainingSize)
+		case MarketOrder:
+			amount = o.sweepCost(order.remainingSize)
+		}
+	}
+
+	if err := o.ledger.reserve(order.AccountID, asset, amount); err != nil {
+		return err
+	}
+
+	if fee > 0 {
+		if err := o.ledger.reserve(order.AccountID, o.instrument.Quote, fee); err != nil {
+			o.ledger.release(order.AccountID, asset, amount)
+			return fmt.Errorf("fees: %w", err)
+		}
+	}
+
+	order.reserved, order.reservedFee = amount, fee
+
+	return nil
+}
+
+// sweepCost is the cost of buying the given size from the asks, best price first.
+func (o *Orderbook) sweepCost(size Size) float64 {
+	cost, _ := sweep(o.asks, size, 0)
+	return cost
+}
+
+// sweep is the notional of taking the given size from the book, best price first, up to the limit price if there is one,
+// along with the size left once it's taken all it can.
+func sweep(book *Book, size Size, limit Price) (float64, Size) {
+	var notional float64
+	for _, pl := range book.levels {
+		if size <= 0 || (limit != 0 && !book.Crosses(limit, pl.price)) {
+			break
+		}
+
+		take := min(size, pl.totalSize)
+		notional += float64(pl.price) * float64(take)
+		size -= take
+	}
+
+	return notional, size
+}
+
+// maxNotional is the most the order's remaining size could trade for. Buys never pay more than their limit, while sells
+// take the bids they cross at their own prices before resting at their limit.
+func (o *Orderbook) maxNotional(order *Order) float64 {
+	switch {
+	case order.Side == BuySide && order.OrderType == MarketOrder:
+		return o.sweepCost(order.remainingSize)
+	case order.Side == BuySide:
+		return float64(order.Price) * float64(order.remainingSize)
+	case order.OrderType == MarketOrder:
+		notional, _ := sweep(o.bids, order.remainingSize, 0)
+		return notional
+	default:
+		notional, left := sweep(o.bids, order.remainingSize, order.Price)
+		return notional + float64(order.Price)*float64(left)
+	}
+}
+
+// settle moves the balances for a single fill of the order; the counterparty's side is settled by its own fill.
+// Buys pay the quote asset to, and sells deliver the base asset to, the counterparty account.
+func (o *Orderbook) settle(order *Order, counterparty *Order, price Price, size Size) {
+	if o.ledger == nil {
+		return
+	}
+
+	switch order.Side {
+	case BuySide:
+		cost := float64(price) * float64(size)
+
+		// Limit buys reserved at their limit price, so release at it to free any price improvement.
+		released := cost
+		if order.OrderType == LimitOrder {
+			released = float64(order.Price) * float64(size)
+		}
+
+		released = min(released, order.reserved)
+		order.reserved -= released
+		o.ledger.transfer(order.AccountID, o.instrument.Quote, cost, released, counterparty.AccountID)
+	case SellSide:
+		released := min(float64(size), order.reserved)
+		order.reserved -= released
+		o.ledger.transfer(order.AccountID, o.instrument.Base, float64(size), released, counterparty.AccountID)
+	}
+}
+
+// release returns whatever the order still has reserved, fees included.
+func (o *Orderbook) release(order *Order) {
+	if o.ledger == nil {
+		return
+	}
+
+	if order.reservedFee != 0 {
+		o.ledger.release(order.AccountID, o.instrument.Quote, order.reservedFee)
+		order.reservedFee = 0
+	}
+
+	if order.reserved == 0 {
+		return
+	}
+
+	asset := o.instrument.Base
+	if order.Side == BuySide {
+		asset = o.instrument.Quote
+	}
+
+	o.ledger.release(order.AccountID, asset, order.reserved)
+	order.reserved = 0
+}
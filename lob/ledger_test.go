@@ -0,0 +1,165 @@
+package lob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBuyer  uint64 = 1
+	testSeller uint64 = 2
+)
+
+func newLedgerOrderbook(t *testing.T, opts ...Option) (*Orderbook, *Ledger) {
+	ledger := NewLedger()
+	require.NoError(t, ledger.Deposit(testBuyer, "USD", 10_000))
+	require.NoError(t, ledger.Deposit(testSeller, "BTC", 10))
+
+	opts = append(opts, WithLedger(ledger), WithInstrument(Instrument{Symbol: "BTC/USD", Base: "BTC", Quote: "USD"}))
+
+	return NewOrderbook(128, opts...), ledger
+}
+
+func placeAccountOrder(t *testing.T, lob *Orderbook, accountID uint64, orderType OrderType, side OrderSide, price Price, size Size) (uint64, error) {
+	order := NewOrder(orderType, side, price, size)
+	order.AccountID = accountID
+
+	return lob.PlaceOrder(order)
+}
+
+func TestLedger_ReservesAndSettles(t *testing.T) {
+	t.Parallel()
+
+	lob, ledger := newLedgerOrderbook(t)
+
+	buyID, err := placeAccountOrder(t, lob, testBuyer, LimitOrder, BuySide, 1000, 5)
+	require.NoError(t, err)
+	assert.Equal(t, Balance{Total: 10_000, Reserved: 5_000}, ledger.Balance(testBuyer, "USD"))
+
+	// Exceeds what's left available.
+	_, err = placeAccountOrder(t, lob, testBuyer, LimitOrder, BuySide, 999, 6)
+	assertRejected(t, err, RejectReasonInsufficientBalance)
+
+	_, err = placeAccountOrder(t, lob, testSeller, LimitOrder, SellSide, 1000, 11)
+	assertRejected(t, err, RejectReasonInsufficientBalance)
+
+	_, err = placeAccountOrder(t, lob, testSeller, LimitOrder, SellSide, 1000, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, Balance{Total: 8_000, Reserved: 3_000}, ledger.Balance(testBuyer, "USD"))
+	assert.Equal(t, Balance{Total: 2}, ledger.Balance(testBuyer, "BTC"))
+	assert.Equal(t, Balance{Total: 8}, ledger.Balance(testSeller, "BTC"))
+	assert.Equal(t, Balance{Total: 2_000}, ledger.Balance(testSeller, "USD"))
+
+	require.NoError(t, lob.CancelOrder(buyID))
+	assert.Equal(t, Balance{Total: 8_000}, ledger.Balance(testBuyer, "USD"))
+
+	require.Error(t, ledger.Withdraw(testBuyer, "USD", 8_001))
+	require.NoError(t, ledger.Withdraw(testBuyer, "USD", 8_000))
+}
+
+func TestLedger_PriceImprovement(t *testing.T) {
+	t.Parallel()
+
+	lob, ledger := newLedgerOrderbook(t)
+
+	_, err := placeAccountOrder(t, lob, testSeller, LimitOrder, SellSide, 990, 1)
+	require.NoError(t, err)
+
+	// Reserves at 1000, but trades at 990.
+	_, err = placeAccountOrder(t, lob, testBuyer, LimitOrder, BuySide, 1000, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, Balance{Total: 9_010, Reserved: 1_000}, ledger.Balance(testBuyer, "USD"))
+	assert.Equal(t, Balance{Total: 990}, ledger.Balance(testSeller, "USD"))
+}
+
+func TestLedger_MarketOrders(t *testing.T) {
+	t.Parallel()
+
+	lob, ledger := newLedgerOrderbook(t)
+
+	_, err := placeAccountOrder(t, lob, testSeller, LimitOrder, SellSide, 1000, 5)
+	require.NoError(t, err)
+
+	_, err = placeAccountOrder(t, lob, testSeller, LimitOrder, SellSide, 1100, 5)
+	require.NoError(t, err)
+
+	// Sweeping 10 would cost 10,500.
+	_, err = placeAccountOrder(t, lob, testBuyer, MarketOrder, BuySide, 0, 10)
+	assertRejected(t, err, RejectReasonInsufficientBalance)
+
+	_, err = placeAccountOrder(t, lob, testBuyer, MarketOrder, BuySide, 0, 6)
+	require.NoError(t, err)
+
+	assert.Equal(t, Balance{Total: 3_900}, ledger.Balance(testBuyer, "USD"))
+	assert.Equal(t, Balance{Total: 6}, ledger.Balance(testBuyer, "BTC"))
+	assert.Equal(t, Balance{Total: 4, Reserved: 4}, ledger.Balance(testSeller, "BTC"))
+
+	_, err = placeAccountOrder(t, lob, testBuyer, MarketOrder, SellSide, 0, 7)
+	assertRejected(t, err, RejectReasonInsufficientBalance)
+}
+
+func TestLedger_AuctionUncross(t *testing.T) {
+	t.Parallel()
+
+	lob, ledger := newLedgerOrderbook(t, WithTradingState(TradingStateAuction))
+
+	_, err := placeAccountOrder(t, lob, testBuyer, LimitOrder, BuySide, 1010, 2)
+	require.NoError(t, err)
+
+	_, err = placeAccountOrder(t, lob, testSeller, LimitOrder, SellSide, 1000, 3)
+	require.NoError(t, err)
+
+	eq, err := lob.Uncross()
+	require.NoError(t, err)
+	require.Equal(t, Size(2), eq.Volume)
+
+	cost := 2 * float64(eq.Price)
+	assert.Equal(t, Balance{Total: 10_000 - cost}, ledger.Balance(testBuyer, "USD"))
+	assert.Equal(t, Balance{Total: 2}, ledger.Balance(testBuyer, "BTC"))
+	assert.Equal(t, Balance{Total: 8, Reserved: 1}, ledger.Balance(testSeller, "BTC"))
+	assert.Equal(t, Balance{Total: cost}, ledger.Balance(testSeller, "USD"))
+}
+
+func TestLedger_EditOrder(t *testing.T) {
+	t.Parallel()
+
+	lob, ledger := newLedgerOrderbook(t)
+
+	id, err := placeAccountOrder(t, lob, testBuyer, LimitOrder, BuySide, 1000, 5)
+	require.NoError(t, err)
+
+	require.NoError(t, lob.EditOrder(&Order{ID: id, Price: 1000, Size: 8}))
+	assert.Equal(t, Balance{Total: 10_000, Reserved: 8_000}, ledger.Balance(testBuyer, "USD"))
+
+	// A failed edit leaves the order, and its reservation, as they were.
+	err = lob.EditOrder(&Order{ID: id, Price: 1100, Size: 10})
+	assertRejected(t, err, RejectReasonInsufficientBalance)
+	assert.Equal(t, Balance{Total: 10_000, Reserved: 8_000}, ledger.Balance(testBuyer, "USD"))
+
+	require.NoError(t, lob.EditOrder(&Order{ID: id, Price: 900, Size: 2}))
+	assert.Equal(t, Balance{Total: 10_000, Reserved: 1_800}, ledger.Balance(testBuyer, "USD"))
+}
+
+func TestLedger_RestoreOnlyGivenBalances(t *testing.T) {
+	t.Parallel()
+
+	ledger := NewLedger()
+	require.NoError(t, ledger.Deposit(1, "USD", 100))
+	require.NoError(t, ledger.Deposit(1, "BTC", 1))
+	snapshot := ledger.Snapshot()
+
+	require.NoError(t, ledger.Deposit(1, "USD", 50))
+	require.NoError(t, ledger.Deposit(1, "ETH", 2))
+	require.NoError(t, ledger.Deposit(2, "USD", 10))
+
+	ledger.Restore(snapshot)
+
+	assert.Equal(t, Balance{Total: 100}, ledger.Balance(1, "USD"))
+	assert.Equal(t, Balance{Total: 1}, ledger.Balance(1, "BTC"))
+	assert.Equal(t, Balance{Total: 2}, ledger.Balance(1, "ETH"))
+	assert.Equal(t, Balance{Total: 10}, ledger.Balance(2, "USD"))
+}
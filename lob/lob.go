@@ -1,16 +1,48 @@
 package lob
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 )
 
-func NewOrderbook(size uint64) *Orderbook {
-	return &Orderbook{
-		asks:      NewBook(SellSide),
-		bids:      NewBook(BuySide),
-		sequencer: NewSequencer(),
+type Option func(o *Orderbook)
+
+// WithOrderRetention sets how long finished orders remain queryable via GetOrder.
+func WithOrderRetention(retention time.Duration) Option {
+	return func(o *Orderbook) {
+		o.orders.retention = retention
+	}
+}
+
+// WithClientOrderIDWindow sets how long after its order finishes a client order ID can't be reused; an order placed with
+// one in use is rejected as a duplicate. Client order IDs of open orders can never be reused.
+func WithClientOrderIDWindow(window time.Duration) Option {
+	return func(o *Orderbook) {
+		o.orders.clientWindow = window
+	}
+}
+
+func NewOrderbook(size uint64, opts ...Option) *Orderbook {
+	clock := NewMonotonicClock()
+
+	o := &Orderbook{
+		asks:       NewBook(SellSide),
+		bids:       NewBook(BuySide),
+		sequencer:  NewSequencer(clock),
+		orders:     newOrderTracker(DefaultOrderRetention, DefaultClientOrderIDWindow),
+		clock:      clock,
+		state:      TradingStateOpen,
+		instrument: defaultInstrument,
+	}
+
+	for _, opt := range opts {
+		opt(o)
 	}
+
+	return o
 }
 
 type Orderbook struct {
@@ -18,9 +50,42 @@ type Orderbook struct {
 	bids      *Book
 	orderID   uint64
 	sequencer *Sequencer
+	orders    *orderTracker
+	clock     Clock
+	mu        sync.RWMutex
+
+	state          TradingState
+	schedule       []scheduledTransition
+	lastTradePrice Price
+	bands          *priceBands
+	checks         []PreTradeCheck
+	riskLimits     map[uint64]RiskLimits
+	instrument     Instrument
+	ledger         *Ledger
+	fees           *FeeSchedule
+	journal        Journal
+	hashLog        HashLog
+	rollingHash    uint64
+	depthLevels    int
+	lastDepth      *DepthSnapshot
+
+	subscribers      []subscriber
+	nextSubscriberID uint64
+}
+
+// Clock returns the clock the Orderbook stamps orders & events with, e.g. for gateways to stamp the orders they receive.
+func (o *Orderbook) Clock() Clock {
+	return o.clock
+}
+
+func (o *Orderbook) now() time.Time {
+	return o.clock.Now()
 }
 
 func (o *Orderbook) Mid() (Price, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
 	bbp, err := o.bids.Top()
 	if err != nil {
 		return 0, fmt.Errorf("fetch bids top: %w", err)
@@ -37,64 +102,569 @@ func (o *Orderbook) Mid() (Price, error) {
 }
 
 func (o *Orderbook) BestAsk() (Price, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
 	return o.asks.Top()
 }
 
 func (o *Orderbook) BestBid() (Price, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
 	return o.bids.Top()
 }
 
 func (o *Orderbook) Depth() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
 	return max(o.asks.Depth(), o.bids.Depth())
 }
 
 func (o *Orderbook) Volume() (Size, Size) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
 	return o.bids.levels.TotalVolume(), o.asks.levels.TotalVolume()
 }
 
+// PlaceOrder places the order, returning its ID. An order with a client order ID already in use is rejected without
+// being placed, and the ID of the order using it returned with the rejection, so a client retrying can find its order.
 func (o *Orderbook) PlaceOrder(order *Order) (uint64, error) {
+	if order == nil {
+		return 0, fmt.Errorf("invalid order: %w", order.Validate())
+	}
+
+	// Stamped before waiting on the lock, so the wait counts towards the order's latency.
+	if order.ReceivedAt.IsZero() {
+		order.ReceivedAt = o.now()
+	}
+
+	// Refused before it's sequenced, as the journal can't hold it.
+	if len(order.ClientOrderID) > MaxClientOrderIDLength {
+		return 0, fmt.Errorf("invalid order: %w", newRejectError(RejectReasonInvalidOrder, "client order id longer than %d", MaxClientOrderIDLength))
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if order.ClientOrderID != "" {
+		key := newClientOrderKey(order.AccountID, order.SessionID, order.ClientOrderID)
+		if orderID, ok := o.orders.clientOrder(key, o.now()); ok {
+			return orderID, fmt.Errorf("place order: %w", newRejectError(RejectReasonDuplicateOrder, "client order id %q already used by order %d", order.ClientOrderID, orderID))
+		}
+	}
+
+	cmd := Command{Type: CommandPlaceOrder, Order: *order}
+	if err := o.sequence(&cmd); err != nil {
+		return 0, fmt.Errorf("place order: %w", err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	order.ID = cmd.Seq
+
+	return order.ID, o.placeOrder(order, cmd.Time)
+}
+
+// begin runs the housekeeping due before applying a command at the given time.
+func (o *Orderbook) begin(now time.Time) {
+	o.orders.prune(now)
+	o.runSchedule(now)
+}
+
+func (o *Orderbook) placeOrder(order *Order, now time.Time) error {
+	o.begin(now)
+	o.orders.useClientOrderID(order)
+	order.sequencedAt = now
+
 	if err := order.Validate(); err != nil {
-		return 0, fmt.Errorf("invalid order: %w", err)
+		o.reject(order, RejectReasonInvalidOrder, now)
+		return fmt.Errorf("invalid order: %w", &RejectError{Reason: RejectReasonInvalidOrder, Err: err})
 	}
 
-	sequencedOrder := o.sequencer.Stamp(order)
-	slog.Debug("LOB: placing order", "order", sequencedOrder.String())
+	order.remainingSize = order.Size
+	order.setStatus(OrderStatusNew, now)
+	slog.Debug("LOB: placing order", "order", order.String())
+
+	if !o.state.AcceptsOrders() {
+		o.reject(order, RejectReasonTradingState, now)
+		return newRejectError(RejectReasonTradingState, "orders not accepted while %s", o.state)
+	}
+
+	for _, check := range o.checks {
+		if err := check.CheckOrder(order, o.estimatePrice(order)); err != nil {
+			reason := RejectReasonInvalidOrder
+			var rejectErr *RejectError
+			if errors.As(err, &rejectErr) {
+				reason = rejectErr.Reason
+			}
+
+			o.reject(order, reason, now)
+			return fmt.Errorf("pre-trade check: %w", err)
+		}
+	}
+
+	if !o.state.Matches() {
+		if order.OrderType == MarketOrder {
+			o.reject(order, RejectReasonTradingState, now)
+			return newRejectError(RejectReasonTradingState, "market orders not accepted while %s", o.state)
+		}
+
+		if err := o.reserve(order); err != nil {
+			o.reject(order, RejectReasonInsufficientBalance, now)
+			return &RejectError{Reason: RejectReasonInsufficientBalance, Err: err}
+		}
+
+		o.rest(order)
+		o.publishOrder(order, execution{})
+		o.publishIndicative(now)
+
+		return nil
+	}
+
+	if order.OrderType == LimitOrder && !o.inBand(now)(order.Price) {
+		o.reject(order, RejectReasonPriceBand, now)
+		return newRejectError(RejectReasonPriceBand, "limit price %.6f outside of price bands", order.Price)
+	}
+
+	if err := o.reserve(order); err != nil {
+		o.reject(order, RejectReasonInsufficientBalance, now)
+		return &RejectError{Reason: RejectReasonInsufficientBalance, Err: err}
+	}
 
 	if order.OrderType == MarketOrder {
 		switch order.Side {
 		case BuySide:
-			if _, err := o.asks.Take(sequencedOrder.Size); err != nil {
-				return 0, fmt.Errorf("take order from asks: %w", err)
+			if err := o.take(o.asks, order, now); err != nil {
+				return fmt.Errorf("take order from asks: %w", err)
 			}
-
-			return sequencedOrder.ID, nil
 		case SellSide:
-			if _, err := o.bids.Take(sequencedOrder.Size); err != nil {
-				return 0, fmt.Errorf(`take order from bids: %w`, err)
+			if err := o.take(o.bids, order, now); err != nil {
+				return fmt.Errorf(`take order from bids: %w`, err)
 			}
+		}
+
+		return nil
+	}
+
+	crossed := o.crosses(order)
+	if crossed {
+		if err := o.take(o.opposite(order.Side), order, now); err != nil {
+			return fmt.Errorf("take crossing limit order: %w", err)
+		}
 
-			return sequencedOrder.ID, nil
+		if order.status.Finished() {
+			return nil
 		}
 	}
 
+	o.rest(order)
+
+	// Taking has already published the order as new, even if it didn't fill.
+	if !crossed {
+		o.publishOrder(order, execution{})
+	}
+
+	// Matching may have paused trading.
+	if !o.state.Matches() {
+		o.publishIndicative(now)
+	}
+
+	return nil
+}
+
+// take matches the taker order against the given book, updating the state of every order involved.
+// Limit orders only match up to their limit price; market orders have any size left once the book is exhausted expired.
+func (o *Orderbook) take(book *Book, taker *Order, now time.Time) error {
+	inBand := o.inBand(now)
+
+	var fills []*FillEvent
+	switch taker.OrderType {
+	case MarketOrder:
+		if book.Depth() == 0 {
+			o.reject(taker, RejectReasonNoLiquidity, now)
+			return newRejectError(RejectReasonNoLiquidity, "not enough liquidity in book %.2f/%.2f", taker.remainingSize, book.TotalVolume())
+		}
+
+		fills = book.TakeWhile(taker.remainingSize, inBand)
+	default:
+		fills = book.TakeWhile(taker.remainingSize, func(price Price) bool {
+			return book.Crosses(taker.Price, price) && inBand(price)
+		})
+	}
+
+	o.publishOrder(taker, execution{})
+
+	for _, fill := range fills {
+		taker.remainingSize -= fill.Size
+
+		maker, ok := o.orders.open[fill.OrderID]
+		if !ok {
+			taker.recordFill(fill.Price, fill.Size, now)
+			continue
+		}
+
+		o.execute(taker, maker, fill.Price, fill.Size, false, now)
+
+		if maker.status == OrderStatusFilled {
+			o.finish(maker)
+		}
+	}
+
+	o.checkBreach(book, taker, inBand, now)
+
+	if taker.OrderType == MarketOrder && taker.remainingSize > 0 {
+		taker.setStatus(OrderStatusExpired, now)
+		o.publishOrder(taker, execution{})
+	}
+
+	if taker.status.Finished() {
+		o.finish(taker)
+	}
+
+	return nil
+}
+
+// rest adds a limit order to its side of the book.
+func (o *Orderbook) rest(order *Order) {
 	switch order.Side {
 	case BuySide:
-		o.bids.Make(sequencedOrder)
-		return sequencedOrder.ID, nil
+		o.bids.Make(order)
 	case SellSide:
-		o.asks.Make(sequencedOrder)
-		return sequencedOrder.ID, nil
+		o.asks.Make(order)
+	}
+
+	o.orders.add(order)
+}
+
+func (o *Orderbook) reject(order *Order, reason RejectReason, now time.Time) {
+	order.rejectReason = reason
+	order.setStatus(OrderStatusRejected, now)
+	o.orders.add(order)
+	o.release(order)
+	o.publishOrder(order, execution{})
+}
+
+// finish moves an order that's left the book into the finished orders, releasing anything it still has reserved.
+func (o *Orderbook) finish(order *Order) {
+	o.orders.finish(order)
+	o.release(order)
+}
+
+// crosses returns true if the limit order would match against the opposite side of the book.
+func (o *Orderbook) crosses(order *Order) bool {
+	book := o.opposite(order.Side)
+
+	top, err := book.Top()
+	if err != nil {
+		return false
+	}
+
+	return book.Crosses(order.Price, top)
+}
+
+// estimatePrice returns the price an order is expected to trade at; its limit price, or for market orders the best opposing price.
+func (o *Orderbook) estimatePrice(order *Order) Price {
+	if order.OrderType == LimitOrder {
+		return order.Price
+	}
+
+	if top, err := o.opposite(order.Side).Top(); err == nil {
+		return top
 	}
 
-	return 0, fmt.Errorf("invalid order")
+	return o.lastTradePrice
+}
+
+func (o *Orderbook) book(side OrderSide) *Book {
+	if side == SellSide {
+		return o.asks
+	}
+
+	return o.bids
+}
+
+func (o *Orderbook) opposite(side OrderSide) *Book {
+	if side == SellSide {
+		return o.bids
+	}
+
+	return o.asks
+}
+
+// execute fills both sides of a match, charging fees & settling balances, then publishes the trade along with both orders' updates.
+// In an auction uncross both sides pay the taker rate and neither is the aggressor.
+func (o *Orderbook) execute(taker, maker *Order, price Price, size Size, auction bool, now time.Time) {
+	maker.recordFill(price, size, now)
+	taker.recordFill(price, size, now)
+
+	var (
+		notional = float64(price) * float64(size)
+		makerFee = o.fee(maker, notional, !auction)
+		takerFee = o.fee(taker, notional, false)
+	)
+
+	maker.fees += makerFee
+	taker.fees += takerFee
+
+	o.settle(maker, taker, price, size)
+	o.settle(taker, maker, price, size)
+	o.chargeFee(maker, makerFee)
+	o.chargeFee(taker, takerFee)
+
+	o.lastTradePrice = price
+	if o.bands != nil {
+		o.bands.record(price, now)
+	}
+
+	if len(o.subscribers) == 0 {
+		return
+	}
+
+	buy, sell := taker, maker
+	buyFee, sellFee := takerFee, makerFee
+	if taker.Side == SellSide {
+		buy, sell = maker, taker
+		buyFee, sellFee = makerFee, takerFee
+	}
+
+	trade := TradeEvent{
+		Price:          price,
+		Size:           size,
+		BuyOrderID:     buy.ID,
+		SellOrderID:    sell.ID,
+		BuyAccountID:   buy.AccountID,
+		SellAccountID:  sell.AccountID,
+		BuyFee:         buyFee,
+		SellFee:        sellFee,
+		AuctionUncross: auction,
+		SequencedAt:    now,
+	}
+
+	if !auction {
+		trade.AggressorSide = taker.Side
+		trade.MakerOrderID = maker.ID
+		trade.TakerOrderID = taker.ID
+		trade.ReceivedAt = taker.ReceivedAt
+	}
+
+	o.publish(trade)
+	o.publishOrder(maker, execution{price: price, size: size, fee: makerFee})
+	o.publishOrder(taker, execution{price: price, size: size, fee: takerFee})
 }
 
 func (o *Orderbook) CancelOrder(orderID uint64) error {
-	return fmt.Errorf("unimplemented")
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cmd := Command{Type: CommandCancelOrder, OrderID: orderID}
+	if err := o.sequence(&cmd); err != nil {
+		return fmt.Errorf("cancel order %d: %w", orderID, err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	return o.cancelOrder(orderID, cmd.Time)
 }
 
+func (o *Orderbook) cancelOrder(orderID uint64, now time.Time) error {
+	o.begin(now)
+
+	if !o.state.AcceptsCancels() {
+		return fmt.Errorf("cancel order %d: %w", orderID, newRejectError(RejectReasonTradingState, "cancels not accepted while %s", o.state))
+	}
+
+	order, ok := o.orders.get(orderID)
+	if !ok {
+		return fmt.Errorf("cancel order %d: %w", orderID, ErrOrderNotFound)
+	}
+
+	if order.status.Finished() {
+		return fmt.Errorf("cancel order %d: order already %s", orderID, order.status)
+	}
+
+	if err := o.cancel(order, now); err != nil {
+		return fmt.Errorf("cancel order %d: %w", orderID, err)
+	}
+
+	if !o.state.Matches() {
+		o.publishIndicative(now)
+	}
+
+	return nil
+}
+
+// cancel removes a resting order from its book and marks it as cancelled.
+func (o *Orderbook) cancel(order *Order, now time.Time) error {
+	book := o.bids
+	if order.Side == SellSide {
+		book = o.asks
+	}
+
+	if !book.Remove(order) {
+		return fmt.Errorf("order not found in %s book", order.Side)
+	}
+
+	order.setStatus(OrderStatusCancelled, now)
+	o.finish(order)
+	o.publishOrder(order, execution{})
+
+	return nil
+}
+
+// EditOrder amends the price & size of the resting order with the given ID; size is the new total, including anything already filled.
+// Reducing the size at the same price keeps the order's queue priority, any other amendment sends it to the back of the queue,
+// and may match it if it now crosses the book.
 func (o *Orderbook) EditOrder(order *Order) error {
-	return fmt.Errorf("unimplemented")
+	if order == nil {
+		return fmt.Errorf("edit order: %w", order.Validate())
+	}
+
+	if order.ReceivedAt.IsZero() {
+		order.ReceivedAt = o.now()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cmd := Command{Type: CommandEditOrder, Order: *order}
+	if err := o.sequence(&cmd); err != nil {
+		return fmt.Errorf("edit order %d: %w", order.ID, err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	return o.editOrder(order.ID, order.Price, order.Size, order.ReceivedAt, cmd.Time)
+}
+
+func (o *Orderbook) editOrder(orderID uint64, price Price, size Size, receivedAt, now time.Time) error {
+	o.begin(now)
+
+	if !o.state.AcceptsOrders() {
+		return fmt.Errorf("edit order %d: %w", orderID, newRejectError(RejectReasonTradingState, "edits not accepted while %s", o.state))
+	}
+
+	order, ok := o.orders.get(orderID)
+	if !ok {
+		return fmt.Errorf("edit order %d: %w", orderID, ErrOrderNotFound)
+	}
+
+	if order.status.Finished() {
+		return fmt.Errorf("edit order %d: order already %s", orderID, order.status)
+	}
+
+	if price <= 0 || size <= order.filledSize {
+		return fmt.Errorf("edit order %d: %w", orderID, newRejectError(RejectReasonInvalidOrder, "price %.6f & size %.6f must be positive and above the filled size %.6f", price, size, order.filledSize))
+	}
+
+	if o.state.Matches() && !o.inBand(now)(price) {
+		return fmt.Errorf("edit order %d: %w", orderID, newRejectError(RejectReasonPriceBand, "limit price %.6f outside of price bands", price))
+	}
+
+	var (
+		book      = o.book(order.Side)
+		remaining = size - order.filledSize
+	)
+
+	// Reserve for the amended order up front, so a failed edit leaves the order as it was.
+	amended := *order
+	amended.Price, amended.Size, amended.remainingSize, amended.reserved, amended.reservedFee = price, size, remaining, 0, 0
+
+	// Amends are checked as the order they'd leave, so they can't get around the limits orders are placed under.
+	for _, check := range o.checks {
+		if err := check.CheckOrder(&amended, o.estimatePrice(&amended)); err != nil {
+			return fmt.Errorf("edit order %d: pre-trade check: %w", orderID, err)
+		}
+	}
+
+	o.release(order)
+	if err := o.reserve(&amended); err != nil {
+		if err := o.reserve(order); err != nil {
+			slog.Warn("LOB: failed to restore reservation", "order", order.String(), "error", err)
+		}
+
+		return fmt.Errorf("edit order %d: %w", orderID, &RejectError{Reason: RejectReasonInsufficientBalance, Err: err})
+	}
+
+	order.reserved, order.reservedFee = amended.reserved, amended.reservedFee
+	order.Size = size
+	order.updatedAt = now
+	order.ReceivedAt, order.sequencedAt = receivedAt, now
+
+	if price == order.Price && remaining <= order.remainingSize {
+		book.Reduce(order, remaining)
+		o.publishOrder(order, execution{})
+
+		return nil
+	}
+
+	book.Remove(order)
+	order.Price, order.remainingSize = price, remaining
+
+	if o.state.Matches() && o.crosses(order) {
+		if err := o.take(o.opposite(order.Side), order, now); err != nil {
+			return fmt.Errorf("edit order %d: take crossing order: %w", orderID, err)
+		}
+
+		if order.status.Finished() {
+			return nil
+		}
+
+		book.Make(order)
+	} else {
+		book.Make(order)
+		o.publishOrder(order, execution{})
+	}
+
+	if !o.state.Matches() {
+		o.publishIndicative(now)
+	}
+
+	return nil
+}
+
+// GetOrder returns the state of an open order, or of a finished order still within the retention window.
+func (o *Orderbook) GetOrder(orderID uint64) (OrderInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.orders.prune(o.now())
+
+	order, ok := o.orders.get(orderID)
+	if !ok {
+		return OrderInfo{}, fmt.Errorf("get order %d: %w", orderID, ErrOrderNotFound)
+	}
+
+	return order.Info(), nil
+}
+
+// ClientOrder returns the state of the order using the client order ID, as scoped when placed: by account, or by session
+// for orders without an account. Like GetOrder, finished orders are only found within the retention window.
+func (o *Orderbook) ClientOrder(accountID, sessionID uint64, clientOrderID string) (OrderInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.now()
+	o.orders.prune(now)
+
+	orderID, ok := o.orders.clientOrder(newClientOrderKey(accountID, sessionID, clientOrderID), now)
+	if !ok {
+		return OrderInfo{}, fmt.Errorf("get order %q: %w", clientOrderID, ErrOrderNotFound)
+	}
+
+	order, ok := o.orders.get(orderID)
+	if !ok {
+		return OrderInfo{}, fmt.Errorf("get order %q: %w", clientOrderID, ErrOrderNotFound)
+	}
+
+	return order.Info(), nil
+}
+
+// OpenOrders returns every order resting in the book, ordered by ID.
+func (o *Orderbook) OpenOrders() []OrderInfo {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.orders.openOrders()
 }
 
 func max(a, b int) int {
@@ -2,7 +2,9 @@ package lob
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sashajdn/orderbook/pkg/slog"
 	"github.com/stretchr/testify/assert"
@@ -81,6 +83,366 @@ func TestLOB_SanityCheck(t *testing.T) {
 	assert.Equal(t, Price(6), spread)
 }
 
+func TestLOB_OrderState(t *testing.T) {
+	t.Parallel()
+
+	lob := NewOrderbook(128)
+
+	makerID, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1001, 2))
+	require.NoError(t, err)
+
+	otherMakerID, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1002, 1))
+	require.NoError(t, err)
+
+	restingID, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 999, 1))
+	require.NoError(t, err)
+
+	assert.Len(t, lob.OpenOrders(), 3)
+
+	takerID, err := lob.PlaceOrder(NewOrder(MarketOrder, BuySide, 0, 1))
+	require.NoError(t, err)
+
+	maker, err := lob.GetOrder(makerID)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusPartiallyFilled, maker.Status)
+	assert.Equal(t, Size(1), maker.FilledSize)
+	assert.Equal(t, Size(1), maker.RemainingSize)
+
+	taker, err := lob.GetOrder(takerID)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusFilled, taker.Status)
+	assert.Equal(t, Price(1001), taker.AvgPrice)
+
+	// Sweep the rest of the asks, leaving the taker partially filled & expired.
+	sweepID, err := lob.PlaceOrder(NewOrder(MarketOrder, BuySide, 0, 4))
+	require.NoError(t, err)
+
+	sweep, err := lob.GetOrder(sweepID)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusExpired, sweep.Status)
+	assert.Equal(t, Size(2), sweep.FilledSize)
+	assert.Equal(t, Price(1001.5), sweep.AvgPrice)
+
+	for _, id := range []uint64{makerID, otherMakerID} {
+		order, err := lob.GetOrder(id)
+		require.NoError(t, err)
+		assert.Equal(t, OrderStatusFilled, order.Status)
+	}
+
+	// No liquidity left on the ask side.
+	rejectedID, err := lob.PlaceOrder(NewOrder(MarketOrder, BuySide, 0, 1))
+	require.Error(t, err)
+
+	rejected, err := lob.GetOrder(rejectedID)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusRejected, rejected.Status)
+
+	require.NoError(t, lob.CancelOrder(restingID))
+	require.Error(t, lob.CancelOrder(restingID))
+
+	resting, err := lob.GetOrder(restingID)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusCancelled, resting.Status)
+
+	assert.Empty(t, lob.OpenOrders())
+	assert.Equal(t, 0, lob.Depth())
+}
+
+func TestLOB_CrossingLimitOrder(t *testing.T) {
+	t.Parallel()
+
+	lob := NewOrderbook(128)
+
+	_, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1001, 1))
+	require.NoError(t, err)
+
+	_, err = lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1003, 1))
+	require.NoError(t, err)
+
+	var trades []TradeEvent
+	lob.Subscribe(func(event Event) {
+		if trade, ok := event.(TradeEvent); ok {
+			trades = append(trades, trade)
+		}
+	})
+
+	// Only matches up to the limit price, resting the remainder.
+	id, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 1002, 3))
+	require.NoError(t, err)
+
+	require.Len(t, trades, 1)
+	assert.Equal(t, Price(1001), trades[0].Price)
+	assert.Equal(t, BuySide, trades[0].AggressorSide)
+	assert.Equal(t, id, trades[0].TakerOrderID)
+
+	order, err := lob.GetOrder(id)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusPartiallyFilled, order.Status)
+	assert.Equal(t, Size(2), order.RemainingSize)
+
+	bb, err := lob.BestBid()
+	require.NoError(t, err)
+	assert.Equal(t, Price(1002), bb)
+
+	ba, err := lob.BestAsk()
+	require.NoError(t, err)
+	assert.Equal(t, Price(1003), ba)
+}
+
+func TestLOB_OrderRetention(t *testing.T) {
+	t.Parallel()
+
+	clock := NewVirtualClock(time.Now())
+
+	lob := NewOrderbook(128, WithClock(clock), WithOrderRetention(time.Minute))
+
+	id, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 999, 1))
+	require.NoError(t, err)
+	require.NoError(t, lob.CancelOrder(id))
+
+	clock.Advance(59 * time.Second)
+	_, err = lob.GetOrder(id)
+	require.NoError(t, err)
+
+	clock.Advance(time.Second)
+	_, err = lob.GetOrder(id)
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+}
+
+func TestLOB_ClientOrderID(t *testing.T) {
+	t.Parallel()
+
+	clock := NewVirtualClock(time.Now())
+
+	lob := NewOrderbook(128, WithClock(clock), WithClientOrderIDWindow(time.Minute))
+
+	place := func(accountID, sessionID uint64, orderType OrderType, side OrderSide, size Size, clientOrderID string) (uint64, error) {
+		order := NewOrder(orderType, side, 999, size)
+		order.AccountID, order.SessionID, order.ClientOrderID = accountID, sessionID, clientOrderID
+		return lob.PlaceOrder(order)
+	}
+
+	assertDuplicate := func(t *testing.T, want uint64, got uint64, err error) {
+		t.Helper()
+
+		var rejectErr *RejectError
+		require.ErrorAs(t, err, &rejectErr)
+		assert.Equal(t, RejectReasonDuplicateOrder, rejectErr.Reason)
+		assert.Equal(t, want, got)
+	}
+
+	id, err := place(7, 1, LimitOrder, BuySide, 1, "a")
+	require.NoError(t, err)
+
+	// Duplicates are rejected without being sequenced, returning the original order's ID.
+	seq := lob.LastSeq()
+	dup, err := place(7, 2, LimitOrder, BuySide, 1, "a")
+	assertDuplicate(t, id, dup, err)
+	assert.Equal(t, seq, lob.LastSeq())
+
+	// As are client order IDs too long to journal.
+	_, err = place(7, 1, LimitOrder, BuySide, 1, strings.Repeat("x", MaxClientOrderIDLength+1))
+	var rejectErr *RejectError
+	require.ErrorAs(t, err, &rejectErr)
+	assert.Equal(t, RejectReasonInvalidOrder, rejectErr.Reason)
+	assert.Equal(t, seq, lob.LastSeq())
+
+	// Client order IDs are scoped by account, or by session without one.
+	_, err = place(8, 1, LimitOrder, BuySide, 1, "a")
+	require.NoError(t, err)
+
+	anonymous, err := place(0, 1, LimitOrder, BuySide, 1, "a")
+	require.NoError(t, err)
+	_, err = place(0, 2, LimitOrder, BuySide, 1, "a")
+	require.NoError(t, err)
+	dup, err = place(0, 1, LimitOrder, BuySide, 1, "a")
+	assertDuplicate(t, anonymous, dup, err)
+
+	info, err := lob.ClientOrder(7, 0, "a")
+	require.NoError(t, err)
+	assert.Equal(t, id, info.ID)
+	assert.Equal(t, "a", info.ClientOrderID)
+
+	_, err = lob.ClientOrder(7, 0, "b")
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+
+	// Orders that finish as they're placed still use their client order IDs.
+	filled, err := place(9, 0, MarketOrder, SellSide, 0.5, "m")
+	require.NoError(t, err)
+	dup, err = place(9, 0, MarketOrder, SellSide, 0.5, "m")
+	assertDuplicate(t, filled, dup, err)
+
+	// Finished orders' client order IDs can be reused once the window's passed, including when restored from a snapshot.
+	require.NoError(t, lob.CancelOrder(id))
+
+	restored := NewOrderbook(128, WithClock(clock), WithClientOrderIDWindow(time.Minute))
+	require.NoError(t, restored.Restore(lob.Snapshot()))
+	assert.Equal(t, lob.Snapshot(), restored.Snapshot())
+
+	clock.Advance(59 * time.Second)
+	dup, err = place(7, 1, LimitOrder, BuySide, 1, "a")
+	assertDuplicate(t, id, dup, err)
+
+	order := NewOrder(LimitOrder, BuySide, 999, 1)
+	order.AccountID, order.ClientOrderID = 7, "a"
+	dup, err = restored.PlaceOrder(order)
+	assertDuplicate(t, id, dup, err)
+
+	clock.Advance(time.Second)
+	reused, err := place(7, 1, LimitOrder, BuySide, 1, "a")
+	require.NoError(t, err)
+	assert.NotEqual(t, id, reused)
+}
+
+func TestLOB_Timestamps(t *testing.T) {
+	t.Parallel()
+
+	clock := NewVirtualClock(time.Unix(1700000000, 0))
+	start := clock.Now()
+
+	journal := &testJournal{}
+	lob := NewOrderbook(128, WithClock(clock), WithJournal(journal))
+
+	var trades []TradeEvent
+	lob.Subscribe(func(event Event) {
+		if e, ok := event.(TradeEvent); ok {
+			trades = append(trades, e)
+		}
+	})
+
+	// Orders not stamped as received are stamped as they're placed.
+	makerID, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 100, 2))
+	require.NoError(t, err)
+
+	maker, err := lob.GetOrder(makerID)
+	require.NoError(t, err)
+	assert.Equal(t, start, maker.ReceivedAt)
+	assert.Equal(t, start, maker.SequencedAt)
+
+	// As if a gateway received the taker a microsecond before it's placed.
+	taker := NewOrder(MarketOrder, BuySide, 0, 1)
+	taker.ReceivedAt = clock.Advance(time.Second)
+	sequencedAt := clock.Advance(time.Microsecond)
+
+	takerID, err := lob.PlaceOrder(taker)
+	require.NoError(t, err)
+
+	require.Len(t, trades, 1)
+	assert.Equal(t, takerID, trades[0].TakerOrderID)
+	assert.Equal(t, start.Add(time.Second), trades[0].ReceivedAt)
+	assert.Equal(t, sequencedAt, trades[0].SequencedAt)
+
+	// Replaying stamps the trade with when the taker was sequenced, not when it's replayed.
+	replay := NewOrderbook(128, WithClock(NewVirtualClock(start.Add(time.Hour))))
+
+	var replayed []TradeEvent
+	replay.Subscribe(func(event Event) {
+		if e, ok := event.(TradeEvent); ok {
+			replayed = append(replayed, e)
+		}
+	})
+	for _, cmd := range journal.commands {
+		require.NoError(t, replay.Apply(cmd))
+	}
+
+	require.Len(t, replayed, 1)
+	assert.Equal(t, sequencedAt, replayed[0].SequencedAt)
+
+	// Amending restamps the order as received & sequenced.
+	amendedAt := clock.Advance(time.Second)
+	require.NoError(t, lob.EditOrder(&Order{ID: makerID, Price: 101, Size: 2}))
+
+	maker, err = lob.GetOrder(makerID)
+	require.NoError(t, err)
+	assert.Equal(t, amendedAt, maker.ReceivedAt)
+	assert.Equal(t, amendedAt, maker.SequencedAt)
+}
+
+func TestLOB_EditOrder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		price         Price
+		size          Size
+		expectedFirst bool
+	}{
+		{
+			name:          "reducing_size_keeps_priority",
+			price:         1000,
+			size:          1,
+			expectedFirst: true,
+		},
+		{
+			name:          "increasing_size_loses_priority",
+			price:         1000,
+			size:          3,
+			expectedFirst: false,
+		},
+		{
+			name:          "repricing_loses_priority",
+			price:         999,
+			size:          2,
+			expectedFirst: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lob := NewOrderbook(128)
+
+			first, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 1000, 2))
+			require.NoError(t, err)
+
+			_, err = lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 1000, 1))
+			require.NoError(t, err)
+
+			require.NoError(t, lob.EditOrder(&Order{ID: first, Price: tt.price, Size: tt.size}))
+
+			edited, err := lob.GetOrder(first)
+			require.NoError(t, err)
+			assert.Equal(t, tt.price, edited.Price)
+			assert.Equal(t, tt.size, edited.RemainingSize)
+
+			_, err = lob.PlaceOrder(NewOrder(MarketOrder, SellSide, 0, 1))
+			require.NoError(t, err)
+
+			edited, err = lob.GetOrder(first)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedFirst, edited.FilledSize > 0)
+		})
+	}
+}
+
+func TestLOB_EditOrderCrossing(t *testing.T) {
+	t.Parallel()
+
+	lob := NewOrderbook(128)
+
+	_, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1002, 1))
+	require.NoError(t, err)
+
+	id, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 1000, 3))
+	require.NoError(t, err)
+
+	require.NoError(t, lob.EditOrder(&Order{ID: id, Price: 1002, Size: 3}))
+
+	order, err := lob.GetOrder(id)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusPartiallyFilled, order.Status)
+	assert.Equal(t, Size(2), order.RemainingSize)
+
+	// The new size includes what's already filled.
+	err = lob.EditOrder(&Order{ID: id, Price: 1002, Size: 1})
+	assertRejected(t, err, RejectReasonInvalidOrder)
+
+	err = lob.EditOrder(&Order{ID: id + 100, Price: 1002, Size: 1})
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+}
+
 func addSymmetricalDepthOf3(t *testing.T, lob *Orderbook) {
 	// Print book
 	printBook(t, lob)
@@ -197,3 +559,40 @@ func printBook(_ *testing.T, lob *Orderbook) {
 	fmt.Println()
 
 }
+
+func TestLOB_TopOfBookReadsWhilePlacing(t *testing.T) {
+	t.Parallel()
+
+	lob := NewOrderbook(128)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			_, _ = lob.PlaceOrder(NewOrder(LimitOrder, BuySide, Price(100-i%10), 1))
+			_, _ = lob.PlaceOrder(NewOrder(LimitOrder, SellSide, Price(101+i%10), 1))
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			mid, err := lob.Mid()
+			require.NoError(t, err)
+			assert.Equal(t, Price(100.5), mid)
+
+			bids, asks := lob.Volume()
+			assert.Equal(t, Size(100), bids)
+			assert.Equal(t, Size(100), asks)
+			assert.Equal(t, 10, lob.Depth())
+			return
+		default:
+			_, _ = lob.Mid()
+			_, _ = lob.BestBid()
+			_, _ = lob.BestAsk()
+			_ = lob.Depth()
+			_, _ = lob.Volume()
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package lob
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// MassCancelRequest selects the resting orders to cancel. Zero valued fields match every order, so the zero request cancels the whole book.
+type MassCancelRequest struct {
+	AccountID uint64
+	SessionID uint64
+	Side      OrderSide
+
+	// CancelOnDisconnectOnly only matches orders flagged to be cancelled on disconnect.
+	CancelOnDisconnectOnly bool
+
+	// Beyond only matches orders priced at or further from the touch than it; bids at or below, asks at or above.
+	Beyond Price
+}
+
+func (m MassCancelRequest) matches(order *Order) bool {
+	if m.AccountID != 0 && order.AccountID != m.AccountID {
+		return false
+	}
+
+	if m.SessionID != 0 && order.SessionID != m.SessionID {
+		return false
+	}
+
+	if m.CancelOnDisconnectOnly && !order.CancelOnDisconnect {
+		return false
+	}
+
+	if m.Side != 0 && order.Side != m.Side {
+		return false
+	}
+
+	if m.Beyond != 0 {
+		switch order.Side {
+		case BuySide:
+			return order.Price <= m.Beyond
+		case SellSide:
+			return order.Price >= m.Beyond
+		}
+	}
+
+	return true
+}
+
+func (m MassCancelRequest) String() string {
+	return fmt.Sprintf(`account=%d session=%d side=%s beyond=%.6f cod_only=%t`, m.AccountID, m.SessionID, m.Side, m.Beyond, m.CancelOnDisconnectOnly)
+}
+
+type CancelReport struct {
+	OrderID       uint64
+	AccountID     uint64
+	Side          OrderSide
+	Price         Price
+	CancelledSize Size
+	Err           error
+}
+
+// MassCancel cancels every resting order matched by the request. It holds the book for the whole operation,
+// so no order matched by the request can trade while it runs. Reports are ordered by order ID.
+// Unlike single cancels, mass cancels are accepted in every trading state, so cancel on disconnect always pulls a session's orders.
+func (o *Orderbook) MassCancel(req MassCancelRequest) ([]CancelReport, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cmd := Command{Type: CommandMassCancel, MassCancel: req}
+	if err := o.sequence(&cmd); err != nil {
+		return nil, fmt.Errorf("mass cancel: %w", err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	return o.massCancel(req, cmd.Time)
+}
+
+func (o *Orderbook) massCancel(req MassCancelRequest, now time.Time) ([]CancelReport, error) {
+	o.begin(now)
+
+	toCancel := make([]*Order, 0, len(o.orders.open))
+	for _, order := range o.orders.open {
+		if req.matches(order) {
+			toCancel = append(toCancel, order)
+		}
+	}
+
+	sort.Slice(toCancel, func(i, j int) bool {
+		return toCancel[i].ID < toCancel[j].ID
+	})
+
+	slog.Debug("LOB: mass cancelling orders", "request", req.String(), "orders", len(toCancel))
+
+	reports := make([]CancelReport, 0, len(toCancel))
+	for _, order := range toCancel {
+		report := CancelReport{
+			OrderID:       order.ID,
+			AccountID:     order.AccountID,
+			Side:          order.Side,
+			Price:         order.Price,
+			CancelledSize: order.remainingSize,
+		}
+
+		if err := o.cancel(order, now); err != nil {
+			report.CancelledSize = 0
+			report.Err = fmt.Errorf("cancel order %d: %w", order.ID, err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	if !o.state.Matches() && len(reports) > 0 {
+		o.publishIndicative(now)
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,108 @@
+package lob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLOB_MassCancel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		request           MassCancelRequest
+		expectedCancelled []uint64
+	}{
+		{
+			name:              "whole_book",
+			request:           MassCancelRequest{},
+			expectedCancelled: []uint64{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name: "by_account",
+			request: MassCancelRequest{
+				AccountID: 1,
+			},
+			expectedCancelled: []uint64{1, 2, 4},
+		},
+		{
+			name: "by_side",
+			request: MassCancelRequest{
+				Side: SellSide,
+			},
+			expectedCancelled: []uint64{4, 5, 6},
+		},
+		{
+			name: "by_account_and_side",
+			request: MassCancelRequest{
+				AccountID: 2,
+				Side:      BuySide,
+			},
+			expectedCancelled: []uint64{3},
+		},
+		{
+			name: "beyond_price",
+			request: MassCancelRequest{
+				Beyond: 998,
+			},
+			expectedCancelled: []uint64{2, 3, 4, 5, 6},
+		},
+		{
+			name: "sells_beyond_price",
+			request: MassCancelRequest{
+				Side:   SellSide,
+				Beyond: 1002,
+			},
+			expectedCancelled: []uint64{5, 6},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lob := NewOrderbook(128)
+			for _, order := range []struct {
+				accountID uint64
+				side      OrderSide
+				price     Price
+			}{
+				{1, BuySide, 999},
+				{1, BuySide, 998},
+				{2, BuySide, 997},
+				{1, SellSide, 1001},
+				{2, SellSide, 1002},
+				{2, SellSide, 1003},
+			} {
+				o := NewOrder(LimitOrder, order.side, order.price, 1)
+				o.AccountID = order.accountID
+
+				_, err := lob.PlaceOrder(o)
+				require.NoError(t, err)
+			}
+
+			reports, err := lob.MassCancel(tt.request)
+			require.NoError(t, err)
+
+			cancelled := make([]uint64, 0, len(reports))
+			for _, report := range reports {
+				require.NoError(t, report.Err)
+				assert.Equal(t, Size(1), report.CancelledSize)
+				cancelled = append(cancelled, report.OrderID)
+
+				order, err := lob.GetOrder(report.OrderID)
+				require.NoError(t, err)
+				assert.Equal(t, OrderStatusCancelled, order.Status)
+			}
+
+			assert.Equal(t, tt.expectedCancelled, cancelled)
+			assert.Len(t, lob.OpenOrders(), 6-len(tt.expectedCancelled))
+
+			bids, asks := lob.Volume()
+			assert.Equal(t, Size(6-len(tt.expectedCancelled)), bids+asks)
+		})
+	}
+}
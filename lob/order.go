@@ -1,6 +1,12 @@
 package lob
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
+
+// MaxClientOrderIDLength bounds a client order ID.
+const MaxClientOrderIDLength = 64
 
 type OrderType byte
 
@@ -38,6 +44,46 @@ func (o OrderSide) String() string {
 	}
 }
 
+type OrderStatus byte
+
+const (
+	OrderStatusNew OrderStatus = iota + 1
+	OrderStatusPartiallyFilled
+	OrderStatusFilled
+	OrderStatusCancelled
+	OrderStatusRejected
+	OrderStatusExpired
+)
+
+func (o OrderStatus) String() string {
+	switch o {
+	case OrderStatusNew:
+		return "new"
+	case OrderStatusPartiallyFilled:
+		return "partially_filled"
+	case OrderStatusFilled:
+		return "filled"
+	case OrderStatusCancelled:
+		return "cancelled"
+	case OrderStatusRejected:
+		return "rejected"
+	case OrderStatusExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// Finished returns true if the order can no longer trade.
+func (o OrderStatus) Finished() bool {
+	switch o {
+	case OrderStatusFilled, OrderStatusCancelled, OrderStatusRejected, OrderStatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
 func NewOrder(orderType OrderType, side OrderSide, price Price, size Size) *Order {
 	return &Order{
 		OrderType:     orderType,
@@ -54,7 +100,33 @@ type Order struct {
 	Price         Price
 	Size          Size
 	ID            uint64
+	AccountID     uint64
 	remainingSize Size
+
+	// SessionID is the order entry session that placed the order; when CancelOnDisconnect is set,
+	// the order is cancelled should that session disconnect.
+	SessionID          uint64
+	CancelOnDisconnect bool
+
+	// ClientOrderID is the client's own ID for the order, unique to its account, or its session if it has no account.
+	// It's optional; orders without one can only be referred to by ID.
+	ClientOrderID string
+
+	// ReceivedAt is when the order, or its last amendment, was received, e.g. by the gateway that read it off the wire; if
+	// it's zero it's stamped as the Orderbook receives it.
+	ReceivedAt time.Time
+
+	status         OrderStatus
+	rejectReason   RejectReason
+	filledSize     Size
+	filledNotional float64
+	fees           float64
+	updatedAt      time.Time
+	sequencedAt    time.Time
+
+	// reserved is what's still held in the ledger for the order, and reservedFee what's held in the quote asset for its fees.
+	reserved    float64
+	reservedFee float64
 }
 
 func (o *Order) Validate() error {
@@ -62,13 +134,111 @@ func (o *Order) Validate() error {
 		return fmt.Errorf("invalid order; nil")
 	}
 
+	if len(o.ClientOrderID) > MaxClientOrderIDLength {
+		return fmt.Errorf("invalid order; client order id longer than %d", MaxClientOrderIDLength)
+	}
+
 	if o.Size == 0 {
 		return fmt.Errorf(`invalid order; zero size`)
 	}
 
+	if o.Size < 0 {
+		return fmt.Errorf("invalid order; negative size")
+	}
+
+	switch o.Side {
+	case BuySide, SellSide:
+	default:
+		return fmt.Errorf("invalid order; unknown side")
+	}
+
+	switch o.OrderType {
+	case LimitOrder:
+		if o.Price <= 0 {
+			return fmt.Errorf("invalid order; limit price must be positive")
+		}
+	case MarketOrder:
+	default:
+		return fmt.Errorf("invalid order; unknown order type")
+	}
+
 	return nil
 }
 
 func (o *Order) String() string {
 	return fmt.Sprintf(`%s @ %.6f : id=%d type=%s size=%.6f remsize=%.6f`, o.Side, o.Price, o.ID, o.OrderType, o.Size, o.remainingSize)
 }
+
+// Info returns a point in time copy of the order's state.
+func (o *Order) Info() OrderInfo {
+	var avgPrice Price
+	if o.filledSize > 0 {
+		avgPrice = Price(o.filledNotional / float64(o.filledSize))
+	}
+
+	return OrderInfo{
+		ID:            o.ID,
+		AccountID:     o.AccountID,
+		SessionID:     o.SessionID,
+		ClientOrderID: o.ClientOrderID,
+		OrderType:     o.OrderType,
+		Side:          o.Side,
+		Price:         o.Price,
+		Size:          o.Size,
+		Status:        o.status,
+		RejectReason:  o.rejectReason,
+		FilledSize:    o.filledSize,
+		RemainingSize: o.remainingSize,
+		AvgPrice:      avgPrice,
+		Fees:          o.fees,
+		UpdatedAt:     o.updatedAt,
+		ReceivedAt:    o.ReceivedAt,
+		SequencedAt:   o.sequencedAt,
+	}
+}
+
+// recordFill accumulates a fill against the order; the caller is responsible for the remaining size.
+func (o *Order) recordFill(price Price, size Size, now time.Time) {
+	o.filledSize += size
+	o.filledNotional += float64(price) * float64(size)
+	o.updatedAt = now
+
+	if o.filledSize >= o.Size {
+		o.status = OrderStatusFilled
+		return
+	}
+
+	o.status = OrderStatusPartiallyFilled
+}
+
+func (o *Order) setStatus(status OrderStatus, now time.Time) {
+	o.status = status
+	o.updatedAt = now
+}
+
+// OrderInfo is a snapshot of an order's lifecycle state.
+type OrderInfo struct {
+	ID            uint64
+	AccountID     uint64
+	SessionID     uint64
+	ClientOrderID string
+	OrderType     OrderType
+	Side          OrderSide
+	Price         Price
+	Size          Size
+	Status        OrderStatus
+	RejectReason  RejectReason
+	FilledSize    Size
+	RemainingSize Size
+	AvgPrice      Price
+	Fees          float64
+	UpdatedAt     time.Time
+
+	// ReceivedAt & SequencedAt are when the order, or its last amendment, was received & sequenced.
+	ReceivedAt  time.Time
+	SequencedAt time.Time
+}
+
+func (o OrderInfo) String() string {
+	return fmt.Sprintf(`%d %s %s %s @ %.6f : size=%.6f filled=%.6f avg=%.6f`, o.ID, o.Status, o.Side, o.OrderType, o.Price, o.Size, o.FilledSize, o.AvgPrice)
+}
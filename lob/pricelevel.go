@@ -68,8 +68,45 @@ func (p *PriceLevel) Append(order *Order) {
 	slog.Debug("Appending order to pricelevel: ", "order", order.String(), "pricelevel", p.String())
 
 	p.orderQueue = append(p.orderQueue, order)
-	p.totalSize += order.Size
+	p.totalSize += order.remainingSize
+}
+
+// Remove removes the order with the given ID from the queue, returning it if found.
+func (p *PriceLevel) Remove(orderID uint64) (*Order, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, order := range p.orderQueue {
+		if order.ID != orderID {
+			continue
+		}
+
+		p.orderQueue = append(p.orderQueue[:i], p.orderQueue[i+1:]...)
+		p.totalSize -= order.remainingSize
+
+		return order, true
+	}
+
+	return nil, false
+}
+
+// Resize sets the remaining size of the order with the given ID, keeping its place in the queue.
+func (p *PriceLevel) Resize(orderID uint64, remaining Size) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, order := range p.orderQueue {
+		if order.ID != orderID {
+			continue
+		}
+
+		p.totalSize += remaining - order.remainingSize
+		order.remainingSize = remaining
+
+		return true
+	}
 
+	return false
 }
 
 func (p *PriceLevel) Take(size Size) (Size, []*FillEvent) {
@@ -115,7 +152,7 @@ func (p *PriceLevel) Take(size Size) (Size, []*FillEvent) {
 				Size:    remainingSize,
 			})
 
-			order.remainingSize -= -remainingSize
+			order.remainingSize -= remainingSize
 			p.totalSize -= remainingSize
 
 			return 0, fills
@@ -57,6 +57,36 @@ func TestPricelevel_Take(t *testing.T) {
 			},
 			expectedRemainingSizes: []Size{0},
 		},
+		{
+			name: `partial_fills_across_orders`,
+			ordersToAppend: []*Order{
+				{
+					ID:            1,
+					Size:          2,
+					remainingSize: 2,
+				},
+				{
+					ID:            2,
+					Size:          2,
+					remainingSize: 2,
+				},
+			},
+			ordersToTake: []*Order{
+				{
+					Size:          1,
+					remainingSize: 1,
+				},
+				{
+					Size:          2,
+					remainingSize: 2,
+				},
+				{
+					Size:          2,
+					remainingSize: 2,
+				},
+			},
+			expectedRemainingSizes: []Size{0, 0, 1},
+		},
 	}
 
 	for _, tt := range tests {
@@ -78,6 +108,25 @@ func TestPricelevel_Take(t *testing.T) {
 	}
 }
 
+func TestPricelevel_Resize(t *testing.T) {
+	t.Parallel()
+
+	pl := NewPriceLevel(1000.0)
+	pl.Append(&Order{ID: 1, Price: 1000, Size: 2, remainingSize: 2})
+	pl.Append(&Order{ID: 2, Price: 1000, Size: 3, remainingSize: 3})
+
+	require.True(t, pl.Resize(2, 1))
+	assert.Equal(t, Size(3), pl.Volume())
+	assert.Equal(t, uint64(1), pl.orderQueue[0].ID, "resizing must keep the order's place in the queue")
+
+	assert.False(t, pl.Resize(3, 1))
+	assert.Equal(t, Size(3), pl.Volume())
+
+	size, fills := pl.Take(3)
+	assert.Equal(t, Size(0), size)
+	assert.Len(t, fills, 2)
+}
+
 func generateOrders(n, m uint, midpoint, spread float64, sizeRange []uint64) []*Order {
 	orders := make([]*Order, 0, n+m)
 
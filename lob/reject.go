@@ -0,0 +1,72 @@
+package lob
+
+import "fmt"
+
+type RejectReason uint16
+
+const (
+	RejectReasonInvalidOrder RejectReason = iota + 1
+	RejectReasonNoLiquidity
+	RejectReasonTradingState
+	RejectReasonPriceBand
+	RejectReasonMaxOrderSize
+	RejectReasonMaxOrderNotional
+	RejectReasonMaxOpenOrders
+	RejectReasonMaxGrossPosition
+	RejectReasonMaxNetPosition
+	RejectReasonCreditLimit
+	RejectReasonInsufficientBalance
+	RejectReasonDuplicateOrder
+)
+
+func (r RejectReason) String() string {
+	switch r {
+	case RejectReasonInvalidOrder:
+		return "invalid_order"
+	case RejectReasonNoLiquidity:
+		return "no_liquidity"
+	case RejectReasonTradingState:
+		return "trading_state"
+	case RejectReasonPriceBand:
+		return "price_band"
+	case RejectReasonMaxOrderSize:
+		return "max_order_size"
+	case RejectReasonMaxOrderNotional:
+		return "max_order_notional"
+	case RejectReasonMaxOpenOrders:
+		return "max_open_orders"
+	case RejectReasonMaxGrossPosition:
+		return "max_gross_position"
+	case RejectReasonMaxNetPosition:
+		return "max_net_position"
+	case RejectReasonCreditLimit:
+		return "credit_limit"
+	case RejectReasonInsufficientBalance:
+		return "insufficient_balance"
+	case RejectReasonDuplicateOrder:
+		return "duplicate_order"
+	default:
+		return "unknown"
+	}
+}
+
+// RejectError is returned whenever the Orderbook rejects a request; use errors.As to recover the reason.
+type RejectError struct {
+	Reason RejectReason
+	Err    error
+}
+
+func (r *RejectError) Error() string {
+	return fmt.Sprintf("rejected (%s): %v", r.Reason, r.Err)
+}
+
+func (r *RejectError) Unwrap() error {
+	return r.Err
+}
+
+func newRejectError(reason RejectReason, format string, args ...any) *RejectError {
+	return &RejectError{
+		Reason: reason,
+		Err:    fmt.Errorf(format, args...),
+	}
+}
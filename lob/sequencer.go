@@ -3,17 +3,21 @@ package lob
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
-var defaultSequencer = &Sequencer{}
+var defaultSequencer = NewSequencer(NewMonotonicClock())
 
-func NewSequencer() *Sequencer {
-	return &Sequencer{}
+// NewSequencer returns a sequencer that stamps with the clock's time.
+func NewSequencer(clock Clock) *Sequencer {
+	return &Sequencer{clock: clock}
 }
 
+// Sequencer hands out sequence numbers, & stamps what it sequences with its clock's time.
 type Sequencer struct {
-	mu sync.Mutex
-	id uint64
+	mu    sync.Mutex
+	id    uint64
+	clock Clock
 }
 
 func (s *Sequencer) NewOrder(orderType OrderType, side OrderSide, price Price, size Size) *Order {
@@ -25,16 +29,44 @@ func (s *Sequencer) NewOrder(orderType OrderType, side OrderSide, price Price, s
 		Size:          size,
 		ID:            orderID,
 		remainingSize: size,
+		sequencedAt:   s.Now(),
 	}
 }
 
+// Stamp assigns the order the next sequence number as its ID, stamping it as sequenced now.
 func (s *Sequencer) Stamp(order *Order) *Order {
 	order.ID = s.generateNextID()
+	order.sequencedAt = s.Now()
 	return order
 }
 
+// Now returns the clock's time, without any monotonic reading, so it's the same once journaled & read back.
+func (s *Sequencer) Now() time.Time {
+	return s.clock.Now().Round(0)
+}
+
 func (s *Sequencer) generateNextID() uint64 {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	return atomic.AddUint64(&s.id, 1)
 }
+
+// Next returns the next sequence number.
+func (s *Sequencer) Next() uint64 {
+	return s.generateNextID()
+}
+
+// Last returns the last sequence number handed out.
+func (s *Sequencer) Last() uint64 {
+	return atomic.LoadUint64(&s.id)
+}
+
+// advance moves the sequencer forward to the given sequence number, e.g. when applying journaled commands.
+func (s *Sequencer) advance(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if seq > s.id {
+		atomic.StoreUint64(&s.id, seq)
+	}
+}
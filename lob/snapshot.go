@@ -0,0 +1,375 @@
+package lob
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Snapshot is the Orderbook's full state as of a sequence number, for persisting and restoring. Restoring a snapshot, then
+// applying every command after its Seq, rebuilds the book exactly; order IDs, queue priority & running totals included.
+//
+// Configuration passed as options isn't included, though fee tiers & risk limits set since are, as is the state of any pre-trade
+// check that's a CheckSnapshotter. Other subscribers, such as PnL, snapshot themselves.
+type Snapshot struct {
+	Seq            uint64
+	State          TradingState
+	Schedule       []ScheduledTransition
+	LastTradePrice Price
+
+	// Bids & Asks are ordered best price first, with each level's orders in queue order.
+	Bids []LevelSnapshot
+	Asks []LevelSnapshot
+
+	// Finished are the finished orders still retained for GetOrder, in the order they finished.
+	Finished []OrderSnapshot
+
+	// ClientOrders are the client order IDs of finished orders that can't yet be reused, in the order they finished.
+	ClientOrders []ClientOrderSnapshot
+
+	Bands *BandsSnapshot
+
+	// Balances is the ledger's state, if the Orderbook has one.
+	Balances []AccountBalance
+
+	// Fees are the fee schedule's tiers & account assignments, if the Orderbook has one.
+	Fees *FeesSnapshot
+
+	// RiskLimits are the limits set through SetRiskLimits, ordered by account.
+	RiskLimits []AccountRiskLimits
+
+	// Checks are the states of the pre-trade checks that are CheckSnapshotters, in the order they were added.
+	Checks [][]byte
+
+	// RollingHash carries on the hashes recorded to a HashLog.
+	RollingHash uint64
+}
+
+type ScheduledTransition struct {
+	At     time.Time
+	To     TradingState
+	Reason string
+}
+
+type LevelSnapshot struct {
+	Price Price
+	// TotalSize is kept as the level's running total, rather than summed again on restore, so it's restored exactly.
+	TotalSize Size
+	Orders    []OrderSnapshot
+}
+
+// OrderSnapshot is every field of an order, exported so it can be serialized.
+type OrderSnapshot struct {
+	ID                 uint64
+	OrderType          OrderType
+	Side               OrderSide
+	Price              Price
+	Size               Size
+	AccountID          uint64
+	SessionID          uint64
+	CancelOnDisconnect bool
+	ClientOrderID      string
+	RemainingSize      Size
+	Status             OrderStatus
+	RejectReason       RejectReason
+	FilledSize         Size
+	FilledNotional     float64
+	Fees               float64
+	UpdatedAt          time.Time
+	ReceivedAt         time.Time
+	SequencedAt        time.Time
+	Reserved           float64
+	ReservedFee        float64
+}
+
+type ClientOrderSnapshot struct {
+	AccountID     uint64
+	SessionID     uint64
+	ClientOrderID string
+	OrderID       uint64
+	FinishedAt    time.Time
+}
+
+// FeesSnapshot is a fee schedule's tiers & account assignments; its default tier & fee account are configuration.
+type FeesSnapshot struct {
+	Tiers    []NamedFeeTier
+	Accounts []AccountFeeTier
+}
+
+type NamedFeeTier struct {
+	Name string
+	Tier FeeTier
+}
+
+type AccountFeeTier struct {
+	AccountID uint64
+	Tier      string
+}
+
+type AccountRiskLimits struct {
+	AccountID uint64
+	Limits    RiskLimits
+}
+
+type BandsSnapshot struct {
+	Trades []BandTrade
+	Sum    float64
+	Last   Price
+}
+
+type BandTrade struct {
+	At    time.Time
+	Price Price
+}
+
+// Snapshot captures the Orderbook's state as of the last command applied.
+func (o *Orderbook) Snapshot() Snapshot {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	snapshot := Snapshot{
+		Seq:            o.sequencer.Last(),
+		State:          o.state,
+		LastTradePrice: o.lastTradePrice,
+		Bids:           snapshotLevels(o.bids),
+		Asks:           snapshotLevels(o.asks),
+		RollingHash:    o.rollingHash,
+	}
+
+	for _, scheduled := range o.schedule {
+		snapshot.Schedule = append(snapshot.Schedule, ScheduledTransition{At: scheduled.at, To: scheduled.to, Reason: scheduled.reason})
+	}
+
+	seen := make(map[uint64]bool, len(o.orders.finished))
+	for _, expiry := range o.orders.expiries {
+		order, ok := o.orders.finished[expiry.id]
+		if !ok || seen[expiry.id] {
+			continue
+		}
+
+		seen[expiry.id] = true
+		snapshot.Finished = append(snapshot.Finished, snapshotOrder(order))
+	}
+
+	for _, expiry := range o.orders.clientExpiries {
+		if o.orders.clientOrders[expiry.key].orderID != expiry.orderID {
+			continue
+		}
+
+		snapshot.ClientOrders = append(snapshot.ClientOrders, ClientOrderSnapshot{
+			AccountID:     expiry.key.accountID,
+			SessionID:     expiry.key.sessionID,
+			ClientOrderID: expiry.key.id,
+			OrderID:       expiry.orderID,
+			FinishedAt:    expiry.at,
+		})
+	}
+
+	if o.bands != nil {
+		bands := &BandsSnapshot{Sum: o.bands.sum, Last: o.bands.last}
+		for _, trade := range o.bands.trades {
+			bands.Trades = append(bands.Trades, BandTrade{At: trade.at, Price: trade.price})
+		}
+
+		snapshot.Bands = bands
+	}
+
+	if o.ledger != nil {
+		snapshot.Balances = o.ledger.Snapshot()
+	}
+
+	if o.fees != nil {
+		snapshot.Fees = o.fees.snapshot()
+	}
+
+	for accountID, limits := range o.riskLimits {
+		snapshot.RiskLimits = append(snapshot.RiskLimits, AccountRiskLimits{AccountID: accountID, Limits: limits})
+	}
+	sort.Slice(snapshot.RiskLimits, func(i, j int) bool { return snapshot.RiskLimits[i].AccountID < snapshot.RiskLimits[j].AccountID })
+
+	for _, snapshotter := range o.checkSnapshotters() {
+		snapshot.Checks = append(snapshot.Checks, snapshotter.SnapshotCheck())
+	}
+
+	return snapshot
+}
+
+// Restore replaces the Orderbook's state with the snapshot's; the sequencer carries on from the snapshot's Seq.
+// Subscribers aren't told about the restored orders, though pre-trade checks that are CheckSnapshotters are restored along
+// with it. Only the ledger balances in the snapshot are restored.
+func (o *Orderbook) Restore(snapshot Snapshot) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if snapshot.Bands != nil && o.bands == nil {
+		return fmt.Errorf("restore snapshot %d: snapshot has price bands but the orderbook doesn't", snapshot.Seq)
+	}
+
+	if len(snapshot.Balances) > 0 && o.ledger == nil {
+		return fmt.Errorf("restore snapshot %d: snapshot has balances but the orderbook has no ledger", snapshot.Seq)
+	}
+
+	if snapshot.Fees != nil && o.fees == nil {
+		return fmt.Errorf("restore snapshot %d: snapshot has fees but the orderbook has no fee schedule", snapshot.Seq)
+	}
+
+	if len(snapshot.RiskLimits) > 0 && !o.hasRiskLimiter() {
+		return fmt.Errorf("restore snapshot %d: snapshot has risk limits but the orderbook has no pre-trade check with limits", snapshot.Seq)
+	}
+
+	snapshotters := o.checkSnapshotters()
+	if len(snapshot.Checks) != len(snapshotters) {
+		return fmt.Errorf("restore snapshot %d: snapshot has %d pre-trade check states but the orderbook has %d", snapshot.Seq, len(snapshot.Checks), len(snapshotters))
+	}
+
+	for i, snapshotter := range snapshotters {
+		if err := snapshotter.RestoreCheck(snapshot.Checks[i]); err != nil {
+			return fmt.Errorf("restore snapshot %d: pre-trade check %d: %w", snapshot.Seq, i, err)
+		}
+	}
+
+	o.sequencer = NewSequencer(o.clock)
+	o.sequencer.advance(snapshot.Seq)
+	o.state = snapshot.State
+	o.lastTradePrice = snapshot.LastTradePrice
+	o.rollingHash = snapshot.RollingHash
+	o.lastDepth = nil
+	o.orders = newOrderTracker(o.orders.retention, o.orders.clientWindow)
+
+	o.schedule = nil
+	for _, scheduled := range snapshot.Schedule {
+		o.schedule = append(o.schedule, scheduledTransition{at: scheduled.At, to: scheduled.To, reason: scheduled.Reason})
+	}
+
+	o.bids = o.restoreLevels(BuySide, snapshot.Bids)
+	o.asks = o.restoreLevels(SellSide, snapshot.Asks)
+
+	for _, finished := range snapshot.Finished {
+		o.orders.retain(restoreOrder(finished))
+	}
+
+	for _, client := range snapshot.ClientOrders {
+		key := newClientOrderKey(client.AccountID, client.SessionID, client.ClientOrderID)
+		o.orders.clientOrders[key] = clientOrder{orderID: client.OrderID, finishedAt: client.FinishedAt}
+		o.orders.clientExpiries = append(o.orders.clientExpiries, clientOrderExpiry{key: key, orderID: client.OrderID, at: client.FinishedAt})
+	}
+
+	if o.bands != nil {
+		o.bands.trades, o.bands.sum, o.bands.last = nil, 0, o.bands.config.ReferencePrice
+		if bands := snapshot.Bands; bands != nil {
+			for _, trade := range bands.Trades {
+				o.bands.trades = append(o.bands.trades, bandTrade{at: trade.At, price: trade.Price})
+			}
+
+			o.bands.sum, o.bands.last = bands.Sum, bands.Last
+		}
+	}
+
+	if o.ledger != nil {
+		o.ledger.Restore(snapshot.Balances)
+	}
+
+	if o.fees != nil {
+		o.fees.restore(snapshot.Fees)
+	}
+
+	o.riskLimits = nil
+	for _, limits := range snapshot.RiskLimits {
+		if err := o.setRiskLimits(limits.AccountID, limits.Limits); err != nil {
+			return fmt.Errorf("restore snapshot %d: %w", snapshot.Seq, err)
+		}
+	}
+
+	return nil
+}
+
+func snapshotLevels(book *Book) []LevelSnapshot {
+	book.mu.RLock()
+	defer book.mu.RUnlock()
+
+	levels := make([]LevelSnapshot, 0, len(book.levels))
+	for _, pl := range book.levels {
+		pl.mu.RLock()
+		level := LevelSnapshot{
+			Price:     pl.price,
+			TotalSize: pl.totalSize,
+			Orders:    make([]OrderSnapshot, 0, len(pl.orderQueue)),
+		}
+
+		for _, order := range pl.orderQueue {
+			level.Orders = append(level.Orders, snapshotOrder(order))
+		}
+		pl.mu.RUnlock()
+
+		levels = append(levels, level)
+	}
+
+	return levels
+}
+
+func (o *Orderbook) restoreLevels(side OrderSide, levels []LevelSnapshot) *Book {
+	book := NewBook(side)
+	for _, level := range levels {
+		pl := NewPriceLevel(level.Price)
+		for _, snapshot := range level.Orders {
+			order := restoreOrder(snapshot)
+			pl.orderQueue = append(pl.orderQueue, order)
+			o.orders.add(order)
+		}
+
+		pl.totalSize = level.TotalSize
+		book.levels = append(book.levels, pl)
+	}
+
+	return book
+}
+
+func snapshotOrder(order *Order) OrderSnapshot {
+	return OrderSnapshot{
+		ID:                 order.ID,
+		OrderType:          order.OrderType,
+		Side:               order.Side,
+		Price:              order.Price,
+		Size:               order.Size,
+		AccountID:          order.AccountID,
+		SessionID:          order.SessionID,
+		CancelOnDisconnect: order.CancelOnDisconnect,
+		ClientOrderID:      order.ClientOrderID,
+		RemainingSize:      order.remainingSize,
+		Status:             order.status,
+		RejectReason:       order.rejectReason,
+		FilledSize:         order.filledSize,
+		FilledNotional:     order.filledNotional,
+		Fees:               order.fees,
+		UpdatedAt:          order.updatedAt,
+		ReceivedAt:         order.ReceivedAt,
+		SequencedAt:        order.sequencedAt,
+		Reserved:           order.reserved,
+		ReservedFee:        order.reservedFee,
+	}
+}
+
+func restoreOrder(snapshot OrderSnapshot) *Order {
+	return &Order{
+		ID:                 snapshot.ID,
+		OrderType:          snapshot.OrderType,
+		Side:               snapshot.Side,
+		Price:              snapshot.Price,
+		Size:               snapshot.Size,
+		AccountID:          snapshot.AccountID,
+		SessionID:          snapshot.SessionID,
+		CancelOnDisconnect: snapshot.CancelOnDisconnect,
+		ClientOrderID:      snapshot.ClientOrderID,
+		remainingSize:      snapshot.RemainingSize,
+		status:             snapshot.Status,
+		rejectReason:       snapshot.RejectReason,
+		filledSize:         snapshot.FilledSize,
+		filledNotional:     snapshot.FilledNotional,
+		fees:               snapshot.Fees,
+		updatedAt:          snapshot.UpdatedAt,
+		ReceivedAt:         snapshot.ReceivedAt,
+		sequencedAt:        snapshot.SequencedAt,
+		reserved:           snapshot.Reserved,
+		reservedFee:        snapshot.ReservedFee,
+	}
+}
@@ -0,0 +1,43 @@
+package lob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_RestoreKeepsQueuePriority(t *testing.T) {
+	t.Parallel()
+
+	lob := NewOrderbook(128)
+
+	first, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1001, 1))
+	require.NoError(t, err)
+
+	second, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1001, 2))
+	require.NoError(t, err)
+
+	_, err = lob.PlaceOrder(NewOrder(MarketOrder, BuySide, 0, 0.5))
+	require.NoError(t, err)
+
+	restored := NewOrderbook(128)
+	require.NoError(t, restored.Restore(lob.Snapshot()))
+	assert.Equal(t, lob.Snapshot(), restored.Snapshot())
+	assert.Equal(t, lob.OpenOrders(), restored.OpenOrders())
+
+	_, err = restored.PlaceOrder(NewOrder(MarketOrder, BuySide, 0, 1))
+	require.NoError(t, err)
+
+	order, err := restored.GetOrder(first)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusFilled, order.Status)
+
+	order, err = restored.GetOrder(second)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusPartiallyFilled, order.Status)
+	assert.Equal(t, Size(1.5), order.RemainingSize)
+
+	// Restoring balances needs somewhere to restore them to.
+	assert.Error(t, restored.Restore(Snapshot{Balances: []AccountBalance{{AccountID: 1, Asset: "USD"}}}))
+}
@@ -0,0 +1,249 @@
+package lob
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+type TradingState byte
+
+const (
+	TradingStatePreOpen TradingState = iota + 1
+	TradingStateOpen
+	TradingStateHalted
+	TradingStateAuction
+	TradingStateClosed
+)
+
+func (t TradingState) String() string {
+	switch t {
+	case TradingStatePreOpen:
+		return "pre_open"
+	case TradingStateOpen:
+		return "open"
+	case TradingStateHalted:
+		return "halted"
+	case TradingStateAuction:
+		return "auction"
+	case TradingStateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// AcceptsOrders returns true if new orders may be placed.
+func (t TradingState) AcceptsOrders() bool {
+	switch t {
+	case TradingStatePreOpen, TradingStateOpen, TradingStateAuction:
+		return true
+	default:
+		return false
+	}
+}
+
+// AcceptsCancels returns true if resting orders may be cancelled one by one; mass cancels are accepted in every state.
+func (t TradingState) AcceptsCancels() bool {
+	return t != TradingStateClosed
+}
+
+// Matches returns true if incoming orders match continuously; in every other state that accepts orders, they accumulate in the book.
+func (t TradingState) Matches() bool {
+	return t == TradingStateOpen
+}
+
+var tradingStateTransitions = map[TradingState][]TradingState{
+	TradingStatePreOpen: {TradingStateOpen, TradingStateAuction, TradingStateHalted, TradingStateClosed},
+	TradingStateOpen:    {TradingStateHalted, TradingStateAuction, TradingStateClosed},
+	TradingStateHalted:  {TradingStateOpen, TradingStateAuction, TradingStateClosed},
+	TradingStateAuction: {TradingStateOpen, TradingStateHalted, TradingStateClosed},
+	TradingStateClosed:  {TradingStatePreOpen},
+}
+
+// CanTransition returns true if the state machine allows moving from one state to the other.
+func (t TradingState) CanTransition(to TradingState) bool {
+	for _, allowed := range tradingStateTransitions[t] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StateChangeEvent is published on every trading state transition; SequencedAt is when the command that made it was
+// sequenced.
+type StateChangeEvent struct {
+	From        TradingState
+	To          TradingState
+	Reason      string
+	Scheduled   bool
+	SequencedAt time.Time
+}
+
+func (StateChangeEvent) isEvent() {}
+
+func (s StateChangeEvent) String() string {
+	return fmt.Sprintf(`state %s -> %s : reason=%q scheduled=%t`, s.From, s.To, s.Reason, s.Scheduled)
+}
+
+// WithTradingState sets the state the Orderbook starts in; defaults to open.
+func WithTradingState(state TradingState) Option {
+	return func(o *Orderbook) {
+		o.state = state
+	}
+}
+
+func (o *Orderbook) State() TradingState {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.state
+}
+
+// Transition manually moves the Orderbook into the given trading state.
+func (o *Orderbook) Transition(to TradingState, reason string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cmd := Command{Type: CommandTransition, State: to, Reason: reason}
+	if err := o.sequence(&cmd); err != nil {
+		return fmt.Errorf("transition %s -> %s: %w", o.state, to, err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	o.runSchedule(cmd.Time)
+
+	return o.transition(to, reason, false, cmd.Time)
+}
+
+type scheduledTransition struct {
+	at     time.Time
+	to     TradingState
+	reason string
+}
+
+// ScheduleTransition schedules a transition into the given state; it fires on the first Tick, or request, at or after the given time.
+func (o *Orderbook) ScheduleTransition(at time.Time, to TradingState, reason string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	at = at.Round(0)
+	cmd := Command{Type: CommandScheduleTransition, State: to, Reason: reason, At: at}
+	if err := o.sequence(&cmd); err != nil {
+		return fmt.Errorf("schedule transition to %s: %w", to, err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	o.scheduleTransition(at, to, reason)
+
+	return nil
+}
+
+func (o *Orderbook) scheduleTransition(at time.Time, to TradingState, reason string) {
+	o.schedule = append(o.schedule, scheduledTransition{at: at, to: to, reason: reason})
+	sort.SliceStable(o.schedule, func(i, j int) bool {
+		return o.schedule[i].at.Before(o.schedule[j].at)
+	})
+}
+
+// Tick fires every scheduled transition that is due. Ticks are only journaled when there's something to fire.
+func (o *Orderbook) Tick() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.schedule) == 0 || o.schedule[0].at.After(o.now()) {
+		return nil
+	}
+
+	cmd := Command{Type: CommandTick}
+	if err := o.sequence(&cmd); err != nil {
+		return fmt.Errorf("tick: %w", err)
+	}
+	defer o.applied(cmd.Seq, cmd.Time, false)
+
+	o.runSchedule(cmd.Time)
+
+	return nil
+}
+
+// RunSchedule ticks the Orderbook every interval until the context is cancelled.
+func (o *Orderbook) RunSchedule(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if err := o.Tick(); err != nil {
+				slog.Warn("LOB: failed to tick schedule", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (o *Orderbook) runSchedule(now time.Time) {
+	var i int
+	for ; i < len(o.schedule) && !o.schedule[i].at.After(now); i++ {
+		scheduled := o.schedule[i]
+		if err := o.transition(scheduled.to, scheduled.reason, true, now); err != nil {
+			slog.Warn("LOB: dropping scheduled transition", "to", scheduled.to.String(), "error", err)
+		}
+	}
+
+	if i > 0 {
+		o.schedule = o.schedule[i:]
+	}
+}
+
+// transition moves into the given state, uncrossing the book when leaving an auction or when opening onto a crossed book.
+func (o *Orderbook) transition(to TradingState, reason string, scheduled bool, now time.Time) error {
+	from := o.state
+	if !from.CanTransition(to) {
+		return fmt.Errorf("transition %s -> %s: not allowed", from, to)
+	}
+
+	slog.Debug("LOB: transitioning trading state", "from", from.String(), "to", to.String(), "reason", reason)
+
+	switch {
+	case from == TradingStateAuction && (to == TradingStateOpen || to == TradingStateClosed):
+		o.uncross(now)
+	case to == TradingStateOpen && o.crossed():
+		o.uncross(now)
+	}
+
+	o.state = to
+	o.publish(StateChangeEvent{
+		From:        from,
+		To:          to,
+		Reason:      reason,
+		Scheduled:   scheduled,
+		SequencedAt: now,
+	})
+
+	if to.AcceptsOrders() && !to.Matches() {
+		o.publishIndicative(now)
+	}
+
+	return nil
+}
+
+// crossed returns true if the best bid is at or through the best ask.
+func (o *Orderbook) crossed() bool {
+	bb, err := o.bids.Top()
+	if err != nil {
+		return false
+	}
+
+	ba, err := o.asks.Top()
+	if err != nil {
+		return false
+	}
+
+	return bb >= ba
+}
@@ -0,0 +1,158 @@
+package lob
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTradingState_Permissions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		state          TradingState
+		expectPlace    bool
+		expectMatching bool
+		expectCancel   bool
+	}{
+		{
+			name:         "pre_open",
+			state:        TradingStatePreOpen,
+			expectPlace:  true,
+			expectCancel: true,
+		},
+		{
+			name:           "open",
+			state:          TradingStateOpen,
+			expectPlace:    true,
+			expectMatching: true,
+			expectCancel:   true,
+		},
+		{
+			name:         "halted",
+			state:        TradingStateHalted,
+			expectCancel: true,
+		},
+		{
+			name:         "auction",
+			state:        TradingStateAuction,
+			expectPlace:  true,
+			expectCancel: true,
+		},
+		{
+			name:  "closed",
+			state: TradingStateClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lob := NewOrderbook(128)
+
+			restingID, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1001, 1))
+			require.NoError(t, err)
+
+			lob.state = tt.state
+
+			id, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 1001, 1))
+			if tt.expectPlace {
+				require.NoError(t, err)
+			} else {
+				assertRejected(t, err, RejectReasonTradingState)
+			}
+
+			order, err := lob.GetOrder(id)
+			require.NoError(t, err)
+
+			switch {
+			case !tt.expectPlace:
+				assert.Equal(t, OrderStatusRejected, order.Status)
+				assert.Equal(t, RejectReasonTradingState, order.RejectReason)
+			case tt.expectMatching:
+				assert.Equal(t, OrderStatusFilled, order.Status)
+			default:
+				assert.Equal(t, OrderStatusNew, order.Status)
+			}
+
+			_, err = lob.PlaceOrder(NewOrder(MarketOrder, BuySide, 0, 1))
+			if !tt.expectMatching {
+				assertRejected(t, err, RejectReasonTradingState)
+			}
+
+			err = lob.CancelOrder(restingID)
+			switch {
+			case !tt.expectCancel:
+				assertRejected(t, err, RejectReasonTradingState)
+			case tt.expectMatching:
+				// Already filled by the crossing order.
+				require.Error(t, err)
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTradingState_Transitions(t *testing.T) {
+	t.Parallel()
+
+	clock := NewVirtualClock(time.Now())
+	start := clock.Now()
+
+	lob := NewOrderbook(128, WithClock(clock), WithTradingState(TradingStateClosed))
+
+	var transitions []StateChangeEvent
+	lob.Subscribe(func(event Event) {
+		if e, ok := event.(StateChangeEvent); ok {
+			transitions = append(transitions, e)
+		}
+	})
+
+	require.Error(t, lob.Transition(TradingStateOpen, "skip pre-open"))
+	require.NoError(t, lob.Transition(TradingStatePreOpen, "start of day"))
+
+	lob.ScheduleTransition(start.Add(2*time.Minute), TradingStateOpen, "continuous trading")
+	lob.ScheduleTransition(start.Add(time.Minute), TradingStateAuction, "opening auction")
+
+	// Orders cross during pre-open without matching.
+	buyID, err := lob.PlaceOrder(NewOrder(LimitOrder, BuySide, 1001, 1))
+	require.NoError(t, err)
+
+	sellID, err := lob.PlaceOrder(NewOrder(LimitOrder, SellSide, 1000, 1))
+	require.NoError(t, err)
+
+	clock.Advance(time.Minute)
+	lob.Tick()
+	assert.Equal(t, TradingStateAuction, lob.State())
+
+	clock.Advance(time.Minute)
+	lob.Tick()
+	assert.Equal(t, TradingStateOpen, lob.State())
+
+	for _, id := range []uint64{buyID, sellID} {
+		order, err := lob.GetOrder(id)
+		require.NoError(t, err)
+		assert.Equal(t, OrderStatusFilled, order.Status)
+	}
+
+	assert.Equal(t, []StateChangeEvent{
+		{From: TradingStateClosed, To: TradingStatePreOpen, Reason: "start of day", SequencedAt: start},
+		{From: TradingStatePreOpen, To: TradingStateAuction, Reason: "opening auction", Scheduled: true, SequencedAt: start.Add(time.Minute)},
+		{From: TradingStateAuction, To: TradingStateOpen, Reason: "continuous trading", Scheduled: true, SequencedAt: start.Add(2 * time.Minute)},
+	}, transitions)
+}
+
+func assertRejected(t *testing.T, err error, reason RejectReason) {
+	t.Helper()
+
+	var rejectErr *RejectError
+	require.True(t, errors.As(err, &rejectErr), "expected reject error, got: %v", err)
+	assert.Equal(t, reason, rejectErr.Reason)
+}
@@ -0,0 +1,193 @@
+package lob
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultOrderRetention is how long finished orders remain queryable by default.
+	DefaultOrderRetention = 5 * time.Minute
+
+	// DefaultClientOrderIDWindow is how long after its order finishes a client order ID can't be reused, by default.
+	DefaultClientOrderIDWindow = 5 * time.Minute
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+func newOrderTracker(retention, clientWindow time.Duration) *orderTracker {
+	return &orderTracker{
+		retention:    retention,
+		open:         make(map[uint64]*Order, 1024),
+		finished:     make(map[uint64]*Order, 1024),
+		clientWindow: clientWindow,
+		clientOrders: make(map[clientOrderKey]clientOrder, 1024),
+	}
+}
+
+type finishedOrder struct {
+	id uint64
+	at time.Time
+}
+
+// orderTracker holds every open order, plus finished orders for the retention window.
+type orderTracker struct {
+	retention time.Duration
+	open      map[uint64]*Order
+	finished  map[uint64]*Order
+
+	// expiries is ordered by finish time, so pruning only ever pops from the front.
+	expiries []finishedOrder
+
+	// clientOrders are the orders using each client order ID: open orders, and those finished within the client window.
+	// clientExpiries is ordered by finish time, as expiries is.
+	clientWindow   time.Duration
+	clientOrders   map[clientOrderKey]clientOrder
+	clientExpiries []clientOrderExpiry
+}
+
+// clientOrderKey scopes a client order ID to an account, or to a session for orders without an account.
+type clientOrderKey struct {
+	accountID uint64
+	sessionID uint64
+	id        string
+}
+
+func newClientOrderKey(accountID, sessionID uint64, clientOrderID string) clientOrderKey {
+	if accountID != 0 {
+		sessionID = 0
+	}
+
+	return clientOrderKey{accountID: accountID, sessionID: sessionID, id: clientOrderID}
+}
+
+// clientOrder is the order using a client order ID, and when it finished; zero while it's open.
+type clientOrder struct {
+	orderID    uint64
+	finishedAt time.Time
+}
+
+type clientOrderExpiry struct {
+	key     clientOrderKey
+	orderID uint64
+	at      time.Time
+}
+
+func (t *orderTracker) add(order *Order) {
+	t.useClientOrderID(order)
+
+	if order.status.Finished() {
+		t.retain(order)
+		t.releaseClientOrderID(order)
+		return
+	}
+
+	t.open[order.ID] = order
+}
+
+func (t *orderTracker) finish(order *Order) {
+	delete(t.open, order.ID)
+	t.retain(order)
+	t.releaseClientOrderID(order)
+}
+
+// useClientOrderID records the order as using its client order ID, from when it's placed; orders that fill as they're
+// placed are never added.
+func (t *orderTracker) useClientOrderID(order *Order) {
+	if order.ClientOrderID != "" {
+		t.clientOrders[newClientOrderKey(order.AccountID, order.SessionID, order.ClientOrderID)] = clientOrder{orderID: order.ID}
+	}
+}
+
+// releaseClientOrderID lets the finished order's client order ID be reused once the client window has passed.
+func (t *orderTracker) releaseClientOrderID(order *Order) {
+	if order.ClientOrderID == "" {
+		return
+	}
+
+	key := newClientOrderKey(order.AccountID, order.SessionID, order.ClientOrderID)
+	if t.clientOrders[key].orderID != order.ID {
+		return
+	}
+
+	if t.clientWindow <= 0 {
+		delete(t.clientOrders, key)
+		return
+	}
+
+	t.clientOrders[key] = clientOrder{orderID: order.ID, finishedAt: order.updatedAt}
+	t.clientExpiries = append(t.clientExpiries, clientOrderExpiry{key: key, orderID: order.ID, at: order.updatedAt})
+}
+
+// clientOrder returns the ID of the order using the client order ID as of now, if any.
+func (t *orderTracker) clientOrder(key clientOrderKey, now time.Time) (uint64, bool) {
+	client, ok := t.clientOrders[key]
+	if !ok || !client.finishedAt.IsZero() && now.Sub(client.finishedAt) >= t.clientWindow {
+		return 0, false
+	}
+
+	return client.orderID, true
+}
+
+func (t *orderTracker) retain(order *Order) {
+	if t.retention <= 0 {
+		return
+	}
+
+	t.finished[order.ID] = order
+	t.expiries = append(t.expiries, finishedOrder{id: order.ID, at: order.updatedAt})
+}
+
+func (t *orderTracker) get(orderID uint64) (*Order, bool) {
+	if order, ok := t.open[orderID]; ok {
+		return order, true
+	}
+
+	order, ok := t.finished[orderID]
+	return order, ok
+}
+
+func (t *orderTracker) prune(now time.Time) {
+	var i int
+	for ; i < len(t.expiries); i++ {
+		if now.Sub(t.expiries[i].at) < t.retention {
+			break
+		}
+
+		delete(t.finished, t.expiries[i].id)
+	}
+
+	if i > 0 {
+		t.expiries = t.expiries[i:]
+	}
+
+	var j int
+	for ; j < len(t.clientExpiries); j++ {
+		expiry := t.clientExpiries[j]
+		if now.Sub(expiry.at) < t.clientWindow {
+			break
+		}
+
+		if t.clientOrders[expiry.key].orderID == expiry.orderID {
+			delete(t.clientOrders, expiry.key)
+		}
+	}
+
+	if j > 0 {
+		t.clientExpiries = t.clientExpiries[j:]
+	}
+}
+
+func (t *orderTracker) openOrders() []OrderInfo {
+	orders := make([]OrderInfo, 0, len(t.open))
+	for _, order := range t.open {
+		orders = append(orders, order.Info())
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID < orders[j].ID
+	})
+
+	return orders
+}
@@ -0,0 +1,226 @@
+// Package multicast publishes the book's depth & trades over UDP multicast: sequenced incremental packets on one group,
+// periodic depth snapshots on another for late joiners, and a TCP retransmission server receivers request missed packets
+// from. Receiver is the reference receiver, rebuilding the book's depth from them.
+package multicast
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// Every packet is a header followed by its body; integers & floats are little-endian, times nanoseconds since the Unix
+// epoch.
+//
+//	header:       seq u64, sending time i64, type u8, reserved u8, body length u16
+//	depth update: book seq u64, checksum u32, count u16, then per change: action u8, side u8, position u16, price f64, size f64
+//	trade:        price f64, size f64, maker order ID u64, taker order ID u64, aggressor side u8
+//	snapshot:     book seq u64, checksum u32, bids u16, asks u16, then per level, bids first: price f64, size f64
+//	heartbeat:    empty
+//
+// Incremental packets, depth updates & trades, are numbered consecutively from 1. A snapshot's seq is that of the last
+// incremental packet it includes, and a heartbeat's that of the last incremental packet sent.
+
+const (
+	HeaderSize = 20
+
+	depthUpdateSize = 14
+	depthChangeSize = 20
+	tradeSize       = 33
+	snapshotSize    = 16
+	levelSize       = 16
+
+	// MaxPacketSize bounds a packet; a datagram larger than the path's MTU is fragmented.
+	MaxPacketSize = math.MaxUint16
+)
+
+var ErrShortPacket = errors.New("short packet")
+
+// PacketType is what a packet carries.
+type PacketType uint8
+
+const (
+	PacketDepthUpdate PacketType = iota + 1
+	PacketTrade
+	PacketSnapshot
+	PacketHeartbeat
+)
+
+func (p PacketType) String() string {
+	switch p {
+	case PacketDepthUpdate:
+		return "depth_update"
+	case PacketTrade:
+		return "trade"
+	case PacketSnapshot:
+		return "snapshot"
+	case PacketHeartbeat:
+		return "heartbeat"
+	default:
+		return "unknown"
+	}
+}
+
+// Trade is a match between two orders.
+type Trade struct {
+	Price         lob.Price
+	Size          lob.Size
+	MakerOrderID  uint64
+	TakerOrderID  uint64
+	AggressorSide lob.OrderSide
+}
+
+// Packet is a decoded packet. Depth is the levels of a snapshot, or the sequence number & checksum of the depth after a
+// depth update's Changes.
+type Packet struct {
+	Seq         uint64
+	SendingTime time.Time
+	Type        PacketType
+
+	Depth   lob.DepthSnapshot
+	Changes []lob.DepthChange
+	Trade   Trade
+}
+
+// AppendPacket appends the encoded packet to buf.
+func AppendPacket(buf []byte, p *Packet) []byte {
+	start := len(buf)
+
+	buf = binary.LittleEndian.AppendUint64(buf, p.Seq)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(p.SendingTime.UnixNano()))
+	buf = append(buf, byte(p.Type), 0, 0, 0)
+
+	switch p.Type {
+	case PacketDepthUpdate:
+		buf = binary.LittleEndian.AppendUint64(buf, p.Depth.Seq)
+		buf = binary.LittleEndian.AppendUint32(buf, p.Depth.Checksum)
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(p.Changes)))
+		for _, change := range p.Changes {
+			buf = append(buf, byte(change.Action), byte(change.Side))
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(change.Position))
+			buf = appendFloat(buf, float64(change.Level.Price))
+			buf = appendFloat(buf, float64(change.Level.Size))
+		}
+	case PacketTrade:
+		buf = appendFloat(buf, float64(p.Trade.Price))
+		buf = appendFloat(buf, float64(p.Trade.Size))
+		buf = binary.LittleEndian.AppendUint64(buf, p.Trade.MakerOrderID)
+		buf = binary.LittleEndian.AppendUint64(buf, p.Trade.TakerOrderID)
+		buf = append(buf, byte(p.Trade.AggressorSide))
+	case PacketSnapshot:
+		buf = binary.LittleEndian.AppendUint64(buf, p.Depth.Seq)
+		buf = binary.LittleEndian.AppendUint32(buf, p.Depth.Checksum)
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(p.Depth.Bids)))
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(p.Depth.Asks)))
+		for _, levels := range [][]lob.DepthLevel{p.Depth.Bids, p.Depth.Asks} {
+			for _, level := range levels {
+				buf = appendFloat(buf, float64(level.Price))
+				buf = appendFloat(buf, float64(level.Size))
+			}
+		}
+	}
+
+	binary.LittleEndian.PutUint16(buf[start+18:], uint16(len(buf)-start-HeaderSize))
+
+	return buf
+}
+
+// DecodePacket decodes a packet; a body longer than its type's is left to a newer version, its extra bytes skipped.
+func DecodePacket(data []byte) (Packet, error) {
+	if len(data) < HeaderSize {
+		return Packet{}, fmt.Errorf("decode header: %w", ErrShortPacket)
+	}
+
+	p := Packet{
+		Seq:         binary.LittleEndian.Uint64(data[0:]),
+		SendingTime: time.Unix(0, int64(binary.LittleEndian.Uint64(data[8:]))),
+		Type:        PacketType(data[16]),
+	}
+
+	body, length := data[HeaderSize:], int(binary.LittleEndian.Uint16(data[18:]))
+	if len(body) < length {
+		return Packet{}, fmt.Errorf("decode %s: %w", p.Type, ErrShortPacket)
+	}
+	body = body[:length]
+
+	switch p.Type {
+	case PacketDepthUpdate:
+		if len(body) < depthUpdateSize {
+			return Packet{}, fmt.Errorf("decode %s: %w", p.Type, ErrShortPacket)
+		}
+
+		p.Depth.Seq = binary.LittleEndian.Uint64(body[0:])
+		p.Depth.Checksum = binary.LittleEndian.Uint32(body[8:])
+		count := int(binary.LittleEndian.Uint16(body[12:]))
+
+		body = body[depthUpdateSize:]
+		if len(body) < count*depthChangeSize {
+			return Packet{}, fmt.Errorf("decode %s changes: %w", p.Type, ErrShortPacket)
+		}
+
+		p.Changes = make([]lob.DepthChange, 0, count)
+		for i := 0; i < count; i++ {
+			change := body[i*depthChangeSize:]
+			p.Changes = append(p.Changes, lob.DepthChange{
+				Action:   lob.DepthAction(change[0]),
+				Side:     lob.OrderSide(change[1]),
+				Position: int(binary.LittleEndian.Uint16(change[2:])),
+				Level:    lob.DepthLevel{Price: lob.Price(getFloat(change[4:])), Size: lob.Size(getFloat(change[12:]))},
+			})
+		}
+	case PacketTrade:
+		if len(body) < tradeSize {
+			return Packet{}, fmt.Errorf("decode %s: %w", p.Type, ErrShortPacket)
+		}
+
+		p.Trade = Trade{
+			Price:         lob.Price(getFloat(body[0:])),
+			Size:          lob.Size(getFloat(body[8:])),
+			MakerOrderID:  binary.LittleEndian.Uint64(body[16:]),
+			TakerOrderID:  binary.LittleEndian.Uint64(body[24:]),
+			AggressorSide: lob.OrderSide(body[32]),
+		}
+	case PacketSnapshot:
+		if len(body) < snapshotSize {
+			return Packet{}, fmt.Errorf("decode %s: %w", p.Type, ErrShortPacket)
+		}
+
+		p.Depth.Seq = binary.LittleEndian.Uint64(body[0:])
+		p.Depth.Checksum = binary.LittleEndian.Uint32(body[8:])
+		bids, asks := int(binary.LittleEndian.Uint16(body[12:])), int(binary.LittleEndian.Uint16(body[14:]))
+
+		body = body[snapshotSize:]
+		if len(body) < (bids+asks)*levelSize {
+			return Packet{}, fmt.Errorf("decode %s levels: %w", p.Type, ErrShortPacket)
+		}
+
+		p.Depth.Bids, body = decodeLevels(body, bids)
+		p.Depth.Asks, _ = decodeLevels(body, asks)
+	case PacketHeartbeat:
+	default:
+		return Packet{}, fmt.Errorf("decode packet type %d: unknown packet type", p.Type)
+	}
+
+	return p, nil
+}
+
+func decodeLevels(body []byte, count int) ([]lob.DepthLevel, []byte) {
+	levels := make([]lob.DepthLevel, 0, count)
+	for i := 0; i < count; i++ {
+		levels = append(levels, lob.DepthLevel{Price: lob.Price(getFloat(body[i*levelSize:])), Size: lob.Size(getFloat(body[i*levelSize+8:]))})
+	}
+
+	return levels, body[count*levelSize:]
+}
+
+func appendFloat(buf []byte, f float64) []byte {
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(f))
+}
+
+func getFloat(b []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
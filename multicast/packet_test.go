@@ -0,0 +1,77 @@
+package multicast
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+func TestPacket(t *testing.T) {
+	t.Parallel()
+
+	sendingTime := time.Unix(0, 1_700_000_000_123_456_789)
+	bids := []lob.DepthLevel{{Price: 100, Size: 5}, {Price: 99, Size: 2.5}}
+	asks := []lob.DepthLevel{{Price: 101, Size: 1}}
+
+	tests := []struct {
+		name   string
+		packet Packet
+	}{
+		{
+			name: "depth_update",
+			packet: Packet{
+				Seq:         7,
+				SendingTime: sendingTime,
+				Type:        PacketDepthUpdate,
+				Depth:       lob.DepthSnapshot{Seq: 42, Checksum: lob.Checksum(bids, asks)},
+				Changes: []lob.DepthChange{
+					{Action: lob.DepthActionDelete, Side: lob.SellSide, Position: 1, Level: lob.DepthLevel{Price: 102}},
+					{Action: lob.DepthActionNew, Side: lob.BuySide, Position: 1, Level: lob.DepthLevel{Price: 99, Size: 2.5}},
+				},
+			},
+		},
+		{
+			name: "trade",
+			packet: Packet{
+				Seq:         8,
+				SendingTime: sendingTime,
+				Type:        PacketTrade,
+				Trade:       Trade{Price: 100, Size: 2, MakerOrderID: 3, TakerOrderID: 4, AggressorSide: lob.SellSide},
+			},
+		},
+		{
+			name: "snapshot",
+			packet: Packet{
+				Seq:         8,
+				SendingTime: sendingTime,
+				Type:        PacketSnapshot,
+				Depth:       lob.DepthSnapshot{Seq: 42, Bids: bids, Asks: asks, Checksum: lob.Checksum(bids, asks)},
+			},
+		},
+		{
+			name:   "heartbeat",
+			packet: Packet{Seq: 8, SendingTime: sendingTime, Type: PacketHeartbeat},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := AppendPacket(nil, &tt.packet)
+
+			got, err := DecodePacket(data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.packet, got)
+
+			_, err = DecodePacket(data[:len(data)-1])
+			assert.True(t, errors.Is(err, ErrShortPacket), err)
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package multicast
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/ipv4"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+const (
+	DefaultSnapshotInterval  = time.Second
+	DefaultHeartbeatInterval = time.Second
+	DefaultRetransmitPackets = 1 << 16
+	DefaultTTL               = 1
+
+	// MaxDepthLevels bounds the levels per side published, so every packet fits an Ethernet MTU.
+	MaxDepthLevels = 16
+
+	// queueSize bounds the packets waiting to be sent; packets that don't fit are left to retransmission.
+	queueSize = 4096
+)
+
+type PublisherConfig struct {
+	Book        *lob.Orderbook
+	DepthLevels int
+
+	// Incremental is the group depth updates & trades are published to, and Snapshot the group depth snapshots are.
+	Incremental *net.UDPAddr
+	Snapshot    *net.UDPAddr
+	// Interface is the interface packets are sent from; the system's default for multicast if nil.
+	Interface *net.Interface
+	TTL       int
+
+	SnapshotInterval time.Duration
+	// HeartbeatInterval is how often a heartbeat's sent while no other packets are, so receivers notice missing the last.
+	HeartbeatInterval time.Duration
+	// RetransmitPackets is how many of the most recent packets are kept to be retransmitted.
+	RetransmitPackets int
+}
+
+// NewPublisher returns a publisher of the book's depth & trades; it subscribes to the book's events until closed.
+func NewPublisher(config PublisherConfig) (*Publisher, error) {
+	if config.DepthLevels <= 0 || config.DepthLevels > MaxDepthLevels {
+		return nil, fmt.Errorf("depth levels must be between 1 & %d, got %d", MaxDepthLevels, config.DepthLevels)
+	}
+
+	if config.Incremental == nil || config.Snapshot == nil {
+		return nil, errors.New("incremental & snapshot groups are required")
+	}
+
+	if config.TTL == 0 {
+		config.TTL = DefaultTTL
+	}
+
+	if config.SnapshotInterval == 0 {
+		config.SnapshotInterval = DefaultSnapshotInterval
+	}
+
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+
+	if config.RetransmitPackets == 0 {
+		config.RetransmitPackets = DefaultRetransmitPackets
+	}
+
+	udpConn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("open multicast socket: %w", err)
+	}
+
+	if err := configureSender(ipv4.NewPacketConn(udpConn), config); err != nil {
+		udpConn.Close()
+		return nil, err
+	}
+
+	p := &Publisher{
+		config:   config,
+		udpConn:  udpConn,
+		sent:     make([][]byte, config.RetransmitPackets),
+		out:      make(chan []byte, queueSize),
+		done:     make(chan struct{}),
+		now:      time.Now,
+		lastSent: time.Now(),
+	}
+
+	// Depth events are full snapshots, so any the book publishes before it's subscribed to are simply diffed against this.
+	p.depth = config.Book.DepthSnapshot(config.DepthLevels)
+	p.unsubscribe = config.Book.Subscribe(p.onEvent)
+
+	p.wg.Add(3)
+	go p.write()
+	go p.heartbeats()
+	go p.snapshots()
+
+	return p, nil
+}
+
+func configureSender(conn *ipv4.PacketConn, config PublisherConfig) error {
+	if config.Interface != nil {
+		if err := conn.SetMulticastInterface(config.Interface); err != nil {
+			return fmt.Errorf("set multicast interface %s: %w", config.Interface.Name, err)
+		}
+	}
+
+	if err := conn.SetMulticastTTL(config.TTL); err != nil {
+		return fmt.Errorf("set multicast ttl: %w", err)
+	}
+
+	// Receivers may be on the same host, e.g. co-located strategies.
+	if err := conn.SetMulticastLoopback(true); err != nil {
+		return fmt.Errorf("set multicast loopback: %w", err)
+	}
+
+	return nil
+}
+
+// Publisher publishes the book's depth changes & trades as sequenced packets to the incremental group, and its depth to
+// the snapshot group every snapshot interval. The most recent packets are kept, for receivers that missed them to request
+// from the retransmission server started with ServeRetransmit.
+type Publisher struct {
+	config  PublisherConfig
+	udpConn *net.UDPConn
+
+	// seq is the last incremental packet's, and depth the depth as of it. sent are the most recent packets, by their seq
+	// modulo its length. All are guarded by mu, as the book's events are published from whichever goroutine changed it.
+	seq      uint64
+	depth    lob.DepthSnapshot
+	sent     [][]byte
+	lastSent time.Time
+	mu       sync.Mutex
+
+	out         chan []byte
+	done        chan struct{}
+	closeOnce   sync.Once
+	wg          sync.WaitGroup
+	unsubscribe func()
+	now         func() time.Time
+}
+
+// Close stops publishing, waiting for packets already queued to be sent.
+func (p *Publisher) Close() error {
+	p.closeOnce.Do(func() {
+		p.unsubscribe()
+		close(p.done)
+		p.wg.Wait()
+		p.udpConn.Close()
+	})
+
+	return nil
+}
+
+// onEvent sequences depth changes & trades as incremental packets; it's called with the book locked.
+func (p *Publisher) onEvent(event lob.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	packet := Packet{SendingTime: p.now()}
+	switch event := event.(type) {
+	case lob.DepthEvent:
+		if event.Seq <= p.depth.Seq {
+			return
+		}
+
+		next := event.Truncate(p.config.DepthLevels)
+		changes := lob.DiffDepth(p.depth, next)
+		p.depth = next
+
+		if len(changes) == 0 {
+			return
+		}
+
+		packet.Type, packet.Depth, packet.Changes = PacketDepthUpdate, next, changes
+	case lob.TradeEvent:
+		packet.Type = PacketTrade
+		packet.Trade = Trade{
+			Price:         event.Price,
+			Size:          event.Size,
+			MakerOrderID:  event.MakerOrderID,
+			TakerOrderID:  event.TakerOrderID,
+			AggressorSide: event.AggressorSide,
+		}
+	default:
+		return
+	}
+
+	p.seq++
+	packet.Seq = p.seq
+
+	data := AppendPacket(nil, &packet)
+	p.sent[p.seq%uint64(len(p.sent))] = data
+	p.lastSent = packet.SendingTime
+
+	select {
+	case p.out <- data:
+	default:
+		slog.Warn("Multicast: send queue full; leaving packet to retransmission", "seq", p.seq)
+	}
+}
+
+// write sends queued packets in order, until closed.
+func (p *Publisher) write() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case data := <-p.out:
+			p.send(data, p.config.Incremental)
+		case <-p.done:
+			for {
+				select {
+				case data := <-p.out:
+					p.send(data, p.config.Incremental)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *Publisher) send(data []byte, group *net.UDPAddr) {
+	if _, err := p.udpConn.WriteToUDP(data, group); err != nil {
+		slog.Warn("Multicast: failed to send packet", "group", group.String(), "error", err)
+	}
+}
+
+// heartbeats sends the last incremental packet's seq while none are being sent.
+func (p *Publisher) heartbeats() {
+	defer p.wg.Done()
+
+	t := time.NewTicker(p.config.HeartbeatInterval / 2)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			p.mu.Lock()
+			now := p.now()
+			if now.Sub(p.lastSent) < p.config.HeartbeatInterval {
+				p.mu.Unlock()
+				continue
+			}
+
+			p.lastSent = now
+			data := AppendPacket(nil, &Packet{Seq: p.seq, SendingTime: now, Type: PacketHeartbeat})
+			p.mu.Unlock()
+
+			p.send(data, p.config.Incremental)
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// snapshots sends the depth, as of the last incremental packet, every snapshot interval.
+func (p *Publisher) snapshots() {
+	defer p.wg.Done()
+
+	t := time.NewTicker(p.config.SnapshotInterval)
+	defer t.Stop()
+
+	for {
+		p.mu.Lock()
+		data := AppendPacket(nil, &Packet{Seq: p.seq, SendingTime: p.now(), Type: PacketSnapshot, Depth: p.depth})
+		p.mu.Unlock()
+
+		p.send(data, p.config.Snapshot)
+
+		select {
+		case <-t.C:
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// packet returns the incremental packet with the given seq, if it's still kept.
+func (p *Publisher) packet(seq uint64) ([]byte, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if seq == 0 || seq > p.seq || p.seq-seq >= uint64(len(p.sent)) {
+		return nil, false
+	}
+
+	return p.sent[seq%uint64(len(p.sent))], true
+}
@@ -0,0 +1,309 @@
+package multicast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+const (
+	// maxPending bounds the incremental packets held while waiting on a snapshot or a retransmission.
+	maxPending = 1 << 16
+
+	readBufferSize = 4 << 20
+)
+
+type ReceiverConfig struct {
+	Incremental *net.UDPAddr
+	Snapshot    *net.UDPAddr
+	// Interface is the interface the groups are joined on; the system's default for multicast if nil.
+	Interface *net.Interface
+	// RetransmitAddr is the publisher's retransmission server; if empty, missed packets are recovered from the next
+	// snapshot instead.
+	RetransmitAddr string
+
+	// OnTrade, if set, is called with every trade, in order, from Run's goroutine. Trades missed & not retransmitted are
+	// skipped by a snapshot.
+	OnTrade func(Trade)
+}
+
+// ReceiverStats are counts of what a receiver's received.
+type ReceiverStats struct {
+	Packets       uint64
+	Retransmitted uint64
+	Snapshots     uint64
+	// Gaps is how many times packets were missed, and Resyncs how many times the depth was taken from a snapshot.
+	Gaps    uint64
+	Resyncs uint64
+}
+
+// NewReceiver returns a receiver joined to the incremental & snapshot groups; it receives once Run.
+func NewReceiver(config ReceiverConfig) (*Receiver, error) {
+	if config.Incremental == nil || config.Snapshot == nil {
+		return nil, errors.New("incremental & snapshot groups are required")
+	}
+
+	incremental, err := listen(config.Interface, config.Incremental)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := listen(config.Interface, config.Snapshot)
+	if err != nil {
+		incremental.Close()
+		return nil, err
+	}
+
+	return &Receiver{
+		config:      config,
+		incremental: incremental,
+		snapshot:    snapshot,
+		received:    make(chan Packet, 1024),
+		failed:      make(chan error, 2),
+		pending:     make(map[uint64]Packet),
+	}, nil
+}
+
+func listen(ifi *net.Interface, group *net.UDPAddr) (*net.UDPConn, error) {
+	udpConn, err := net.ListenMulticastUDP("udp4", ifi, group)
+	if err != nil {
+		return nil, fmt.Errorf("join multicast group %s: %w", group, err)
+	}
+
+	if err := udpConn.SetReadBuffer(readBufferSize); err != nil {
+		slog.Debug("Multicast: failed to set read buffer", "group", group.String(), "error", err)
+	}
+
+	return udpConn, nil
+}
+
+// Receiver is a reference receiver: it rebuilds the publisher's depth from its incremental packets, starting from a
+// snapshot. Missed packets are requested from the retransmission server; if they can't be, or the depth's checksum
+// doesn't match the publisher's, it waits for the next snapshot.
+type Receiver struct {
+	config      ReceiverConfig
+	incremental *net.UDPConn
+	snapshot    *net.UDPConn
+	received    chan Packet
+	failed      chan error
+
+	// depth is as of the incremental packet before nextSeq, if synced. pending are incremental packets received out of
+	// order, by seq, and requested the last seq requested from the retransmission server. All are guarded by mu.
+	depth     lob.DepthSnapshot
+	synced    bool
+	nextSeq   uint64
+	pending   map[uint64]Packet
+	requested uint64
+	stats     ReceiverStats
+	trades    []Trade
+	mu        sync.Mutex
+}
+
+// Depth returns the rebuilt depth, if the receiver's in sync with the publisher.
+func (r *Receiver) Depth() (lob.DepthSnapshot, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.depth, r.synced
+}
+
+func (r *Receiver) Stats() ReceiverStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stats
+}
+
+// Run receives until the context's done, then leaves the groups.
+func (r *Receiver) Run(ctx context.Context) error {
+	defer r.snapshot.Close()
+	defer r.incremental.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go r.read(ctx, r.incremental)
+	go r.read(ctx, r.snapshot)
+
+	for {
+		select {
+		case packet := <-r.received:
+			r.handle(ctx, packet)
+
+			for _, trade := range r.trades {
+				r.config.OnTrade(trade)
+			}
+			r.trades = r.trades[:0]
+		case err := <-r.failed:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (r *Receiver) read(ctx context.Context, udpConn *net.UDPConn) {
+	go func() {
+		<-ctx.Done()
+		udpConn.Close()
+	}()
+
+	buf := make([]byte, MaxPacketSize)
+	for {
+		n, _, err := udpConn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.failed <- fmt.Errorf("receive: %w", err)
+			}
+			return
+		}
+
+		packet, err := DecodePacket(buf[:n])
+		if err != nil {
+			slog.Warn("Multicast: failed to decode packet", "error", err)
+			continue
+		}
+
+		select {
+		case r.received <- packet:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Receiver) handle(ctx context.Context, packet Packet) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch packet.Type {
+	case PacketSnapshot:
+		r.stats.Snapshots++
+
+		// A snapshot's only needed to start, or to recover packets the retransmission server couldn't.
+		if r.synced && r.requested < r.nextSeq {
+			return
+		}
+
+		if r.synced && packet.Seq < r.nextSeq {
+			return
+		}
+
+		if lob.Checksum(packet.Depth.Bids, packet.Depth.Asks) != packet.Depth.Checksum {
+			slog.Warn("Multicast: snapshot checksum mismatch", "seq", packet.Seq)
+			return
+		}
+
+		r.depth, r.synced = packet.Depth, true
+		r.nextSeq, r.requested = packet.Seq+1, packet.Seq
+		r.stats.Resyncs++
+
+		for seq := range r.pending {
+			if seq <= packet.Seq {
+				delete(r.pending, seq)
+			}
+		}
+		r.drain()
+
+		return
+	case PacketHeartbeat:
+		// The publisher's sent up to the heartbeat's seq; any not received were missed.
+		if r.synced && packet.Seq >= r.nextSeq {
+			r.retransmit(ctx, packet.Seq)
+		}
+
+		return
+	}
+
+	r.stats.Packets++
+
+	if r.synced && packet.Seq < r.nextSeq {
+		return
+	}
+
+	if len(r.pending) >= maxPending {
+		slog.Warn("Multicast: too many pending packets; waiting for a snapshot", "next_seq", r.nextSeq)
+		clear(r.pending)
+		r.synced = false
+	}
+	r.pending[packet.Seq] = packet
+
+	if !r.synced {
+		return
+	}
+
+	r.drain()
+	if r.synced && packet.Seq > r.nextSeq {
+		r.retransmit(ctx, packet.Seq-1)
+	}
+}
+
+// drain applies pending packets in order, until one's missing.
+func (r *Receiver) drain() {
+	for r.synced {
+		packet, ok := r.pending[r.nextSeq]
+		if !ok {
+			return
+		}
+		delete(r.pending, r.nextSeq)
+
+		switch packet.Type {
+		case PacketDepthUpdate:
+			depth := lob.ApplyDepth(r.depth, packet.Changes)
+			if depth.Checksum != packet.Depth.Checksum {
+				slog.Warn("Multicast: depth checksum mismatch; waiting for a snapshot", "seq", packet.Seq)
+				r.synced = false
+				return
+			}
+
+			depth.Seq = packet.Depth.Seq
+			r.depth = depth
+		case PacketTrade:
+			if r.config.OnTrade != nil {
+				r.trades = append(r.trades, packet.Trade)
+			}
+		}
+
+		r.nextSeq++
+	}
+}
+
+// retransmit requests the packets missed, up to seq, that haven't already been; they're handled as they're received.
+func (r *Receiver) retransmit(ctx context.Context, to uint64) {
+	from := max(r.nextSeq, r.requested+1)
+	if from > to {
+		return
+	}
+
+	r.requested = to
+	r.stats.Gaps++
+
+	if r.config.RetransmitAddr == "" {
+		return
+	}
+
+	go func() {
+		packets, err := Retransmit(ctx, r.config.RetransmitAddr, from, to)
+		if err != nil {
+			slog.Warn("Multicast: failed to request retransmission; waiting for a snapshot", "from", from, "to", to, "error", err)
+			return
+		}
+
+		r.mu.Lock()
+		r.stats.Retransmitted += uint64(len(packets))
+		r.mu.Unlock()
+
+		for _, packet := range packets {
+			select {
+			case r.received <- packet:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
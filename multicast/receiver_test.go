@@ -0,0 +1,204 @@
+package multicast
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+func publish(t *testing.T, book *lob.Orderbook, incremental, snapshot *net.UDPAddr, ifi *net.Interface) (*Publisher, string) {
+	t.Helper()
+
+	publisher, err := NewPublisher(PublisherConfig{
+		Book:              book,
+		DepthLevels:       5,
+		Incremental:       incremental,
+		Snapshot:          snapshot,
+		Interface:         ifi,
+		SnapshotInterval:  20 * time.Millisecond,
+		HeartbeatInterval: 20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { publisher.Close() })
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go publisher.ServeRetransmit(l)
+
+	return publisher, l.Addr().String()
+}
+
+// groups returns loopback multicast groups on free ports.
+func groups(t *testing.T) (*net.Interface, *net.UDPAddr, *net.UDPAddr) {
+	t.Helper()
+
+	ifi, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skipf("no loopback interface: %v", err)
+	}
+
+	port := func() int {
+		udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+		require.NoError(t, err)
+		defer udpConn.Close()
+
+		return udpConn.LocalAddr().(*net.UDPAddr).Port
+	}
+
+	return ifi, &net.UDPAddr{IP: net.IPv4(239, 255, 0, 1), Port: port()}, &net.UDPAddr{IP: net.IPv4(239, 255, 0, 2), Port: port()}
+}
+
+func TestReceiver(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	ifi, incremental, snapshot := groups(t)
+
+	book := lob.NewOrderbook(128, lob.WithDepthEvents(5))
+	_, addr := publish(t, book, incremental, snapshot, ifi)
+
+	c := client.NewLOBClient(book)
+	for _, req := range []client.AddOrderRequest{
+		{OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 100, Size: 5},
+		{OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 99, Size: 3},
+		{OrderType: lob.LimitOrder, OrderSide: lob.SellSide, Price: 101, Size: 4},
+	} {
+		_, err := c.AddOrder(ctx, req)
+		require.NoError(t, err)
+	}
+
+	// The receiver joins late, so starts from a snapshot.
+	trades := make(chan Trade, 16)
+	receiver, err := NewReceiver(ReceiverConfig{
+		Incremental:    incremental,
+		Snapshot:       snapshot,
+		Interface:      ifi,
+		RetransmitAddr: addr,
+		OnTrade:        func(trade Trade) { trades <- trade },
+	})
+	if err != nil {
+		t.Skipf("multicast unavailable: %v", err)
+	}
+
+	go receiver.Run(ctx)
+
+	synced := func() bool {
+		got, ok := receiver.Depth()
+		want := book.DepthSnapshot(5)
+		return ok && got.Seq == want.Seq && got.Checksum == want.Checksum
+	}
+	require.Eventually(t, synced, 2*time.Second, 5*time.Millisecond)
+
+	_, err = c.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.MarketOrder, OrderSide: lob.SellSide, Size: 6})
+	require.NoError(t, err)
+
+	for _, want := range []Trade{{Price: 100, Size: 5}, {Price: 99, Size: 1}} {
+		select {
+		case trade := <-trades:
+			assert.Equal(t, want.Price, trade.Price)
+			assert.Equal(t, want.Size, trade.Size)
+			assert.Equal(t, lob.SellSide, trade.AggressorSide)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for trade")
+		}
+	}
+
+	require.Eventually(t, synced, 2*time.Second, 5*time.Millisecond)
+
+	got, _ := receiver.Depth()
+	assert.Equal(t, []lob.DepthLevel{{Price: 99, Size: 2}}, got.Bids)
+	assert.Equal(t, []lob.DepthLevel{{Price: 101, Size: 4}}, got.Asks)
+}
+
+func TestReceiver_Recovery(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	ifi, incremental, snapshot := groups(t)
+
+	book := lob.NewOrderbook(128, lob.WithDepthEvents(5))
+	publisher, addr := publish(t, book, incremental, snapshot, ifi)
+
+	// Packets are handed to the receiver directly, so some can be missed.
+	receiver := &Receiver{config: ReceiverConfig{RetransmitAddr: addr}, received: make(chan Packet, 16), pending: make(map[uint64]Packet)}
+	receiver.handle(ctx, Packet{Type: PacketSnapshot, Depth: book.DepthSnapshot(5)})
+
+	c := client.NewLOBClient(book)
+	for i := 0; i < 5; i++ {
+		_, err := c.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: lob.Price(100 - i), Size: 1})
+		require.NoError(t, err)
+	}
+
+	packet := func(seq uint64) Packet {
+		data, ok := publisher.packet(seq)
+		require.True(t, ok, seq)
+
+		packet, err := DecodePacket(data)
+		require.NoError(t, err)
+		return packet
+	}
+
+	// Missing 2 & 3, they're retransmitted.
+	receiver.handle(ctx, packet(1))
+	receiver.handle(ctx, packet(4))
+	for i := 0; i < 2; i++ {
+		select {
+		case retransmitted := <-receiver.received:
+			receiver.handle(ctx, retransmitted)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for retransmission")
+		}
+	}
+
+	got, ok := receiver.Depth()
+	require.True(t, ok)
+	assert.Len(t, got.Bids, 4)
+	assert.Equal(t, uint64(5), receiver.nextSeq)
+
+	// Missing the last, the heartbeat after it has it retransmitted.
+	receiver.handle(ctx, Packet{Seq: 5, Type: PacketHeartbeat})
+	select {
+	case retransmitted := <-receiver.received:
+		receiver.handle(ctx, retransmitted)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for retransmission")
+	}
+
+	got, ok = receiver.Depth()
+	require.True(t, ok)
+	assert.Equal(t, book.DepthSnapshot(5).Checksum, got.Checksum)
+
+	stats := receiver.Stats()
+	assert.Equal(t, uint64(2), stats.Gaps)
+	assert.Equal(t, uint64(3), stats.Retransmitted)
+
+	// A mismatched checksum waits for the next snapshot.
+	_, err := c.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.LimitOrder, OrderSide: lob.SellSide, Price: 110, Size: 1})
+	require.NoError(t, err)
+
+	corrupt := packet(6)
+	corrupt.Depth.Checksum++
+	receiver.handle(ctx, corrupt)
+
+	_, ok = receiver.Depth()
+	assert.False(t, ok)
+
+	receiver.handle(ctx, Packet{Seq: 6, Type: PacketSnapshot, Depth: book.DepthSnapshot(5)})
+	got, ok = receiver.Depth()
+	require.True(t, ok)
+	assert.Equal(t, book.DepthSnapshot(5).Checksum, got.Checksum)
+	assert.Equal(t, uint64(7), receiver.nextSeq)
+}
@@ -0,0 +1,135 @@
+package multicast
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// Retransmission is over TCP: a receiver sends the first & last seq of the packets it missed, as two u64s, and is sent
+// each of them still kept, in order, as a u16 length followed by the packet, then a zero length. A connection may carry
+// any number of requests.
+
+const (
+	retransmitRequestSize = 16
+
+	// retransmitTimeout bounds a retransmission request & its response.
+	retransmitTimeout = 5 * time.Second
+)
+
+// ServeRetransmit serves retransmission requests until the listener is closed.
+func (p *Publisher) ServeRetransmit(l net.Listener) error {
+	for {
+		netConn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return fmt.Errorf("accept retransmission connection: %w", err)
+		}
+
+		go p.retransmit(netConn)
+	}
+}
+
+func (p *Publisher) retransmit(netConn net.Conn) {
+	defer netConn.Close()
+
+	var (
+		r   = bufio.NewReader(netConn)
+		w   = bufio.NewWriter(netConn)
+		req [retransmitRequestSize]byte
+	)
+	for {
+		if _, err := io.ReadFull(r, req[:]); err != nil {
+			return
+		}
+
+		from, to := binary.LittleEndian.Uint64(req[0:]), binary.LittleEndian.Uint64(req[8:])
+		if to-from >= uint64(p.config.RetransmitPackets) {
+			to = from + uint64(p.config.RetransmitPackets) - 1
+		}
+
+		if err := netConn.SetWriteDeadline(p.now().Add(retransmitTimeout)); err != nil {
+			return
+		}
+
+		for seq := from; seq <= to && seq >= from; seq++ {
+			data, ok := p.packet(seq)
+			if !ok {
+				continue
+			}
+
+			w.Write(binary.LittleEndian.AppendUint16(nil, uint16(len(data))))
+			w.Write(data)
+		}
+
+		w.Write([]byte{0, 0})
+		if err := w.Flush(); err != nil {
+			slog.Warn("Multicast: failed to retransmit", "remote", netConn.RemoteAddr().String(), "error", err)
+			return
+		}
+	}
+}
+
+// Retransmit requests the packets from & to seq, inclusive, from the retransmission server at addr; only those it still
+// keeps are returned.
+func Retransmit(ctx context.Context, addr string, from, to uint64) ([]Packet, error) {
+	ctx, cancel := context.WithTimeout(ctx, retransmitTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial retransmission server %s: %w", addr, err)
+	}
+	defer netConn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := netConn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	var req [retransmitRequestSize]byte
+	binary.LittleEndian.PutUint64(req[0:], from)
+	binary.LittleEndian.PutUint64(req[8:], to)
+	if _, err := netConn.Write(req[:]); err != nil {
+		return nil, fmt.Errorf("request retransmission: %w", err)
+	}
+
+	var (
+		r       = bufio.NewReader(netConn)
+		packets []Packet
+		length  [2]byte
+	)
+	for {
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return nil, fmt.Errorf("read retransmission: %w", err)
+		}
+
+		size := binary.LittleEndian.Uint16(length[:])
+		if size == 0 {
+			return packets, nil
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("read retransmission: %w", err)
+		}
+
+		packet, err := DecodePacket(data)
+		if err != nil {
+			return nil, fmt.Errorf("read retransmission: %w", err)
+		}
+
+		packets = append(packets, packet)
+	}
+}
@@ -0,0 +1,188 @@
+package pnl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// Position is an account's position in a single instrument. Size is signed; negative when short.
+type Position struct {
+	AccountID     uint64
+	Size          lob.Size
+	AvgEntryPrice lob.Price
+	RealizedPnL   float64
+	UnrealizedPnL float64
+	Fees          float64
+	MarkPrice     lob.Price
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf(`account=%d size=%.6f entry=%.6f realized=%.6f unrealized=%.6f fees=%.6f mark=%.6f`, p.AccountID, p.Size, p.AvgEntryPrice, p.RealizedPnL, p.UnrealizedPnL, p.Fees, p.MarkPrice)
+}
+
+// Snapshot is the tracker's full state, for persisting and restoring.
+type Snapshot struct {
+	Positions []Position
+}
+
+// Marker is where positions are marked to; satisfied by *lob.Orderbook.
+type Marker interface {
+	Mid() (lob.Price, error)
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		positions: make(map[uint64]*Position),
+	}
+}
+
+// Tracker maintains positions & PnL per account from an Orderbook's trades; subscribe HandleEvent to the Orderbook.
+type Tracker struct {
+	positions map[uint64]*Position
+	mu        sync.RWMutex
+}
+
+// HandleEvent applies trades to both the buyer's & seller's positions.
+func (t *Tracker) HandleEvent(event lob.Event) {
+	trade, ok := event.(lob.TradeEvent)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.position(trade.BuyAccountID).apply(trade.Size, trade.Price, trade.BuyFee)
+	t.position(trade.SellAccountID).apply(-trade.Size, trade.Price, trade.SellFee)
+}
+
+// Position returns the account's position, with unrealized PnL marked to the given price.
+func (t *Tracker) Position(accountID uint64, mark lob.Price) Position {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	p, ok := t.positions[accountID]
+	if !ok {
+		return Position{AccountID: accountID, MarkPrice: mark}
+	}
+
+	return p.marked(mark)
+}
+
+// Positions returns every account's position, ordered by account, with unrealized PnL marked to the given price.
+func (t *Tracker) Positions(mark lob.Price) []Position {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	positions := make([]Position, 0, len(t.positions))
+	for _, p := range t.positions {
+		positions = append(positions, p.marked(mark))
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].AccountID < positions[j].AccountID
+	})
+
+	return positions
+}
+
+// MarkToMid returns every account's position, with unrealized PnL marked to the book's mid.
+func (t *Tracker) MarkToMid(marker Marker) ([]Position, error) {
+	mid, err := marker.Mid()
+	if err != nil {
+		return nil, fmt.Errorf("mark to mid: %w", err)
+	}
+
+	return t.Positions(mid), nil
+}
+
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	positions := make([]Position, 0, len(t.positions))
+	for _, p := range t.positions {
+		positions = append(positions, *p)
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].AccountID < positions[j].AccountID
+	})
+
+	return Snapshot{
+		Positions: positions,
+	}
+}
+
+// Restore replaces every position with those in the snapshot.
+func (t *Tracker) Restore(snapshot Snapshot) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.positions = make(map[uint64]*Position, len(snapshot.Positions))
+	for _, p := range snapshot.Positions {
+		p := p
+		p.UnrealizedPnL, p.MarkPrice = 0, 0
+		t.positions[p.AccountID] = &p
+	}
+}
+
+func (t *Tracker) position(accountID uint64) *Position {
+	p, ok := t.positions[accountID]
+	if !ok {
+		p = &Position{AccountID: accountID}
+		t.positions[accountID] = p
+	}
+
+	return p
+}
+
+// apply adds a signed fill to the position. Fills that reduce the position realize PnL against the average entry price;
+// any size left over once the position flips opens at the fill price.
+func (p *Position) apply(size lob.Size, price lob.Price, fee float64) {
+	p.Fees += fee
+
+	if p.Size == 0 || sameSign(p.Size, size) {
+		total := math.Abs(float64(p.Size)) + math.Abs(float64(size))
+		p.AvgEntryPrice = lob.Price((float64(p.AvgEntryPrice)*math.Abs(float64(p.Size)) + float64(price)*math.Abs(float64(size))) / total)
+		p.Size += size
+		return
+	}
+
+	closed := lob.Size(math.Min(math.Abs(float64(p.Size)), math.Abs(float64(size))))
+
+	direction := 1.0
+	if p.Size < 0 {
+		direction = -1.0
+	}
+
+	p.RealizedPnL += float64(price-p.AvgEntryPrice) * float64(closed) * direction
+	p.Size += size
+
+	switch {
+	case p.Size == 0:
+		p.AvgEntryPrice = 0
+	case !sameSign(p.Size, -size):
+		// Flipped through flat; what's left is a new position at the fill price.
+		p.AvgEntryPrice = price
+	}
+}
+
+func (p *Position) marked(mark lob.Price) Position {
+	marked := *p
+	marked.MarkPrice = mark
+
+	if mark > 0 && p.Size != 0 {
+		marked.UnrealizedPnL = float64(mark-p.AvgEntryPrice) * float64(p.Size)
+	}
+
+	return marked
+}
+
+func sameSign(a, b lob.Size) bool {
+	return (a > 0) == (b > 0)
+}
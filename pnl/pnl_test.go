@@ -0,0 +1,105 @@
+package pnl
+
+import (
+	"testing"
+
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPosition_Apply(t *testing.T) {
+	t.Parallel()
+
+	type fill struct {
+		size  lob.Size
+		price lob.Price
+	}
+
+	tests := []struct {
+		name             string
+		fills            []fill
+		expectedSize     lob.Size
+		expectedEntry    lob.Price
+		expectedRealized float64
+	}{
+		{
+			name:          "increasing_long",
+			fills:         []fill{{1, 100}, {3, 104}},
+			expectedSize:  4,
+			expectedEntry: 103,
+		},
+		{
+			name:             "partially_closing_long",
+			fills:            []fill{{2, 100}, {-1, 110}},
+			expectedSize:     1,
+			expectedEntry:    100,
+			expectedRealized: 10,
+		},
+		{
+			name:             "closing_short",
+			fills:            []fill{{-2, 100}, {2, 90}},
+			expectedSize:     0,
+			expectedEntry:    0,
+			expectedRealized: 20,
+		},
+		{
+			name:             "flipping_long_to_short",
+			fills:            []fill{{2, 100}, {-3, 95}},
+			expectedSize:     -1,
+			expectedEntry:    95,
+			expectedRealized: -10,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &Position{}
+			for _, f := range tt.fills {
+				p.apply(f.size, f.price, 0)
+			}
+
+			assert.Equal(t, tt.expectedSize, p.Size)
+			assert.Equal(t, tt.expectedEntry, p.AvgEntryPrice)
+			assert.Equal(t, tt.expectedRealized, p.RealizedPnL)
+		})
+	}
+}
+
+func TestTracker_FromOrderbook(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128)
+	tracker := NewTracker()
+	book.Subscribe(tracker.HandleEvent)
+
+	place := func(accountID uint64, orderType lob.OrderType, side lob.OrderSide, price lob.Price, size lob.Size) {
+		order := lob.NewOrder(orderType, side, price, size)
+		order.AccountID = accountID
+
+		_, err := book.PlaceOrder(order)
+		require.NoError(t, err)
+	}
+
+	place(1, lob.LimitOrder, lob.SellSide, 100, 2)
+	place(2, lob.MarketOrder, lob.BuySide, 0, 2)
+
+	// Quote a market around 110.
+	place(3, lob.LimitOrder, lob.BuySide, 109, 1)
+	place(3, lob.LimitOrder, lob.SellSide, 111, 1)
+
+	positions, err := tracker.MarkToMid(book)
+	require.NoError(t, err)
+	require.Len(t, positions, 2)
+
+	assert.Equal(t, Position{AccountID: 1, Size: -2, AvgEntryPrice: 100, UnrealizedPnL: -20, MarkPrice: 110}, positions[0])
+	assert.Equal(t, Position{AccountID: 2, Size: 2, AvgEntryPrice: 100, UnrealizedPnL: 20, MarkPrice: 110}, positions[1])
+
+	restored := NewTracker()
+	restored.Restore(tracker.Snapshot())
+	assert.Equal(t, tracker.Positions(110), restored.Positions(110))
+	assert.Equal(t, Position{AccountID: 4, MarkPrice: 110}, restored.Position(4, 110))
+}
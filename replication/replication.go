@@ -0,0 +1,508 @@
+package replication
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sashajdn/orderbook/journal"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+type Role uint8
+
+const (
+	RoleFollower Role = iota + 1
+	RolePrimary
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleFollower:
+		return "follower"
+	case RolePrimary:
+		return "primary"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	DefaultAckTimeout   = time.Second
+	DefaultWriteTimeout = time.Second
+)
+
+var (
+	ErrNotPrimary         = errors.New("not the primary")
+	ErrNotEnoughFollowers = errors.New("not enough followers connected")
+	ErrAckTimeout         = errors.New("timed out waiting for follower acks")
+	ErrSequenceGap        = errors.New("sequence gap")
+)
+
+type Config struct {
+	Role Role
+
+	// Journal, if set, is the node's local journal; a primary appends every command to it before replicating it,
+	// and a follower appends every command it receives before applying it.
+	Journal *journal.Writer
+	// JournalPath is where Journal writes to; followers that connect behind the primary are caught up from it. Without
+	// it, followers behind the primary are rejected.
+	JournalPath string
+
+	// MinAcks is how many followers must acknowledge each command before the primary applies it; 0 replicates asynchronously.
+	// The primary refuses commands while fewer than MinAcks followers are connected, and once a command isn't acknowledged
+	// within AckTimeout, until it is.
+	MinAcks    int
+	AckTimeout time.Duration
+
+	WriteTimeout time.Duration
+}
+
+func NewNode(config Config) *Node {
+	if config.Role == 0 {
+		config.Role = RoleFollower
+	}
+
+	if config.AckTimeout == 0 {
+		config.AckTimeout = DefaultAckTimeout
+	}
+
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = DefaultWriteTimeout
+	}
+
+	return &Node{
+		config:    config,
+		role:      config.Role,
+		followers: make(map[*follower]struct{}),
+		acked:     make(chan struct{}, 1),
+	}
+}
+
+// Node replicates an Orderbook's journal from a primary to its followers over TCP. Pass the node to the Orderbook with
+// lob.WithJournal: as the primary it replicates every command before the Orderbook applies it, and as a follower it refuses
+// commands, since its Orderbook is only driven by the primary's stream.
+//
+// Followers acknowledge a command once they've applied it, so with MinAcks set no acknowledged command is lost when a
+// follower is promoted.
+type Node struct {
+	config Config
+	role   Role
+
+	// followers connected to the primary.
+	followers map[*follower]struct{}
+	acked     chan struct{}
+	buf       []byte
+
+	// unacked is the last command that wasn't acknowledged by MinAcks followers in time; commands are refused until it is.
+	unacked uint64
+
+	// lastSeq is the last command replicated as the primary, or applied as a follower.
+	lastSeq atomic.Uint64
+
+	// cancelFollow stops the follower's stream once it's promoted, and following is closed once it has.
+	cancelFollow context.CancelFunc
+	following    chan struct{}
+
+	mu sync.Mutex
+}
+
+type follower struct {
+	conn  net.Conn
+	acked atomic.Uint64
+}
+
+func (n *Node) Role() Role {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.role
+}
+
+// Followers returns how many followers are connected to the primary.
+func (n *Node) Followers() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.followers)
+}
+
+// Append replicates the command to the followers; it satisfies lob.Journal.
+func (n *Node) Append(cmd lob.Command) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.role != RolePrimary {
+		return ErrNotPrimary
+	}
+
+	if len(n.followers) < n.config.MinAcks {
+		return fmt.Errorf("%w: %d of %d", ErrNotEnoughFollowers, len(n.followers), n.config.MinAcks)
+	}
+
+	if n.unacked > 0 {
+		if acks := n.acks(n.unacked); acks < n.config.MinAcks {
+			return fmt.Errorf("%w: seq %d acked by %d of %d", ErrAckTimeout, n.unacked, acks, n.config.MinAcks)
+		}
+
+		n.unacked = 0
+	}
+
+	frame, err := journal.AppendFrame(n.buf[:0], cmd)
+	if err != nil {
+		return err
+	}
+	n.buf = frame
+
+	if n.config.Journal != nil {
+		if err := n.config.Journal.Append(cmd); err != nil {
+			return fmt.Errorf("append to local journal: %w", err)
+		}
+	}
+	n.lastSeq.Store(cmd.Seq)
+
+	for f := range n.followers {
+		if err := n.send(f, n.buf); err != nil {
+			slog.Warn("Replication: dropping follower", "follower", f.conn.RemoteAddr().String(), "error", err)
+			n.drop(f)
+		}
+	}
+
+	if n.config.MinAcks == 0 {
+		return nil
+	}
+
+	// The command's already local & with the followers, so it must be applied, or the primary would reuse its sequence
+	// number; instead every command after it is refused until enough followers have acknowledged it.
+	if err := n.waitForAcks(cmd.Seq); err != nil {
+		slog.Warn("Replication: refusing commands until enough followers acknowledge", "seq", cmd.Seq, "error", err)
+		n.unacked = cmd.Seq
+	}
+
+	return nil
+}
+
+func (n *Node) send(f *follower, frame []byte) error {
+	if err := f.conn.SetWriteDeadline(time.Now().Add(n.config.WriteTimeout)); err != nil {
+		return err
+	}
+
+	_, err := f.conn.Write(frame)
+	return err
+}
+
+func (n *Node) waitForAcks(seq uint64) error {
+	timer := time.NewTimer(n.config.AckTimeout)
+	defer timer.Stop()
+
+	for {
+		acks := n.acks(seq)
+		if acks >= n.config.MinAcks {
+			return nil
+		}
+
+		select {
+		case <-n.acked:
+		case <-timer.C:
+			return fmt.Errorf("%w: seq %d acked by %d of %d", ErrAckTimeout, seq, acks, n.config.MinAcks)
+		}
+	}
+}
+
+// acks counts the followers that have acknowledged the command.
+func (n *Node) acks(seq uint64) int {
+	var acks int
+	for f := range n.followers {
+		if f.acked.Load() >= seq {
+			acks++
+		}
+	}
+
+	return acks
+}
+
+func (n *Node) drop(f *follower) {
+	delete(n.followers, f)
+	f.conn.Close()
+}
+
+// Serve accepts followers until the listener is closed. Each follower is caught up from the local journal, then streamed
+// every command from then on.
+func (n *Node) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return fmt.Errorf("accept follower: %w", err)
+		}
+
+		go n.handleFollower(conn)
+	}
+}
+
+func (n *Node) handleFollower(conn net.Conn) {
+	var hello [8]byte
+	if _, err := io.ReadFull(conn, hello[:]); err != nil {
+		slog.Warn("Replication: follower failed to say hello", "follower", conn.RemoteAddr().String(), "error", err)
+		conn.Close()
+		return
+	}
+
+	f := &follower{conn: conn}
+	f.acked.Store(binary.LittleEndian.Uint64(hello[:]))
+
+	if err := n.register(f); err != nil {
+		slog.Warn("Replication: rejecting follower", "follower", conn.RemoteAddr().String(), "error", err)
+		conn.Close()
+		return
+	}
+
+	slog.Info("Replication: follower connected", "follower", conn.RemoteAddr().String(), "seq", f.acked.Load())
+
+	var ack [8]byte
+	for {
+		if _, err := io.ReadFull(conn, ack[:]); err != nil {
+			n.mu.Lock()
+			if _, ok := n.followers[f]; ok {
+				slog.Warn("Replication: follower disconnected", "follower", conn.RemoteAddr().String(), "error", err)
+				n.drop(f)
+			}
+			n.mu.Unlock()
+			return
+		}
+
+		f.acked.Store(binary.LittleEndian.Uint64(ack[:]))
+
+		select {
+		case n.acked <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// register catches the follower up from the local journal then adds it to the stream. The follower's caught up to where
+// the journal was when it connected without holding off commands; only those journaled since are sent with them held off.
+func (n *Node) register(f *follower) error {
+	from := f.acked.Load()
+
+	n.mu.Lock()
+	if n.role != RolePrimary {
+		n.mu.Unlock()
+		return ErrNotPrimary
+	}
+
+	to := n.lastSeq.Load()
+	if n.config.Journal != nil {
+		to = max(to, n.config.Journal.LastSeq())
+	}
+	n.mu.Unlock()
+
+	if from > to {
+		return fmt.Errorf("follower at seq %d is ahead of the primary at %d", from, to)
+	}
+
+	// Commands it missed can only be sent from the journal; streamed only those after, it'd diverge.
+	if n.config.JournalPath == "" && from < to {
+		return fmt.Errorf("follower at seq %d is behind the primary at %d, with no journal to catch it up from", from, to)
+	}
+
+	var offset int64
+	if n.config.JournalPath != "" && to > from {
+		var err error
+		if offset, err = n.catchUp(f, 0, from, to); err != nil {
+			return fmt.Errorf("catch up from seq %d: %w", from, err)
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.role != RolePrimary {
+		return ErrNotPrimary
+	}
+
+	if n.config.JournalPath != "" {
+		if _, err := n.catchUp(f, offset, to, math.MaxUint64); err != nil {
+			return fmt.Errorf("catch up from seq %d: %w", to, err)
+		}
+	}
+
+	n.followers[f] = struct{}{}
+
+	return nil
+}
+
+// catchUp sends the follower every journaled command after from, up to & including to, reading the journal from the
+// given offset. It returns the offset to carry on from.
+func (n *Node) catchUp(f *follower, offset int64, from, to uint64) (int64, error) {
+	file, err := os.Open(n.config.JournalPath)
+	if err != nil {
+		return offset, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	var (
+		r   = journal.NewReader(file)
+		buf []byte
+	)
+	for {
+		next := offset + r.Offset()
+
+		cmd, err := r.Next()
+		// The tail may be a hash record still being written.
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return next, nil
+		}
+
+		if err != nil {
+			return next, err
+		}
+
+		if cmd.Seq <= from {
+			continue
+		}
+
+		if cmd.Seq > to {
+			return next, nil
+		}
+
+		buf, err = journal.AppendFrame(buf[:0], cmd)
+		if err != nil {
+			return next, err
+		}
+
+		if err := n.send(f, buf); err != nil {
+			return next, err
+		}
+	}
+}
+
+// Follow streams commands from the primary at addr, applying each to the book, and its local journal first if it has one,
+// then acknowledging it. It runs until the connection fails, the context is cancelled, or the node is promoted.
+func (n *Node) Follow(ctx context.Context, addr string, book *lob.Orderbook) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n.mu.Lock()
+	if n.role != RoleFollower {
+		n.mu.Unlock()
+		return fmt.Errorf("follow %s: node is %s", addr, n.role)
+	}
+	following := make(chan struct{})
+	defer close(following)
+
+	n.cancelFollow, n.following = cancel, following
+	n.mu.Unlock()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("follow %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	n.lastSeq.Store(book.LastSeq())
+
+	var seq [8]byte
+	binary.LittleEndian.PutUint64(seq[:], book.LastSeq())
+	if _, err := conn.Write(seq[:]); err != nil {
+		return fmt.Errorf("follow %s: hello: %w", addr, err)
+	}
+
+	r := journal.NewReader(conn)
+	for {
+		cmd, err := r.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("follow %s: read command: %w", addr, err)
+		}
+
+		if cmd.Seq <= book.LastSeq() {
+			continue
+		}
+
+		if last := book.LastSeq(); cmd.Seq != last+1 {
+			return fmt.Errorf("follow %s: %w: received seq %d at %d", addr, ErrSequenceGap, cmd.Seq, last)
+		}
+
+		if n.config.Journal != nil {
+			if err := n.config.Journal.Append(cmd); err != nil {
+				return fmt.Errorf("follow %s: append to local journal: %w", addr, err)
+			}
+		}
+
+		// Rejections replay just as they happened on the primary.
+		if err := book.Apply(cmd); err != nil {
+			slog.Debug("Replication: applied command failed", "cmd", cmd.String(), "error", err)
+		}
+		n.lastSeq.Store(cmd.Seq)
+
+		binary.LittleEndian.PutUint64(seq[:], cmd.Seq)
+		if _, err := conn.Write(seq[:]); err != nil {
+			return fmt.Errorf("follow %s: ack %d: %w", addr, cmd.Seq, err)
+		}
+	}
+}
+
+// Promote makes the follower the primary: it stops following, and its Orderbook accepts commands, replicating them to
+// any followers that connect to it with Serve.
+func (n *Node) Promote() error {
+	n.mu.Lock()
+	if n.role == RolePrimary {
+		n.mu.Unlock()
+		return fmt.Errorf("promote: already primary")
+	}
+
+	cancel, following := n.cancelFollow, n.following
+	n.mu.Unlock()
+
+	// Let the stream finish applying whatever it's in the middle of, so nothing from the old primary lands after our own commands.
+	if cancel != nil {
+		cancel()
+		<-following
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.role = RolePrimary
+	slog.Info("Replication: promoted to primary")
+
+	return nil
+}
+
+// Close disconnects every follower.
+func (n *Node) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for f := range n.followers {
+		n.drop(f)
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package replication
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sashajdn/orderbook/journal"
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testNode struct {
+	node *Node
+	book *lob.Orderbook
+}
+
+func newTestNode(t *testing.T, role Role, minAcks int) testNode {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "journal")
+	w, err := journal.Open(path, journal.Config{Sync: journal.SyncOS})
+	require.NoError(t, err)
+	t.Cleanup(func() { w.Close() })
+
+	node := NewNode(Config{Role: role, Journal: w, JournalPath: path, MinAcks: minAcks})
+	t.Cleanup(func() { node.Close() })
+
+	return testNode{node: node, book: lob.NewOrderbook(128, lob.WithJournal(node))}
+}
+
+func (n testNode) serve(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go n.node.Serve(l)
+
+	return l.Addr().String()
+}
+
+func (n testNode) follow(ctx context.Context, addr string) chan error {
+	done := make(chan error, 1)
+	go func() { done <- n.node.Follow(ctx, addr, n.book) }()
+
+	return done
+}
+
+func TestReplication_FailoverLosesNoAcknowledgedCommands(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		primary   = newTestNode(t, RolePrimary, 1)
+		standby   = newTestNode(t, RoleFollower, 0)
+		latecomer = newTestNode(t, RoleFollower, 0)
+		addr      = primary.serve(t)
+	)
+
+	// The primary refuses commands until enough followers are connected to acknowledge them.
+	_, err := primary.book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 1000, 1))
+	require.ErrorIs(t, err, ErrNotEnoughFollowers)
+
+	standbyDone := standby.follow(ctx, addr)
+	require.Eventually(t, func() bool { return primary.node.Followers() == 1 }, time.Second, time.Millisecond)
+
+	for _, order := range []*lob.Order{
+		lob.NewOrder(lob.LimitOrder, lob.BuySide, 1000, 2),
+		lob.NewOrder(lob.LimitOrder, lob.SellSide, 1010, 3),
+		lob.NewOrder(lob.LimitOrder, lob.SellSide, 1000, 1),
+	} {
+		_, err := primary.book.PlaceOrder(order)
+		require.NoError(t, err)
+
+		// Acknowledged commands have already been applied by the standby.
+		assert.Equal(t, primary.book.Snapshot(), standby.book.Snapshot())
+	}
+
+	// Followers only take commands from the primary.
+	_, err = standby.book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 990, 1))
+	require.ErrorIs(t, err, ErrNotPrimary)
+
+	// A follower joining late is caught up from the primary's journal.
+	latecomerDone := latecomer.follow(ctx, addr)
+	require.Eventually(t, func() bool { return latecomer.book.LastSeq() == primary.book.LastSeq() }, time.Second, time.Millisecond)
+	assert.Equal(t, primary.book.Snapshot(), latecomer.book.Snapshot())
+
+	// The primary dies; the standby takes over and the latecomer follows it instead.
+	require.NoError(t, primary.node.Close())
+	require.Error(t, <-standbyDone)
+	require.Error(t, <-latecomerDone)
+
+	require.NoError(t, standby.node.Promote())
+	assert.Equal(t, RolePrimary, standby.node.Role())
+
+	addr = standby.serve(t)
+	latecomer.follow(ctx, addr)
+	require.Eventually(t, func() bool { return standby.node.Followers() == 1 }, time.Second, time.Millisecond)
+
+	id, err := standby.book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 1005, 1))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), id)
+
+	// The standby replicates asynchronously, so doesn't wait for the latecomer to acknowledge.
+	require.Eventually(t, func() bool { return latecomer.book.LastSeq() == id }, time.Second, time.Millisecond)
+	assert.Equal(t, standby.book.Snapshot(), latecomer.book.Snapshot())
+}
+
+func TestReplication_RefusesCommandsUntilAcknowledged(t *testing.T) {
+	t.Parallel()
+
+	primary := newTestNode(t, RolePrimary, 1)
+	primary.node.config.AckTimeout = 50 * time.Millisecond
+	addr := primary.serve(t)
+
+	// A follower that says hello from the start of the journal, but never acknowledges anything.
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write(binary.LittleEndian.AppendUint64(nil, 0))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return primary.node.Followers() == 1 }, time.Second, time.Millisecond)
+
+	// The first command's already replicated by the time it times out, so it's still applied.
+	id, err := primary.book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 1000, 1))
+	require.NoError(t, err)
+	assert.Len(t, primary.book.OpenOrders(), 1)
+
+	// Every command after it is refused, without being applied, until it's acknowledged.
+	_, err = primary.book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 1000, 1))
+	require.ErrorIs(t, err, ErrAckTimeout)
+	assert.Equal(t, id, primary.book.LastSeq())
+	assert.Len(t, primary.book.OpenOrders(), 1)
+
+	_, err = conn.Write(binary.LittleEndian.AppendUint64(nil, id+1))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, err := primary.book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 1000, 1))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, id+1, primary.book.LastSeq())
+}
+
+func TestReplication_CatchUpWhileCommandsArrive(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		primary  = newTestNode(t, RolePrimary, 0)
+		follower = newTestNode(t, RoleFollower, 0)
+		addr     = primary.serve(t)
+	)
+
+	place := func(n int) {
+		for i := 0; i < n; i++ {
+			_, err := primary.book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, lob.Price(1000+i%10), 1))
+			assert.NoError(t, err)
+		}
+	}
+
+	place(100)
+
+	// Commands keep arriving while the follower's caught up from the journal, so it's streamed some of them & sent the rest.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		place(200)
+	}()
+
+	follower.follow(ctx, addr)
+	<-done
+
+	require.Eventually(t, func() bool { return follower.book.LastSeq() == primary.book.LastSeq() }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, primary.book.Snapshot(), follower.book.Snapshot())
+}
+
+func TestReplication_RejectsFollowerThatCantBeCaughtUp(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Without a journal, the primary has nothing to catch a follower up from.
+	node := NewNode(Config{Role: RolePrimary})
+	t.Cleanup(func() { node.Close() })
+
+	primary := testNode{node: node, book: lob.NewOrderbook(128, lob.WithJournal(node))}
+	addr := primary.serve(t)
+
+	_, err := primary.book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 1000, 1))
+	require.NoError(t, err)
+
+	follower := newTestNode(t, RoleFollower, 0)
+	require.Error(t, <-follower.follow(ctx, addr))
+	assert.Equal(t, 0, primary.node.Followers())
+	assert.Equal(t, uint64(0), follower.book.LastSeq())
+}
+
+func TestReplication_FollowFailsOnSequenceGap(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	// A primary that skips the first command.
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var hello [8]byte
+		if _, err := conn.Read(hello[:]); err != nil {
+			return
+		}
+
+		frame, _ := journal.AppendFrame(nil, lob.Command{Seq: 2, Type: lob.CommandTick})
+		_, _ = conn.Write(frame)
+
+		var ack [8]byte
+		_, _ = conn.Read(ack[:])
+	}()
+
+	follower := newTestNode(t, RoleFollower, 0)
+	require.ErrorIs(t, <-follower.follow(context.Background(), l.Addr().String()), ErrSequenceGap)
+	assert.Equal(t, uint64(0), follower.book.LastSeq())
+}
@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/jsonapi"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// NewClient returns a client for the REST API at baseURL, e.g. "http://localhost:8080"; by default with
+// http.DefaultClient.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+var _ client.Client = &Client{}
+
+// Client places & manages orders through the REST API; the engine's errors are returned as it would have returned them.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func (c *Client) AddOrder(ctx context.Context, req client.AddOrderRequest) (client.AddOrderResponse, error) {
+	var resp jsonapi.NewOrderResponse
+	if err := c.do(ctx, http.MethodPost, "/orders", jsonapi.NewOrderRequestFrom(req), &resp); err != nil {
+		return client.AddOrderResponse{}, fmt.Errorf("add order: %w", err)
+	}
+
+	return client.AddOrderResponse{OrderID: resp.OrderID}, nil
+}
+
+func (c *Client) CancelOrder(ctx context.Context, req client.CancelOrderRequest) (client.CancelOrderResponse, error) {
+	if err := c.do(ctx, http.MethodDelete, orderPath(req.OrderID, req.AccountID, req.ClientOrderID), nil, nil); err != nil {
+		return client.CancelOrderResponse{}, fmt.Errorf("cancel order: %w", err)
+	}
+
+	return client.CancelOrderResponse{}, nil
+}
+
+func (c *Client) EditOrder(ctx context.Context, req client.EditOrderRequest) (client.EditOrderResponse, error) {
+	amend := jsonapi.AmendOrderRequest{Price: float64(req.Price), Size: float64(req.Size)}
+	if err := c.do(ctx, http.MethodPatch, orderPath(req.OrderID, req.AccountID, req.ClientOrderID), amend, nil); err != nil {
+		return client.EditOrderResponse{}, fmt.Errorf("edit order: %w", err)
+	}
+
+	return client.EditOrderResponse{}, nil
+}
+
+func (c *Client) GetOrder(ctx context.Context, req client.GetOrderRequest) (client.GetOrderResponse, error) {
+	var order jsonapi.Order
+	if err := c.do(ctx, http.MethodGet, orderPath(req.OrderID, req.AccountID, req.ClientOrderID), nil, &order); err != nil {
+		return client.GetOrderResponse{}, fmt.Errorf("get order: %w", err)
+	}
+
+	return client.GetOrderResponse{Order: order.OrderInfo()}, nil
+}
+
+func (c *Client) ListOpenOrders(ctx context.Context, req client.ListOpenOrdersRequest) (client.ListOpenOrdersResponse, error) {
+	var orders []jsonapi.Order
+	if err := c.do(ctx, http.MethodGet, "/orders", nil, &orders); err != nil {
+		return client.ListOpenOrdersResponse{}, fmt.Errorf("list open orders: %w", err)
+	}
+
+	resp := client.ListOpenOrdersResponse{Orders: make([]lob.OrderInfo, 0, len(orders))}
+	for _, order := range orders {
+		resp.Orders = append(resp.Orders, order.OrderInfo())
+	}
+
+	return resp, nil
+}
+
+// orderPath is the path of the order with the ID, or if it's zero of the account's order with the client order ID.
+func orderPath(orderID, accountID uint64, clientOrderID string) string {
+	if orderID == 0 {
+		return "/orders/client/" + url.PathEscape(clientOrderID) + "?account_id=" + strconv.FormatUint(accountID, 10)
+	}
+
+	return "/orders/" + strconv.FormatUint(orderID, 10)
+}
+
+// do sends the request, decoding a successful response into v if it's not nil, and a failed one as the engine's error.
+func (c *Client) do(ctx context.Context, method, path string, body, v any) error {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr jsonapi.Error
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+			return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+
+		return apiErr.Err()
+	}
+
+	if v == nil {
+		// Drained, so the connection's reused.
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128)
+	server := NewServer(Config{Book: book})
+	t.Cleanup(func() { server.Close() })
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	var (
+		c   = NewClient(ts.URL, ts.Client())
+		ctx = context.Background()
+	)
+
+	placed, err := c.AddOrder(ctx, client.AddOrderRequest{AccountID: 7, OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 100, Size: 5})
+	require.NoError(t, err)
+
+	_, err = c.EditOrder(ctx, client.EditOrderRequest{OrderID: placed.OrderID, Price: 101, Size: 6})
+	require.NoError(t, err)
+
+	_, err = c.AddOrder(ctx, client.AddOrderRequest{AccountID: 8, OrderType: lob.MarketOrder, OrderSide: lob.SellSide, Size: 2})
+	require.NoError(t, err)
+
+	got, err := c.GetOrder(ctx, client.GetOrderRequest{OrderID: placed.OrderID})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), got.Order.AccountID)
+	assert.Equal(t, lob.LimitOrder, got.Order.OrderType)
+	assert.Equal(t, lob.BuySide, got.Order.Side)
+	assert.Equal(t, lob.Price(101), got.Order.Price)
+	assert.Equal(t, lob.OrderStatusPartiallyFilled, got.Order.Status)
+	assert.Equal(t, lob.Size(2), got.Order.FilledSize)
+
+	open, err := c.ListOpenOrders(ctx, client.ListOpenOrdersRequest{})
+	require.NoError(t, err)
+	require.Len(t, open.Orders, 1)
+	assert.Equal(t, placed.OrderID, open.Orders[0].ID)
+
+	_, err = c.CancelOrder(ctx, client.CancelOrderRequest{OrderID: placed.OrderID})
+	require.NoError(t, err)
+
+	// The engine's errors are returned as it returns them.
+	_, err = c.CancelOrder(ctx, client.CancelOrderRequest{OrderID: 999})
+	assert.True(t, errors.Is(err, lob.ErrOrderNotFound), err)
+
+	_, err = c.GetOrder(ctx, client.GetOrderRequest{OrderID: 999})
+	assert.True(t, errors.Is(err, lob.ErrOrderNotFound), err)
+
+	_, err = c.AddOrder(ctx, client.AddOrderRequest{AccountID: 8, OrderType: lob.MarketOrder, OrderSide: lob.SellSide, Size: 1})
+	var rejectErr *lob.RejectError
+	require.True(t, errors.As(err, &rejectErr), err)
+	assert.Equal(t, lob.RejectReasonNoLiquidity, rejectErr.Reason)
+}
+
+func TestClient_ClientOrderID(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128)
+	server := NewServer(Config{Book: book})
+	t.Cleanup(func() { server.Close() })
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	var (
+		c   = NewClient(ts.URL, ts.Client())
+		ctx = context.Background()
+		req = client.AddOrderRequest{AccountID: 7, OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 100, Size: 5, ClientOrderID: "a/1"}
+	)
+
+	placed, err := c.AddOrder(ctx, req)
+	require.NoError(t, err)
+
+	// Retrying is rejected as a duplicate.
+	_, err = c.AddOrder(ctx, req)
+	var rejectErr *lob.RejectError
+	require.True(t, errors.As(err, &rejectErr), err)
+	assert.Equal(t, lob.RejectReasonDuplicateOrder, rejectErr.Reason)
+
+	_, err = c.EditOrder(ctx, client.EditOrderRequest{AccountID: 7, ClientOrderID: "a/1", Price: 101, Size: 6})
+	require.NoError(t, err)
+
+	got, err := c.GetOrder(ctx, client.GetOrderRequest{AccountID: 7, ClientOrderID: "a/1"})
+	require.NoError(t, err)
+	assert.Equal(t, placed.OrderID, got.Order.ID)
+	assert.Equal(t, "a/1", got.Order.ClientOrderID)
+	assert.Equal(t, lob.Price(101), got.Order.Price)
+
+	// Client order IDs are unique to the account.
+	_, err = c.GetOrder(ctx, client.GetOrderRequest{AccountID: 8, ClientOrderID: "a/1"})
+	assert.True(t, errors.Is(err, lob.ErrOrderNotFound), err)
+
+	_, err = c.CancelOrder(ctx, client.CancelOrderRequest{AccountID: 7, ClientOrderID: "a/1"})
+	require.NoError(t, err)
+
+	got, err = c.GetOrder(ctx, client.GetOrderRequest{OrderID: placed.OrderID})
+	require.NoError(t, err)
+	assert.Equal(t, lob.OrderStatusCancelled, got.Order.Status)
+	assert.Equal(t, "a/1", got.Order.ClientOrderID)
+}
@@ -0,0 +1,374 @@
+// Package rest serves the book over HTTP: orders are placed, amended & cancelled, and the book queried, all as JSON.
+package rest
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/jsonapi"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+const (
+	DefaultRecentTrades = 1000
+	DefaultDepthLevels  = 10
+
+	// maxRequestSize bounds a request body.
+	maxRequestSize = 64 << 10
+)
+
+type Config struct {
+	Book *lob.Orderbook
+	// Client executes order commands; by default a client.LOBClient on Book.
+	Client client.Client
+
+	// RecentTrades is how many of the most recent trades are kept to be listed.
+	RecentTrades int
+}
+
+// NewServer returns a server for the book; it subscribes to the book's trades, keeping the most recent, until closed.
+func NewServer(config Config) *Server {
+	if config.Client == nil {
+		config.Client = client.NewLOBClient(config.Book)
+	}
+
+	if config.RecentTrades == 0 {
+		config.RecentTrades = DefaultRecentTrades
+	}
+
+	s := &Server{
+		config: config,
+		trades: newTradeLog(config.RecentTrades),
+		mux:    http.NewServeMux(),
+	}
+	s.unsubscribe = config.Book.Subscribe(s.onEvent)
+
+	s.mux.HandleFunc("POST /orders", s.placeOrder)
+	s.mux.HandleFunc("GET /orders", s.listOpenOrders)
+	s.mux.HandleFunc("GET /orders/{id}", s.getOrder)
+	s.mux.HandleFunc("PATCH /orders/{id}", s.amendOrder)
+	s.mux.HandleFunc("DELETE /orders/{id}", s.cancelOrder)
+	s.mux.HandleFunc("GET /orders/client/{client_order_id}", s.getOrder)
+	s.mux.HandleFunc("PATCH /orders/client/{client_order_id}", s.amendOrder)
+	s.mux.HandleFunc("DELETE /orders/client/{client_order_id}", s.cancelOrder)
+	s.mux.HandleFunc("GET /depth", s.depth)
+	s.mux.HandleFunc("GET /bbo", s.bbo)
+	s.mux.HandleFunc("GET /mid", s.mid)
+	s.mux.HandleFunc("GET /trades", s.recentTrades)
+
+	return s
+}
+
+// Server is an http.Handler serving the book's REST API:
+//
+//	POST   /orders           place an order, as a jsonapi.NewOrderRequest
+//	GET    /orders           list open orders, optionally ?account_id=
+//	GET    /orders/{id}      get an order
+//	PATCH  /orders/{id}      amend an open order's price & size, as a jsonapi.AmendOrderRequest; answered with the order
+//	DELETE /orders/{id}      cancel an open order; answered with the order
+//
+// Orders may be referred to by the account's client order ID instead, as /orders/client/{client_order_id}?account_id=.
+//
+//	GET    /depth?levels=    the top levels of each side
+//	GET    /bbo              the best bid & offer, and the mid
+//	GET    /mid              the mid
+//	GET    /trades?limit=    the most recent trades, newest first
+//
+// Failures are answered with a jsonapi.Error, and a status following its code.
+type Server struct {
+	config      Config
+	trades      *tradeLog
+	mux         *http.ServeMux
+	unsubscribe func()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close stops keeping the book's trades.
+func (s *Server) Close() error {
+	s.unsubscribe()
+	return nil
+}
+
+// onEvent keeps trades; it's called with the book locked.
+func (s *Server) onEvent(event lob.Event) {
+	if trade, ok := event.(lob.TradeEvent); ok {
+		s.trades.add(jsonapi.NewTrade(trade))
+	}
+}
+
+func (s *Server) placeOrder(w http.ResponseWriter, r *http.Request) {
+	var req jsonapi.NewOrderRequest
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	addReq, err := req.AddOrderRequest()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp, err := s.config.Client.AddOrder(r.Context(), addReq)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, jsonapi.NewOrderResponse{OrderID: resp.OrderID})
+}
+
+func (s *Server) listOpenOrders(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.config.Client.ListOpenOrders(r.Context(), client.ListOpenOrdersRequest{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	orders := resp.Orders
+	if r.URL.Query().Has("account_id") {
+		accountID, err := queryAccountID(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		orders = orders[:0:0]
+		for _, order := range resp.Orders {
+			if order.AccountID == accountID {
+				orders = append(orders, order)
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, jsonapi.NewOrders(orders))
+}
+
+func (s *Server) getOrder(w http.ResponseWriter, r *http.Request) {
+	ref, err := pathOrder(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp, err := s.config.Client.GetOrder(r.Context(), client.GetOrderRequest{OrderID: ref.orderID, AccountID: ref.accountID, ClientOrderID: ref.clientOrderID})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jsonapi.NewOrder(resp.Order))
+}
+
+func (s *Server) amendOrder(w http.ResponseWriter, r *http.Request) {
+	ref, err := pathOrder(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req jsonapi.AmendOrderRequest
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	editReq := client.EditOrderRequest{
+		OrderID:       ref.orderID,
+		Price:         lob.Price(req.Price),
+		Size:          lob.Size(req.Size),
+		AccountID:     ref.accountID,
+		ClientOrderID: ref.clientOrderID,
+	}
+	if _, err := s.config.Client.EditOrder(r.Context(), editReq); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.getOrder(w, r)
+}
+
+func (s *Server) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	ref, err := pathOrder(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	cancelReq := client.CancelOrderRequest{OrderID: ref.orderID, AccountID: ref.accountID, ClientOrderID: ref.clientOrderID}
+	if _, err := s.config.Client.CancelOrder(r.Context(), cancelReq); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.getOrder(w, r)
+}
+
+func (s *Server) depth(w http.ResponseWriter, r *http.Request) {
+	levels, err := queryInt(r, "levels", DefaultDepthLevels)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jsonapi.NewDepth(s.config.Book.DepthSnapshot(levels)))
+}
+
+// bbo is the best bid & offer, absent for an empty side, and their mid, absent unless both sides have orders.
+type bbo struct {
+	Seq uint64         `json:"seq"`
+	Bid *jsonapi.Level `json:"bid"`
+	Ask *jsonapi.Level `json:"ask"`
+	Mid *float64       `json:"mid"`
+}
+
+func (s *Server) topOfBook() bbo {
+	depth := jsonapi.NewDepth(s.config.Book.DepthSnapshot(1))
+
+	top := bbo{Seq: depth.Seq}
+	if len(depth.Bids) > 0 {
+		top.Bid = &depth.Bids[0]
+	}
+
+	if len(depth.Asks) > 0 {
+		top.Ask = &depth.Asks[0]
+	}
+
+	if top.Bid != nil && top.Ask != nil {
+		mid := (top.Bid.Price + top.Ask.Price) / 2
+		top.Mid = &mid
+	}
+
+	return top
+}
+
+func (s *Server) bbo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.topOfBook())
+}
+
+func (s *Server) mid(w http.ResponseWriter, r *http.Request) {
+	top := s.topOfBook()
+	writeJSON(w, http.StatusOK, struct {
+		Seq uint64   `json:"seq"`
+		Mid *float64 `json:"mid"`
+	}{Seq: top.Seq, Mid: top.Mid})
+}
+
+func (s *Server) recentTrades(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.trades.recent(limit))
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return jsonapi.InvalidRequest("decode request: %v", err)
+	}
+
+	return nil
+}
+
+// orderRef is the order a request's path refers to: by ID, or by the account's client order ID.
+type orderRef struct {
+	orderID       uint64
+	accountID     uint64
+	clientOrderID string
+}
+
+func pathOrder(r *http.Request) (orderRef, error) {
+	if clientOrderID := r.PathValue("client_order_id"); clientOrderID != "" {
+		accountID, err := queryAccountID(r)
+		if err != nil {
+			return orderRef{}, err
+		}
+
+		return orderRef{accountID: accountID, clientOrderID: clientOrderID}, nil
+	}
+
+	value := r.PathValue("id")
+
+	orderID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return orderRef{}, jsonapi.InvalidRequest("invalid order id %q", value)
+	}
+
+	return orderRef{orderID: orderID}, nil
+}
+
+// queryAccountID returns the account_id query parameter, or 0 if it's not given.
+func queryAccountID(r *http.Request) (uint64, error) {
+	value := r.URL.Query().Get("account_id")
+	if value == "" {
+		return 0, nil
+	}
+
+	accountID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, jsonapi.InvalidRequest("invalid account_id %q", value)
+	}
+
+	return accountID, nil
+}
+
+// queryInt returns the query parameter as a positive integer, or the default if it's not given.
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, jsonapi.InvalidRequest("invalid %s %q", name, value)
+	}
+
+	return n, nil
+}
+
+// StatusFor is the HTTP status an error's answered with, following its code: the engine refusing an order as it's not
+// trading, or as a duplicate, is a conflict, while other rejections are unprocessable.
+func StatusFor(apiErr *jsonapi.Error) int {
+	switch apiErr.Code {
+	case jsonapi.CodeInvalidRequest:
+		return http.StatusBadRequest
+	case jsonapi.CodeOrderNotFound:
+		return http.StatusNotFound
+	case jsonapi.CodeInternal:
+		return http.StatusInternalServerError
+	case lob.RejectReasonTradingState.String(), lob.RejectReasonDuplicateOrder.String():
+		return http.StatusConflict
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	apiErr := jsonapi.ErrorFor(err)
+	if apiErr.Code == jsonapi.CodeInternal {
+		slog.Error("REST: request failed", "error", err)
+	}
+
+	writeJSON(w, StatusFor(apiErr), apiErr)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("REST: failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,194 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sashajdn/orderbook/jsonapi"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+func do(t *testing.T, handler http.Handler, method, target string, body any, v any) int {
+	t.Helper()
+
+	var reqBody bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&reqBody).Encode(body))
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, target, &reqBody))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	if v != nil {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v), rec.Body.String())
+	}
+
+	return rec.Code
+}
+
+func TestServer_Orders(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128)
+	server := NewServer(Config{Book: book, RecentTrades: 2})
+	t.Cleanup(func() { server.Close() })
+
+	var placed jsonapi.NewOrderResponse
+	status := do(t, server, http.MethodPost, "/orders", jsonapi.NewOrderRequest{AccountID: 7, Type: "limit", Side: "buy", Price: 100, Size: 5}, &placed)
+	require.Equal(t, http.StatusCreated, status)
+
+	var order jsonapi.Order
+	require.Equal(t, http.StatusOK, do(t, server, http.MethodGet, "/orders/"+idString(placed.OrderID), nil, &order))
+	assert.Equal(t, "new", order.Status)
+	assert.Equal(t, uint64(7), order.AccountID)
+
+	require.Equal(t, http.StatusOK, do(t, server, http.MethodPatch, "/orders/"+idString(placed.OrderID), jsonapi.AmendOrderRequest{Price: 101, Size: 6}, &order))
+	assert.Equal(t, 101.0, order.Price)
+
+	for i := 0; i < 3; i++ {
+		status := do(t, server, http.MethodPost, "/orders", jsonapi.NewOrderRequest{AccountID: 8, Type: "market", Side: "sell", Size: float64(i + 1)}, nil)
+		require.Equal(t, http.StatusCreated, status)
+	}
+
+	// Only the most recent trades are kept, newest first.
+	var trades []jsonapi.Trade
+	require.Equal(t, http.StatusOK, do(t, server, http.MethodGet, "/trades", nil, &trades))
+	require.Len(t, trades, 2)
+	assert.Equal(t, 3.0, trades[0].Size)
+	assert.Equal(t, 2.0, trades[1].Size)
+
+	require.Equal(t, http.StatusOK, do(t, server, http.MethodGet, "/trades?limit=1", nil, &trades))
+	assert.Len(t, trades, 1)
+
+	for _, order := range []jsonapi.NewOrderRequest{
+		{AccountID: 7, Type: "limit", Side: "buy", Price: 99, Size: 1},
+		{AccountID: 8, Type: "limit", Side: "sell", Price: 103, Size: 2},
+	} {
+		require.Equal(t, http.StatusCreated, do(t, server, http.MethodPost, "/orders", order, nil))
+	}
+
+	var orders []jsonapi.Order
+	require.Equal(t, http.StatusOK, do(t, server, http.MethodGet, "/orders?account_id=8", nil, &orders))
+	require.Len(t, orders, 1)
+	assert.Equal(t, 103.0, orders[0].Price)
+
+	var depth jsonapi.Depth
+	require.Equal(t, http.StatusOK, do(t, server, http.MethodGet, "/depth?levels=1", nil, &depth))
+	assert.Equal(t, []jsonapi.Level{{Price: 99, Size: 1}}, depth.Bids)
+	assert.Equal(t, []jsonapi.Level{{Price: 103, Size: 2}}, depth.Asks)
+	assert.Equal(t, lob.Checksum(book.DepthSnapshot(1).Bids, book.DepthSnapshot(1).Asks), depth.Checksum)
+
+	var top bbo
+	require.Equal(t, http.StatusOK, do(t, server, http.MethodGet, "/bbo", nil, &top))
+	require.NotNil(t, top.Mid)
+	assert.Equal(t, 101.0, *top.Mid)
+	assert.Equal(t, &jsonapi.Level{Price: 99, Size: 1}, top.Bid)
+
+	require.Equal(t, http.StatusOK, do(t, server, http.MethodDelete, "/orders/"+idString(orders[0].ID), nil, &order))
+	assert.Equal(t, "cancelled", order.Status)
+
+	require.Equal(t, http.StatusOK, do(t, server, http.MethodGet, "/mid", nil, &top))
+	assert.Nil(t, top.Mid)
+}
+
+func TestServer_Errors(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128)
+	server := NewServer(Config{Book: book})
+	t.Cleanup(func() { server.Close() })
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           any
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "unknown_side",
+			method:         http.MethodPost,
+			target:         "/orders",
+			body:           jsonapi.NewOrderRequest{Type: "limit", Side: "sideways", Price: 1, Size: 1},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   jsonapi.CodeInvalidRequest,
+		},
+		{
+			name:           "unknown_field",
+			method:         http.MethodPost,
+			target:         "/orders",
+			body:           map[string]any{"colour": "blue"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   jsonapi.CodeInvalidRequest,
+		},
+		{
+			name:           "no_liquidity",
+			method:         http.MethodPost,
+			target:         "/orders",
+			body:           jsonapi.NewOrderRequest{Type: "market", Side: "buy", Size: 1},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   lob.RejectReasonNoLiquidity.String(),
+		},
+		{
+			name:           "invalid_order",
+			method:         http.MethodPost,
+			target:         "/orders",
+			body:           jsonapi.NewOrderRequest{Type: "limit", Side: "buy", Price: -1, Size: 1},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   lob.RejectReasonInvalidOrder.String(),
+		},
+		{
+			name:           "unknown_order",
+			method:         http.MethodGet,
+			target:         "/orders/999",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   jsonapi.CodeOrderNotFound,
+		},
+		{
+			name:           "cancel_unknown_order",
+			method:         http.MethodDelete,
+			target:         "/orders/999",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   jsonapi.CodeOrderNotFound,
+		},
+		{
+			name:           "invalid_order_id",
+			method:         http.MethodGet,
+			target:         "/orders/abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   jsonapi.CodeInvalidRequest,
+		},
+		{
+			name:           "invalid_levels",
+			method:         http.MethodGet,
+			target:         "/depth?levels=-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   jsonapi.CodeInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var apiErr jsonapi.Error
+			assert.Equal(t, tt.expectedStatus, do(t, server, tt.method, tt.target, tt.body, &apiErr))
+			assert.Equal(t, tt.expectedCode, apiErr.Code)
+			assert.NotEmpty(t, apiErr.Message)
+		})
+	}
+}
+
+func idString(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
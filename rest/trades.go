@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"sync"
+
+	"github.com/sashajdn/orderbook/jsonapi"
+)
+
+// tradeLog keeps the most recent trades, overwriting the oldest once full.
+type tradeLog struct {
+	trades []jsonapi.Trade
+	next   int
+	full   bool
+	mu     sync.Mutex
+}
+
+func newTradeLog(size int) *tradeLog {
+	return &tradeLog{trades: make([]jsonapi.Trade, size)}
+}
+
+func (l *tradeLog) add(trade jsonapi.Trade) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.trades[l.next] = trade
+	l.next++
+	if l.next == len(l.trades) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// recent returns up to limit of the most recent trades, newest first.
+func (l *tradeLog) recent(limit int) []jsonapi.Trade {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := l.next
+	if l.full {
+		n = len(l.trades)
+	}
+
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	recent := make([]jsonapi.Trade, 0, n)
+	for i := 1; i <= n; i++ {
+		recent = append(recent, l.trades[(l.next-i+len(l.trades))%len(l.trades)])
+	}
+
+	return recent
+}
@@ -0,0 +1,401 @@
+package risk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// Limits are the per account pre-trade limits; a zero value disables that limit.
+type Limits = lob.RiskLimits
+
+func NewEngine(defaults Limits) *Engine {
+	return &Engine{
+		defaults: defaults,
+		limits:   make(map[uint64]Limits),
+		accounts: make(map[uint64]*account),
+	}
+}
+
+var (
+	_ lob.PreTradeCheck    = &Engine{}
+	_ lob.RiskLimiter      = &Engine{}
+	_ lob.CheckSnapshotter = &Engine{}
+)
+
+var errShortSnapshot = errors.New("short snapshot")
+
+// Engine enforces per account limits in front of the Orderbook. It tracks open orders and positions from the Orderbook's
+// events, so it must be subscribed to the same Orderbook it checks orders for.
+type Engine struct {
+	defaults Limits
+	limits   map[uint64]Limits
+	accounts map[uint64]*account
+	mu       sync.RWMutex
+}
+
+type openOrder struct {
+	side      lob.OrderSide
+	price     lob.Price
+	remaining lob.Size
+}
+
+type account struct {
+	openOrders map[uint64]openOrder
+	openBuys   lob.Size
+	openSells  lob.Size
+	notional   float64
+	position   lob.Size
+	lastPrice  lob.Price
+}
+
+// Exposure is a point in time view of what the engine holds against an account's limits.
+type Exposure struct {
+	OpenOrders   int
+	OpenBuys     lob.Size
+	OpenSells    lob.Size
+	OpenNotional float64
+	Position     lob.Size
+}
+
+// SetDefaultLimits sets the limits applied to every account without their own.
+func (e *Engine) SetDefaultLimits(limits Limits) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.defaults = limits
+}
+
+// SetLimits sets the limits for a single account, taking effect from the next order checked. Limits set directly aren't
+// journaled; set them through the Orderbook's SetRiskLimits to have them replayed.
+func (e *Engine) SetLimits(accountID uint64, limits Limits) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.limits[accountID] = limits
+}
+
+func (e *Engine) Limits(accountID uint64) Limits {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.limitsFor(accountID)
+}
+
+func (e *Engine) Exposure(accountID uint64) Exposure {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	a, ok := e.accounts[accountID]
+	if !ok {
+		return Exposure{}
+	}
+
+	return Exposure{
+		OpenOrders:   len(a.openOrders),
+		OpenBuys:     a.openBuys,
+		OpenSells:    a.openSells,
+		OpenNotional: a.notional,
+		Position:     a.position,
+	}
+}
+
+// CheckOrder implements lob.PreTradeCheck. An amended order is checked in place of what it held before the amend.
+func (e *Engine) CheckOrder(order *lob.Order, price lob.Price) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	limits := e.limitsFor(order.AccountID)
+	info := order.Info()
+
+	if limits.MaxOrderSize > 0 && order.Size > limits.MaxOrderSize {
+		return reject(lob.RejectReasonMaxOrderSize, "order size %.6f exceeds limit %.6f", order.Size, limits.MaxOrderSize)
+	}
+
+	if limits.MaxOrderNotional > 0 && float64(price)*float64(order.Size) > limits.MaxOrderNotional {
+		return reject(lob.RejectReasonMaxOrderNotional, "order notional %.6f exceeds limit %.6f", float64(price)*float64(order.Size), limits.MaxOrderNotional)
+	}
+
+	a := &account{}
+	if held, ok := e.accounts[order.AccountID]; ok {
+		view := *held
+		a = &view
+	}
+
+	previous, amending := a.openOrders[order.ID]
+	if amending {
+		a.remove(previous)
+	}
+
+	if limits.MaxOpenOrders > 0 && order.OrderType == lob.LimitOrder && !amending && len(a.openOrders) >= limits.MaxOpenOrders {
+		return reject(lob.RejectReasonMaxOpenOrders, "%d open orders at limit %d", len(a.openOrders), limits.MaxOpenOrders)
+	}
+
+	var (
+		open        = order.Size - info.FilledSize
+		notional    = float64(price) * float64(open)
+		absPosition = lob.Size(math.Abs(float64(a.position)))
+	)
+
+	if limits.MaxGrossPosition > 0 {
+		gross := absPosition + a.openBuys + a.openSells + open
+		if gross > limits.MaxGrossPosition {
+			return reject(lob.RejectReasonMaxGrossPosition, "gross position %.6f would exceed limit %.6f", gross, limits.MaxGrossPosition)
+		}
+	}
+
+	if limits.MaxNetPosition > 0 {
+		net := a.position + a.openBuys + open
+		if order.Side == lob.SellSide {
+			net = -(a.position - a.openSells - open)
+		}
+
+		if net > limits.MaxNetPosition {
+			return reject(lob.RejectReasonMaxNetPosition, "net %s position %.6f would exceed limit %.6f", order.Side, net, limits.MaxNetPosition)
+		}
+	}
+
+	if limits.CreditLimit > 0 {
+		markPrice := a.lastPrice
+		if markPrice == 0 {
+			markPrice = price
+		}
+
+		credit := a.notional + float64(absPosition)*float64(markPrice) + notional
+		if credit > limits.CreditLimit {
+			return reject(lob.RejectReasonCreditLimit, "credit used %.6f would exceed limit %.6f", credit, limits.CreditLimit)
+		}
+	}
+
+	return nil
+}
+
+// SnapshotCheck implements lob.CheckSnapshotter, encoding the open orders & positions the engine's tracking. Limits aren't
+// included; the Orderbook snapshots those set through it, & those set directly are configuration.
+func (e *Engine) SnapshotCheck() []byte {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	accountIDs := make([]uint64, 0, len(e.accounts))
+	for accountID := range e.accounts {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Slice(accountIDs, func(i, j int) bool { return accountIDs[i] < accountIDs[j] })
+
+	buf := binary.LittleEndian.AppendUint64(nil, uint64(len(accountIDs)))
+	for _, accountID := range accountIDs {
+		a := e.accounts[accountID]
+
+		buf = binary.LittleEndian.AppendUint64(buf, accountID)
+		buf = appendFloat(buf, float64(a.openBuys))
+		buf = appendFloat(buf, float64(a.openSells))
+		buf = appendFloat(buf, a.notional)
+		buf = appendFloat(buf, float64(a.position))
+		buf = appendFloat(buf, float64(a.lastPrice))
+
+		orderIDs := make([]uint64, 0, len(a.openOrders))
+		for orderID := range a.openOrders {
+			orderIDs = append(orderIDs, orderID)
+		}
+		sort.Slice(orderIDs, func(i, j int) bool { return orderIDs[i] < orderIDs[j] })
+
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(len(orderIDs)))
+		for _, orderID := range orderIDs {
+			order := a.openOrders[orderID]
+
+			buf = binary.LittleEndian.AppendUint64(buf, orderID)
+			buf = append(buf, byte(order.side))
+			buf = appendFloat(buf, float64(order.price))
+			buf = appendFloat(buf, float64(order.remaining))
+		}
+	}
+
+	return buf
+}
+
+// RestoreCheck implements lob.CheckSnapshotter, replacing the open orders & positions with those encoded by SnapshotCheck.
+func (e *Engine) RestoreCheck(data []byte) error {
+	d := decoder{buf: data}
+
+	accounts := make(map[uint64]*account)
+	for n := d.uint64(); n > 0 && d.err == nil; n-- {
+		accountID := d.uint64()
+		a := &account{
+			openOrders: make(map[uint64]openOrder),
+			openBuys:   lob.Size(d.float()),
+			openSells:  lob.Size(d.float()),
+			notional:   d.float(),
+			position:   lob.Size(d.float()),
+			lastPrice:  lob.Price(d.float()),
+		}
+
+		for m := d.uint64(); m > 0 && d.err == nil; m-- {
+			orderID := d.uint64()
+			a.openOrders[orderID] = openOrder{
+				side:      lob.OrderSide(d.byte()),
+				price:     lob.Price(d.float()),
+				remaining: lob.Size(d.float()),
+			}
+		}
+
+		accounts[accountID] = a
+	}
+
+	if d.err != nil {
+		return fmt.Errorf("restore risk engine: %w", d.err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.accounts = accounts
+
+	return nil
+}
+
+// HandleEvent updates open orders and positions; subscribe it to the Orderbook.
+func (e *Engine) HandleEvent(event lob.Event) {
+	switch ev := event.(type) {
+	case lob.OrderEvent:
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		e.handleOrder(ev.Order)
+	case lob.TradeEvent:
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		buyer, seller := e.account(ev.BuyAccountID), e.account(ev.SellAccountID)
+		buyer.position += ev.Size
+		buyer.lastPrice = ev.Price
+		seller.position -= ev.Size
+		seller.lastPrice = ev.Price
+	}
+}
+
+func (e *Engine) handleOrder(order lob.OrderInfo) {
+	if order.OrderType != lob.LimitOrder {
+		return
+	}
+
+	a := e.account(order.AccountID)
+
+	// Remove what we held before, then add back what's still open.
+	if previous, ok := a.openOrders[order.ID]; ok {
+		a.remove(previous)
+		delete(a.openOrders, order.ID)
+	}
+
+	if order.Status.Finished() || order.RemainingSize <= 0 {
+		return
+	}
+
+	open := openOrder{
+		side:      order.Side,
+		price:     order.Price,
+		remaining: order.RemainingSize,
+	}
+	a.openOrders[order.ID] = open
+	a.add(open)
+}
+
+func (e *Engine) account(accountID uint64) *account {
+	a, ok := e.accounts[accountID]
+	if !ok {
+		a = &account{
+			openOrders: make(map[uint64]openOrder),
+		}
+		e.accounts[accountID] = a
+	}
+
+	return a
+}
+
+func (e *Engine) limitsFor(accountID uint64) Limits {
+	if limits, ok := e.limits[accountID]; ok {
+		return limits
+	}
+
+	return e.defaults
+}
+
+func (a *account) add(order openOrder) {
+	switch order.side {
+	case lob.BuySide:
+		a.openBuys += order.remaining
+	case lob.SellSide:
+		a.openSells += order.remaining
+	}
+
+	a.notional += float64(order.price) * float64(order.remaining)
+}
+
+func (a *account) remove(order openOrder) {
+	switch order.side {
+	case lob.BuySide:
+		a.openBuys -= order.remaining
+	case lob.SellSide:
+		a.openSells -= order.remaining
+	}
+
+	a.notional -= float64(order.price) * float64(order.remaining)
+}
+
+func reject(reason lob.RejectReason, format string, args ...any) error {
+	err := lob.NewRejectError(reason, fmt.Errorf(format, args...))
+	slog.Debug("Risk: rejecting order", "reason", reason.String(), "error", err)
+
+	return err
+}
+
+func appendFloat(buf []byte, f float64) []byte {
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(f))
+}
+
+// decoder reads a snapshot's fields in order, recording the first error so it only needs checking once at the end.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if len(d.buf) < n {
+		d.err = errShortSnapshot
+		return nil
+	}
+
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	return 0
+}
+
+func (d *decoder) float() float64 {
+	return math.Float64frombits(d.uint64())
+}
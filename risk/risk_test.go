@@ -0,0 +1,253 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOrder struct {
+	accountID uint64
+	orderType lob.OrderType
+	side      lob.OrderSide
+	price     lob.Price
+	size      lob.Size
+}
+
+func TestEngine_CheckOrder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		limits         Limits
+		setup          []testOrder
+		order          testOrder
+		expectedReason lob.RejectReason
+	}{
+		{
+			name:   "within_limits",
+			limits: Limits{MaxOrderSize: 10, MaxOrderNotional: 10_000, MaxOpenOrders: 2},
+			order:  testOrder{1, lob.LimitOrder, lob.BuySide, 999, 10},
+		},
+		{
+			name:           "max_order_size",
+			limits:         Limits{MaxOrderSize: 10},
+			order:          testOrder{1, lob.LimitOrder, lob.BuySide, 999, 11},
+			expectedReason: lob.RejectReasonMaxOrderSize,
+		},
+		{
+			name:           "max_order_notional",
+			limits:         Limits{MaxOrderNotional: 1_000},
+			order:          testOrder{1, lob.LimitOrder, lob.BuySide, 999, 2},
+			expectedReason: lob.RejectReasonMaxOrderNotional,
+		},
+		{
+			name:   "max_order_notional_market_order_priced_off_book",
+			limits: Limits{MaxOrderNotional: 1_000},
+			setup: []testOrder{
+				{2, lob.LimitOrder, lob.SellSide, 1001, 5},
+			},
+			order:          testOrder{1, lob.MarketOrder, lob.BuySide, 0, 1},
+			expectedReason: lob.RejectReasonMaxOrderNotional,
+		},
+		{
+			name:   "max_open_orders",
+			limits: Limits{MaxOpenOrders: 2},
+			setup: []testOrder{
+				{1, lob.LimitOrder, lob.BuySide, 999, 1},
+				{1, lob.LimitOrder, lob.SellSide, 1001, 1},
+			},
+			order:          testOrder{1, lob.LimitOrder, lob.BuySide, 998, 1},
+			expectedReason: lob.RejectReasonMaxOpenOrders,
+		},
+		{
+			name:   "max_open_orders_other_account",
+			limits: Limits{MaxOpenOrders: 2},
+			setup: []testOrder{
+				{1, lob.LimitOrder, lob.BuySide, 999, 1},
+				{1, lob.LimitOrder, lob.SellSide, 1001, 1},
+			},
+			order: testOrder{2, lob.LimitOrder, lob.BuySide, 998, 1},
+		},
+		{
+			name:   "max_gross_position",
+			limits: Limits{MaxGrossPosition: 5},
+			setup: []testOrder{
+				{1, lob.LimitOrder, lob.BuySide, 999, 2},
+				{1, lob.LimitOrder, lob.SellSide, 1001, 2},
+			},
+			order:          testOrder{1, lob.LimitOrder, lob.SellSide, 1002, 2},
+			expectedReason: lob.RejectReasonMaxGrossPosition,
+		},
+		{
+			name:   "max_net_position_from_fills",
+			limits: Limits{MaxNetPosition: 3},
+			setup: []testOrder{
+				{2, lob.LimitOrder, lob.SellSide, 1001, 5},
+				{1, lob.MarketOrder, lob.BuySide, 0, 3},
+			},
+			order:          testOrder{1, lob.LimitOrder, lob.BuySide, 999, 1},
+			expectedReason: lob.RejectReasonMaxNetPosition,
+		},
+		{
+			name:   "max_net_position_reducing",
+			limits: Limits{MaxNetPosition: 3},
+			setup: []testOrder{
+				{2, lob.LimitOrder, lob.SellSide, 1001, 5},
+				{1, lob.MarketOrder, lob.BuySide, 0, 3},
+			},
+			order: testOrder{1, lob.LimitOrder, lob.SellSide, 1002, 3},
+		},
+		{
+			name:   "credit_limit",
+			limits: Limits{CreditLimit: 3_000},
+			setup: []testOrder{
+				{1, lob.LimitOrder, lob.BuySide, 1000, 2},
+			},
+			order:          testOrder{1, lob.LimitOrder, lob.BuySide, 999, 1.5},
+			expectedReason: lob.RejectReasonCreditLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine := NewEngine(Limits{})
+			book := lob.NewOrderbook(128, lob.WithPreTradeCheck(engine))
+			book.Subscribe(engine.HandleEvent)
+
+			for _, order := range tt.setup {
+				_, err := book.PlaceOrder(newOrder(order))
+				require.NoError(t, err)
+			}
+
+			engine.SetLimits(tt.order.accountID, tt.limits)
+
+			id, err := book.PlaceOrder(newOrder(tt.order))
+			if tt.expectedReason == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var rejectErr *lob.RejectError
+			require.True(t, errors.As(err, &rejectErr), "expected reject error, got: %v", err)
+			assert.Equal(t, tt.expectedReason, rejectErr.Reason)
+
+			order, err := book.GetOrder(id)
+			require.NoError(t, err)
+			assert.Equal(t, lob.OrderStatusRejected, order.Status)
+			assert.Equal(t, tt.expectedReason, order.RejectReason)
+		})
+	}
+}
+
+func TestEngine_Exposure(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine(Limits{})
+	book := lob.NewOrderbook(128, lob.WithPreTradeCheck(engine))
+	book.Subscribe(engine.HandleEvent)
+
+	restingID, err := book.PlaceOrder(newOrder(testOrder{1, lob.LimitOrder, lob.SellSide, 1001, 3}))
+	require.NoError(t, err)
+
+	_, err = book.PlaceOrder(newOrder(testOrder{2, lob.MarketOrder, lob.BuySide, 0, 1}))
+	require.NoError(t, err)
+
+	assert.Equal(t, Exposure{
+		OpenOrders:   1,
+		OpenSells:    2,
+		OpenNotional: 2002,
+		Position:     -1,
+	}, engine.Exposure(1))
+	assert.Equal(t, Exposure{Position: 1}, engine.Exposure(2))
+
+	require.NoError(t, book.CancelOrder(restingID))
+	assert.Equal(t, Exposure{Position: -1}, engine.Exposure(1))
+}
+
+func TestEngine_CheckOrderAmend(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine(Limits{MaxOrderSize: 10, MaxOpenOrders: 1, CreditLimit: 10_000})
+	book := lob.NewOrderbook(128, lob.WithPreTradeCheck(engine))
+	book.Subscribe(engine.HandleEvent)
+
+	id, err := book.PlaceOrder(newOrder(testOrder{1, lob.LimitOrder, lob.BuySide, 999, 5}))
+	require.NoError(t, err)
+
+	// The order's own open size isn't counted against it, nor is it counted as another open order.
+	require.NoError(t, book.EditOrder(&lob.Order{ID: id, Price: 999, Size: 10}))
+	assert.Equal(t, Exposure{OpenOrders: 1, OpenBuys: 10, OpenNotional: 9990}, engine.Exposure(1))
+
+	err = book.EditOrder(&lob.Order{ID: id, Price: 999, Size: 11})
+	var rejectErr *lob.RejectError
+	require.True(t, errors.As(err, &rejectErr), "expected reject error, got: %v", err)
+	assert.Equal(t, lob.RejectReasonMaxOrderSize, rejectErr.Reason)
+
+	err = book.EditOrder(&lob.Order{ID: id, Price: 1001, Size: 10})
+	require.True(t, errors.As(err, &rejectErr), "expected reject error, got: %v", err)
+	assert.Equal(t, lob.RejectReasonCreditLimit, rejectErr.Reason)
+
+	order, err := book.GetOrder(id)
+	require.NoError(t, err)
+	assert.Equal(t, lob.Price(999), order.Price)
+	assert.Equal(t, lob.Size(10), order.Size)
+	assert.Equal(t, lob.OrderStatusNew, order.Status)
+	assert.Equal(t, Exposure{OpenOrders: 1, OpenBuys: 10, OpenNotional: 9990}, engine.Exposure(1))
+}
+
+func TestEngine_SnapshotRestoredWithOrderbook(t *testing.T) {
+	t.Parallel()
+
+	newBook := func() (*lob.Orderbook, *Engine) {
+		engine := NewEngine(Limits{})
+		book := lob.NewOrderbook(128, lob.WithPreTradeCheck(engine))
+		book.Subscribe(engine.HandleEvent)
+
+		return book, engine
+	}
+
+	book, engine := newBook()
+	require.NoError(t, book.SetRiskLimits(1, Limits{MaxOrderSize: 5, MaxOpenOrders: 2}))
+
+	_, err := book.PlaceOrder(newOrder(testOrder{1, lob.LimitOrder, lob.SellSide, 1001, 3}))
+	require.NoError(t, err)
+	_, err = book.PlaceOrder(newOrder(testOrder{1, lob.LimitOrder, lob.BuySide, 990, 2}))
+	require.NoError(t, err)
+	_, err = book.PlaceOrder(newOrder(testOrder{2, lob.MarketOrder, lob.BuySide, 0, 1}))
+	require.NoError(t, err)
+
+	restored, restoredEngine := newBook()
+	require.NoError(t, restored.Restore(book.Snapshot()))
+
+	assert.Equal(t, book.Snapshot(), restored.Snapshot())
+	assert.Equal(t, Limits{MaxOrderSize: 5, MaxOpenOrders: 2}, restoredEngine.Limits(1))
+	for _, accountID := range []uint64{1, 2} {
+		assert.Equal(t, engine.Exposure(accountID), restoredEngine.Exposure(accountID))
+	}
+
+	// Both limits are enforced against what was open before the restore.
+	var rejectErr *lob.RejectError
+	_, err = restored.PlaceOrder(newOrder(testOrder{1, lob.LimitOrder, lob.BuySide, 980, 6}))
+	require.True(t, errors.As(err, &rejectErr), "expected reject error, got: %v", err)
+	assert.Equal(t, lob.RejectReasonMaxOrderSize, rejectErr.Reason)
+
+	_, err = restored.PlaceOrder(newOrder(testOrder{1, lob.LimitOrder, lob.BuySide, 980, 1}))
+	require.True(t, errors.As(err, &rejectErr), "expected reject error, got: %v", err)
+	assert.Equal(t, lob.RejectReasonMaxOpenOrders, rejectErr.Reason)
+
+	assert.Error(t, restoredEngine.RestoreCheck([]byte{1}))
+}
+
+func newOrder(o testOrder) *lob.Order {
+	order := lob.NewOrder(o.orderType, o.side, o.price, o.size)
+	order.AccountID = o.accountID
+
+	return order
+}
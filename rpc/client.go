@@ -0,0 +1,220 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/rpc/pb"
+)
+
+var ErrStreamClosed = errors.New("order entry stream closed")
+
+// NewClient returns a client for the Orderbook service on the connection, e.g. as returned by grpc.NewClient.
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{rpc: pb.NewOrderbookClient(conn)}
+}
+
+var _ client.Client = &Client{}
+
+// Client places & manages orders with the service's unary calls; the engine's errors are returned as it would have returned
+// them.
+type Client struct {
+	rpc pb.OrderbookClient
+}
+
+func (c *Client) AddOrder(ctx context.Context, req client.AddOrderRequest) (client.AddOrderResponse, error) {
+	resp, err := c.rpc.AddOrder(ctx, newAddOrderRequest(req))
+	if err != nil {
+		return client.AddOrderResponse{}, fmt.Errorf("add order: %w", errFor(err))
+	}
+
+	return client.AddOrderResponse{OrderID: resp.GetOrderId()}, nil
+}
+
+func (c *Client) CancelOrder(ctx context.Context, req client.CancelOrderRequest) (client.CancelOrderResponse, error) {
+	if _, err := c.rpc.CancelOrder(ctx, newCancelOrderRequest(req)); err != nil {
+		return client.CancelOrderResponse{}, fmt.Errorf("cancel order: %w", errFor(err))
+	}
+
+	return client.CancelOrderResponse{}, nil
+}
+
+func (c *Client) EditOrder(ctx context.Context, req client.EditOrderRequest) (client.EditOrderResponse, error) {
+	edit := newEditOrderRequest(req)
+	if _, err := c.rpc.EditOrder(ctx, edit); err != nil {
+		return client.EditOrderResponse{}, fmt.Errorf("edit order: %w", errFor(err))
+	}
+
+	return client.EditOrderResponse{}, nil
+}
+
+func (c *Client) GetOrder(ctx context.Context, req client.GetOrderRequest) (client.GetOrderResponse, error) {
+	resp, err := c.rpc.GetOrder(ctx, newGetOrderRequest(req))
+	if err != nil {
+		return client.GetOrderResponse{}, fmt.Errorf("get order: %w", errFor(err))
+	}
+
+	return client.GetOrderResponse{Order: orderInfo(resp.GetOrder())}, nil
+}
+
+func (c *Client) ListOpenOrders(ctx context.Context, req client.ListOpenOrdersRequest) (client.ListOpenOrdersResponse, error) {
+	resp, err := c.rpc.ListOpenOrders(ctx, &pb.ListOpenOrdersRequest{})
+	if err != nil {
+		return client.ListOpenOrdersResponse{}, fmt.Errorf("list open orders: %w", errFor(err))
+	}
+
+	orders := make([]lob.OrderInfo, 0, len(resp.GetOrders()))
+	for _, order := range resp.GetOrders() {
+		orders = append(orders, orderInfo(order))
+	}
+
+	return client.ListOpenOrdersResponse{Orders: orders}, nil
+}
+
+// Subscribe streams the book's depth & trades; see the service's Subscribe.
+func (c *Client) Subscribe(ctx context.Context, depthLevels int, trades bool) (pb.Orderbook_SubscribeClient, error) {
+	return c.rpc.Subscribe(ctx, &pb.SubscribeRequest{DepthLevels: uint32(depthLevels), Trades: trades})
+}
+
+// OrderEntry opens an order entry stream, open until the client's closed or ctx is cancelled.
+func (c *Client) OrderEntry(ctx context.Context) (*StreamClient, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	stream, err := c.rpc.OrderEntry(ctx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("open order entry stream: %w", err)
+	}
+
+	s := &StreamClient{
+		Client:  c,
+		stream:  stream,
+		cancel:  cancel,
+		pending: make(map[uint64]chan *pb.OrderEntryResponse),
+		done:    make(chan struct{}),
+	}
+	go s.read()
+
+	return s, nil
+}
+
+var _ client.Client = &StreamClient{}
+
+// StreamClient places, cancels & edits orders over an order entry stream, pipelining requests; orders are queried with
+// the service's unary calls.
+type StreamClient struct {
+	*Client
+
+	stream pb.Orderbook_OrderEntryClient
+	cancel context.CancelFunc
+
+	// sendMu serializes sends, which the stream doesn't allow concurrently.
+	sendMu sync.Mutex
+
+	// pending are the requests waiting on their responses, by request ID.
+	nextRequestID uint64
+	pending       map[uint64]chan *pb.OrderEntryResponse
+	mu            sync.Mutex
+
+	// done is closed once the stream's closed, with err why.
+	done chan struct{}
+	err  error
+}
+
+// Close closes the stream, failing any requests still waiting.
+func (s *StreamClient) Close() error {
+	s.cancel()
+	return nil
+}
+
+// read delivers responses to their requests.
+func (s *StreamClient) read() {
+	for {
+		resp, err := s.stream.Recv()
+		if err != nil {
+			s.err = err
+			close(s.done)
+			return
+		}
+
+		s.mu.Lock()
+		ch, ok := s.pending[resp.GetRequestId()]
+		delete(s.pending, resp.GetRequestId())
+		s.mu.Unlock()
+
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// call sends the request, returning its response; a failed request's returned as its error.
+func (s *StreamClient) call(ctx context.Context, req *pb.OrderEntryRequest) (*pb.OrderEntryResponse, error) {
+	ch := make(chan *pb.OrderEntryResponse, 1)
+
+	s.mu.Lock()
+	s.nextRequestID++
+	req.RequestId = s.nextRequestID
+	s.pending[req.RequestId] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, req.RequestId)
+		s.mu.Unlock()
+	}()
+
+	s.sendMu.Lock()
+	err := s.stream.Send(req)
+	s.sendMu.Unlock()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStreamClosed, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if e := resp.GetError(); e != nil {
+			return nil, errForResponse(e)
+		}
+
+		return resp, nil
+	case <-s.done:
+		return nil, fmt.Errorf("%w: %w", ErrStreamClosed, s.err)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *StreamClient) AddOrder(ctx context.Context, req client.AddOrderRequest) (client.AddOrderResponse, error) {
+	resp, err := s.call(ctx, &pb.OrderEntryRequest{Request: &pb.OrderEntryRequest_AddOrder{AddOrder: newAddOrderRequest(req)}})
+	if err != nil {
+		return client.AddOrderResponse{}, fmt.Errorf("add order: %w", err)
+	}
+
+	return client.AddOrderResponse{OrderID: resp.GetAddOrder().GetOrderId()}, nil
+}
+
+func (s *StreamClient) CancelOrder(ctx context.Context, req client.CancelOrderRequest) (client.CancelOrderResponse, error) {
+	cancel := newCancelOrderRequest(req)
+	if _, err := s.call(ctx, &pb.OrderEntryRequest{Request: &pb.OrderEntryRequest_CancelOrder{CancelOrder: cancel}}); err != nil {
+		return client.CancelOrderResponse{}, fmt.Errorf("cancel order: %w", err)
+	}
+
+	return client.CancelOrderResponse{}, nil
+}
+
+func (s *StreamClient) EditOrder(ctx context.Context, req client.EditOrderRequest) (client.EditOrderResponse, error) {
+	edit := newEditOrderRequest(req)
+	if _, err := s.call(ctx, &pb.OrderEntryRequest{Request: &pb.OrderEntryRequest_EditOrder{EditOrder: edit}}); err != nil {
+		return client.EditOrderResponse{}, fmt.Errorf("edit order: %w", err)
+	}
+
+	return client.EditOrderResponse{}, nil
+}
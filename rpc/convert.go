@@ -0,0 +1,263 @@
+package rpc
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/jsonapi"
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/rpc/pb"
+)
+
+// errorDomain is the domain of the ErrorInfo failed calls carry.
+const errorDomain = "orderbook"
+
+// The service's enums number their values as the engine's, from 1, leaving 0 unspecified; they're converted by value.
+
+func newOrder(info lob.OrderInfo) *pb.Order {
+	order := &pb.Order{
+		Id:            info.ID,
+		AccountId:     info.AccountID,
+		Type:          pb.OrderType(info.OrderType),
+		Side:          pb.Side(info.Side),
+		Price:         float64(info.Price),
+		Size:          float64(info.Size),
+		Status:        pb.OrderStatus(info.Status),
+		FilledSize:    float64(info.FilledSize),
+		RemainingSize: float64(info.RemainingSize),
+		AvgPrice:      float64(info.AvgPrice),
+		Fees:          info.Fees,
+		ClientOrderId: info.ClientOrderID,
+	}
+	if info.RejectReason != 0 {
+		order.RejectReason = info.RejectReason.String()
+	}
+
+	if !info.UpdatedAt.IsZero() {
+		order.UpdatedAt = timestamppb.New(info.UpdatedAt)
+	}
+
+	if !info.ReceivedAt.IsZero() {
+		order.ReceivedAt = timestamppb.New(info.ReceivedAt)
+	}
+
+	if !info.SequencedAt.IsZero() {
+		order.SequencedAt = timestamppb.New(info.SequencedAt)
+	}
+
+	return order
+}
+
+func orderInfo(order *pb.Order) lob.OrderInfo {
+	info := lob.OrderInfo{
+		ID:            order.GetId(),
+		AccountID:     order.GetAccountId(),
+		OrderType:     lob.OrderType(order.GetType()),
+		Side:          lob.OrderSide(order.GetSide()),
+		Price:         lob.Price(order.GetPrice()),
+		Size:          lob.Size(order.GetSize()),
+		Status:        lob.OrderStatus(order.GetStatus()),
+		FilledSize:    lob.Size(order.GetFilledSize()),
+		RemainingSize: lob.Size(order.GetRemainingSize()),
+		AvgPrice:      lob.Price(order.GetAvgPrice()),
+		Fees:          order.GetFees(),
+		RejectReason:  jsonapi.ParseRejectReason(order.GetRejectReason()),
+		ClientOrderID: order.GetClientOrderId(),
+	}
+
+	if order.GetUpdatedAt() != nil {
+		info.UpdatedAt = order.GetUpdatedAt().AsTime()
+	}
+
+	if order.GetReceivedAt() != nil {
+		info.ReceivedAt = order.GetReceivedAt().AsTime()
+	}
+
+	if order.GetSequencedAt() != nil {
+		info.SequencedAt = order.GetSequencedAt().AsTime()
+	}
+
+	return info
+}
+
+func newOrders(infos []lob.OrderInfo) []*pb.Order {
+	orders := make([]*pb.Order, 0, len(infos))
+	for _, info := range infos {
+		orders = append(orders, newOrder(info))
+	}
+
+	return orders
+}
+
+func newAddOrderRequest(req client.AddOrderRequest) *pb.AddOrderRequest {
+	return &pb.AddOrderRequest{
+		AccountId:     req.AccountID,
+		Type:          pb.OrderType(req.OrderType),
+		Side:          pb.Side(req.OrderSide),
+		Price:         float64(req.Price),
+		Size:          float64(req.Size),
+		ClientOrderId: req.ClientOrderID,
+	}
+}
+
+func addOrderRequest(req *pb.AddOrderRequest) (client.AddOrderRequest, error) {
+	switch req.GetType() {
+	case pb.OrderType_ORDER_TYPE_LIMIT, pb.OrderType_ORDER_TYPE_MARKET:
+	default:
+		return client.AddOrderRequest{}, jsonapi.InvalidRequest("unknown order type %s", req.GetType())
+	}
+
+	switch req.GetSide() {
+	case pb.Side_SIDE_BUY, pb.Side_SIDE_SELL:
+	default:
+		return client.AddOrderRequest{}, jsonapi.InvalidRequest("unknown side %s", req.GetSide())
+	}
+
+	return client.AddOrderRequest{
+		AccountID:     req.GetAccountId(),
+		OrderType:     lob.OrderType(req.GetType()),
+		OrderSide:     lob.OrderSide(req.GetSide()),
+		Price:         lob.Price(req.GetPrice()),
+		Size:          lob.Size(req.GetSize()),
+		ClientOrderID: req.GetClientOrderId(),
+	}, nil
+}
+
+func newCancelOrderRequest(req client.CancelOrderRequest) *pb.CancelOrderRequest {
+	return &pb.CancelOrderRequest{OrderId: req.OrderID, AccountId: req.AccountID, ClientOrderId: req.ClientOrderID}
+}
+
+func cancelOrderRequest(req *pb.CancelOrderRequest) client.CancelOrderRequest {
+	return client.CancelOrderRequest{OrderID: req.GetOrderId(), AccountID: req.GetAccountId(), ClientOrderID: req.GetClientOrderId()}
+}
+
+func newEditOrderRequest(req client.EditOrderRequest) *pb.EditOrderRequest {
+	return &pb.EditOrderRequest{
+		OrderId:       req.OrderID,
+		Price:         float64(req.Price),
+		Size:          float64(req.Size),
+		AccountId:     req.AccountID,
+		ClientOrderId: req.ClientOrderID,
+	}
+}
+
+func editOrderRequest(req *pb.EditOrderRequest) client.EditOrderRequest {
+	return client.EditOrderRequest{
+		OrderID:       req.GetOrderId(),
+		Price:         lob.Price(req.GetPrice()),
+		Size:          lob.Size(req.GetSize()),
+		AccountID:     req.GetAccountId(),
+		ClientOrderID: req.GetClientOrderId(),
+	}
+}
+
+func newGetOrderRequest(req client.GetOrderRequest) *pb.GetOrderRequest {
+	return &pb.GetOrderRequest{OrderId: req.OrderID, AccountId: req.AccountID, ClientOrderId: req.ClientOrderID}
+}
+
+func getOrderRequest(req *pb.GetOrderRequest) client.GetOrderRequest {
+	return client.GetOrderRequest{OrderID: req.GetOrderId(), AccountID: req.GetAccountId(), ClientOrderID: req.GetClientOrderId()}
+}
+
+func newDepthSnapshot(snapshot lob.DepthSnapshot) *pb.DepthSnapshot {
+	return &pb.DepthSnapshot{
+		Seq:      snapshot.Seq,
+		Bids:     newLevels(snapshot.Bids),
+		Asks:     newLevels(snapshot.Asks),
+		Checksum: snapshot.Checksum,
+	}
+}
+
+func newLevels(levels []lob.DepthLevel) []*pb.Level {
+	encoded := make([]*pb.Level, 0, len(levels))
+	for _, level := range levels {
+		encoded = append(encoded, &pb.Level{Price: float64(level.Price), Size: float64(level.Size)})
+	}
+
+	return encoded
+}
+
+func newDepthUpdate(next lob.DepthSnapshot, changes []lob.DepthChange) *pb.DepthUpdate {
+	update := &pb.DepthUpdate{
+		Seq:      next.Seq,
+		Changes:  make([]*pb.DepthChange, 0, len(changes)),
+		Checksum: next.Checksum,
+	}
+	for _, change := range changes {
+		update.Changes = append(update.Changes, &pb.DepthChange{
+			Action:   pb.DepthAction(change.Action),
+			Side:     pb.Side(change.Side),
+			Position: uint32(change.Position),
+			Level:    &pb.Level{Price: float64(change.Level.Price), Size: float64(change.Level.Size)},
+		})
+	}
+
+	return update
+}
+
+func newTrade(event lob.TradeEvent) *pb.Trade {
+	return &pb.Trade{
+		Price:         float64(event.Price),
+		Size:          float64(event.Size),
+		AggressorSide: pb.Side(event.AggressorSide),
+		MakerOrderId:  event.MakerOrderID,
+		TakerOrderId:  event.TakerOrderID,
+		Time:          timestamppb.New(event.SequencedAt),
+	}
+}
+
+// statusFor returns the engine's error as a status, coded as the JSON APIs code it; the code's carried as the reason of
+// the status's ErrorInfo.
+func statusFor(err error) error {
+	apiErr := jsonapi.ErrorFor(err)
+
+	var code codes.Code
+	switch apiErr.Code {
+	case jsonapi.CodeInvalidRequest:
+		code = codes.InvalidArgument
+	case jsonapi.CodeOrderNotFound:
+		code = codes.NotFound
+	case lob.RejectReasonDuplicateOrder.String():
+		code = codes.AlreadyExists
+	case jsonapi.CodeInternal:
+		code = codes.Internal
+	default:
+		code = codes.FailedPrecondition
+	}
+
+	st, detailErr := status.New(code, apiErr.Message).WithDetails(&errdetails.ErrorInfo{Reason: apiErr.Code, Domain: errorDomain})
+	if detailErr != nil {
+		return status.Error(code, apiErr.Message)
+	}
+
+	return st.Err()
+}
+
+// errFor returns a failed call's error as the engine would have returned it, should it carry the service's ErrorInfo.
+func errFor(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == errorDomain {
+			return (&jsonapi.Error{Code: info.GetReason(), Message: st.Message()}).Err()
+		}
+	}
+
+	return err
+}
+
+// errForResponse returns an order entry stream's error as the engine would have returned it.
+func errForResponse(e *pb.Error) error {
+	return (&jsonapi.Error{Code: e.GetCode(), Message: e.GetMessage()}).Err()
+}
+
+func newError(err error) *pb.Error {
+	apiErr := jsonapi.ErrorFor(err)
+	return &pb.Error{Code: apiErr.Code, Message: apiErr.Message}
+}
@@ -0,0 +1,4 @@
+// Package pb is the generated code for the gRPC service defined in orderbook.proto.
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative orderbook.proto
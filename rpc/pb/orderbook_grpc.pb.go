@@ -0,0 +1,367 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: orderbook.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Orderbook_AddOrder_FullMethodName       = "/orderbook.v1.Orderbook/AddOrder"
+	Orderbook_CancelOrder_FullMethodName    = "/orderbook.v1.Orderbook/CancelOrder"
+	Orderbook_EditOrder_FullMethodName      = "/orderbook.v1.Orderbook/EditOrder"
+	Orderbook_GetOrder_FullMethodName       = "/orderbook.v1.Orderbook/GetOrder"
+	Orderbook_ListOpenOrders_FullMethodName = "/orderbook.v1.Orderbook/ListOpenOrders"
+	Orderbook_Subscribe_FullMethodName      = "/orderbook.v1.Orderbook/Subscribe"
+	Orderbook_OrderEntry_FullMethodName     = "/orderbook.v1.Orderbook/OrderEntry"
+)
+
+// OrderbookClient is the client API for Orderbook service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Orderbook is order entry & market data for a single book.
+//
+// Requests the engine rejects fail with FAILED_PRECONDITION, unknown orders with NOT_FOUND, and invalid requests with
+// INVALID_ARGUMENT; each carries a google.rpc.ErrorInfo whose reason is the engine's reject reason, "order_not_found" or
+// "invalid_request", as coded by the JSON APIs.
+type OrderbookClient interface {
+	AddOrder(ctx context.Context, in *AddOrderRequest, opts ...grpc.CallOption) (*AddOrderResponse, error)
+	CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*CancelOrderResponse, error)
+	EditOrder(ctx context.Context, in *EditOrderRequest, opts ...grpc.CallOption) (*EditOrderResponse, error)
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*GetOrderResponse, error)
+	ListOpenOrders(ctx context.Context, in *ListOpenOrdersRequest, opts ...grpc.CallOption) (*ListOpenOrdersResponse, error)
+	// Subscribe streams the book's depth, as a snapshot followed by updates, and its trades. A subscriber that falls behind
+	// has its stream ended with RESOURCE_EXHAUSTED, and must resubscribe.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MarketData], error)
+	// OrderEntry places, cancels & edits orders over a single stream; requests are carried out in order, each answered with
+	// its request ID.
+	OrderEntry(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[OrderEntryRequest, OrderEntryResponse], error)
+}
+
+type orderbookClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrderbookClient(cc grpc.ClientConnInterface) OrderbookClient {
+	return &orderbookClient{cc}
+}
+
+func (c *orderbookClient) AddOrder(ctx context.Context, in *AddOrderRequest, opts ...grpc.CallOption) (*AddOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddOrderResponse)
+	err := c.cc.Invoke(ctx, Orderbook_AddOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderbookClient) CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*CancelOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelOrderResponse)
+	err := c.cc.Invoke(ctx, Orderbook_CancelOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderbookClient) EditOrder(ctx context.Context, in *EditOrderRequest, opts ...grpc.CallOption) (*EditOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EditOrderResponse)
+	err := c.cc.Invoke(ctx, Orderbook_EditOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderbookClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*GetOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrderResponse)
+	err := c.cc.Invoke(ctx, Orderbook_GetOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderbookClient) ListOpenOrders(ctx context.Context, in *ListOpenOrdersRequest, opts ...grpc.CallOption) (*ListOpenOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListOpenOrdersResponse)
+	err := c.cc.Invoke(ctx, Orderbook_ListOpenOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderbookClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MarketData], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Orderbook_ServiceDesc.Streams[0], Orderbook_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, MarketData]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Orderbook_SubscribeClient = grpc.ServerStreamingClient[MarketData]
+
+func (c *orderbookClient) OrderEntry(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[OrderEntryRequest, OrderEntryResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Orderbook_ServiceDesc.Streams[1], Orderbook_OrderEntry_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[OrderEntryRequest, OrderEntryResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Orderbook_OrderEntryClient = grpc.BidiStreamingClient[OrderEntryRequest, OrderEntryResponse]
+
+// OrderbookServer is the server API for Orderbook service.
+// All implementations must embed UnimplementedOrderbookServer
+// for forward compatibility.
+//
+// Orderbook is order entry & market data for a single book.
+//
+// Requests the engine rejects fail with FAILED_PRECONDITION, unknown orders with NOT_FOUND, and invalid requests with
+// INVALID_ARGUMENT; each carries a google.rpc.ErrorInfo whose reason is the engine's reject reason, "order_not_found" or
+// "invalid_request", as coded by the JSON APIs.
+type OrderbookServer interface {
+	AddOrder(context.Context, *AddOrderRequest) (*AddOrderResponse, error)
+	CancelOrder(context.Context, *CancelOrderRequest) (*CancelOrderResponse, error)
+	EditOrder(context.Context, *EditOrderRequest) (*EditOrderResponse, error)
+	GetOrder(context.Context, *GetOrderRequest) (*GetOrderResponse, error)
+	ListOpenOrders(context.Context, *ListOpenOrdersRequest) (*ListOpenOrdersResponse, error)
+	// Subscribe streams the book's depth, as a snapshot followed by updates, and its trades. A subscriber that falls behind
+	// has its stream ended with RESOURCE_EXHAUSTED, and must resubscribe.
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[MarketData]) error
+	// OrderEntry places, cancels & edits orders over a single stream; requests are carried out in order, each answered with
+	// its request ID.
+	OrderEntry(grpc.BidiStreamingServer[OrderEntryRequest, OrderEntryResponse]) error
+	mustEmbedUnimplementedOrderbookServer()
+}
+
+// UnimplementedOrderbookServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrderbookServer struct{}
+
+func (UnimplementedOrderbookServer) AddOrder(context.Context, *AddOrderRequest) (*AddOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddOrder not implemented")
+}
+func (UnimplementedOrderbookServer) CancelOrder(context.Context, *CancelOrderRequest) (*CancelOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOrder not implemented")
+}
+func (UnimplementedOrderbookServer) EditOrder(context.Context, *EditOrderRequest) (*EditOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EditOrder not implemented")
+}
+func (UnimplementedOrderbookServer) GetOrder(context.Context, *GetOrderRequest) (*GetOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedOrderbookServer) ListOpenOrders(context.Context, *ListOpenOrdersRequest) (*ListOpenOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOpenOrders not implemented")
+}
+func (UnimplementedOrderbookServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[MarketData]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedOrderbookServer) OrderEntry(grpc.BidiStreamingServer[OrderEntryRequest, OrderEntryResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method OrderEntry not implemented")
+}
+func (UnimplementedOrderbookServer) mustEmbedUnimplementedOrderbookServer() {}
+func (UnimplementedOrderbookServer) testEmbeddedByValue()                   {}
+
+// UnsafeOrderbookServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrderbookServer will
+// result in compilation errors.
+type UnsafeOrderbookServer interface {
+	mustEmbedUnimplementedOrderbookServer()
+}
+
+func RegisterOrderbookServer(s grpc.ServiceRegistrar, srv OrderbookServer) {
+	// If the following call pancis, it indicates UnimplementedOrderbookServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Orderbook_ServiceDesc, srv)
+}
+
+func _Orderbook_AddOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderbookServer).AddOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Orderbook_AddOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderbookServer).AddOrder(ctx, req.(*AddOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orderbook_CancelOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderbookServer).CancelOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Orderbook_CancelOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderbookServer).CancelOrder(ctx, req.(*CancelOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orderbook_EditOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderbookServer).EditOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Orderbook_EditOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderbookServer).EditOrder(ctx, req.(*EditOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orderbook_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderbookServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Orderbook_GetOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderbookServer).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orderbook_ListOpenOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOpenOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderbookServer).ListOpenOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Orderbook_ListOpenOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderbookServer).ListOpenOrders(ctx, req.(*ListOpenOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Orderbook_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderbookServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, MarketData]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Orderbook_SubscribeServer = grpc.ServerStreamingServer[MarketData]
+
+func _Orderbook_OrderEntry_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(OrderbookServer).OrderEntry(&grpc.GenericServerStream[OrderEntryRequest, OrderEntryResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Orderbook_OrderEntryServer = grpc.BidiStreamingServer[OrderEntryRequest, OrderEntryResponse]
+
+// Orderbook_ServiceDesc is the grpc.ServiceDesc for Orderbook service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Orderbook_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orderbook.v1.Orderbook",
+	HandlerType: (*OrderbookServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddOrder",
+			Handler:    _Orderbook_AddOrder_Handler,
+		},
+		{
+			MethodName: "CancelOrder",
+			Handler:    _Orderbook_CancelOrder_Handler,
+		},
+		{
+			MethodName: "EditOrder",
+			Handler:    _Orderbook_EditOrder_Handler,
+		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _Orderbook_GetOrder_Handler,
+		},
+		{
+			MethodName: "ListOpenOrders",
+			Handler:    _Orderbook_ListOpenOrders_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Orderbook_Subscribe_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "OrderEntry",
+			Handler:       _Orderbook_OrderEntry_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "orderbook.proto",
+}
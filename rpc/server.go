@@ -0,0 +1,341 @@
+// Package rpc serves the book over gRPC: order entry, both unary & streamed, and streamed depth & trades. The service is
+// defined in pb/orderbook.proto.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/jsonapi"
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/rpc/pb"
+	"github.com/sashajdn/orderbook/session"
+)
+
+const DefaultQueueSize = 1024
+
+type Config struct {
+	Book *lob.Orderbook
+	// Client executes order commands; by default a client.LOBClient on Book, which on an OrderEntry stream enters orders on
+	// the stream's session. Orders entered through a Client set here aren't tied to a session, so aren't cancelled once
+	// the stream ends.
+	Client client.Client
+
+	// Sessions opens an order entry session for each OrderEntry stream, cancelling every order entered on it once the
+	// stream ends; by default a manager cancelling through Book. Unary calls aren't made on a session.
+	Sessions *session.Manager
+
+	// DepthLevels is the most levels per side a subscription is streamed; it must be no more than the book publishes
+	// DepthEvents with. Depth subscriptions are refused while it's 0.
+	DepthLevels int
+
+	// QueueSize bounds the depth & trades waiting to be streamed to a subscriber; a subscriber that falls this far behind
+	// has its stream ended.
+	QueueSize int
+}
+
+// NewServer returns a server for the book, to be registered with pb.RegisterOrderbookServer; it subscribes to the book's
+// events until closed.
+func NewServer(config Config) *Server {
+	if config.Sessions == nil {
+		config.Sessions = session.NewManager(session.Config{Canceller: config.Book, Clock: config.Book.Clock()})
+	}
+
+	if config.QueueSize == 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+
+	s := &Server{
+		config:      config,
+		client:      config.Client,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+	if s.client == nil {
+		s.client = client.NewLOBClient(config.Book)
+	}
+	s.unsubscribe = config.Book.Subscribe(s.onEvent)
+
+	return s
+}
+
+var _ pb.OrderbookServer = &Server{}
+
+// Server implements the Orderbook service.
+type Server struct {
+	pb.UnimplementedOrderbookServer
+
+	config Config
+	// client executes unary calls' order commands.
+	client      client.Client
+	subscribers map[*subscriber]struct{}
+	unsubscribe func()
+	mu          sync.Mutex
+}
+
+// Close stops streaming the book's events, ending every subscription.
+func (s *Server) Close() error {
+	s.unsubscribe()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscribers {
+		sub.close(errServerClosed)
+		delete(s.subscribers, sub)
+	}
+
+	return nil
+}
+
+func (s *Server) AddOrder(ctx context.Context, req *pb.AddOrderRequest) (*pb.AddOrderResponse, error) {
+	resp, err := addOrder(ctx, s.client, req)
+	if err != nil {
+		return nil, statusFor(err)
+	}
+
+	return resp, nil
+}
+
+func addOrder(ctx context.Context, cl client.Client, req *pb.AddOrderRequest) (*pb.AddOrderResponse, error) {
+	addReq, err := addOrderRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := cl.AddOrder(ctx, addReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.AddOrderResponse{OrderId: resp.OrderID}, nil
+}
+
+func (s *Server) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error) {
+	if _, err := s.client.CancelOrder(ctx, cancelOrderRequest(req)); err != nil {
+		return nil, statusFor(err)
+	}
+
+	return &pb.CancelOrderResponse{}, nil
+}
+
+func (s *Server) EditOrder(ctx context.Context, req *pb.EditOrderRequest) (*pb.EditOrderResponse, error) {
+	if _, err := s.client.EditOrder(ctx, editOrderRequest(req)); err != nil {
+		return nil, statusFor(err)
+	}
+
+	return &pb.EditOrderResponse{}, nil
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
+	resp, err := s.client.GetOrder(ctx, getOrderRequest(req))
+	if err != nil {
+		return nil, statusFor(err)
+	}
+
+	return &pb.GetOrderResponse{Order: newOrder(resp.Order)}, nil
+}
+
+func (s *Server) ListOpenOrders(ctx context.Context, req *pb.ListOpenOrdersRequest) (*pb.ListOpenOrdersResponse, error) {
+	resp, err := s.client.ListOpenOrders(ctx, client.ListOpenOrdersRequest{})
+	if err != nil {
+		return nil, statusFor(err)
+	}
+
+	return &pb.ListOpenOrdersResponse{Orders: newOrders(resp.Orders)}, nil
+}
+
+// OrderEntry carries out the stream's requests in order, answering each before receiving the next. The stream's a session:
+// the orders entered on it are cancelled once it ends.
+func (s *Server) OrderEntry(stream pb.Orderbook_OrderEntryServer) error {
+	ctx := stream.Context()
+
+	sess := s.config.Sessions.Connect(0, true)
+	defer func() {
+		if _, err := s.config.Sessions.Disconnect(sess.ID); err != nil {
+			slog.Warn("gRPC: failed to disconnect session", "session", sess.ID, "error", err)
+		}
+	}()
+
+	go s.config.Sessions.KeepAlive(ctx, sess.ID)
+
+	cl := s.config.Client
+	if cl == nil {
+		cl = client.NewLOBClient(s.config.Book).WithSession(sess)
+	}
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		resp := &pb.OrderEntryResponse{RequestId: req.GetRequestId()}
+		switch r := req.GetRequest().(type) {
+		case *pb.OrderEntryRequest_AddOrder:
+			added, err := addOrder(ctx, cl, r.AddOrder)
+			if err != nil {
+				resp.Response = &pb.OrderEntryResponse_Error{Error: newError(err)}
+				break
+			}
+
+			resp.Response = &pb.OrderEntryResponse_AddOrder{AddOrder: added}
+		case *pb.OrderEntryRequest_CancelOrder:
+			if _, err := cl.CancelOrder(ctx, cancelOrderRequest(r.CancelOrder)); err != nil {
+				resp.Response = &pb.OrderEntryResponse_Error{Error: newError(err)}
+				break
+			}
+
+			resp.Response = &pb.OrderEntryResponse_CancelOrder{CancelOrder: &pb.CancelOrderResponse{}}
+		case *pb.OrderEntryRequest_EditOrder:
+			if _, err := cl.EditOrder(ctx, editOrderRequest(r.EditOrder)); err != nil {
+				resp.Response = &pb.OrderEntryResponse_Error{Error: newError(err)}
+				break
+			}
+
+			resp.Response = &pb.OrderEntryResponse_EditOrder{EditOrder: &pb.EditOrderResponse{}}
+		default:
+			resp.Response = &pb.OrderEntryResponse_Error{Error: newError(jsonapi.InvalidRequest("missing request"))}
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+var (
+	errSlowSubscriber = status.Error(codes.ResourceExhausted, "subscriber fell behind")
+	errServerClosed   = status.Error(codes.Unavailable, "server closed")
+)
+
+// subscriber is a subscription's queue of the book's events; it's closed, with err why, once it's unsubscribed.
+type subscriber struct {
+	levels int
+	trades bool
+	events chan lob.Event
+
+	done chan struct{}
+	err  error
+}
+
+func (sub *subscriber) close(err error) {
+	sub.err = err
+	close(sub.done)
+}
+
+// Subscribe streams the top levels of the book as a snapshot followed by updates, and the book's trades.
+func (s *Server) Subscribe(req *pb.SubscribeRequest, stream pb.Orderbook_SubscribeServer) error {
+	levels := int(req.GetDepthLevels())
+	if levels > s.config.DepthLevels {
+		return status.Errorf(codes.InvalidArgument, "at most %d depth levels are served", s.config.DepthLevels)
+	}
+
+	sub := &subscriber{
+		levels: levels,
+		trades: req.GetTrades(),
+		events: make(chan lob.Event, s.config.QueueSize),
+		done:   make(chan struct{}),
+	}
+
+	// Subscribed before the snapshot's taken, so no depth's missed; depth queued as of the snapshot is skipped.
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+	}()
+
+	var depth lob.DepthSnapshot
+	if levels > 0 {
+		depth = s.config.Book.DepthSnapshot(levels)
+
+		snapshot := &pb.MarketData{Data: &pb.MarketData_DepthSnapshot{DepthSnapshot: newDepthSnapshot(depth)}}
+		if err := stream.Send(snapshot); err != nil {
+			return err
+		}
+	}
+
+	for {
+		var event lob.Event
+		select {
+		case event = <-sub.events:
+		case <-sub.done:
+			return sub.err
+		case <-stream.Context().Done():
+			return nil
+		}
+
+		var data *pb.MarketData
+		switch event := event.(type) {
+		case lob.DepthEvent:
+			if event.Seq <= depth.Seq {
+				continue
+			}
+
+			next := event.Truncate(levels)
+			changes := lob.DiffDepth(depth, next)
+			depth = next
+
+			if len(changes) == 0 {
+				continue
+			}
+
+			data = &pb.MarketData{Data: &pb.MarketData_DepthUpdate{DepthUpdate: newDepthUpdate(next, changes)}}
+		case lob.TradeEvent:
+			data = &pb.MarketData{Data: &pb.MarketData_Trade{Trade: newTrade(event)}}
+		default:
+			continue
+		}
+
+		if err := stream.Send(data); err != nil {
+			return err
+		}
+	}
+}
+
+// onEvent queues depth & trades for subscribers, ending the subscription of any that's fallen behind; it's called with the
+// book locked.
+func (s *Server) onEvent(event lob.Event) {
+	switch event.(type) {
+	case lob.DepthEvent, lob.TradeEvent:
+	default:
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscribers {
+		switch event.(type) {
+		case lob.DepthEvent:
+			if sub.levels == 0 {
+				continue
+			}
+		case lob.TradeEvent:
+			if !sub.trades {
+				continue
+			}
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			sub.close(errSlowSubscriber)
+			delete(s.subscribers, sub)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/rpc/pb"
+)
+
+func serve(t *testing.T, config Config) *Client {
+	t.Helper()
+
+	server := NewServer(config)
+	t.Cleanup(func() { server.Close() })
+
+	grpcServer := grpc.NewServer()
+	pb.RegisterOrderbookServer(grpcServer, server)
+	t.Cleanup(grpcServer.Stop)
+
+	l := bufconn.Listen(1 << 20)
+	go grpcServer.Serve(l)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return NewClient(conn)
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	unary := serve(t, Config{Book: lob.NewOrderbook(128)})
+
+	stream, err := unary.OrderEntry(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() { stream.Close() })
+
+	tests := []struct {
+		name   string
+		client client.Client
+	}{
+		{name: "unary", client: unary},
+		{name: "stream", client: stream},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.client
+
+			req := client.AddOrderRequest{AccountID: 7, OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 100, Size: 5, ClientOrderID: tt.name}
+			placed, err := c.AddOrder(ctx, req)
+			require.NoError(t, err)
+
+			_, err = c.AddOrder(ctx, req)
+			var rejectErr *lob.RejectError
+			require.True(t, errors.As(err, &rejectErr), err)
+			assert.Equal(t, lob.RejectReasonDuplicateOrder, rejectErr.Reason)
+
+			_, err = c.EditOrder(ctx, client.EditOrderRequest{AccountID: 7, ClientOrderID: tt.name, Price: 101, Size: 6})
+			require.NoError(t, err)
+
+			_, err = c.AddOrder(ctx, client.AddOrderRequest{AccountID: 8, OrderType: lob.MarketOrder, OrderSide: lob.SellSide, Size: 2})
+			require.NoError(t, err)
+
+			got, err := c.GetOrder(ctx, client.GetOrderRequest{OrderID: placed.OrderID})
+			require.NoError(t, err)
+			assert.Equal(t, uint64(7), got.Order.AccountID)
+			assert.Equal(t, tt.name, got.Order.ClientOrderID)
+			assert.Equal(t, lob.LimitOrder, got.Order.OrderType)
+			assert.Equal(t, lob.BuySide, got.Order.Side)
+			assert.Equal(t, lob.Price(101), got.Order.Price)
+			assert.Equal(t, lob.OrderStatusPartiallyFilled, got.Order.Status)
+			assert.Equal(t, lob.Size(2), got.Order.FilledSize)
+			assert.False(t, got.Order.UpdatedAt.IsZero())
+			assert.False(t, got.Order.ReceivedAt.After(got.Order.SequencedAt))
+			assert.False(t, got.Order.SequencedAt.IsZero())
+
+			open, err := c.ListOpenOrders(ctx, client.ListOpenOrdersRequest{})
+			require.NoError(t, err)
+			require.Len(t, open.Orders, 1)
+			assert.Equal(t, placed.OrderID, open.Orders[0].ID)
+
+			_, err = c.CancelOrder(ctx, client.CancelOrderRequest{OrderID: placed.OrderID})
+			require.NoError(t, err)
+
+			// The engine's errors are returned as it returns them.
+			_, err = c.CancelOrder(ctx, client.CancelOrderRequest{OrderID: 999})
+			assert.True(t, errors.Is(err, lob.ErrOrderNotFound), err)
+
+			_, err = c.AddOrder(ctx, client.AddOrderRequest{AccountID: 8, OrderType: lob.MarketOrder, OrderSide: lob.SellSide, Size: 1})
+			require.True(t, errors.As(err, &rejectErr), err)
+			assert.Equal(t, lob.RejectReasonNoLiquidity, rejectErr.Reason)
+		})
+	}
+}
+
+func TestServer_OrderEntryCancelsOrdersOnClose(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	book := lob.NewOrderbook(128)
+	unary := serve(t, Config{Book: book})
+
+	// Orders placed by unary calls aren't on a session, so outlive the stream.
+	kept, err := unary.AddOrder(ctx, client.AddOrderRequest{AccountID: 7, OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 99, Size: 1})
+	require.NoError(t, err)
+
+	stream, err := unary.OrderEntry(ctx)
+	require.NoError(t, err)
+
+	placed, err := stream.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 100, Size: 1, ClientOrderID: "a"})
+	require.NoError(t, err)
+
+	// Without an account, client order IDs are scoped to the stream's session.
+	_, err = stream.EditOrder(ctx, client.EditOrderRequest{ClientOrderID: "a", Price: 101, Size: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, stream.Close())
+
+	assert.Eventually(t, func() bool {
+		info, err := book.GetOrder(placed.OrderID)
+		return err == nil && info.Status == lob.OrderStatusCancelled
+	}, 5*time.Second, 10*time.Millisecond)
+
+	open := book.OpenOrders()
+	require.Len(t, open, 1)
+	assert.Equal(t, kept.OrderID, open[0].ID)
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	book := lob.NewOrderbook(128, lob.WithDepthEvents(5))
+	c := serve(t, Config{Book: book, DepthLevels: 2})
+
+	_, err := c.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 100, Size: 5})
+	require.NoError(t, err)
+
+	refused, err := c.Subscribe(ctx, 3, false)
+	require.NoError(t, err)
+	_, err = refused.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	sub, err := c.Subscribe(ctx, 2, true)
+	require.NoError(t, err)
+
+	msg, err := sub.Recv()
+	require.NoError(t, err)
+	snapshot := msg.GetDepthSnapshot()
+	require.NotNil(t, snapshot)
+	require.Len(t, snapshot.GetBids(), 1)
+	assert.Equal(t, 100.0, snapshot.GetBids()[0].GetPrice())
+	assert.Equal(t, lob.Checksum([]lob.DepthLevel{{Price: 100, Size: 5}}, nil), snapshot.GetChecksum())
+
+	_, err = c.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.MarketOrder, OrderSide: lob.SellSide, Size: 2})
+	require.NoError(t, err)
+
+	var (
+		update *pb.DepthUpdate
+		trade  *pb.Trade
+	)
+	for update == nil || trade == nil {
+		msg, err := sub.Recv()
+		require.NoError(t, err)
+
+		switch {
+		case msg.GetDepthUpdate() != nil:
+			update = msg.GetDepthUpdate()
+		case msg.GetTrade() != nil:
+			trade = msg.GetTrade()
+		}
+	}
+
+	assert.Greater(t, update.GetSeq(), snapshot.GetSeq())
+	require.Len(t, update.GetChanges(), 1)
+	assert.Equal(t, pb.DepthAction_DEPTH_ACTION_CHANGE, update.GetChanges()[0].GetAction())
+	assert.Equal(t, pb.Side_SIDE_BUY, update.GetChanges()[0].GetSide())
+	assert.Equal(t, 3.0, update.GetChanges()[0].GetLevel().GetSize())
+	assert.Equal(t, lob.Checksum([]lob.DepthLevel{{Price: 100, Size: 3}}, nil), update.GetChecksum())
+
+	assert.Equal(t, 100.0, trade.GetPrice())
+	assert.Equal(t, 2.0, trade.GetSize())
+	assert.Equal(t, pb.Side_SIDE_SELL, trade.GetAggressorSide())
+}
+
+func TestServer_SlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	book := lob.NewOrderbook(128, lob.WithDepthEvents(5))
+	server := NewServer(Config{Book: book, DepthLevels: 5, QueueSize: 2})
+	t.Cleanup(func() { server.Close() })
+
+	sub := &subscriber{levels: 5, events: make(chan lob.Event, 2), done: make(chan struct{})}
+	server.mu.Lock()
+	server.subscribers[sub] = struct{}{}
+	server.mu.Unlock()
+
+	c := client.NewLOBClient(book)
+	for i := 0; i < 3; i++ {
+		_, err := c.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: lob.Price(100 - i), Size: 1})
+		require.NoError(t, err)
+	}
+
+	<-sub.done
+	assert.Equal(t, codes.ResourceExhausted, status.Code(sub.err))
+
+	server.mu.Lock()
+	assert.NotContains(t, server.subscribers, sub)
+	server.mu.Unlock()
+}
@@ -0,0 +1,345 @@
+package sbe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+var (
+	ErrClientClosed = errors.New("sbe client closed")
+
+	// ErrClientOrderIDUnsupported is returned for requests giving a client order ID; the protocol's client order IDs are
+	// the session's own, numbering its requests, so the engine's can't be carried.
+	ErrClientOrderIDUnsupported = errors.New("client order ids aren't supported over sbe")
+)
+
+type ClientConfig struct {
+	// AccountID is the account orders are placed for when a request doesn't give one.
+	AccountID          uint64
+	HeartbeatInterval  time.Duration
+	CancelOnDisconnect bool
+}
+
+// Dial connects & logs on to the binary order entry server at addr.
+func Dial(ctx context.Context, addr string, config ClientConfig) (*Client, error) {
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial sbe %s: %w", addr, err)
+	}
+
+	c := &Client{
+		netConn:   netConn,
+		r:         NewReader(netConn),
+		heartbeat: config.HeartbeatInterval,
+		pending:   make(map[uint64]chan answer),
+		orders:    make(map[uint64]lob.OrderInfo),
+		done:      make(chan struct{}),
+	}
+
+	if err := c.logon(ctx, config); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("logon: %w", err)
+	}
+
+	go c.read()
+	go c.heartbeats()
+
+	return c, nil
+}
+
+var _ client.Client = &Client{}
+
+// answer is the message answering a request: an ExecutionReport or a Reject.
+type answer struct {
+	template TemplateID
+	block    []byte
+}
+
+// Client places & manages orders over a binary order entry session; requests are pipelined, each answered by the first
+// execution report or reject for its client order ID. The engine's errors are returned as it would have returned them.
+//
+// Orders are only known through their execution reports, so GetOrder & ListOpenOrders answer from the reports received,
+// and only for the session's own orders. Orders can only be identified by their order IDs; requests giving a client order
+// ID are refused with ErrClientOrderIDUnsupported rather than sent without it.
+type Client struct {
+	netConn   net.Conn
+	r         *Reader
+	sessionID uint64
+	heartbeat time.Duration
+
+	// writeMu serializes writes, so messages aren't interleaved.
+	writeMu sync.Mutex
+
+	// pending are the requests waiting on their answer by client order ID, and orders the latest state reported of each
+	// of the session's orders.
+	nextClientOrderID uint64
+	pending           map[uint64]chan answer
+	orders            map[uint64]lob.OrderInfo
+	mu                sync.Mutex
+
+	// done is closed once the connection's closed, with err why.
+	done chan struct{}
+	err  error
+}
+
+// SessionID is the engine's ID for the session.
+func (c *Client) SessionID() uint64 {
+	return c.sessionID
+}
+
+// Close closes the connection, failing any requests still waiting; the engine cancels the session's orders if it logged
+// on with CancelOnDisconnect.
+func (c *Client) Close() error {
+	return c.netConn.Close()
+}
+
+func (c *Client) logon(ctx context.Context, config ClientConfig) error {
+	buf, logon := AppendLogon(nil)
+	logon.SetAccountID(config.AccountID)
+	logon.SetHeartbeatInterval(config.HeartbeatInterval)
+	logon.SetCancelOnDisconnect(config.CancelOnDisconnect)
+
+	if err := c.write(ctx, buf); err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.netConn.SetReadDeadline(deadline); err != nil {
+			return err
+		}
+		defer c.netConn.SetReadDeadline(time.Time{})
+	}
+
+	template, block, err := c.r.Next()
+	if err != nil {
+		return err
+	}
+
+	if template != TemplateLogon {
+		return fmt.Errorf("expected logon, got %s", template)
+	}
+
+	c.sessionID = Logon(block).SessionID()
+
+	return nil
+}
+
+// write writes the message, by the context's deadline if it has one.
+func (c *Client) write(ctx context.Context, msg []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	if err := c.netConn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	_, err := c.netConn.Write(msg)
+	return err
+}
+
+// read keeps the orders' states from their execution reports, delivering the first report or reject for a client order
+// ID to its request.
+func (c *Client) read() {
+	for {
+		template, block, err := c.r.Next()
+		if err != nil {
+			c.err = err
+			close(c.done)
+			return
+		}
+
+		var clientOrderID uint64
+		switch template {
+		case TemplateExecutionReport:
+			report := ExecutionReport(block)
+			clientOrderID = report.ClientOrderID()
+
+			c.mu.Lock()
+			c.orders[report.OrderID()] = report.OrderInfo()
+			c.mu.Unlock()
+		case TemplateReject:
+			clientOrderID = Reject(block).ClientOrderID()
+		default:
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[clientOrderID]
+		delete(c.pending, clientOrderID)
+		c.mu.Unlock()
+
+		if ok {
+			// The reader's buffer is reused for the next message.
+			ch <- answer{template: template, block: append([]byte(nil), block...)}
+		}
+	}
+}
+
+// heartbeats keeps the session alive while it's idle.
+func (c *Client) heartbeats() {
+	t := time.NewTicker(c.heartbeat)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			buf, heartbeat := AppendHeartbeat(nil)
+			heartbeat.SetSendingTime(time.Now())
+
+			if err := c.write(context.Background(), buf); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// call sends the request built by appendRequest for a new client order ID, returning the execution report answering it;
+// a Reject is returned as its error.
+func (c *Client) call(ctx context.Context, appendRequest func(clientOrderID uint64) []byte) ([]byte, error) {
+	ch := make(chan answer, 1)
+
+	c.mu.Lock()
+	c.nextClientOrderID++
+	clientOrderID := c.nextClientOrderID
+	c.pending[clientOrderID] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, clientOrderID)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(ctx, appendRequest(clientOrderID)); err != nil {
+		if errors.Is(err, net.ErrClosed) {
+			return nil, fmt.Errorf("%w: %w", ErrClientClosed, err)
+		}
+
+		return nil, err
+	}
+
+	select {
+	case a := <-ch:
+		if a.template == TemplateReject {
+			return nil, Reject(a.block).Err()
+		}
+
+		return a.block, nil
+	case <-c.done:
+		return nil, fmt.Errorf("%w: %w", ErrClientClosed, c.err)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Client) AddOrder(ctx context.Context, req client.AddOrderRequest) (client.AddOrderResponse, error) {
+	if req.ClientOrderID != "" {
+		return client.AddOrderResponse{}, fmt.Errorf("add order: %w", ErrClientOrderIDUnsupported)
+	}
+
+	block, err := c.call(ctx, func(clientOrderID uint64) []byte {
+		buf, m := AppendNewOrder(nil)
+		m.SetClientOrderID(clientOrderID)
+		m.SetAddOrderRequest(req)
+
+		return buf
+	})
+	if err != nil {
+		return client.AddOrderResponse{}, fmt.Errorf("add order: %w", err)
+	}
+
+	report := ExecutionReport(block)
+	if report.ExecType() == ExecTypeRejected {
+		return client.AddOrderResponse{}, fmt.Errorf("add order: %w", &lob.RejectError{
+			Reason: report.RejectReason(),
+			Err:    fmt.Errorf("order %d rejected", report.OrderID()),
+		})
+	}
+
+	return client.AddOrderResponse{OrderID: report.OrderID()}, nil
+}
+
+func (c *Client) CancelOrder(ctx context.Context, req client.CancelOrderRequest) (client.CancelOrderResponse, error) {
+	if req.OrderID == 0 && req.ClientOrderID != "" {
+		return client.CancelOrderResponse{}, fmt.Errorf("cancel order: %w", ErrClientOrderIDUnsupported)
+	}
+
+	_, err := c.call(ctx, func(clientOrderID uint64) []byte {
+		buf, m := AppendCancel(nil)
+		m.SetClientOrderID(clientOrderID)
+		m.SetCancelOrderRequest(req)
+
+		return buf
+	})
+	if err != nil {
+		return client.CancelOrderResponse{}, fmt.Errorf("cancel order: %w", err)
+	}
+
+	return client.CancelOrderResponse{}, nil
+}
+
+func (c *Client) EditOrder(ctx context.Context, req client.EditOrderRequest) (client.EditOrderResponse, error) {
+	if req.OrderID == 0 && req.ClientOrderID != "" {
+		return client.EditOrderResponse{}, fmt.Errorf("edit order: %w", ErrClientOrderIDUnsupported)
+	}
+
+	_, err := c.call(ctx, func(clientOrderID uint64) []byte {
+		buf, m := AppendReplace(nil)
+		m.SetClientOrderID(clientOrderID)
+		m.SetEditOrderRequest(req)
+
+		return buf
+	})
+	if err != nil {
+		return client.EditOrderResponse{}, fmt.Errorf("edit order: %w", err)
+	}
+
+	return client.EditOrderResponse{}, nil
+}
+
+func (c *Client) GetOrder(ctx context.Context, req client.GetOrderRequest) (client.GetOrderResponse, error) {
+	if req.OrderID == 0 && req.ClientOrderID != "" {
+		return client.GetOrderResponse{}, fmt.Errorf("get order: %w", ErrClientOrderIDUnsupported)
+	}
+
+	c.mu.Lock()
+	info, ok := c.orders[req.OrderID]
+	c.mu.Unlock()
+
+	if !ok {
+		return client.GetOrderResponse{}, fmt.Errorf("get order: order %d: %w", req.OrderID, lob.ErrOrderNotFound)
+	}
+
+	return client.GetOrderResponse{Order: info}, nil
+}
+
+func (c *Client) ListOpenOrders(ctx context.Context, req client.ListOpenOrdersRequest) (client.ListOpenOrdersResponse, error) {
+	c.mu.Lock()
+	resp := client.ListOpenOrdersResponse{Orders: make([]lob.OrderInfo, 0, len(c.orders))}
+	for _, info := range c.orders {
+		if !info.Status.Finished() {
+			resp.Orders = append(resp.Orders, info)
+		}
+	}
+	c.mu.Unlock()
+
+	sort.Slice(resp.Orders, func(i, j int) bool { return resp.Orders[i].ID < resp.Orders[j].ID })
+
+	return resp, nil
+}
@@ -0,0 +1,123 @@
+package sbe
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// Order returns a new order for the engine to place.
+func (m NewOrder) Order() *lob.Order {
+	order := lob.NewOrder(m.OrderType(), m.Side(), m.Price(), m.Size())
+	order.AccountID = m.AccountID()
+	order.CancelOnDisconnect = m.CancelOnDisconnect()
+
+	return order
+}
+
+func (m NewOrder) AddOrderRequest() client.AddOrderRequest {
+	return client.AddOrderRequest{
+		AccountID: m.AccountID(),
+		OrderType: m.OrderType(),
+		OrderSide: m.Side(),
+		Price:     m.Price(),
+		Size:      m.Size(),
+	}
+}
+
+func (m NewOrder) SetAddOrderRequest(req client.AddOrderRequest) {
+	m.SetAccountID(req.AccountID)
+	m.SetOrderType(req.OrderType)
+	m.SetSide(req.OrderSide)
+	m.SetPrice(req.Price)
+	m.SetSize(req.Size)
+}
+
+func (m Cancel) CancelOrderRequest() client.CancelOrderRequest {
+	return client.CancelOrderRequest{OrderID: m.OrderID()}
+}
+
+func (m Cancel) SetCancelOrderRequest(req client.CancelOrderRequest) {
+	m.SetOrderID(req.OrderID)
+}
+
+func (m Replace) EditOrderRequest() client.EditOrderRequest {
+	return client.EditOrderRequest{
+		OrderID: m.OrderID(),
+		Price:   m.Price(),
+		Size:    m.Size(),
+	}
+}
+
+func (m Replace) SetEditOrderRequest(req client.EditOrderRequest) {
+	m.SetOrderID(req.OrderID)
+	m.SetPrice(req.Price)
+	m.SetSize(req.Size)
+}
+
+func (m ExecutionReport) OrderInfo() lob.OrderInfo {
+	return lob.OrderInfo{
+		ID:            m.OrderID(),
+		AccountID:     m.AccountID(),
+		OrderType:     m.OrderType(),
+		Side:          m.Side(),
+		Price:         m.Price(),
+		Size:          m.Size(),
+		Status:        m.Status(),
+		RejectReason:  m.RejectReason(),
+		FilledSize:    m.FilledSize(),
+		RemainingSize: m.RemainingSize(),
+		AvgPrice:      m.AvgPrice(),
+		Fees:          m.Fees(),
+		UpdatedAt:     m.TransactTime(),
+	}
+}
+
+// SetOrderInfo fills in the report from the order's state; the client order ID & exec type are left to the caller.
+func (m ExecutionReport) SetOrderInfo(info lob.OrderInfo) {
+	m.SetOrderID(info.ID)
+	m.SetAccountID(info.AccountID)
+	m.SetOrderType(info.OrderType)
+	m.SetSide(info.Side)
+	m.SetPrice(info.Price)
+	m.SetSize(info.Size)
+	m.SetStatus(info.Status)
+	m.SetRejectReason(info.RejectReason)
+	m.SetFilledSize(info.FilledSize)
+	m.SetRemainingSize(info.RemainingSize)
+	m.SetAvgPrice(info.AvgPrice)
+	m.SetFees(info.Fees)
+	m.SetTransactTime(info.UpdatedAt)
+}
+
+// RejectCodeFor returns the code to reject a request with, given the error the engine returned for it.
+func RejectCodeFor(err error) RejectCode {
+	var rejectErr *lob.RejectError
+	switch {
+	case errors.As(err, &rejectErr):
+		return RejectCode(rejectErr.Reason)
+	case errors.Is(err, lob.ErrOrderNotFound):
+		return RejectCodeUnknownOrder
+	default:
+		return RejectCodeOther
+	}
+}
+
+// Err returns the rejection as an error, as the engine would have returned it: a *lob.RejectError for the engine's own
+// reject reasons, and lob.ErrOrderNotFound for unknown orders.
+func (m Reject) Err() error {
+	code := m.Code()
+	switch {
+	case code == RejectCodeUnknownOrder:
+		return fmt.Errorf("%s %d: %w", m.RefTemplateID(), m.OrderID(), lob.ErrOrderNotFound)
+	case code < RejectCodeUnknownOrder:
+		return &lob.RejectError{
+			Reason: lob.RejectReason(code),
+			Err:    fmt.Errorf("%s %d rejected", m.RefTemplateID(), m.OrderID()),
+		}
+	default:
+		return fmt.Errorf("%s %d rejected: %s", m.RefTemplateID(), m.OrderID(), code)
+	}
+}
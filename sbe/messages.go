@@ -0,0 +1,245 @@
+package sbe
+
+import (
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// Each message is a flyweight over its fixed block: getters decode fields in place, setters encode them in place.
+// Integers & floats are little-endian; times are nanoseconds since the Unix epoch, zero being unset.
+
+const (
+	logonSize           = 24
+	heartbeatSize       = 8
+	newOrderSize        = 40
+	cancelSize          = 16
+	replaceSize         = 32
+	executionReportSize = 88
+	rejectSize          = 24
+)
+
+// Logon opens an order entry session for an account.
+type Logon []byte
+
+func AppendLogon(buf []byte) ([]byte, Logon) {
+	buf, block := appendMessage(buf, TemplateLogon)
+	return buf, Logon(block)
+}
+
+func (m Logon) AccountID() uint64 { return getUint64(m, 0) }
+func (m Logon) SessionID() uint64 { return getUint64(m, 8) }
+
+// HeartbeatInterval is how often each side should expect a message, heartbeats included.
+func (m Logon) HeartbeatInterval() time.Duration {
+	return time.Duration(getUint32(m, 16)) * time.Millisecond
+}
+
+// CancelOnDisconnect cancels the session's orders should it disconnect.
+func (m Logon) CancelOnDisconnect() bool { return getBool(m, 20) }
+
+func (m Logon) SetAccountID(v uint64) { putUint64(m, 0, v) }
+func (m Logon) SetSessionID(v uint64) { putUint64(m, 8, v) }
+func (m Logon) SetHeartbeatInterval(d time.Duration) {
+	putUint32(m, 16, uint32(d/time.Millisecond))
+}
+func (m Logon) SetCancelOnDisconnect(v bool) { putBool(m, 20, v) }
+
+// Heartbeat keeps an idle session alive.
+type Heartbeat []byte
+
+func AppendHeartbeat(buf []byte) ([]byte, Heartbeat) {
+	buf, block := appendMessage(buf, TemplateHeartbeat)
+	return buf, Heartbeat(block)
+}
+
+func (m Heartbeat) SendingTime() time.Time     { return getTime(m, 0) }
+func (m Heartbeat) SetSendingTime(t time.Time) { putTime(m, 0, t) }
+
+// NewOrder places an order.
+type NewOrder []byte
+
+func AppendNewOrder(buf []byte) ([]byte, NewOrder) {
+	buf, block := appendMessage(buf, TemplateNewOrder)
+	return buf, NewOrder(block)
+}
+
+func (m NewOrder) ClientOrderID() uint64        { return getUint64(m, 0) }
+func (m NewOrder) AccountID() uint64            { return getUint64(m, 8) }
+func (m NewOrder) Price() lob.Price             { return lob.Price(getFloat(m, 16)) }
+func (m NewOrder) Size() lob.Size               { return lob.Size(getFloat(m, 24)) }
+func (m NewOrder) OrderType() lob.OrderType     { return lob.OrderType(m[32]) }
+func (m NewOrder) Side() lob.OrderSide          { return lob.OrderSide(m[33]) }
+func (m NewOrder) CancelOnDisconnect() bool     { return getBool(m, 34) }
+func (m NewOrder) SetClientOrderID(v uint64)    { putUint64(m, 0, v) }
+func (m NewOrder) SetAccountID(v uint64)        { putUint64(m, 8, v) }
+func (m NewOrder) SetPrice(v lob.Price)         { putFloat(m, 16, float64(v)) }
+func (m NewOrder) SetSize(v lob.Size)           { putFloat(m, 24, float64(v)) }
+func (m NewOrder) SetOrderType(v lob.OrderType) { m[32] = byte(v) }
+func (m NewOrder) SetSide(v lob.OrderSide)      { m[33] = byte(v) }
+func (m NewOrder) SetCancelOnDisconnect(v bool) { putBool(m, 34, v) }
+
+// Cancel cancels a resting order.
+type Cancel []byte
+
+func AppendCancel(buf []byte) ([]byte, Cancel) {
+	buf, block := appendMessage(buf, TemplateCancel)
+	return buf, Cancel(block)
+}
+
+func (m Cancel) ClientOrderID() uint64     { return getUint64(m, 0) }
+func (m Cancel) OrderID() uint64           { return getUint64(m, 8) }
+func (m Cancel) SetClientOrderID(v uint64) { putUint64(m, 0, v) }
+func (m Cancel) SetOrderID(v uint64)       { putUint64(m, 8, v) }
+
+// Replace amends a resting order's price & total size.
+type Replace []byte
+
+func AppendReplace(buf []byte) ([]byte, Replace) {
+	buf, block := appendMessage(buf, TemplateReplace)
+	return buf, Replace(block)
+}
+
+func (m Replace) ClientOrderID() uint64     { return getUint64(m, 0) }
+func (m Replace) OrderID() uint64           { return getUint64(m, 8) }
+func (m Replace) Price() lob.Price          { return lob.Price(getFloat(m, 16)) }
+func (m Replace) Size() lob.Size            { return lob.Size(getFloat(m, 24)) }
+func (m Replace) SetClientOrderID(v uint64) { putUint64(m, 0, v) }
+func (m Replace) SetOrderID(v uint64)       { putUint64(m, 8, v) }
+func (m Replace) SetPrice(v lob.Price)      { putFloat(m, 16, float64(v)) }
+func (m Replace) SetSize(v lob.Size)        { putFloat(m, 24, float64(v)) }
+
+// ExecType is what an execution report is reporting.
+type ExecType uint8
+
+const (
+	ExecTypeNew ExecType = iota + 1
+	ExecTypeTrade
+	ExecTypeCancelled
+	ExecTypeReplaced
+	ExecTypeRejected
+	ExecTypeOrderStatus
+)
+
+func (e ExecType) String() string {
+	switch e {
+	case ExecTypeNew:
+		return "new"
+	case ExecTypeTrade:
+		return "trade"
+	case ExecTypeCancelled:
+		return "cancelled"
+	case ExecTypeReplaced:
+		return "replaced"
+	case ExecTypeRejected:
+		return "rejected"
+	case ExecTypeOrderStatus:
+		return "order_status"
+	default:
+		return "unknown"
+	}
+}
+
+// ExecutionReport reports an order's state after a change to it.
+type ExecutionReport []byte
+
+func AppendExecutionReport(buf []byte) ([]byte, ExecutionReport) {
+	buf, block := appendMessage(buf, TemplateExecutionReport)
+	return buf, ExecutionReport(block)
+}
+
+func (m ExecutionReport) ClientOrderID() uint64          { return getUint64(m, 0) }
+func (m ExecutionReport) OrderID() uint64                { return getUint64(m, 8) }
+func (m ExecutionReport) AccountID() uint64              { return getUint64(m, 16) }
+func (m ExecutionReport) Price() lob.Price               { return lob.Price(getFloat(m, 24)) }
+func (m ExecutionReport) Size() lob.Size                 { return lob.Size(getFloat(m, 32)) }
+func (m ExecutionReport) FilledSize() lob.Size           { return lob.Size(getFloat(m, 40)) }
+func (m ExecutionReport) RemainingSize() lob.Size        { return lob.Size(getFloat(m, 48)) }
+func (m ExecutionReport) AvgPrice() lob.Price            { return lob.Price(getFloat(m, 56)) }
+func (m ExecutionReport) Fees() float64                  { return getFloat(m, 64) }
+func (m ExecutionReport) TransactTime() time.Time        { return getTime(m, 72) }
+func (m ExecutionReport) OrderType() lob.OrderType       { return lob.OrderType(m[80]) }
+func (m ExecutionReport) Side() lob.OrderSide            { return lob.OrderSide(m[81]) }
+func (m ExecutionReport) Status() lob.OrderStatus        { return lob.OrderStatus(m[82]) }
+func (m ExecutionReport) ExecType() ExecType             { return ExecType(m[83]) }
+func (m ExecutionReport) RejectReason() lob.RejectReason { return lob.RejectReason(getUint16(m, 84)) }
+
+func (m ExecutionReport) SetClientOrderID(v uint64)          { putUint64(m, 0, v) }
+func (m ExecutionReport) SetOrderID(v uint64)                { putUint64(m, 8, v) }
+func (m ExecutionReport) SetAccountID(v uint64)              { putUint64(m, 16, v) }
+func (m ExecutionReport) SetPrice(v lob.Price)               { putFloat(m, 24, float64(v)) }
+func (m ExecutionReport) SetSize(v lob.Size)                 { putFloat(m, 32, float64(v)) }
+func (m ExecutionReport) SetFilledSize(v lob.Size)           { putFloat(m, 40, float64(v)) }
+func (m ExecutionReport) SetRemainingSize(v lob.Size)        { putFloat(m, 48, float64(v)) }
+func (m ExecutionReport) SetAvgPrice(v lob.Price)            { putFloat(m, 56, float64(v)) }
+func (m ExecutionReport) SetFees(v float64)                  { putFloat(m, 64, v) }
+func (m ExecutionReport) SetTransactTime(t time.Time)        { putTime(m, 72, t) }
+func (m ExecutionReport) SetOrderType(v lob.OrderType)       { m[80] = byte(v) }
+func (m ExecutionReport) SetSide(v lob.OrderSide)            { m[81] = byte(v) }
+func (m ExecutionReport) SetStatus(v lob.OrderStatus)        { m[82] = byte(v) }
+func (m ExecutionReport) SetExecType(v ExecType)             { m[83] = byte(v) }
+func (m ExecutionReport) SetRejectReason(v lob.RejectReason) { putUint16(m, 84, uint16(v)) }
+
+// RejectCode is why a request was rejected: the engine's lob.RejectReason when it rejected the request, otherwise one of
+// the protocol's own codes.
+type RejectCode uint16
+
+const (
+	RejectCodeUnknownOrder RejectCode = iota + 1000
+	RejectCodeNotLoggedOn
+	RejectCodeUnsupported
+	RejectCodeOther
+)
+
+func (r RejectCode) String() string {
+	switch r {
+	case RejectCodeUnknownOrder:
+		return "unknown_order"
+	case RejectCodeNotLoggedOn:
+		return "not_logged_on"
+	case RejectCodeUnsupported:
+		return "unsupported"
+	case RejectCodeOther:
+		return "other"
+	default:
+		return lob.RejectReason(r).String()
+	}
+}
+
+// Reject rejects a request that couldn't be carried out.
+type Reject []byte
+
+func AppendReject(buf []byte) ([]byte, Reject) {
+	buf, block := appendMessage(buf, TemplateReject)
+	return buf, Reject(block)
+}
+
+func (m Reject) ClientOrderID() uint64 { return getUint64(m, 0) }
+func (m Reject) OrderID() uint64       { return getUint64(m, 8) }
+
+// RefTemplateID is the template of the request being rejected.
+func (m Reject) RefTemplateID() TemplateID { return TemplateID(getUint16(m, 16)) }
+func (m Reject) Code() RejectCode          { return RejectCode(getUint16(m, 18)) }
+
+func (m Reject) SetClientOrderID(v uint64)     { putUint64(m, 0, v) }
+func (m Reject) SetOrderID(v uint64)           { putUint64(m, 8, v) }
+func (m Reject) SetRefTemplateID(v TemplateID) { putUint16(m, 16, uint16(v)) }
+func (m Reject) SetCode(v RejectCode)          { putUint16(m, 18, uint16(v)) }
+
+func getTime(b []byte, offset int) time.Time {
+	nanos := int64(getUint64(b, offset))
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nanos)
+}
+
+func putTime(b []byte, offset int, t time.Time) {
+	var nanos int64
+	if !t.IsZero() {
+		nanos = t.UnixNano()
+	}
+
+	putUint64(b, offset, uint64(nanos))
+}
@@ -0,0 +1,214 @@
+package sbe
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// TemplateID identifies a message's layout.
+type TemplateID uint16
+
+const (
+	TemplateLogon TemplateID = iota + 1
+	TemplateHeartbeat
+	TemplateNewOrder
+	TemplateCancel
+	TemplateReplace
+	TemplateExecutionReport
+	TemplateReject
+)
+
+func (t TemplateID) String() string {
+	switch t {
+	case TemplateLogon:
+		return "logon"
+	case TemplateHeartbeat:
+		return "heartbeat"
+	case TemplateNewOrder:
+		return "new_order"
+	case TemplateCancel:
+		return "cancel"
+	case TemplateReplace:
+		return "replace"
+	case TemplateExecutionReport:
+		return "execution_report"
+	case TemplateReject:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+// blockLength is the size of the template's fixed block; a message may carry a longer block from a newer schema version,
+// the extra fields of which are skipped.
+func (t TemplateID) blockLength() int {
+	switch t {
+	case TemplateLogon:
+		return logonSize
+	case TemplateHeartbeat:
+		return heartbeatSize
+	case TemplateNewOrder:
+		return newOrderSize
+	case TemplateCancel:
+		return cancelSize
+	case TemplateReplace:
+		return replaceSize
+	case TemplateExecutionReport:
+		return executionReportSize
+	case TemplateReject:
+		return rejectSize
+	default:
+		return -1
+	}
+}
+
+const (
+	SchemaID      = 1
+	SchemaVersion = 1
+
+	// HeaderSize is the size of the header preceding every message: block length, template ID, schema ID & version,
+	// each a little-endian uint16.
+	HeaderSize = 8
+
+	// MaxMessageSize bounds a message, header included.
+	MaxMessageSize = HeaderSize + math.MaxUint16
+)
+
+var (
+	ErrShortMessage    = errors.New("short message")
+	ErrUnknownTemplate = errors.New("unknown template")
+	ErrUnknownSchema   = errors.New("unknown schema")
+)
+
+// Header is a flyweight over a message header.
+type Header []byte
+
+func (h Header) BlockLength() uint16    { return binary.LittleEndian.Uint16(h[0:]) }
+func (h Header) TemplateID() TemplateID { return TemplateID(binary.LittleEndian.Uint16(h[2:])) }
+func (h Header) SchemaID() uint16       { return binary.LittleEndian.Uint16(h[4:]) }
+func (h Header) Version() uint16        { return binary.LittleEndian.Uint16(h[6:]) }
+
+// appendMessage appends the header & a zeroed block for the template, returning the extended buffer & the block to fill in.
+func appendMessage(buf []byte, template TemplateID) ([]byte, []byte) {
+	size := template.blockLength()
+
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(size))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(template))
+	buf = binary.LittleEndian.AppendUint16(buf, SchemaID)
+	buf = binary.LittleEndian.AppendUint16(buf, SchemaVersion)
+
+	start := len(buf)
+	buf = append(buf, make([]byte, size)...)
+
+	return buf, buf[start:]
+}
+
+// Next splits the first message off buf without copying it, returning its template, its block, and what follows it.
+// The block is wrapped with the template's flyweight, e.g. NewOrder(block).
+func Next(buf []byte) (TemplateID, []byte, []byte, error) {
+	if len(buf) < HeaderSize {
+		return 0, nil, buf, ErrShortMessage
+	}
+
+	header := Header(buf[:HeaderSize])
+	if header.SchemaID() != SchemaID {
+		return 0, nil, buf, fmt.Errorf("%w: %d", ErrUnknownSchema, header.SchemaID())
+	}
+
+	end := HeaderSize + int(header.BlockLength())
+	if len(buf) < end {
+		return 0, nil, buf, ErrShortMessage
+	}
+
+	template := header.TemplateID()
+	if err := checkBlock(template, int(header.BlockLength())); err != nil {
+		return 0, nil, buf, err
+	}
+
+	return template, buf[HeaderSize:end], buf[end:], nil
+}
+
+func checkBlock(template TemplateID, blockLength int) error {
+	size := template.blockLength()
+	if size < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownTemplate, template)
+	}
+
+	if blockLength < size {
+		return fmt.Errorf("%s: block of %d bytes, want at least %d: %w", template, blockLength, size, ErrShortMessage)
+	}
+
+	return nil
+}
+
+// Reader reads messages from a stream.
+type Reader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:   bufio.NewReader(r),
+		buf: make([]byte, HeaderSize, 256),
+	}
+}
+
+// Next reads the next message, returning its template & block. The block is only valid until the next call.
+func (r *Reader) Next() (TemplateID, []byte, error) {
+	r.buf = r.buf[:HeaderSize]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return 0, nil, err
+	}
+
+	header := Header(r.buf)
+	if header.SchemaID() != SchemaID {
+		return 0, nil, fmt.Errorf("%w: %d", ErrUnknownSchema, header.SchemaID())
+	}
+
+	var (
+		template    = header.TemplateID()
+		blockLength = int(header.BlockLength())
+	)
+	if err := checkBlock(template, blockLength); err != nil {
+		return 0, nil, err
+	}
+
+	if cap(r.buf) < HeaderSize+blockLength {
+		r.buf = append(r.buf, make([]byte, blockLength)...)
+	}
+
+	block := r.buf[HeaderSize : HeaderSize+blockLength]
+	if _, err := io.ReadFull(r.r, block); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return 0, nil, err
+	}
+
+	return template, block, nil
+}
+
+func getUint16(b []byte, offset int) uint16 { return binary.LittleEndian.Uint16(b[offset:]) }
+func getUint32(b []byte, offset int) uint32 { return binary.LittleEndian.Uint32(b[offset:]) }
+func getUint64(b []byte, offset int) uint64 { return binary.LittleEndian.Uint64(b[offset:]) }
+func getFloat(b []byte, offset int) float64 { return math.Float64frombits(getUint64(b, offset)) }
+
+func putUint16(b []byte, offset int, v uint16) { binary.LittleEndian.PutUint16(b[offset:], v) }
+func putUint32(b []byte, offset int, v uint32) { binary.LittleEndian.PutUint32(b[offset:], v) }
+func putUint64(b []byte, offset int, v uint64) { binary.LittleEndian.PutUint64(b[offset:], v) }
+func putFloat(b []byte, offset int, f float64) { putUint64(b, offset, math.Float64bits(f)) }
+
+func getBool(b []byte, offset int) bool { return b[offset] != 0 }
+
+func putBool(b []byte, offset int, v bool) {
+	b[offset] = 0
+	if v {
+		b[offset] = 1
+	}
+}
@@ -0,0 +1,210 @@
+package sbe
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessages_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now  = time.Unix(1_700_000_000, 123_456_789)
+		info = lob.OrderInfo{
+			ID:            42,
+			AccountID:     7,
+			OrderType:     lob.LimitOrder,
+			Side:          lob.SellSide,
+			Price:         1001.5,
+			Size:          3,
+			Status:        lob.OrderStatusPartiallyFilled,
+			FilledSize:    1,
+			RemainingSize: 2,
+			AvgPrice:      1001.5,
+			Fees:          0.25,
+			UpdatedAt:     now,
+		}
+		buf []byte
+	)
+
+	buf, logon := AppendLogon(buf)
+	logon.SetAccountID(7)
+	logon.SetSessionID(3)
+	logon.SetHeartbeatInterval(5 * time.Second)
+	logon.SetCancelOnDisconnect(true)
+
+	buf, heartbeat := AppendHeartbeat(buf)
+	heartbeat.SetSendingTime(now)
+
+	buf, newOrder := AppendNewOrder(buf)
+	newOrder.SetClientOrderID(100)
+	newOrder.SetAddOrderRequest(client.AddOrderRequest{AccountID: 7, OrderType: lob.LimitOrder, OrderSide: lob.SellSide, Price: 1001.5, Size: 3})
+	newOrder.SetCancelOnDisconnect(true)
+
+	buf, cancel := AppendCancel(buf)
+	cancel.SetClientOrderID(101)
+	cancel.SetCancelOrderRequest(client.CancelOrderRequest{OrderID: 42})
+
+	buf, replace := AppendReplace(buf)
+	replace.SetClientOrderID(102)
+	replace.SetEditOrderRequest(client.EditOrderRequest{OrderID: 42, Price: 1002, Size: 4})
+
+	buf, report := AppendExecutionReport(buf)
+	report.SetClientOrderID(100)
+	report.SetExecType(ExecTypeTrade)
+	report.SetOrderInfo(info)
+
+	buf, reject := AppendReject(buf)
+	reject.SetClientOrderID(103)
+	reject.SetOrderID(43)
+	reject.SetRefTemplateID(TemplateCancel)
+	reject.SetCode(RejectCodeUnknownOrder)
+
+	r := NewReader(bytes.NewReader(buf))
+	next := func(want TemplateID) []byte {
+		template, block, err := r.Next()
+		require.NoError(t, err)
+		require.Equal(t, want, template)
+
+		return block
+	}
+
+	gotLogon := Logon(next(TemplateLogon))
+	assert.Equal(t, uint64(7), gotLogon.AccountID())
+	assert.Equal(t, uint64(3), gotLogon.SessionID())
+	assert.Equal(t, 5*time.Second, gotLogon.HeartbeatInterval())
+	assert.True(t, gotLogon.CancelOnDisconnect())
+
+	assert.True(t, now.Equal(Heartbeat(next(TemplateHeartbeat)).SendingTime()))
+
+	gotNewOrder := NewOrder(next(TemplateNewOrder))
+	assert.Equal(t, uint64(100), gotNewOrder.ClientOrderID())
+	assert.Equal(t, client.AddOrderRequest{AccountID: 7, OrderType: lob.LimitOrder, OrderSide: lob.SellSide, Price: 1001.5, Size: 3}, gotNewOrder.AddOrderRequest())
+	order := gotNewOrder.Order()
+	assert.Equal(t, uint64(7), order.AccountID)
+	assert.True(t, order.CancelOnDisconnect)
+
+	gotCancel := Cancel(next(TemplateCancel))
+	assert.Equal(t, uint64(101), gotCancel.ClientOrderID())
+	assert.Equal(t, client.CancelOrderRequest{OrderID: 42}, gotCancel.CancelOrderRequest())
+
+	gotReplace := Replace(next(TemplateReplace))
+	assert.Equal(t, uint64(102), gotReplace.ClientOrderID())
+	assert.Equal(t, client.EditOrderRequest{OrderID: 42, Price: 1002, Size: 4}, gotReplace.EditOrderRequest())
+
+	gotReport := ExecutionReport(next(TemplateExecutionReport))
+	assert.Equal(t, uint64(100), gotReport.ClientOrderID())
+	assert.Equal(t, ExecTypeTrade, gotReport.ExecType())
+	gotInfo := gotReport.OrderInfo()
+	assert.True(t, now.Equal(gotInfo.UpdatedAt))
+	gotInfo.UpdatedAt = info.UpdatedAt
+	assert.Equal(t, info, gotInfo)
+
+	gotReject := Reject(next(TemplateReject))
+	assert.Equal(t, uint64(103), gotReject.ClientOrderID())
+	assert.Equal(t, TemplateCancel, gotReject.RefTemplateID())
+	assert.ErrorIs(t, gotReject.Err(), lob.ErrOrderNotFound)
+
+	_, _, err := r.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	buf, cancel := AppendCancel(nil)
+	cancel.SetOrderID(42)
+
+	// A newer schema version's longer block; the fields it adds are skipped.
+	longer := append([]byte(nil), buf...)
+	longer[0] += 8
+	longer = append(longer, make([]byte, 8)...)
+
+	unknown := append([]byte(nil), buf...)
+	unknown[2] = 0xff
+
+	tests := []struct {
+		name    string
+		buf     []byte
+		wantErr error
+	}{
+		{name: "message", buf: buf},
+		{name: "longer_block", buf: longer},
+		{name: "short_header", buf: buf[:HeaderSize-1], wantErr: ErrShortMessage},
+		{name: "short_block", buf: buf[:len(buf)-1], wantErr: ErrShortMessage},
+		{name: "unknown_template", buf: unknown, wantErr: ErrUnknownTemplate},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			template, block, rest, err := Next(tt.buf)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, TemplateCancel, template)
+			assert.Equal(t, uint64(42), Cancel(block).OrderID())
+			assert.Empty(t, rest)
+		})
+	}
+}
+
+func TestNext_ZeroCopy(t *testing.T) {
+	buf, newOrder := AppendNewOrder(nil)
+	newOrder.SetPrice(1000)
+	newOrder.SetSize(2)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, block, _, err := Next(buf)
+		if err != nil || NewOrder(block).Price() != 1000 {
+			t.Fatal("failed to decode new order")
+		}
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestRejectCodeFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want RejectCode
+	}{
+		{name: "engine_reject", err: &lob.RejectError{Reason: lob.RejectReasonPriceBand, Err: errors.New("out of band")}, want: RejectCode(lob.RejectReasonPriceBand)},
+		{name: "not_found", err: lob.ErrOrderNotFound, want: RejectCodeUnknownOrder},
+		{name: "other", err: errors.New("boom"), want: RejectCodeOther},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, RejectCodeFor(tt.err))
+
+			// Rejects read back as the error the engine returned.
+			_, reject := AppendReject(nil)
+			reject.SetCode(tt.want)
+
+			var rejectErr *lob.RejectError
+			if tt.want < RejectCodeUnknownOrder {
+				require.ErrorAs(t, reject.Err(), &rejectErr)
+				assert.Equal(t, lob.RejectReasonPriceBand, rejectErr.Reason)
+			}
+		})
+	}
+}
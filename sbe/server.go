@@ -0,0 +1,461 @@
+package sbe
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/session"
+)
+
+const (
+	DefaultHeartbeatInterval = 5 * time.Second
+	DefaultLogonTimeout      = 10 * time.Second
+	DefaultWriteTimeout      = 5 * time.Second
+
+	// outboundQueueSize bounds the messages waiting to be written to a session; a session that falls this far behind is
+	// disconnected.
+	outboundQueueSize = 4096
+)
+
+var errSlowConsumer = errors.New("outbound queue full")
+
+type ServerConfig struct {
+	Book *lob.Orderbook
+	// Sessions tracks each connection as an order entry session; by default a manager cancelling through Book.
+	Sessions *session.Manager
+
+	LogonTimeout time.Duration
+	WriteTimeout time.Duration
+}
+
+// NewServer returns a server routing orders to the book; execution reports are built from the book's order events, so it
+// subscribes to them until closed.
+func NewServer(config ServerConfig) *Server {
+	if config.Sessions == nil {
+		config.Sessions = session.NewManager(session.Config{Canceller: config.Book, Clock: config.Book.Clock()})
+	}
+
+	if config.LogonTimeout == 0 {
+		config.LogonTimeout = DefaultLogonTimeout
+	}
+
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = DefaultWriteTimeout
+	}
+
+	s := &Server{
+		config: config,
+		conns:  make(map[uint64]*serverConn),
+		now:    time.Now,
+	}
+	s.unsubscribe = config.Book.Subscribe(s.onEvent)
+
+	return s
+}
+
+// Server is a binary order entry server: clients log on, then place, cancel & replace orders on the book, receiving
+// execution reports as their orders change.
+type Server struct {
+	config ServerConfig
+
+	// conns are the logged on connections, by engine session ID.
+	conns       map[uint64]*serverConn
+	unsubscribe func()
+	now         func() time.Time
+	mu          sync.Mutex
+}
+
+// Serve accepts connections until the listener is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		netConn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return fmt.Errorf("accept sbe connection: %w", err)
+		}
+
+		go s.handle(netConn)
+	}
+}
+
+// Close disconnects every session, and stops reporting the book's events.
+func (s *Server) Close() error {
+	s.unsubscribe()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.conns {
+		c.close()
+	}
+
+	return nil
+}
+
+// pendingRequest is a request waiting on the book; the book reports the orders it changes synchronously, so events for it
+// are reported against its client order ID.
+type pendingRequest struct {
+	template      TemplateID
+	clientOrderID uint64
+	orderID       uint64
+
+	// reported is set once an event's been reported for the request.
+	reported bool
+}
+
+// serverConn is a client's logged on connection.
+type serverConn struct {
+	server    *Server
+	netConn   net.Conn
+	engine    *session.Session
+	heartbeat time.Duration
+
+	out       chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	lastReceived atomic.Int64
+	lastSent     atomic.Int64
+
+	// pending is the request waiting on the book, and clientOrderIDs those of the session's open orders by order ID. Both
+	// are guarded by mu, as the book's events are reported from whichever goroutine changed the book.
+	pending        *pendingRequest
+	clientOrderIDs map[uint64]uint64
+	mu             sync.Mutex
+}
+
+func (s *Server) handle(netConn net.Conn) {
+	defer netConn.Close()
+
+	r := NewReader(netConn)
+
+	if err := netConn.SetReadDeadline(s.now().Add(s.config.LogonTimeout)); err != nil {
+		return
+	}
+
+	template, block, err := r.Next()
+	if err != nil {
+		slog.Warn("SBE: failed to read logon", "remote", netConn.RemoteAddr().String(), "error", err)
+		return
+	}
+
+	if template != TemplateLogon {
+		slog.Warn("SBE: expected logon", "remote", netConn.RemoteAddr().String(), "template", template)
+		return
+	}
+
+	c := s.logon(netConn, Logon(block))
+	defer s.logout(c)
+
+	if err := netConn.SetReadDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	go c.write()
+	go c.monitor()
+
+	for {
+		template, block, err := r.Next()
+		if err != nil {
+			select {
+			case <-c.done:
+			default:
+				slog.Info("SBE: connection closed", "session", c.engine.ID, "error", err)
+			}
+			return
+		}
+
+		c.lastReceived.Store(s.now().UnixNano())
+		if err := s.config.Sessions.Heartbeat(c.engine.ID); err != nil {
+			slog.Warn("SBE: failed to heartbeat session", "session", c.engine.ID, "error", err)
+		}
+
+		switch template {
+		case TemplateHeartbeat:
+		case TemplateNewOrder:
+			c.newOrder(NewOrder(block))
+		case TemplateCancel:
+			c.cancel(Cancel(block))
+		case TemplateReplace:
+			c.replace(Replace(block))
+		default:
+			c.reject(template, 0, 0, RejectCodeUnsupported)
+		}
+	}
+}
+
+// logon registers the connection as an engine session, answering the logon with the session's ID.
+func (s *Server) logon(netConn net.Conn, logon Logon) *serverConn {
+	heartbeat := logon.HeartbeatInterval()
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeatInterval
+	}
+
+	c := &serverConn{
+		server:         s,
+		netConn:        netConn,
+		engine:         s.config.Sessions.Connect(logon.AccountID(), logon.CancelOnDisconnect()),
+		heartbeat:      heartbeat,
+		out:            make(chan []byte, outboundQueueSize),
+		done:           make(chan struct{}),
+		clientOrderIDs: make(map[uint64]uint64),
+	}
+	c.lastReceived.Store(s.now().UnixNano())
+	c.lastSent.Store(s.now().UnixNano())
+
+	s.mu.Lock()
+	s.conns[c.engine.ID] = c
+	s.mu.Unlock()
+
+	buf, reply := AppendLogon(nil)
+	reply.SetAccountID(c.engine.AccountID)
+	reply.SetSessionID(c.engine.ID)
+	reply.SetHeartbeatInterval(heartbeat)
+	reply.SetCancelOnDisconnect(c.engine.CancelOnDisconnect)
+	c.send(buf)
+
+	slog.Info("SBE: logged on", "session", c.engine.ID, "account", c.engine.AccountID, "heartbeat", heartbeat)
+
+	return c
+}
+
+// logout unregisters the connection, disconnecting its engine session.
+func (s *Server) logout(c *serverConn) {
+	c.close()
+
+	s.mu.Lock()
+	delete(s.conns, c.engine.ID)
+	s.mu.Unlock()
+
+	if _, err := s.config.Sessions.Disconnect(c.engine.ID); err != nil {
+		slog.Warn("SBE: failed to disconnect session", "session", c.engine.ID, "error", err)
+	}
+
+	slog.Info("SBE: logged out", "session", c.engine.ID)
+}
+
+func (c *serverConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.netConn.Close()
+	})
+}
+
+// send queues the message to be written; it never blocks, as it's called while the book is locked.
+func (c *serverConn) send(msg []byte) {
+	select {
+	case c.out <- msg:
+	default:
+		slog.Warn("SBE: disconnecting slow session", "session", c.engine.ID, "error", errSlowConsumer)
+		c.close()
+	}
+}
+
+// write writes queued messages in order.
+func (c *serverConn) write() {
+	for {
+		select {
+		case msg := <-c.out:
+			now := c.server.now()
+			if err := c.netConn.SetWriteDeadline(now.Add(c.server.config.WriteTimeout)); err != nil {
+				c.close()
+				return
+			}
+
+			if _, err := c.netConn.Write(msg); err != nil {
+				slog.Warn("SBE: failed to write message", "session", c.engine.ID, "error", err)
+				c.close()
+				return
+			}
+
+			c.lastSent.Store(now.UnixNano())
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// monitor heartbeats an idle connection, disconnecting a client that's stayed quiet for two heartbeat intervals.
+func (c *serverConn) monitor() {
+	t := time.NewTicker(c.heartbeat / 4)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			now := c.server.now()
+
+			if now.Sub(time.Unix(0, c.lastSent.Load())) >= c.heartbeat {
+				buf, heartbeat := AppendHeartbeat(nil)
+				heartbeat.SetSendingTime(now)
+				c.send(buf)
+			}
+
+			if quiet := now.Sub(time.Unix(0, c.lastReceived.Load())); quiet >= 2*c.heartbeat {
+				slog.Warn("SBE: session stopped responding", "session", c.engine.ID, "quiet", quiet)
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// begin sets the request pending on the book, returning a func to clear it once the book's done with it.
+func (c *serverConn) begin(p *pendingRequest) func() *pendingRequest {
+	c.mu.Lock()
+	c.pending = p
+	c.mu.Unlock()
+
+	return func() *pendingRequest {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.pending = nil
+		return p
+	}
+}
+
+func (c *serverConn) newOrder(m NewOrder) {
+	order := m.Order()
+	c.engine.Tag(order)
+
+	end := c.begin(&pendingRequest{template: TemplateNewOrder, clientOrderID: m.ClientOrderID()})
+	_, err := c.server.config.Book.PlaceOrder(order)
+	p := end()
+
+	// Orders the book rejected have been reported as such; anything else is rejected here.
+	if err != nil && !p.reported {
+		c.reject(TemplateNewOrder, m.ClientOrderID(), 0, RejectCodeFor(err))
+	}
+}
+
+// owns reports whether the order is one of the session's open orders.
+func (c *serverConn) owns(orderID uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.clientOrderIDs[orderID]
+	return ok
+}
+
+func (c *serverConn) cancel(m Cancel) {
+	if !c.owns(m.OrderID()) {
+		c.reject(TemplateCancel, m.ClientOrderID(), m.OrderID(), RejectCodeUnknownOrder)
+		return
+	}
+
+	end := c.begin(&pendingRequest{template: TemplateCancel, clientOrderID: m.ClientOrderID(), orderID: m.OrderID()})
+	err := c.server.config.Book.CancelOrder(m.OrderID())
+	end()
+
+	if err != nil {
+		c.reject(TemplateCancel, m.ClientOrderID(), m.OrderID(), RejectCodeFor(err))
+	}
+}
+
+func (c *serverConn) replace(m Replace) {
+	if !c.owns(m.OrderID()) {
+		c.reject(TemplateReplace, m.ClientOrderID(), m.OrderID(), RejectCodeUnknownOrder)
+		return
+	}
+
+	end := c.begin(&pendingRequest{template: TemplateReplace, clientOrderID: m.ClientOrderID(), orderID: m.OrderID()})
+	err := c.server.config.Book.EditOrder(&lob.Order{ID: m.OrderID(), Price: m.Price(), Size: m.Size()})
+	end()
+
+	if err != nil {
+		c.reject(TemplateReplace, m.ClientOrderID(), m.OrderID(), RejectCodeFor(err))
+	}
+}
+
+func (c *serverConn) reject(template TemplateID, clientOrderID, orderID uint64, code RejectCode) {
+	buf, reject := AppendReject(nil)
+	reject.SetClientOrderID(clientOrderID)
+	reject.SetOrderID(orderID)
+	reject.SetRefTemplateID(template)
+	reject.SetCode(code)
+	c.send(buf)
+}
+
+// onEvent reports order events to the session whose order it is; it's called with the book locked.
+func (s *Server) onEvent(event lob.Event) {
+	orderEvent, ok := event.(lob.OrderEvent)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	c, ok := s.conns[orderEvent.Order.SessionID]
+	s.mu.Unlock()
+
+	if ok {
+		c.report(orderEvent)
+	}
+}
+
+// report sends an execution report for the event, against the client order ID of the request that last changed the
+// order.
+func (c *serverConn) report(event lob.OrderEvent) {
+	info := event.Order
+
+	c.mu.Lock()
+	var (
+		p                    = c.pending
+		clientOrderID, known = c.clientOrderIDs[info.ID]
+		forPending           = p != nil && p.orderID == info.ID
+		execType             ExecType
+	)
+
+	switch {
+	case !known && p != nil && p.template == TemplateNewOrder:
+		// The first event for a new order; the book's only now assigned its ID.
+		p.orderID, forPending = info.ID, true
+	case !known && !forPending:
+		c.mu.Unlock()
+		return
+	}
+
+	if forPending {
+		p.reported = true
+		clientOrderID = p.clientOrderID
+	}
+
+	switch {
+	case event.LastSize > 0:
+		execType = ExecTypeTrade
+	case info.Status == lob.OrderStatusRejected:
+		execType = ExecTypeRejected
+	case info.Status == lob.OrderStatusCancelled || info.Status == lob.OrderStatusExpired:
+		execType = ExecTypeCancelled
+	case forPending && p.template == TemplateReplace:
+		execType = ExecTypeReplaced
+	case forPending && p.template == TemplateNewOrder:
+		execType = ExecTypeNew
+	default:
+		execType = ExecTypeOrderStatus
+	}
+
+	if info.Status.Finished() {
+		delete(c.clientOrderIDs, info.ID)
+	} else {
+		c.clientOrderIDs[info.ID] = clientOrderID
+	}
+	c.mu.Unlock()
+
+	buf, report := AppendExecutionReport(nil)
+	report.SetClientOrderID(clientOrderID)
+	report.SetExecType(execType)
+	report.SetOrderInfo(info)
+	c.send(buf)
+}
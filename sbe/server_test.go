@@ -0,0 +1,115 @@
+package sbe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+func serve(t *testing.T, book *lob.Orderbook) string {
+	t.Helper()
+
+	server := NewServer(ServerConfig{Book: book})
+	t.Cleanup(func() { server.Close() })
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go server.Serve(l)
+
+	return l.Addr().String()
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128)
+	addr := serve(t, book)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	maker, err := Dial(ctx, addr, ClientConfig{AccountID: 7, CancelOnDisconnect: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { maker.Close() })
+
+	taker, err := Dial(ctx, addr, ClientConfig{AccountID: 8})
+	require.NoError(t, err)
+	t.Cleanup(func() { taker.Close() })
+
+	assert.NotEqual(t, maker.SessionID(), taker.SessionID())
+
+	placed, err := maker.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 100, Size: 5})
+	require.NoError(t, err)
+
+	_, err = maker.EditOrder(ctx, client.EditOrderRequest{OrderID: placed.OrderID, Price: 101, Size: 6})
+	require.NoError(t, err)
+
+	// Client order IDs can't be carried, so requests giving one are refused rather than sent without it.
+	_, err = maker.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 99, Size: 1, ClientOrderID: "a"})
+	assert.ErrorIs(t, err, ErrClientOrderIDUnsupported)
+	_, err = maker.CancelOrder(ctx, client.CancelOrderRequest{AccountID: 7, ClientOrderID: "a"})
+	assert.ErrorIs(t, err, ErrClientOrderIDUnsupported)
+	_, err = maker.EditOrder(ctx, client.EditOrderRequest{AccountID: 7, ClientOrderID: "a", Price: 99, Size: 1})
+	assert.ErrorIs(t, err, ErrClientOrderIDUnsupported)
+	_, err = maker.GetOrder(ctx, client.GetOrderRequest{AccountID: 7, ClientOrderID: "a"})
+	assert.ErrorIs(t, err, ErrClientOrderIDUnsupported)
+	assert.Len(t, book.OpenOrders(), 1)
+
+	// Sessions may only cancel & replace their own orders.
+	_, err = taker.CancelOrder(ctx, client.CancelOrderRequest{OrderID: placed.OrderID})
+	assert.True(t, errors.Is(err, lob.ErrOrderNotFound), err)
+
+	taken, err := taker.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.MarketOrder, OrderSide: lob.SellSide, Size: 2})
+	require.NoError(t, err)
+
+	got, err := taker.GetOrder(ctx, client.GetOrderRequest{OrderID: taken.OrderID})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), got.Order.AccountID)
+	assert.Equal(t, lob.OrderStatusFilled, got.Order.Status)
+
+	// Orders are only known through their execution reports: the maker's fill is reported to it alone, once the book's
+	// matched it.
+	_, err = taker.GetOrder(ctx, client.GetOrderRequest{OrderID: placed.OrderID})
+	assert.True(t, errors.Is(err, lob.ErrOrderNotFound), err)
+
+	require.Eventually(t, func() bool {
+		got, err := maker.GetOrder(ctx, client.GetOrderRequest{OrderID: placed.OrderID})
+		return err == nil && got.Order.Status == lob.OrderStatusPartiallyFilled
+	}, time.Second, time.Millisecond)
+
+	got, err = maker.GetOrder(ctx, client.GetOrderRequest{OrderID: placed.OrderID})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), got.Order.AccountID)
+	assert.Equal(t, lob.Price(101), got.Order.Price)
+	assert.Equal(t, lob.Size(2), got.Order.FilledSize)
+
+	open, err := maker.ListOpenOrders(ctx, client.ListOpenOrdersRequest{})
+	require.NoError(t, err)
+	require.Len(t, open.Orders, 1)
+	assert.Equal(t, placed.OrderID, open.Orders[0].ID)
+
+	_, err = taker.AddOrder(ctx, client.AddOrderRequest{OrderType: lob.MarketOrder, OrderSide: lob.BuySide, Size: 1})
+	var rejectErr *lob.RejectError
+	require.True(t, errors.As(err, &rejectErr), err)
+	assert.Equal(t, lob.RejectReasonNoLiquidity, rejectErr.Reason)
+
+	// The maker logged on to have its orders cancelled should it disconnect.
+	require.NoError(t, maker.Close())
+	require.Eventually(t, func() bool {
+		info, err := book.GetOrder(placed.OrderID)
+		return err == nil && info.Status == lob.OrderStatusCancelled
+	}, time.Second, time.Millisecond)
+
+	_, err = maker.CancelOrder(ctx, client.CancelOrderRequest{OrderID: placed.OrderID})
+	assert.True(t, errors.Is(err, ErrClientClosed), err)
+}
@@ -0,0 +1,214 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+)
+
+const (
+	DefaultHeartbeatTimeout = 10 * time.Second
+	DefaultCheckInterval    = time.Second
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Canceller is the engine's cancel path; satisfied by *lob.Orderbook.
+type Canceller interface {
+	MassCancel(req lob.MassCancelRequest) ([]lob.CancelReport, error)
+}
+
+type Config struct {
+	Canceller        Canceller
+	HeartbeatTimeout time.Duration
+	CheckInterval    time.Duration
+	// Clock times heartbeats; by default it's a lob.MonotonicClock. Virtual clocks let tests time sessions out.
+	Clock lob.Clock
+
+	// OnDisconnect, if set, is called with the cancel reports of every session that disconnects or times out.
+	OnDisconnect func(session *Session, reports []lob.CancelReport)
+}
+
+func NewManager(config Config) *Manager {
+	if config.HeartbeatTimeout == 0 {
+		config.HeartbeatTimeout = DefaultHeartbeatTimeout
+	}
+
+	if config.CheckInterval == 0 {
+		config.CheckInterval = DefaultCheckInterval
+	}
+
+	if config.Clock == nil {
+		config.Clock = lob.NewMonotonicClock()
+	}
+
+	return &Manager{
+		canceller:        config.Canceller,
+		heartbeatTimeout: config.HeartbeatTimeout,
+		checkInterval:    config.CheckInterval,
+		onDisconnect:     config.OnDisconnect,
+		sessions:         make(map[uint64]*Session),
+		clock:            config.Clock,
+	}
+}
+
+// Manager tracks order entry sessions and their heartbeats, cancelling the orders of any session that disconnects.
+type Manager struct {
+	canceller        Canceller
+	heartbeatTimeout time.Duration
+	checkInterval    time.Duration
+	onDisconnect     func(session *Session, reports []lob.CancelReport)
+	sessions         map[uint64]*Session
+	nextID           uint64
+	clock            lob.Clock
+	mu               sync.Mutex
+}
+
+type Session struct {
+	ID                 uint64
+	AccountID          uint64
+	CancelOnDisconnect bool
+	lastHeartbeat      time.Time
+}
+
+// Tag stamps the order with the session that entered it, flagging it for cancel on disconnect if the session requires it.
+func (s *Session) Tag(order *lob.Order) {
+	order.SessionID = s.ID
+	if order.AccountID == 0 {
+		order.AccountID = s.AccountID
+	}
+
+	if s.CancelOnDisconnect {
+		order.CancelOnDisconnect = true
+	}
+}
+
+// Connect opens a new session; the connect itself counts as the first heartbeat.
+func (m *Manager) Connect(accountID uint64, cancelOnDisconnect bool) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	s := &Session{
+		ID:                 m.nextID,
+		AccountID:          accountID,
+		CancelOnDisconnect: cancelOnDisconnect,
+		lastHeartbeat:      m.clock.Now(),
+	}
+	m.sessions[s.ID] = s
+
+	slog.Debug("Session connected", "session", s.ID, "account", s.AccountID, "cod", s.CancelOnDisconnect)
+
+	return s
+}
+
+func (m *Manager) Heartbeat(sessionID uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[sessionID]
+	if !ok {
+		return fmt.Errorf("heartbeat session %d: %w", sessionID, ErrSessionNotFound)
+	}
+
+	s.lastHeartbeat = m.clock.Now()
+
+	return nil
+}
+
+// Disconnect closes the session, cancelling every order it flagged for cancel on disconnect.
+func (m *Manager) Disconnect(sessionID uint64) ([]lob.CancelReport, error) {
+	m.mu.Lock()
+	s, ok := m.sessions[sessionID]
+	delete(m.sessions, sessionID)
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("disconnect session %d: %w", sessionID, ErrSessionNotFound)
+	}
+
+	return m.disconnect(s), nil
+}
+
+// CheckHeartbeats disconnects every session that hasn't heartbeated within the timeout.
+func (m *Manager) CheckHeartbeats() {
+	m.mu.Lock()
+	now := m.clock.Now()
+
+	var expired []*Session
+	for id, s := range m.sessions {
+		if now.Sub(s.lastHeartbeat) < m.heartbeatTimeout {
+			continue
+		}
+
+		expired = append(expired, s)
+		delete(m.sessions, id)
+	}
+	m.mu.Unlock()
+
+	for _, s := range expired {
+		slog.Warn("Session missed heartbeats; disconnecting", "session", s.ID, "last_heartbeat", s.lastHeartbeat)
+		m.disconnect(s)
+	}
+}
+
+// Run checks heartbeats every check interval until the context is cancelled.
+func (m *Manager) Run(ctx context.Context) {
+	t := time.NewTicker(m.checkInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			m.CheckHeartbeats()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// KeepAlive heartbeats the session, twice per heartbeat timeout, until the context's cancelled or the session's gone. It's
+// for connections whose transport detects a dead peer itself, such as WebSocket pings or gRPC keepalives, so the session
+// lives as long as the connection does.
+func (m *Manager) KeepAlive(ctx context.Context, sessionID uint64) {
+	t := time.NewTicker(m.heartbeatTimeout / 2)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if err := m.Heartbeat(sessionID); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) disconnect(s *Session) []lob.CancelReport {
+	var reports []lob.CancelReport
+	if m.canceller != nil {
+		var err error
+		reports, err = m.canceller.MassCancel(lob.MassCancelRequest{
+			SessionID:              s.ID,
+			CancelOnDisconnectOnly: true,
+		})
+		if err != nil {
+			slog.Error("Failed to cancel orders on disconnect", "session", s.ID, "error", err)
+		}
+	}
+
+	slog.Debug("Session disconnected", "session", s.ID, "cancelled", len(reports))
+
+	if m.onDisconnect != nil {
+		m.onDisconnect(s, reports)
+	}
+
+	return reports
+}
@@ -0,0 +1,127 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Disconnect(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128)
+	manager := NewManager(Config{
+		Canceller: book,
+	})
+
+	cod := manager.Connect(1, true)
+	other := manager.Connect(2, false)
+
+	codOrderID := placeOrder(t, book, cod, 999)
+	otherOrderID := placeOrder(t, book, other, 998)
+
+	reports, err := manager.Disconnect(cod.ID)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, codOrderID, reports[0].OrderID)
+
+	order, err := book.GetOrder(codOrderID)
+	require.NoError(t, err)
+	assert.Equal(t, lob.OrderStatusCancelled, order.Status)
+
+	// Sessions without cancel on disconnect leave their orders resting.
+	reports, err = manager.Disconnect(other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+
+	order, err = book.GetOrder(otherOrderID)
+	require.NoError(t, err)
+	assert.Equal(t, lob.OrderStatusNew, order.Status)
+
+	_, err = manager.Disconnect(cod.ID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestManager_DisconnectWhileClosed(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128)
+	manager := NewManager(Config{
+		Canceller: book,
+	})
+
+	cod := manager.Connect(1, true)
+	orderID := placeOrder(t, book, cod, 999)
+
+	// Orders rest through the close, so the session's are still pulled when it disconnects.
+	require.NoError(t, book.Transition(lob.TradingStateClosed, "end of day"))
+	require.Error(t, book.CancelOrder(orderID))
+
+	reports, err := manager.Disconnect(cod.ID)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.NoError(t, reports[0].Err)
+	assert.Equal(t, orderID, reports[0].OrderID)
+
+	order, err := book.GetOrder(orderID)
+	require.NoError(t, err)
+	assert.Equal(t, lob.OrderStatusCancelled, order.Status)
+}
+
+func TestManager_HeartbeatTimeout(t *testing.T) {
+	t.Parallel()
+
+	clock := lob.NewVirtualClock(time.Now())
+
+	var disconnected []uint64
+	book := lob.NewOrderbook(128, lob.WithClock(clock))
+	manager := NewManager(Config{
+		Canceller:        book,
+		HeartbeatTimeout: 5 * time.Second,
+		Clock:            clock,
+		OnDisconnect: func(s *Session, _ []lob.CancelReport) {
+			disconnected = append(disconnected, s.ID)
+		},
+	})
+
+	alive := manager.Connect(1, true)
+	stale := manager.Connect(2, true)
+
+	aliveOrderID := placeOrder(t, book, alive, 999)
+	staleOrderID := placeOrder(t, book, stale, 1001)
+
+	clock.Advance(4 * time.Second)
+	require.NoError(t, manager.Heartbeat(alive.ID))
+
+	clock.Advance(time.Second)
+	manager.CheckHeartbeats()
+
+	assert.Equal(t, []uint64{stale.ID}, disconnected)
+	assert.ErrorIs(t, manager.Heartbeat(stale.ID), ErrSessionNotFound)
+
+	order, err := book.GetOrder(staleOrderID)
+	require.NoError(t, err)
+	assert.Equal(t, lob.OrderStatusCancelled, order.Status)
+
+	order, err = book.GetOrder(aliveOrderID)
+	require.NoError(t, err)
+	assert.Equal(t, lob.OrderStatusNew, order.Status)
+}
+
+func placeOrder(t *testing.T, book *lob.Orderbook, s *Session, price lob.Price) uint64 {
+	side := lob.BuySide
+	if price > 1000 {
+		side = lob.SellSide
+	}
+
+	order := lob.NewOrder(lob.LimitOrder, side, price, 1)
+	s.Tag(order)
+
+	id, err := book.PlaceOrder(order)
+	require.NoError(t, err)
+
+	return id
+}
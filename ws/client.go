@@ -0,0 +1,201 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coder/websocket"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/jsonapi"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+var ErrClientClosed = errors.New("websocket client closed")
+
+// maxResponseSize bounds a message read by the client; open orders may be many.
+const maxResponseSize = 64 << 20
+
+// Dial connects to the WebSocket server at url, e.g. "ws://localhost:8080/ws".
+func Dial(ctx context.Context, url string) (*Client, error) {
+	wsConn, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dial websocket %s: %w", url, err)
+	}
+	wsConn.SetReadLimit(maxResponseSize)
+
+	c := &Client{
+		ws:      wsConn,
+		pending: make(map[uint64]chan clientResponse),
+		done:    make(chan struct{}),
+	}
+	go c.read()
+
+	return c, nil
+}
+
+var _ client.Client = &Client{}
+
+// Client places & manages orders over a WebSocket connection; requests are pipelined, each answered by ID. The engine's
+// errors are returned as it would have returned them.
+type Client struct {
+	ws *websocket.Conn
+
+	// pending are the requests waiting on their responses, by ID.
+	nextID  uint64
+	pending map[uint64]chan clientResponse
+	mu      sync.Mutex
+
+	// done is closed once the connection's closed, with err why.
+	done chan struct{}
+	err  error
+}
+
+// clientResponse is a response as the client decodes it, leaving the result to the request.
+type clientResponse struct {
+	Type   string          `json:"type"`
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonapi.Error  `json:"error"`
+}
+
+// Close closes the connection, failing any requests still waiting.
+func (c *Client) Close() error {
+	return c.ws.Close(websocket.StatusNormalClosure, "")
+}
+
+// read delivers responses to their requests, skipping anything published by subscriptions.
+func (c *Client) read() {
+	for {
+		_, data, err := c.ws.Read(context.Background())
+		if err != nil {
+			c.err = err
+			close(c.done)
+			return
+		}
+
+		var resp clientResponse
+		if err := json.Unmarshal(data, &resp); err != nil || (resp.Type != typeResponse && resp.Type != typeError) {
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.mu.Unlock()
+
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// call sends the request, decoding its result into v if it's not nil.
+func (c *Client) call(ctx context.Context, req request, v any) error {
+	ch := make(chan clientResponse, 1)
+
+	c.mu.Lock()
+	c.nextID++
+	req.ID = c.nextID
+	c.pending[req.ID] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+
+	if err := c.ws.Write(ctx, websocket.MessageText, data); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error.Err()
+		}
+
+		if v == nil {
+			return nil
+		}
+
+		if err := json.Unmarshal(resp.Result, v); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+
+		return nil
+	case <-c.done:
+		return fmt.Errorf("%w: %w", ErrClientClosed, c.err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) AddOrder(ctx context.Context, req client.AddOrderRequest) (client.AddOrderResponse, error) {
+	order := jsonapi.NewOrderRequestFrom(req)
+
+	var resp jsonapi.NewOrderResponse
+	if err := c.call(ctx, request{Op: opAddOrder, Order: &order}, &resp); err != nil {
+		return client.AddOrderResponse{}, fmt.Errorf("add order: %w", err)
+	}
+
+	return client.AddOrderResponse{OrderID: resp.OrderID}, nil
+}
+
+func (c *Client) CancelOrder(ctx context.Context, req client.CancelOrderRequest) (client.CancelOrderResponse, error) {
+	cancel := request{Op: opCancelOrder, OrderID: req.OrderID, AccountID: req.AccountID, ClientOrderID: req.ClientOrderID}
+	if err := c.call(ctx, cancel, nil); err != nil {
+		return client.CancelOrderResponse{}, fmt.Errorf("cancel order: %w", err)
+	}
+
+	return client.CancelOrderResponse{}, nil
+}
+
+func (c *Client) EditOrder(ctx context.Context, req client.EditOrderRequest) (client.EditOrderResponse, error) {
+	edit := request{
+		Op:            opEditOrder,
+		OrderID:       req.OrderID,
+		AccountID:     req.AccountID,
+		ClientOrderID: req.ClientOrderID,
+		Price:         float64(req.Price),
+		Size:          float64(req.Size),
+	}
+	if err := c.call(ctx, edit, nil); err != nil {
+		return client.EditOrderResponse{}, fmt.Errorf("edit order: %w", err)
+	}
+
+	return client.EditOrderResponse{}, nil
+}
+
+func (c *Client) GetOrder(ctx context.Context, req client.GetOrderRequest) (client.GetOrderResponse, error) {
+	var order jsonapi.Order
+	get := request{Op: opGetOrder, OrderID: req.OrderID, AccountID: req.AccountID, ClientOrderID: req.ClientOrderID}
+	if err := c.call(ctx, get, &order); err != nil {
+		return client.GetOrderResponse{}, fmt.Errorf("get order: %w", err)
+	}
+
+	return client.GetOrderResponse{Order: order.OrderInfo()}, nil
+}
+
+func (c *Client) ListOpenOrders(ctx context.Context, req client.ListOpenOrdersRequest) (client.ListOpenOrdersResponse, error) {
+	var orders []jsonapi.Order
+	if err := c.call(ctx, request{Op: opListOpenOrders}, &orders); err != nil {
+		return client.ListOpenOrdersResponse{}, fmt.Errorf("list open orders: %w", err)
+	}
+
+	resp := client.ListOpenOrdersResponse{Orders: make([]lob.OrderInfo, 0, len(orders))}
+	for _, order := range orders {
+		resp.Orders = append(resp.Orders, order.OrderInfo())
+	}
+
+	return resp, nil
+}
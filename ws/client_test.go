@@ -0,0 +1,89 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128, lob.WithDepthEvents(5))
+	server := NewServer(Config{Book: book, DepthLevels: 5})
+	t.Cleanup(func() { server.Close() })
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	c, err := Dial(ctx, "ws"+strings.TrimPrefix(ts.URL, "http"))
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	placed, err := c.AddOrder(ctx, client.AddOrderRequest{AccountID: 7, OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 100, Size: 5})
+	require.NoError(t, err)
+
+	_, err = c.EditOrder(ctx, client.EditOrderRequest{OrderID: placed.OrderID, Price: 101, Size: 6})
+	require.NoError(t, err)
+
+	_, err = c.AddOrder(ctx, client.AddOrderRequest{AccountID: 8, OrderType: lob.MarketOrder, OrderSide: lob.SellSide, Size: 2})
+	require.NoError(t, err)
+
+	got, err := c.GetOrder(ctx, client.GetOrderRequest{OrderID: placed.OrderID})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), got.Order.AccountID)
+	assert.Equal(t, lob.Price(101), got.Order.Price)
+	assert.Equal(t, lob.OrderStatusPartiallyFilled, got.Order.Status)
+	assert.Equal(t, lob.Size(2), got.Order.FilledSize)
+
+	open, err := c.ListOpenOrders(ctx, client.ListOpenOrdersRequest{})
+	require.NoError(t, err)
+	require.Len(t, open.Orders, 1)
+	assert.Equal(t, placed.OrderID, open.Orders[0].ID)
+
+	_, err = c.CancelOrder(ctx, client.CancelOrderRequest{OrderID: placed.OrderID})
+	require.NoError(t, err)
+
+	// The engine's errors are returned as it returns them.
+	_, err = c.CancelOrder(ctx, client.CancelOrderRequest{OrderID: 999})
+	assert.True(t, errors.Is(err, lob.ErrOrderNotFound), err)
+
+	_, err = c.AddOrder(ctx, client.AddOrderRequest{AccountID: 8, OrderType: lob.MarketOrder, OrderSide: lob.SellSide, Size: 1})
+	var rejectErr *lob.RejectError
+	require.True(t, errors.As(err, &rejectErr), err)
+	assert.Equal(t, lob.RejectReasonNoLiquidity, rejectErr.Reason)
+
+	// Orders may be referred to by the account's client order ID, which can't be reused while in use.
+	withClientID := client.AddOrderRequest{AccountID: 7, OrderType: lob.LimitOrder, OrderSide: lob.BuySide, Price: 99, Size: 1, ClientOrderID: "w1"}
+	_, err = c.AddOrder(ctx, withClientID)
+	require.NoError(t, err)
+
+	_, err = c.AddOrder(ctx, withClientID)
+	require.True(t, errors.As(err, &rejectErr), err)
+	assert.Equal(t, lob.RejectReasonDuplicateOrder, rejectErr.Reason)
+
+	_, err = c.CancelOrder(ctx, client.CancelOrderRequest{AccountID: 7, ClientOrderID: "w1"})
+	require.NoError(t, err)
+
+	got, err = c.GetOrder(ctx, client.GetOrderRequest{AccountID: 7, ClientOrderID: "w1"})
+	require.NoError(t, err)
+	assert.Equal(t, "w1", got.Order.ClientOrderID)
+	assert.Equal(t, lob.OrderStatusCancelled, got.Order.Status)
+
+	// Requests fail once the connection's closed.
+	require.NoError(t, c.Close())
+	_, err = c.GetOrder(ctx, client.GetOrderRequest{OrderID: placed.OrderID})
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package ws
+
+import (
+	"time"
+
+	"github.com/sashajdn/orderbook/jsonapi"
+)
+
+// Request ops & subscription channels.
+const (
+	opSubscribe      = "subscribe"
+	opUnsubscribe    = "unsubscribe"
+	opAddOrder       = "add_order"
+	opCancelOrder    = "cancel_order"
+	opEditOrder      = "edit_order"
+	opGetOrder       = "get_order"
+	opListOpenOrders = "list_open_orders"
+
+	channelDepth  = "depth"
+	channelTrades = "trades"
+)
+
+// Message types sent to clients.
+const (
+	typeResponse      = "response"
+	typeError         = "error"
+	typeDepthSnapshot = "depth_snapshot"
+	typeDepthUpdate   = "depth_update"
+	typeTrade         = "trade"
+	typeTradesDropped = "trades_dropped"
+	typeHeartbeat     = "heartbeat"
+)
+
+// request is a client's command; ID is echoed on its response, so clients may pipeline requests.
+//
+//	{"id": 1, "op": "subscribe", "channel": "depth", "levels": 10}
+//	{"id": 2, "op": "add_order", "order": {"account_id": 7, "type": "limit", "side": "buy", "price": 100, "size": 1}}
+//	{"id": 3, "op": "edit_order", "order_id": 1, "price": 101, "size": 2}
+//	{"id": 4, "op": "cancel_order", "account_id": 7, "client_order_id": "a"}
+//
+// Orders are referred to by order_id, or without one by the account's client_order_id; without an account either, by the
+// client_order_id of an order entered on the connection.
+type request struct {
+	ID            uint64                   `json:"id"`
+	Op            string                   `json:"op"`
+	Channel       string                   `json:"channel,omitempty"`
+	Levels        int                      `json:"levels,omitempty"`
+	Order         *jsonapi.NewOrderRequest `json:"order,omitempty"`
+	OrderID       uint64                   `json:"order_id,omitempty"`
+	AccountID     uint64                   `json:"account_id,omitempty"`
+	ClientOrderID string                   `json:"client_order_id,omitempty"`
+	Price         float64                  `json:"price,omitempty"`
+	Size          float64                  `json:"size,omitempty"`
+}
+
+type response struct {
+	Type   string         `json:"type"`
+	ID     uint64         `json:"id"`
+	Result any            `json:"result,omitempty"`
+	Error  *jsonapi.Error `json:"error,omitempty"`
+}
+
+type subscribed struct {
+	Channel string `json:"channel"`
+	Levels  int    `json:"levels,omitempty"`
+}
+
+// depthSnapshot replaces the subscriber's levels.
+type depthSnapshot struct {
+	Type string `json:"type"`
+	jsonapi.Depth
+}
+
+// depthUpdate changes the subscriber's levels: applied in order, they leave levels with the checksum.
+type depthUpdate struct {
+	Type     string                `json:"type"`
+	Seq      uint64                `json:"seq"`
+	Changes  []jsonapi.DepthChange `json:"changes"`
+	Checksum uint32                `json:"checksum"`
+}
+
+type trade struct {
+	Type string `json:"type"`
+	jsonapi.Trade
+}
+
+// tradesDropped precedes the next trade after trades are dropped for a slow subscriber.
+type tradesDropped struct {
+	Type  string `json:"type"`
+	Count uint64 `json:"count"`
+}
+
+type heartbeat struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+}
@@ -0,0 +1,562 @@
+// Package ws serves the book over WebSocket: clients subscribe to depth & trades, and send order commands, all as JSON.
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/sashajdn/orderbook/benchmarks/client"
+	"github.com/sashajdn/orderbook/jsonapi"
+	"github.com/sashajdn/orderbook/lob"
+	"github.com/sashajdn/orderbook/session"
+)
+
+const (
+	DefaultHeartbeatInterval = 15 * time.Second
+	DefaultWriteTimeout      = 5 * time.Second
+	DefaultQueueSize         = 1024
+
+	// maxRequestSize bounds a request message.
+	maxRequestSize = 64 << 10
+)
+
+type Config struct {
+	Book *lob.Orderbook
+	// Client executes order commands; by default a client.LOBClient on Book, entering orders on the connection's session.
+	// Orders entered through a Client set here aren't tied to the session, so aren't cancelled on disconnect.
+	Client client.Client
+
+	// Sessions opens an order entry session for each connection, cancelling every order it entered once it disconnects;
+	// by default a manager cancelling through Book. The session's heartbeated for as long as pings are answered.
+	Sessions *session.Manager
+
+	// DepthLevels is the most levels per side a depth subscription is served; it must be no more than the book publishes
+	// DepthEvents with. Depth subscriptions are refused while it's 0.
+	DepthLevels int
+
+	// OriginPatterns are the origins, other than the server's own host, browsers may connect from.
+	OriginPatterns []string
+
+	// HeartbeatInterval is how often each connection is sent a heartbeat message & pinged; a connection that doesn't answer
+	// a ping within the interval is closed.
+	HeartbeatInterval time.Duration
+	WriteTimeout      time.Duration
+
+	// QueueSize bounds the messages waiting to be written to a connection. Command responses wait for room, holding up the
+	// connection's next command; depth updates & trades are dropped, and the connection resynced once it catches up.
+	QueueSize int
+}
+
+// NewServer returns a server for the book; it subscribes to the book's events until closed.
+func NewServer(config Config) *Server {
+	if config.Sessions == nil {
+		config.Sessions = session.NewManager(session.Config{Canceller: config.Book, Clock: config.Book.Clock()})
+	}
+
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = DefaultWriteTimeout
+	}
+
+	if config.QueueSize == 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+
+	s := &Server{
+		config: config,
+		conns:  make(map[*conn]struct{}),
+		now:    time.Now,
+	}
+	s.unsubscribe = config.Book.Subscribe(s.onEvent)
+
+	return s
+}
+
+// Server is an http.Handler upgrading each request to a WebSocket connection.
+type Server struct {
+	config Config
+
+	// conns are the open connections; mu also guards each connection's subscriptions.
+	conns       map[*conn]struct{}
+	unsubscribe func()
+	now         func() time.Time
+	mu          sync.Mutex
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wsConn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: s.config.OriginPatterns})
+	if err != nil {
+		slog.Warn("WebSocket: failed to accept connection", "remote", r.RemoteAddr, "error", err)
+		return
+	}
+	wsConn.SetReadLimit(maxRequestSize)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &conn{
+		server:  s,
+		ws:      wsConn,
+		remote:  r.RemoteAddr,
+		session: s.config.Sessions.Connect(0, true),
+		out:     make(chan []byte, s.config.QueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	c.client = s.config.Client
+	if c.client == nil {
+		c.client = client.NewLOBClient(s.config.Book).WithSession(c.session)
+	}
+
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+
+		cancel()
+		wsConn.CloseNow()
+
+		if _, err := s.config.Sessions.Disconnect(c.session.ID); err != nil {
+			slog.Warn("WebSocket: failed to disconnect session", "remote", c.remote, "session", c.session.ID, "error", err)
+		}
+	}()
+
+	slog.Info("WebSocket: connected", "remote", c.remote, "session", c.session.ID)
+
+	go c.write()
+	go c.monitor()
+	go s.config.Sessions.KeepAlive(ctx, c.session.ID)
+	c.read()
+}
+
+// Close closes every connection, and stops publishing the book's events.
+func (s *Server) Close() error {
+	s.unsubscribe()
+
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, c := range conns {
+		wg.Add(1)
+		go func(c *conn) {
+			defer wg.Done()
+			c.ws.Close(websocket.StatusGoingAway, "server closing")
+		}(c)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// conn is a client's connection, and its subscriptions.
+type conn struct {
+	server *Server
+	ws     *websocket.Conn
+	remote string
+
+	// session is the connection's order entry session, which client enters orders on.
+	session *session.Session
+	client  client.Client
+
+	out    chan []byte
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// depth & trades are the connection's subscriptions, guarded by the server's mu. droppedTrades counts the trades
+	// dropped for a full queue, since the connection was last told.
+	depth         *depthSubscription
+	trades        bool
+	droppedTrades uint64
+
+	// resync is set once a depth update's been dropped; the connection's sent a fresh snapshot once its queue drains.
+	// syncing serializes the snapshots, as both the reader & writer sync.
+	resync  atomic.Bool
+	syncing sync.Mutex
+}
+
+// depthSubscription is a connection's depth subscription, and the levels it was last sent.
+type depthSubscription struct {
+	levels int
+
+	// ready is set once the subscription's snapshot's been queued; until then, buffered holds the latest depth published.
+	ready    bool
+	buffered *lob.DepthSnapshot
+	last     lob.DepthSnapshot
+}
+
+// read handles the client's requests in order, until the connection closes.
+func (c *conn) read() {
+	for {
+		_, data, err := c.ws.Read(c.ctx)
+		if err != nil {
+			if websocket.CloseStatus(err) == -1 && c.ctx.Err() == nil {
+				slog.Info("WebSocket: connection closed", "remote", c.remote, "error", err)
+			}
+			return
+		}
+
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.respond(0, nil, jsonapi.InvalidRequest("decode request: %v", err))
+			continue
+		}
+
+		result, err := c.handle(req)
+		c.respond(req.ID, result, err)
+
+		// A depth subscription's snapshot follows its response.
+		if err == nil && req.Op == opSubscribe && req.Channel == channelDepth {
+			c.syncDepth()
+		}
+	}
+}
+
+// respond queues the response, waiting for room so a client sending commands faster than it reads is held up.
+func (c *conn) respond(id uint64, result any, err error) {
+	msg := response{Type: typeResponse, ID: id, Result: result}
+	if err != nil {
+		msg = response{Type: typeError, ID: id, Error: jsonapi.ErrorFor(err)}
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("WebSocket: failed to encode response", "remote", c.remote, "error", err)
+		return
+	}
+
+	select {
+	case c.out <- data:
+	case <-c.ctx.Done():
+	}
+}
+
+// enqueue queues a published message without waiting, as it's called while the book is locked; it reports whether there
+// was room.
+func (c *conn) enqueue(msg any) bool {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("WebSocket: failed to encode message", "remote", c.remote, "error", err)
+		return false
+	}
+
+	return c.enqueueEncoded(data)
+}
+
+func (c *conn) enqueueEncoded(data []byte) bool {
+	select {
+	case c.out <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+// write writes queued messages in order, resyncing depth whenever it's drained the queue after dropping an update.
+func (c *conn) write() {
+	defer c.cancel()
+
+	for {
+		if len(c.out) == 0 && c.resync.Load() {
+			c.syncDepth()
+		}
+
+		select {
+		case data := <-c.out:
+			ctx, cancel := context.WithTimeout(c.ctx, c.server.config.WriteTimeout)
+			err := c.ws.Write(ctx, websocket.MessageText, data)
+			cancel()
+
+			if err != nil {
+				if c.ctx.Err() == nil {
+					slog.Warn("WebSocket: failed to write message", "remote", c.remote, "error", err)
+				}
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// monitor heartbeats & pings the connection, closing it should a ping go unanswered.
+func (c *conn) monitor() {
+	interval := c.server.config.HeartbeatInterval
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			c.enqueue(heartbeat{Type: typeHeartbeat, Time: c.server.now()})
+
+			ctx, cancel := context.WithTimeout(c.ctx, interval)
+			err := c.ws.Ping(ctx)
+			cancel()
+
+			if err != nil {
+				if c.ctx.Err() == nil {
+					slog.Warn("WebSocket: client stopped responding", "remote", c.remote, "error", err)
+				}
+				c.cancel()
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *conn) handle(req request) (any, error) {
+	cl := c.client
+
+	switch req.Op {
+	case opSubscribe:
+		return c.subscribe(req)
+	case opUnsubscribe:
+		return c.unsubscribe(req)
+	case opAddOrder:
+		if req.Order == nil {
+			return nil, jsonapi.InvalidRequest("missing order")
+		}
+
+		addReq, err := req.Order.AddOrderRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := cl.AddOrder(c.ctx, addReq)
+		if err != nil {
+			return nil, err
+		}
+
+		return jsonapi.NewOrderResponse{OrderID: resp.OrderID}, nil
+	case opCancelOrder:
+		cancelReq := client.CancelOrderRequest{OrderID: req.OrderID, AccountID: req.AccountID, ClientOrderID: req.ClientOrderID}
+		if _, err := cl.CancelOrder(c.ctx, cancelReq); err != nil {
+			return nil, err
+		}
+
+		return struct{}{}, nil
+	case opEditOrder:
+		editReq := client.EditOrderRequest{
+			OrderID:       req.OrderID,
+			Price:         lob.Price(req.Price),
+			Size:          lob.Size(req.Size),
+			AccountID:     req.AccountID,
+			ClientOrderID: req.ClientOrderID,
+		}
+		if _, err := cl.EditOrder(c.ctx, editReq); err != nil {
+			return nil, err
+		}
+
+		return struct{}{}, nil
+	case opGetOrder:
+		getReq := client.GetOrderRequest{OrderID: req.OrderID, AccountID: req.AccountID, ClientOrderID: req.ClientOrderID}
+		resp, err := cl.GetOrder(c.ctx, getReq)
+		if err != nil {
+			return nil, err
+		}
+
+		return jsonapi.NewOrder(resp.Order), nil
+	case opListOpenOrders:
+		resp, err := cl.ListOpenOrders(c.ctx, client.ListOpenOrdersRequest{})
+		if err != nil {
+			return nil, err
+		}
+
+		return jsonapi.NewOrders(resp.Orders), nil
+	default:
+		return nil, jsonapi.InvalidRequest("unknown op %q", req.Op)
+	}
+}
+
+func (c *conn) subscribe(req request) (any, error) {
+	s := c.server
+
+	switch req.Channel {
+	case channelDepth:
+		if s.config.DepthLevels <= 0 {
+			return nil, jsonapi.InvalidRequest("depth isn't enabled")
+		}
+
+		levels := req.Levels
+		switch {
+		case levels == 0:
+			levels = s.config.DepthLevels
+		case levels < 0 || levels > s.config.DepthLevels:
+			return nil, jsonapi.InvalidRequest("levels must be between 1 & %d", s.config.DepthLevels)
+		}
+
+		s.mu.Lock()
+		c.depth = &depthSubscription{levels: levels}
+		s.mu.Unlock()
+
+		return subscribed{Channel: channelDepth, Levels: levels}, nil
+	case channelTrades:
+		s.mu.Lock()
+		c.trades = true
+		s.mu.Unlock()
+
+		return subscribed{Channel: channelTrades}, nil
+	default:
+		return nil, jsonapi.InvalidRequest("unknown channel %q", req.Channel)
+	}
+}
+
+func (c *conn) unsubscribe(req request) (any, error) {
+	s := c.server
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Channel {
+	case channelDepth:
+		c.depth = nil
+	case channelTrades:
+		c.trades = false
+	default:
+		return nil, jsonapi.InvalidRequest("unknown channel %q", req.Channel)
+	}
+
+	return struct{}{}, nil
+}
+
+// syncDepth queues a depth snapshot for the connection's subscription. It's registered as not ready before the snapshot's
+// taken, so depth published in between is buffered rather than missed; the book's events are handled with the book
+// locked, so the snapshot mustn't be taken holding mu.
+func (c *conn) syncDepth() {
+	s := c.server
+
+	c.syncing.Lock()
+	defer c.syncing.Unlock()
+
+	s.mu.Lock()
+	sub := c.depth
+	if sub == nil {
+		c.resync.Store(false)
+		s.mu.Unlock()
+		return
+	}
+	sub.ready = false
+	sub.buffered = nil
+	s.mu.Unlock()
+
+	snapshot := s.config.Book.DepthSnapshot(sub.levels)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Resubscribed in the meantime; the new subscription's synced next.
+	if c.depth != sub {
+		return
+	}
+
+	if !c.enqueue(depthSnapshot{Type: typeDepthSnapshot, Depth: jsonapi.NewDepth(snapshot)}) {
+		c.resync.Store(true)
+		return
+	}
+
+	c.resync.Store(false)
+	sub.last = snapshot
+	sub.ready = true
+
+	if sub.buffered != nil && sub.buffered.Seq > snapshot.Seq {
+		c.publishDepth(sub, *sub.buffered)
+	}
+	sub.buffered = nil
+}
+
+// onEvent publishes depth & trades to subscribers; it's called with the book locked.
+func (s *Server) onEvent(event lob.Event) {
+	switch event := event.(type) {
+	case lob.DepthEvent:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for c := range s.conns {
+			sub := c.depth
+			switch {
+			case sub == nil:
+			case !sub.ready:
+				snapshot := event.DepthSnapshot
+				sub.buffered = &snapshot
+			default:
+				c.publishDepth(sub, event.DepthSnapshot)
+			}
+		}
+	case lob.TradeEvent:
+		data, err := json.Marshal(trade{Type: typeTrade, Trade: jsonapi.NewTrade(event)})
+		if err != nil {
+			slog.Error("WebSocket: failed to encode trade", "error", err)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for c := range s.conns {
+			if c.trades {
+				c.publishTrade(data)
+			}
+		}
+	}
+}
+
+// publishDepth queues whatever's changed in the subscriber's levels since it was last sent them; should there be no room,
+// the subscription's resynced once the queue drains.
+func (c *conn) publishDepth(sub *depthSubscription, depth lob.DepthSnapshot) {
+	depth = depth.Truncate(sub.levels)
+
+	changes := lob.DiffDepth(sub.last, depth)
+	if len(changes) == 0 {
+		return
+	}
+
+	if !c.enqueue(depthUpdate{
+		Type:     typeDepthUpdate,
+		Seq:      depth.Seq,
+		Changes:  jsonapi.NewDepthChanges(changes),
+		Checksum: depth.Checksum,
+	}) {
+		sub.ready = false
+		c.resync.Store(true)
+		return
+	}
+
+	sub.last = depth
+}
+
+// publishTrade queues the trade, telling the subscriber first of any trades dropped since there was last room.
+func (c *conn) publishTrade(data []byte) {
+	if c.droppedTrades > 0 {
+		if !c.enqueue(tradesDropped{Type: typeTradesDropped, Count: c.droppedTrades}) {
+			c.droppedTrades++
+			return
+		}
+
+		c.droppedTrades = 0
+	}
+
+	if !c.enqueueEncoded(data) {
+		c.droppedTrades++
+	}
+}
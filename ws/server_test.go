@@ -0,0 +1,271 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sashajdn/orderbook/jsonapi"
+	"github.com/sashajdn/orderbook/lob"
+)
+
+// received is any message sent to the client.
+type received struct {
+	Type     string                `json:"type"`
+	ID       uint64                `json:"id"`
+	Result   json.RawMessage       `json:"result"`
+	Error    *jsonapi.Error        `json:"error"`
+	Bids     []jsonapi.Level       `json:"bids"`
+	Asks     []jsonapi.Level       `json:"asks"`
+	Changes  []jsonapi.DepthChange `json:"changes"`
+	Checksum uint32                `json:"checksum"`
+	Price    float64               `json:"price"`
+	Size     float64               `json:"size"`
+}
+
+type testClient struct {
+	t  *testing.T
+	ws *websocket.Conn
+	id uint64
+}
+
+func dial(t *testing.T, url string) *testClient {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wsConn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(url, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { wsConn.CloseNow() })
+
+	return &testClient{t: t, ws: wsConn}
+}
+
+// send sends the request, returning its ID.
+func (c *testClient) send(req request) uint64 {
+	c.t.Helper()
+
+	c.id++
+	req.ID = c.id
+
+	data, err := json.Marshal(req)
+	require.NoError(c.t, err)
+	require.NoError(c.t, c.ws.Write(context.Background(), websocket.MessageText, data))
+
+	return req.ID
+}
+
+// expect reads the next message other than a heartbeat, which must be of the given type.
+func (c *testClient) expect(msgType string) received {
+	c.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for {
+		_, data, err := c.ws.Read(ctx)
+		require.NoError(c.t, err)
+
+		var msg received
+		require.NoError(c.t, json.Unmarshal(data, &msg))
+		if msg.Type == typeHeartbeat {
+			continue
+		}
+
+		require.Equal(c.t, msgType, msg.Type, string(data))
+
+		return msg
+	}
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128, lob.WithDepthEvents(5))
+	server := NewServer(Config{Book: book, DepthLevels: 5})
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		server.Close()
+		httpServer.Close()
+	})
+
+	c := dial(t, httpServer.URL)
+
+	id := c.send(request{Op: opSubscribe, Channel: channelDepth, Levels: 2})
+	assert.Equal(t, id, c.expect(typeResponse).ID)
+	snapshot := c.expect(typeDepthSnapshot)
+	assert.Empty(t, snapshot.Bids)
+	assert.Equal(t, lob.Checksum(nil, nil), snapshot.Checksum)
+
+	c.send(request{Op: opSubscribe, Channel: channelTrades})
+	c.expect(typeResponse)
+
+	c.send(request{Op: opSubscribe, Channel: channelDepth, Levels: 6})
+	assert.Equal(t, jsonapi.CodeInvalidRequest, c.expect(typeError).Error.Code)
+
+	// Updates are published as the book changes, so precede the response to the command that changed it.
+	id = c.send(request{Op: opAddOrder, Order: &jsonapi.NewOrderRequest{AccountID: 7, Type: "limit", Side: "buy", Price: 100, Size: 2}})
+	update := c.expect(typeDepthUpdate)
+	assert.Equal(t, []jsonapi.DepthChange{{Action: "new", Side: "buy", Position: 0, Price: 100, Size: 2}}, update.Changes)
+
+	response := c.expect(typeResponse)
+	assert.Equal(t, id, response.ID)
+
+	var placed jsonapi.NewOrderResponse
+	require.NoError(t, json.Unmarshal(response.Result, &placed))
+
+	c.send(request{Op: opAddOrder, Order: &jsonapi.NewOrderRequest{AccountID: 8, Type: "limit", Side: "sell", Price: 100, Size: 1}})
+	trade := c.expect(typeTrade)
+	assert.Equal(t, 100.0, trade.Price)
+	assert.Equal(t, 1.0, trade.Size)
+
+	update = c.expect(typeDepthUpdate)
+	assert.Equal(t, []jsonapi.DepthChange{{Action: "change", Side: "buy", Position: 0, Price: 100, Size: 1}}, update.Changes)
+	assert.Equal(t, lob.Checksum([]lob.DepthLevel{{Price: 100, Size: 1}}, nil), update.Checksum)
+	c.expect(typeResponse)
+
+	c.send(request{Op: opGetOrder, OrderID: placed.OrderID})
+	var order jsonapi.Order
+	require.NoError(t, json.Unmarshal(c.expect(typeResponse).Result, &order))
+	assert.Equal(t, "partially_filled", order.Status)
+	assert.Equal(t, 1.0, order.RemainingSize)
+
+	c.send(request{Op: opEditOrder, OrderID: placed.OrderID, Price: 99, Size: 3})
+	update = c.expect(typeDepthUpdate)
+	assert.Equal(t, "delete", update.Changes[0].Action)
+	assert.Equal(t, "new", update.Changes[1].Action)
+	c.expect(typeResponse)
+
+	c.send(request{Op: opListOpenOrders})
+	var orders []jsonapi.Order
+	require.NoError(t, json.Unmarshal(c.expect(typeResponse).Result, &orders))
+	require.Len(t, orders, 1)
+	assert.Equal(t, 99.0, orders[0].Price)
+
+	// Errors are coded by the engine's reject reason, or what else went wrong.
+	c.send(request{Op: opAddOrder, Order: &jsonapi.NewOrderRequest{Type: "market", Side: "buy", Size: 1}})
+	assert.Equal(t, lob.RejectReasonNoLiquidity.String(), c.expect(typeError).Error.Code)
+
+	c.send(request{Op: opAddOrder, Order: &jsonapi.NewOrderRequest{Type: "limit", Side: "sideways", Price: 1, Size: 1}})
+	assert.Equal(t, jsonapi.CodeInvalidRequest, c.expect(typeError).Error.Code)
+
+	c.send(request{Op: opCancelOrder, OrderID: 999})
+	assert.Equal(t, jsonapi.CodeOrderNotFound, c.expect(typeError).Error.Code)
+
+	c.send(request{Op: "launch"})
+	assert.Equal(t, jsonapi.CodeInvalidRequest, c.expect(typeError).Error.Code)
+
+	// Once unsubscribed, cancelling the order publishes nothing to the client.
+	c.send(request{Op: opUnsubscribe, Channel: channelDepth})
+	c.expect(typeResponse)
+	c.send(request{Op: opCancelOrder, OrderID: placed.OrderID})
+	c.expect(typeResponse)
+}
+
+func TestServer_SlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128, lob.WithDepthEvents(5))
+	server := NewServer(Config{Book: book, DepthLevels: 5})
+	t.Cleanup(func() { server.Close() })
+
+	// A connection with no writer, so its queue fills up.
+	c := &conn{server: server, out: make(chan []byte, 2)}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		server.mu.Lock()
+		delete(server.conns, c)
+		server.mu.Unlock()
+
+		c.cancel()
+	})
+
+	server.mu.Lock()
+	server.conns[c] = struct{}{}
+	c.depth = &depthSubscription{levels: 5}
+	c.trades = true
+	server.mu.Unlock()
+
+	c.syncDepth()
+	require.Len(t, c.out, 1)
+	assert.False(t, c.resync.Load())
+
+	for _, price := range []lob.Price{100, 99} {
+		_, err := book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, price, 1))
+		require.NoError(t, err)
+	}
+
+	// The second update's dropped, so the subscription's resynced once the queue drains.
+	assert.Len(t, c.out, 2)
+	assert.True(t, c.resync.Load())
+
+	_, err := book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.SellSide, 99, 2))
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	assert.Equal(t, uint64(2), c.droppedTrades)
+	server.mu.Unlock()
+
+	<-c.out
+	<-c.out
+
+	c.syncDepth()
+	var msg received
+	require.NoError(t, json.Unmarshal(<-c.out, &msg))
+	assert.Equal(t, typeDepthSnapshot, msg.Type)
+	assert.Empty(t, msg.Bids)
+	assert.False(t, c.resync.Load())
+
+	_, err = book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.BuySide, 98, 1))
+	require.NoError(t, err)
+	_, err = book.PlaceOrder(lob.NewOrder(lob.LimitOrder, lob.SellSide, 98, 1))
+	require.NoError(t, err)
+
+	// The new bid's level, then the count of dropped trades; the trade itself, & the level's removal, are dropped.
+	require.NoError(t, json.Unmarshal(<-c.out, &msg))
+	assert.Equal(t, typeDepthUpdate, msg.Type)
+
+	var dropped tradesDropped
+	require.NoError(t, json.Unmarshal(<-c.out, &dropped))
+	assert.Equal(t, tradesDropped{Type: typeTradesDropped, Count: 2}, dropped)
+}
+
+func TestServer_CancelsOrdersOnDisconnect(t *testing.T) {
+	t.Parallel()
+
+	book := lob.NewOrderbook(128)
+	server := NewServer(Config{Book: book, DepthLevels: 5})
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		server.Close()
+		httpServer.Close()
+	})
+
+	c := dial(t, httpServer.URL)
+
+	c.send(request{Op: opAddOrder, Order: &jsonapi.NewOrderRequest{Type: "limit", Side: "buy", Price: 100, Size: 2, ClientOrderID: "a"}})
+	var placed jsonapi.NewOrderResponse
+	require.NoError(t, json.Unmarshal(c.expect(typeResponse).Result, &placed))
+
+	// Without an account, client order IDs are scoped to the connection's session.
+	c.send(request{Op: opGetOrder, ClientOrderID: "a"})
+	var order jsonapi.Order
+	require.NoError(t, json.Unmarshal(c.expect(typeResponse).Result, &order))
+	assert.Equal(t, placed.OrderID, order.ID)
+
+	require.NoError(t, c.ws.Close(websocket.StatusNormalClosure, ""))
+
+	assert.Eventually(t, func() bool {
+		info, err := book.GetOrder(placed.OrderID)
+		return err == nil && info.Status == lob.OrderStatusCancelled
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, book.OpenOrders())
+}